@@ -0,0 +1,23 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/timescale/tsbs/pkg/targets/elasticsearch/fake"
+)
+
+var addr string
+
+func init() {
+	flag.StringVar(&addr, "addr", ":9200", "address for the fake Elasticsearch to listen on")
+}
+
+// Start a fake Elasticsearch node that accepts _bulk requests without storing
+// the documents. Useful for testing purposes
+func main() {
+	flag.Parse()
+	if err := fake.NewServer().ListenAndServe(addr); err != nil {
+		log.Fatal(err)
+	}
+}
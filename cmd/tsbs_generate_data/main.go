@@ -7,6 +7,7 @@
 // MongoDB BSON format
 // TimescaleDB pseudo-CSV format (the same as for ClickHouse)
 // VictoriaMetrics bulk load format (the same as for InfluxDB)
+// Apache Parquet and Arrow IPC stream files, one per measurement
 
 // Supported use cases:
 // devops: scale is the number of hosts to simulate, with log messages
@@ -29,12 +29,7 @@ func (d *Devops) getHostWhereWithHostnames(hostnames []string) string {
 
 	if d.UseTags {
 		// Use separated table for Tags
-		// Need to prepare WHERE with `tags` table
-		// WHERE tags_id IN (SELECT those tag.id FROM separated tags table WHERE )
-		for _, s := range hostnames {
-			hostnameSelectionClauses = append(hostnameSelectionClauses, fmt.Sprintf("'%s'", s))
-		}
-		return fmt.Sprintf("tags_id IN (SELECT id FROM tags WHERE hostname IN (%s))", strings.Join(hostnameSelectionClauses, ","))
+		return getTagsIDWhereWithHostnames(hostnames)
 	}
 
 	// Here we DO NOT use tags as a separate table
@@ -57,6 +52,26 @@ func (d *Devops) getHostWhereString(nhosts int) string {
 	return d.getHostWhereWithHostnames(hostnames)
 }
 
+// getTagsIDWhereWithHostnames creates WHERE SQL statement for multiple hostnames
+// with the separated `tags` table:
+// tags_id IN (SELECT those tag.id FROM separated tags table WHERE )
+func getTagsIDWhereWithHostnames(hostnames []string) string {
+	hostnameSelectionClauses := make([]string, len(hostnames))
+	for i, s := range hostnames {
+		hostnameSelectionClauses[i] = fmt.Sprintf("'%s'", s)
+	}
+	return fmt.Sprintf("tags_id IN (SELECT id FROM tags WHERE hostname IN (%s))", strings.Join(hostnameSelectionClauses, ","))
+}
+
+// getRollupHostWhereString gets multiple random hostnames and create WHERE SQL
+// statement for these hostnames in the rollups. They only have the tags_id of
+// the hosts, the tags table being filled in whether or not --use-tags is set.
+func (d *Devops) getRollupHostWhereString(nhosts int) string {
+	hostnames, err := d.GetRandomHosts(nhosts)
+	panicIfErr(err)
+	return getTagsIDWhereWithHostnames(hostnames)
+}
+
 // getSelectClausesAggMetrics gets specified aggregate function clause for multiple memtrics
 // Ex.: max(cpu_time) AS max_cpu_time
 func (d *Devops) getSelectClausesAggMetrics(aggregateFunction string, metrics []string) []string {
@@ -327,3 +342,75 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
 	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
 }
+
+// getRollupSelectClauses returns the max of the rollup columns of metrics. They
+// are named after the metrics, as ClickHouse does not allow an alias to hide
+// the column aggregated.
+// Ex.: max(max_usage_user) AS usage_user
+func (d *Devops) getRollupSelectClauses(metrics []string) []string {
+	selectAggregateClauses := make([]string, len(metrics))
+	for i, metric := range metrics {
+		selectAggregateClauses[i] = fmt.Sprintf("max(%s) AS %s", devops.GetRollupColumn(metric), metric)
+	}
+	return selectAggregateClauses
+}
+
+// GroupByTimeRollup selects the MAX for numMetrics metrics per minute for
+// nhosts hosts, like GroupByTime, from the 1m rollup of the cpu table
+func (d *Devops) GroupByTimeRollup(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.MustRandWindow(timeRange)
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	panicIfErr(err)
+
+	sql := fmt.Sprintf(`
+        SELECT
+            bucket AS minute,
+            %s
+        FROM %s
+        WHERE %s AND (bucket >= '%s') AND (bucket < '%s')
+        GROUP BY minute
+        ORDER BY minute ASC
+        `,
+		strings.Join(d.getRollupSelectClauses(metrics), ", "),
+		devops.RollupTableName1m,
+		d.getRollupHostWhereString(nHosts),
+		interval.Start().Format(clickhouseTimeStringFormat),
+		interval.End().Format(clickhouseTimeStringFormat))
+
+	humanLabel := devops.GetGroupByTimeRollupLabel("ClickHouse", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.RollupTableName1m, sql)
+}
+
+// MaxAllCPURollup selects the MAX of all metrics per hour for nHosts hosts,
+// like MaxAllCPU, from the 1h rollup of the cpu table
+func (d *Devops) MaxAllCPURollup(qi query.Query, nHosts int, duration time.Duration) {
+	interval := d.Interval.MustRandWindow(duration)
+
+	sql := fmt.Sprintf(`
+        SELECT
+            bucket AS hour,
+            %s
+        FROM %s
+        WHERE %s AND (bucket >= '%s') AND (bucket < '%s')
+        GROUP BY hour
+        ORDER BY hour
+        `,
+		strings.Join(d.getRollupSelectClauses(devops.GetAllCPUMetrics()), ", "),
+		devops.RollupTableName1h,
+		d.getRollupHostWhereString(nHosts),
+		interval.Start().Format(clickhouseTimeStringFormat),
+		interval.End().Format(clickhouseTimeStringFormat))
+
+	humanLabel := devops.GetMaxAllRollupLabel("ClickHouse", nHosts, duration)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.RollupTableName1h, sql)
+}
+
+// TemplateQuery fills in a query of a user-defined template, its text being
+// SQL.
+func (d *Devops) TemplateQuery(qi query.Query, tq *devops.TemplateQuery) {
+	humanLabel := devops.GetTemplateLabel("ClickHouse", tq.Name)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, tq.TimeInterval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, tq.Text)
+}
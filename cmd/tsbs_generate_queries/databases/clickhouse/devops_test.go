@@ -459,6 +459,110 @@ func TestGroupByTime(t *testing.T) {
 	runTestCases(t, testFunc, start, end, cases)
 }
 
+func TestGroupByTimeRollup(t *testing.T) {
+	cases := []testCase{
+		{
+			desc:               "tags table",
+			input:              2,
+			devopsUseTags:      true,
+			expectedHumanLabel: "ClickHouse 2 cpu metric(s) from the 1m rollup, random    2 hosts, random 1h0m0s by 1m",
+			expectedHumanDesc:  "ClickHouse 2 cpu metric(s) from the 1m rollup, random    2 hosts, random 1h0m0s by 1m: 1970-01-01T00:16:22Z",
+			expectedQuery: `
+        SELECT
+            bucket AS minute,
+            max(max_usage_user) AS usage_user, max(max_usage_system) AS usage_system
+        FROM cpu_1m
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9','host_3')) AND (bucket >= '1970-01-01 00:16:22') AND (bucket < '1970-01-01 01:16:22')
+        GROUP BY minute
+        ORDER BY minute ASC
+        `,
+		},
+		{
+			desc:               "no tags table",
+			input:              2,
+			devopsUseTags:      false,
+			expectedHumanLabel: "ClickHouse 2 cpu metric(s) from the 1m rollup, random    2 hosts, random 1h0m0s by 1m",
+			expectedHumanDesc:  "ClickHouse 2 cpu metric(s) from the 1m rollup, random    2 hosts, random 1h0m0s by 1m: 1970-01-01T00:37:12Z",
+			expectedQuery: `
+        SELECT
+            bucket AS minute,
+            max(max_usage_user) AS usage_user, max(max_usage_system) AS usage_system
+        FROM cpu_1m
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9','host_5')) AND (bucket >= '1970-01-01 00:37:12') AND (bucket < '1970-01-01 01:37:12')
+        GROUP BY minute
+        ORDER BY minute ASC
+        `,
+		},
+	}
+
+	testFunc := func(d *Devops, c testCase) query.Query {
+		q := d.GenerateEmptyQuery()
+		d.GroupByTimeRollup(q, c.input, 2, time.Hour)
+		return q
+	}
+
+	start := time.Unix(0, 0)
+	end := start.Add(2 * time.Hour)
+
+	runTestCases(t, testFunc, start, end, cases)
+}
+
+func TestMaxAllCPURollup(t *testing.T) {
+	cases := []testCase{
+		{
+			desc:               "1 host",
+			input:              1,
+			devopsUseTags:      true,
+			expectedHumanLabel: "ClickHouse max of all CPU metrics from the 1h rollup, random    1 hosts, random 24h0m0s by 1h",
+			expectedHumanDesc: "ClickHouse max of all CPU metrics from the 1h rollup, random    1 hosts, " +
+				"random 24h0m0s by 1h: 1970-01-01T18:16:22Z",
+			expectedQuery: `
+        SELECT
+            bucket AS hour,
+            max(max_usage_user) AS usage_user, max(max_usage_system) AS usage_system, max(max_usage_idle) AS usage_idle, max(max_usage_nice) AS usage_nice, max(max_usage_iowait) AS usage_iowait, max(max_usage_irq) AS usage_irq, max(max_usage_softirq) AS usage_softirq, max(max_usage_steal) AS usage_steal, max(max_usage_guest) AS usage_guest, max(max_usage_guest_nice) AS usage_guest_nice
+        FROM cpu_1h
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9')) AND (bucket >= '1970-01-01 18:16:22') AND (bucket < '1970-01-02 18:16:22')
+        GROUP BY hour
+        ORDER BY hour
+        `,
+		},
+		{
+			desc:               "1 host - no tags table",
+			input:              1,
+			devopsUseTags:      false,
+			expectedHumanLabel: "ClickHouse max of all CPU metrics from the 1h rollup, random    1 hosts, random 24h0m0s by 1h",
+			expectedHumanDesc: "ClickHouse max of all CPU metrics from the 1h rollup, random    1 hosts, " +
+				"random 24h0m0s by 1h: 1970-01-01T17:47:30Z",
+			expectedQuery: `
+        SELECT
+            bucket AS hour,
+            max(max_usage_user) AS usage_user, max(max_usage_system) AS usage_system, max(max_usage_idle) AS usage_idle, max(max_usage_nice) AS usage_nice, max(max_usage_iowait) AS usage_iowait, max(max_usage_irq) AS usage_irq, max(max_usage_softirq) AS usage_softirq, max(max_usage_steal) AS usage_steal, max(max_usage_guest) AS usage_guest, max(max_usage_guest_nice) AS usage_guest_nice
+        FROM cpu_1h
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_5')) AND (bucket >= '1970-01-01 17:47:30') AND (bucket < '1970-01-02 17:47:30')
+        GROUP BY hour
+        ORDER BY hour
+        `,
+		},
+		{
+			desc:    "more hosts then cardinality (11)",
+			input:   11,
+			fail:    true,
+			failMsg: "number of hosts (11) larger than total hosts. See --scale (10)",
+		},
+	}
+
+	testFunc := func(d *Devops, c testCase) query.Query {
+		q := d.GenerateEmptyQuery()
+		d.MaxAllCPURollup(q, c.input, 24*time.Hour)
+		return q
+	}
+
+	start := time.Unix(0, 0)
+	end := start.Add(48 * time.Hour)
+
+	runTestCases(t, testFunc, start, end, cases)
+}
+
 type testCase struct {
 	desc               string
 	input              int
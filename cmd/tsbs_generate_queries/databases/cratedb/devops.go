@@ -214,3 +214,11 @@ func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange t
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
 	d.fillInQuery(qi, humanLabel, humanDesc, sql)
 }
+
+// TemplateQuery fills in a query of a user-defined template, its text being
+// SQL.
+func (d *Devops) TemplateQuery(qi query.Query, tq *devops.TemplateQuery) {
+	humanLabel := devops.GetTemplateLabel("CrateDB", tq.Name)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, tq.TimeInterval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, tq.Text)
+}
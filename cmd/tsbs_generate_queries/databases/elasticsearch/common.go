@@ -0,0 +1,113 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	iutils "github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Names of the fields of the documents written by the elasticsearch target
+const (
+	timestampField = "@timestamp"
+	tagsField      = "tags"
+)
+
+// BaseGenerator contains settings specific for the Elasticsearch search API.
+type BaseGenerator struct {
+	// DBName is the name of the database the data was loaded into, it is
+	// the prefix of the names of the indices
+	DBName string
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	if g.DBName == "" {
+		return nil, fmt.Errorf("the name of the database is required")
+	}
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+	}, nil
+}
+
+// object is a JSON object of a search request body
+type object = map[string]interface{}
+
+type queryInfo struct {
+	// measurement to search the index of
+	measurement string
+	// search request body
+	body object
+	// label to describe type of query
+	label string
+	// time range for query executing
+	interval *iutils.TimeInterval
+}
+
+// fillInQuery fills the query struct with data
+func (g *BaseGenerator) fillInQuery(qq query.Query, qi *queryInfo) {
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", qi.label, qi.interval.StartString()))
+	q.Method = []byte("POST")
+	// the indices are named <db-name>-<measurement> by the loader
+	q.Path = []byte("/" + g.DBName + "-" + qi.measurement + "/_search")
+
+	body, err := json.Marshal(qi.body)
+	if err != nil {
+		panic(fmt.Sprintf("could not marshal query: %v", err))
+	}
+	q.Body = body
+	q.StartTimestamp = qi.interval.StartUnixNano()
+	q.EndTimestamp = qi.interval.EndUnixNano()
+}
+
+// timeRange returns a range filter on the timestamp with the given bounds,
+// e.g. "gte" and "lt", in epoch milliseconds
+func timeRange(bounds object) object {
+	bounds["format"] = "epoch_millis"
+	return object{"range": object{timestampField: bounds}}
+}
+
+// timeRangeOf returns a range filter on the timestamp over an interval
+func timeRangeOf(interval *iutils.TimeInterval) object {
+	return timeRange(object{"gte": interval.StartUnixMillis(), "lt": interval.EndUnixMillis()})
+}
+
+// tagTerms returns a terms filter matching any of the values of a tag
+func tagTerms(tag string, values []string) object {
+	return object{"terms": object{tagField(tag): values}}
+}
+
+// tagField returns the name of the field of a tag
+func tagField(tag string) string {
+	return tagsField + "." + tag
+}
+
+// boolFilter returns a query matching all the filters, without scoring
+func boolFilter(filters ...object) object {
+	return object{"bool": object{"filter": filters}}
+}
+
+// metricAggs returns an aggregation of every metric, named <agg>_<metric>
+func metricAggs(agg string, metrics []string) object {
+	aggs := object{}
+	for _, m := range metrics {
+		aggs[agg+"_"+m] = object{agg: object{"field": m}}
+	}
+	return aggs
+}
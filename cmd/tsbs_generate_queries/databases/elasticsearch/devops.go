@@ -0,0 +1,202 @@
+package elasticsearch
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Devops produces Elasticsearch _search requests for all the devops query
+// types. The time buckets are date_histogram aggregations and the groupings
+// by host are terms aggregations on the hostname dimension.
+type Devops struct {
+	*BaseGenerator
+	*devops.Core
+}
+
+// mustGetRandomHosts is the form of GetRandomHosts that cannot error; if it does error,
+// it causes a panic.
+func (d *Devops) mustGetRandomHosts(nHosts int) []string {
+	hosts, err := d.GetRandomHosts(nHosts)
+	if err != nil {
+		panic(err.Error())
+	}
+	return hosts
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu'
+// per minute for nhosts hosts,
+// e.g. in pseudo-JSON:
+//
+// {"size": 0,
+// "query": {"bool": {"filter": [{"range": {"@timestamp": ...}}, {"terms": {"tags.hostname": [...]}}]}},
+// "aggs": {"minute": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1m"},
+// "aggs": {"max_metric1": {"max": {"field": "metric1"}}, ...}}}}
+func (d *Devops) GroupByTime(qq query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	hosts := d.mustGetRandomHosts(nHosts)
+	interval := d.Interval.MustRandWindow(timeRange)
+	d.fillInQuery(qq, &queryInfo{
+		measurement: devops.TableName,
+		body: object{
+			"size":  0,
+			"query": boolFilter(timeRangeOf(interval), tagTerms("hostname", hosts)),
+			"aggs":  object{"minute": dateHistogram("1m", metricAggs("max", metrics))},
+		},
+		label:    fmt.Sprintf("Elasticsearch %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange),
+		interval: interval,
+	})
+}
+
+// GroupByOrderByLimit selects the MAX of usage_user of the last 5 minutes
+// before a random end,
+// e.g. in pseudo-JSON:
+//
+// {"size": 0, "query": {"range": {"@timestamp": {"lt": ...}}},
+// "aggs": {"minute": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1m", "order": {"_key": "desc"}},
+// "aggs": {"max_usage_user": {"max": {"field": "usage_user"}}, "limit": {"bucket_sort": {"size": 5}}}}}}
+func (d *Devops) GroupByOrderByLimit(qq query.Query) {
+	interval := d.Interval.MustRandWindow(time.Hour)
+	aggs := metricAggs("max", []string{"usage_user"})
+	aggs["limit"] = object{"bucket_sort": object{"size": 5}}
+	histogram := dateHistogram("1m", aggs)
+	histogram["date_histogram"].(object)["order"] = object{"_key": "desc"}
+	d.fillInQuery(qq, &queryInfo{
+		measurement: devops.TableName,
+		body: object{
+			"size":  0,
+			"query": timeRange(object{"lt": interval.EndUnixMillis()}),
+			"aggs":  object{"minute": histogram},
+		},
+		label:    "Elasticsearch max cpu over last 5 min-intervals (random end)",
+		interval: interval,
+	})
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu'
+// per host per hour for a day,
+// e.g. in pseudo-JSON:
+//
+// {"size": 0, "query": {"range": {"@timestamp": ...}},
+// "aggs": {"hostname": {"terms": {"field": "tags.hostname", "size": <scale>},
+// "aggs": {"hour": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1h"},
+// "aggs": {"avg_metric1": {"avg": {"field": "metric1"}}, ...}}}}}}
+func (d *Devops) GroupByTimeAndPrimaryTag(qq query.Query, numMetrics int) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	interval := d.Interval.MustRandWindow(devops.DoubleGroupByDuration)
+	d.fillInQuery(qq, &queryInfo{
+		measurement: devops.TableName,
+		body: object{
+			"size":  0,
+			"query": timeRangeOf(interval),
+			"aggs": object{"hostname": object{
+				"terms": object{"field": tagField("hostname"), "size": d.Scale},
+				"aggs":  object{"hour": dateHistogram("1h", metricAggs("avg", metrics))},
+			}},
+		},
+		label:    devops.GetDoubleGroupByLabel("Elasticsearch", numMetrics),
+		interval: interval,
+	})
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. in pseudo-JSON:
+//
+// {"size": 0,
+// "query": {"bool": {"filter": [{"range": {"@timestamp": ...}}, {"terms": {"tags.hostname": [...]}}]}},
+// "aggs": {"hour": {"date_histogram": {"field": "@timestamp", "fixed_interval": "1h"},
+// "aggs": {"max_metric1": {"max": {"field": "metric1"}}, ...}}}}
+func (d *Devops) MaxAllCPU(qq query.Query, nHosts int, duration time.Duration) {
+	hosts := d.mustGetRandomHosts(nHosts)
+	interval := d.Interval.MustRandWindow(duration)
+	d.fillInQuery(qq, &queryInfo{
+		measurement: devops.TableName,
+		body: object{
+			"size":  0,
+			"query": boolFilter(timeRangeOf(interval), tagTerms("hostname", hosts)),
+			"aggs":  object{"hour": dateHistogram("1h", metricAggs("max", devops.GetAllCPUMetrics()))},
+		},
+		label:    devops.GetMaxAllLabel("Elasticsearch", nHosts),
+		interval: interval,
+	})
+}
+
+// LastPointPerHost finds the last document of every host,
+// e.g. in pseudo-JSON:
+//
+// {"size": 0,
+// "aggs": {"hostname": {"terms": {"field": "tags.hostname", "size": <scale>},
+// "aggs": {"last": {"top_hits": {"size": 1, "sort": [{"@timestamp": {"order": "desc"}}]}}}}}}
+func (d *Devops) LastPointPerHost(qq query.Query) {
+	d.fillInQuery(qq, &queryInfo{
+		measurement: devops.TableName,
+		body: object{
+			"size": 0,
+			"aggs": object{"hostname": object{
+				"terms": object{"field": tagField("hostname"), "size": d.Scale},
+				"aggs": object{"last": object{"top_hits": object{
+					"size": 1,
+					"sort": []object{{timestampField: object{"order": "desc"}}},
+				}}},
+			}},
+		},
+		label:    "Elasticsearch last row per host",
+		interval: d.Interval,
+	})
+}
+
+// HighCPUForHosts selects the documents of nHosts hosts (if 0, all hosts)
+// with a usage_user above 90 in a random window,
+// e.g. in pseudo-JSON:
+//
+// {"size": 10000,
+// "query": {"bool": {"filter": [{"range": {"@timestamp": ...}}, {"range": {"usage_user": {"gt": 90}}},
+// {"terms": {"tags.hostname": [...]}}]}}}
+func (d *Devops) HighCPUForHosts(qq query.Query, nHosts int) {
+	interval := d.Interval.MustRandWindow(devops.HighCPUDuration)
+	filters := []object{
+		timeRangeOf(interval),
+		{"range": object{"usage_user": object{"gt": 90.0}}},
+	}
+	if nHosts > 0 {
+		filters = append(filters, tagTerms("hostname", d.mustGetRandomHosts(nHosts)))
+	}
+	label, err := devops.GetHighCPULabel("Elasticsearch", nHosts)
+	if err != nil {
+		panic(err.Error())
+	}
+	d.fillInQuery(qq, &queryInfo{
+		measurement: devops.TableName,
+		body: object{
+			// the maximum number of hits of a search by default
+			"size":  10000,
+			"query": boolFilter(filters...),
+		},
+		label:    label,
+		interval: interval,
+	})
+}
+
+// dateHistogram returns a date_histogram aggregation of fixed buckets with
+// the given sub-aggregations
+func dateHistogram(fixedInterval string, aggs object) object {
+	return object{
+		"date_histogram": object{
+			"field":          timestampField,
+			"fixed_interval": fixedInterval,
+		},
+		"aggs": aggs,
+	}
+}
+
+// mustGetCPUMetricsSlice is the form of GetCPUMetricsSlice that cannot error; if it does error,
+// it causes a panic.
+func mustGetCPUMetricsSlice(numMetrics int) []string {
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	if err != nil {
+		panic(err.Error())
+	}
+	return metrics
+}
@@ -0,0 +1,143 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestDevopsQueries(t *testing.T) {
+	testCases := map[string]struct {
+		fn        func(g *Devops, q *query.HTTP)
+		expLabel  string
+		expBody   string
+		expToFail bool
+	}{
+		"GroupByTime_1_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, 1, time.Hour)
+			},
+			expLabel: "Elasticsearch 1 cpu metric(s), random    1 hosts, random 1h0m0s by 1m",
+			expBody: `{"aggs":{"minute":{"aggs":{"max_usage_user":{"max":{"field":"usage_user"}}},"date_histogram":{"field":"@timestamp","fixed_interval":"1m"}}},` +
+				`"query":{"bool":{"filter":[{"range":{"@timestamp":{"format":"epoch_millis","gte":17650138,"lt":21250138}}},{"terms":{"tags.hostname":["host_5"]}}]}},"size":0}`,
+		},
+		"GroupByOrderByLimit": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByOrderByLimit(q)
+			},
+			expLabel: "Elasticsearch max cpu over last 5 min-intervals (random end)",
+			expBody: `{"aggs":{"minute":{"aggs":{"limit":{"bucket_sort":{"size":5}},"max_usage_user":{"max":{"field":"usage_user"}}},` +
+				`"date_histogram":{"field":"@timestamp","fixed_interval":"1m","order":{"_key":"desc"}}}},` +
+				`"query":{"range":{"@timestamp":{"format":"epoch_millis","lt":76582646}}},"size":0}`,
+		},
+		"GroupByTimeAndPrimaryTag": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTimeAndPrimaryTag(q, 1)
+			},
+			expLabel: "Elasticsearch mean of 1 metrics, all hosts, random 12h0m0s by 1h",
+			expBody: `{"aggs":{"hostname":{"aggs":{"hour":{"aggs":{"avg_usage_user":{"avg":{"field":"usage_user"}}},"date_histogram":{"field":"@timestamp","fixed_interval":"1h"}}},` +
+				`"terms":{"field":"tags.hostname","size":10}}},` +
+				`"query":{"range":{"@timestamp":{"format":"epoch_millis","gte":22582646,"lt":65782646}}},"size":0}`,
+		},
+		"MaxAllCPU": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.MaxAllCPU(q, 1, devops.MaxAllDuration)
+			},
+			expLabel: "Elasticsearch max of all CPU metrics, random    1 hosts, random 8h0m0s by 1h",
+		},
+		"LastPointPerHost": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.LastPointPerHost(q)
+			},
+			expLabel: "Elasticsearch last row per host",
+			expBody: `{"aggs":{"hostname":{"aggs":{"last":{"top_hits":{"size":1,"sort":[{"@timestamp":{"order":"desc"}}]}}},` +
+				`"terms":{"field":"tags.hostname","size":10}}},"size":0}`,
+		},
+		"HighCPUForHosts_all": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.HighCPUForHosts(q, 0)
+			},
+			expLabel: "Elasticsearch CPU over threshold, all hosts",
+			expBody: `{"query":{"bool":{"filter":[{"range":{"@timestamp":{"format":"epoch_millis","gte":22582646,"lt":65782646}}},` +
+				`{"range":{"usage_user":{"gt":90}}}]}},"size":10000}`,
+		},
+		"HighCPUForHosts_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.HighCPUForHosts(q, 1)
+			},
+			expLabel: "Elasticsearch CPU over threshold, 1 host(s)",
+			expBody: `{"query":{"bool":{"filter":[{"range":{"@timestamp":{"format":"epoch_millis","gte":22582646,"lt":65782646}}},` +
+				`{"range":{"usage_user":{"gt":90}}},{"terms":{"tags.hostname":["host_9"]}}]}},"size":10000}`,
+		},
+		"GroupByTime_negative_metrics": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, -1, time.Hour)
+			},
+			expToFail: true,
+		},
+		"GroupByTime_too_many_hosts": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 100, 1, time.Hour)
+			},
+			expToFail: true,
+		},
+	}
+	g := acquireGenerator(t, time.Hour*24, 10)
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			q := g.GenerateEmptyQuery().(*query.HTTP)
+			if tc.expToFail {
+				func() {
+					defer func() {
+						if recover() == nil {
+							t.Errorf("expected to panic")
+						}
+					}()
+					tc.fn(g, q)
+				}()
+				return
+			}
+
+			tc.fn(g, q)
+			checkEqual(t, "method", http.MethodPost, string(q.Method))
+			checkEqual(t, "path", "/benchmark-cpu/_search", string(q.Path))
+			checkEqual(t, "label", tc.expLabel, string(q.HumanLabel))
+			if tc.expBody != "" {
+				checkEqual(t, "body", tc.expBody, string(q.Body))
+			}
+			if !json.Valid(q.Body) {
+				t.Errorf("body is not valid JSON: %s", q.Body)
+			}
+		})
+	}
+}
+
+func TestNewDevopsRequiresDBName(t *testing.T) {
+	b := &BaseGenerator{}
+	if _, err := b.NewDevops(time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour), 10); err == nil {
+		t.Errorf("expected an error without a database name")
+	}
+}
+
+func checkEqual(t *testing.T, name, a, b string) {
+	if a != b {
+		t.Fatalf("values for %q are not equal \na: %q \nb: %q", name, a, b)
+	}
+}
+
+func acquireGenerator(t *testing.T, interval time.Duration, scale int) *Devops {
+	b := &BaseGenerator{DBName: "benchmark"}
+	s := time.Unix(0, 0)
+	e := s.Add(interval)
+	g, err := b.NewDevops(s, e, scale)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	return g.(*Devops)
+}
@@ -0,0 +1,127 @@
+package embedded
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// timeFmt matches the way the embedded targets store times in SQLite, and is
+// read as a timestamp by DuckDB.
+const timeFmt = "2006-01-02 15:04:05.999999"
+
+const (
+	oneMinute = 60
+	oneHour   = oneMinute * 60
+	oneDay    = oneHour * 24
+)
+
+// BaseGenerator contains settings specific for the embedded DuckDB and SQLite
+// databases. The queries are the TimescaleDB ones, with time_bucket replaced by
+// date truncation and without lateral joins, which SQLite lacks.
+type BaseGenerator struct {
+	// Engine is constants.FormatDuckDB or constants.FormatSQLite
+	Engine string
+}
+
+// GenerateEmptyQuery returns an empty query.SQL.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewSQL()
+}
+
+// fillInQuery fills the query struct with data.
+func (g *BaseGenerator) fillInQuery(qi query.Query, humanLabel, humanDesc, table, sql string) {
+	q := qi.(*query.SQL)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Table = []byte(table)
+	q.SqlQuery = []byte(sql)
+}
+
+// label returns the prefix of the human readable labels.
+func (g *BaseGenerator) label() string {
+	if g.Engine == constants.FormatSQLite {
+		return "SQLite"
+	}
+	return "DuckDB"
+}
+
+// timeBucket returns the expression truncating column to the given number of
+// seconds.
+func (g *BaseGenerator) timeBucket(seconds int, column string) string {
+	if g.Engine == constants.FormatSQLite {
+		switch seconds {
+		case oneMinute:
+			return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:%%M:00', %s)", column)
+		case oneHour:
+			return fmt.Sprintf("strftime('%%Y-%%m-%%d %%H:00:00', %s)", column)
+		case oneDay:
+			return fmt.Sprintf("strftime('%%Y-%%m-%%d 00:00:00', %s)", column)
+		}
+		return fmt.Sprintf("datetime((CAST(strftime('%%s', %[1]s) AS INTEGER) / %[2]d) * %[2]d, 'unixepoch')", column, seconds)
+	}
+
+	switch seconds {
+	case oneMinute:
+		return fmt.Sprintf("date_trunc('minute', %s)", column)
+	case oneHour:
+		return fmt.Sprintf("date_trunc('hour', %s)", column)
+	case oneDay:
+		return fmt.Sprintf("date_trunc('day', %s)", column)
+	}
+	return fmt.Sprintf("epoch_ms(CAST(floor(epoch(%[1]s) / %[2]d) AS BIGINT) * %[3]d)", column, seconds, seconds*1000)
+}
+
+// secondsBetween returns the expression computing the number of seconds from
+// start to end.
+func (g *BaseGenerator) secondsBetween(start, end string) string {
+	if g.Engine == constants.FormatSQLite {
+		return fmt.Sprintf("(strftime('%%s', %s) - strftime('%%s', %s))", end, start)
+	}
+	return fmt.Sprintf("(epoch(%s) - epoch(%s))", end, start)
+}
+
+// intDiv returns the expression dividing a by b, truncating the result like
+// PostgreSQL does for integers. DuckDB's "/" always returns a double.
+func (g *BaseGenerator) intDiv(a, b string) string {
+	if g.Engine == constants.FormatSQLite {
+		return fmt.Sprintf("%s / %s", a, b)
+	}
+	return fmt.Sprintf("%s // %s", a, b)
+}
+
+// timeLiteral formats t the way times are compared with in WHERE clauses.
+func timeLiteral(t time.Time) string {
+	return t.UTC().Format(timeFmt)
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+	}, nil
+}
+
+// NewIoT creates a new iot use case query generator.
+func (g *BaseGenerator) NewIoT(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	core, err := iot.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IoT{
+		BaseGenerator: g,
+		Core:          core,
+	}, nil
+}
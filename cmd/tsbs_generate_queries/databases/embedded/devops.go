@@ -0,0 +1,212 @@
+package embedded
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// TODO: Remove the need for this by continuing to bubble up errors
+func panicIfErr(err error) {
+	if err != nil {
+		panic(err.Error())
+	}
+}
+
+// Devops produces DuckDB and SQLite specific queries for all the devops query types.
+type Devops struct {
+	*BaseGenerator
+	*devops.Core
+}
+
+// getHostWhereWithHostnames creates WHERE SQL statement for multiple hostnames.
+// NOTE 'WHERE' itself is not included, just hostname filter clauses, ready to concatenate to 'WHERE' string
+func (d *Devops) getHostWhereWithHostnames(hostnames []string) string {
+	hostnameClauses := make([]string, len(hostnames))
+	for i, s := range hostnames {
+		hostnameClauses[i] = fmt.Sprintf("'%s'", s)
+	}
+	return fmt.Sprintf("hostname IN (%s)", strings.Join(hostnameClauses, ","))
+}
+
+// getHostWhereString gets multiple random hostnames and creates a WHERE SQL statement for these hostnames.
+func (d *Devops) getHostWhereString(nHosts int) string {
+	hostnames, err := d.GetRandomHosts(nHosts)
+	panicIfErr(err)
+	return d.getHostWhereWithHostnames(hostnames)
+}
+
+func (d *Devops) getSelectClausesAggMetrics(agg string, metrics []string) []string {
+	selectClauses := make([]string, len(metrics))
+	for i, m := range metrics {
+		selectClauses[i] = fmt.Sprintf("%[1]s(%[2]s) as %[1]s_%[2]s", agg, m)
+	}
+
+	return selectClauses
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu',
+// per minute for nhosts hosts,
+// e.g. in pseudo-SQL:
+//
+// SELECT minute, max(metric1), ..., max(metricN)
+// FROM cpu
+// WHERE hostname IN ('$HOSTNAME_1',...,'$HOSTNAME_N')
+// AND time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY minute ORDER BY minute ASC
+func (d *Devops) GroupByTime(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.MustRandWindow(timeRange)
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	panicIfErr(err)
+	selectClauses := d.getSelectClausesAggMetrics("max", metrics)
+	if len(selectClauses) < 1 {
+		panic(fmt.Sprintf("invalid number of select clauses: got %d", len(selectClauses)))
+	}
+
+	sql := fmt.Sprintf(`SELECT %s AS minute,
+        %s
+        FROM cpu
+        WHERE %s AND time >= '%s' AND time < '%s'
+        GROUP BY minute ORDER BY minute ASC`,
+		d.timeBucket(oneMinute, "time"),
+		strings.Join(selectClauses, ", "),
+		d.getHostWhereString(nHosts),
+		timeLiteral(interval.Start()),
+		timeLiteral(interval.End()))
+
+	humanLabel := fmt.Sprintf("%s %d cpu metric(s), random %4d hosts, random %s by 1m", d.label(), numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
+}
+
+// GroupByOrderByLimit populates a query.Query that has a time WHERE clause, that groups by a truncated date, orders by that date, and takes a limit:
+// SELECT date_trunc('minute', time) AS t, MAX(cpu) FROM cpu
+// WHERE time < '$TIME'
+// GROUP BY t ORDER BY t DESC
+// LIMIT $LIMIT
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	interval := d.Interval.MustRandWindow(time.Hour)
+	sql := fmt.Sprintf(`SELECT %s AS minute, max(usage_user)
+        FROM cpu
+        WHERE time < '%s'
+        GROUP BY minute
+        ORDER BY minute DESC
+        LIMIT 5`,
+		d.timeBucket(oneMinute, "time"),
+		timeLiteral(interval.End()))
+
+	humanLabel := fmt.Sprintf("%s max cpu over last 5 min-intervals (random end)", d.label())
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.EndString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g. in pseudo-SQL:
+//
+// SELECT AVG(metric1), ..., AVG(metricN)
+// FROM cpu
+// WHERE time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour, hostname ORDER BY hour
+func (d *Devops) GroupByTimeAndPrimaryTag(qi query.Query, numMetrics int) {
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	panicIfErr(err)
+	interval := d.Interval.MustRandWindow(devops.DoubleGroupByDuration)
+
+	selectClauses := make([]string, numMetrics)
+	meanClauses := make([]string, numMetrics)
+	for i, m := range metrics {
+		meanClauses[i] = "mean_" + m
+		selectClauses[i] = fmt.Sprintf("avg(%s) as %s", m, meanClauses[i])
+	}
+
+	sql := fmt.Sprintf(`
+        WITH cpu_avg AS (
+          SELECT %s as hour, hostname,
+          %s
+          FROM cpu
+          WHERE time >= '%s' AND time < '%s'
+          GROUP BY 1, 2
+        )
+        SELECT hour, hostname, %s
+        FROM cpu_avg
+        ORDER BY hour, hostname`,
+		d.timeBucket(oneHour, "time"),
+		strings.Join(selectClauses, ", "),
+		timeLiteral(interval.Start()),
+		timeLiteral(interval.End()),
+		strings.Join(meanClauses, ", "))
+	humanLabel := devops.GetDoubleGroupByLabel(d.label(), numMetrics)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. in pseudo-SQL:
+//
+// SELECT MAX(metric1), ..., MAX(metricN)
+// FROM cpu WHERE hostname IN ('$HOSTNAME_1',...,'$HOSTNAME_N')
+// AND time >= '$HOUR_START' AND time < '$HOUR_END'
+// GROUP BY hour ORDER BY hour
+func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
+	interval := d.Interval.MustRandWindow(duration)
+
+	metrics := devops.GetAllCPUMetrics()
+	selectClauses := d.getSelectClausesAggMetrics("max", metrics)
+
+	sql := fmt.Sprintf(`SELECT %s AS hour,
+        %s
+        FROM cpu
+        WHERE %s AND time >= '%s' AND time < '%s'
+        GROUP BY hour ORDER BY hour`,
+		d.timeBucket(oneHour, "time"),
+		strings.Join(selectClauses, ", "),
+		d.getHostWhereString(nHosts),
+		timeLiteral(interval.Start()),
+		timeLiteral(interval.End()))
+
+	humanLabel := devops.GetMaxAllLabel(d.label(), nHosts)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
+}
+
+// LastPointPerHost finds the last row for every host in the dataset
+func (d *Devops) LastPointPerHost(qi query.Query) {
+	sql := `SELECT DISTINCT ON (hostname) * FROM cpu ORDER BY hostname, time DESC`
+	if d.Engine == constants.FormatSQLite {
+		sql = `SELECT * FROM cpu c WHERE time = (SELECT max(time) FROM cpu WHERE hostname = c.hostname) ORDER BY hostname`
+	}
+
+	humanLabel := fmt.Sprintf("%s last row per host", d.label())
+	humanDesc := humanLabel
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
+}
+
+// HighCPUForHosts populates a query that gets CPU metrics when the CPU has high
+// usage between a time period for a number of hosts (if 0, it will search all hosts),
+// e.g. in pseudo-SQL:
+//
+// SELECT * FROM cpu
+// WHERE usage_user > 90.0
+// AND time >= '$TIME_START' AND time < '$TIME_END'
+// AND (hostname = '$HOST' OR hostname = '$HOST2'...)
+func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	var hostWhereClause string
+	if nHosts == 0 {
+		hostWhereClause = ""
+	} else {
+		hostWhereClause = fmt.Sprintf("AND %s", d.getHostWhereString(nHosts))
+	}
+	interval := d.Interval.MustRandWindow(devops.HighCPUDuration)
+
+	sql := fmt.Sprintf(`SELECT * FROM cpu WHERE usage_user > 90.0 and time >= '%s' AND time < '%s' %s`,
+		timeLiteral(interval.Start()), timeLiteral(interval.End()), hostWhereClause)
+
+	humanLabel, err := devops.GetHighCPULabel(d.label(), nHosts)
+	panicIfErr(err)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
+}
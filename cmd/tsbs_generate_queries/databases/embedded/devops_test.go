@@ -0,0 +1,167 @@
+package embedded
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/diff"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func TestTimeBucket(t *testing.T) {
+	cases := []struct {
+		engine  string
+		seconds int
+		want    string
+	}{
+		{
+			engine:  constants.FormatDuckDB,
+			seconds: oneMinute,
+			want:    "date_trunc('minute', time)",
+		},
+		{
+			engine:  constants.FormatDuckDB,
+			seconds: oneDay,
+			want:    "date_trunc('day', time)",
+		},
+		{
+			engine:  constants.FormatDuckDB,
+			seconds: 10 * oneMinute,
+			want:    "epoch_ms(CAST(floor(epoch(time) / 600) AS BIGINT) * 600000)",
+		},
+		{
+			engine:  constants.FormatSQLite,
+			seconds: oneHour,
+			want:    "strftime('%Y-%m-%d %H:00:00', time)",
+		},
+		{
+			engine:  constants.FormatSQLite,
+			seconds: 10 * oneMinute,
+			want:    "datetime((CAST(strftime('%s', time) AS INTEGER) / 600) * 600, 'unixepoch')",
+		},
+	}
+
+	for _, c := range cases {
+		b := BaseGenerator{Engine: c.engine}
+		if got := b.timeBucket(c.seconds, "time"); got != c.want {
+			t.Errorf("%s %ds: incorrect output: got %s want %s", c.engine, c.seconds, got, c.want)
+		}
+	}
+}
+
+func TestIntDiv(t *testing.T) {
+	duck := BaseGenerator{Engine: constants.FormatDuckDB}
+	if got, want := duck.intDiv("count(*)", "6"), "count(*) // 6"; got != want {
+		t.Errorf("incorrect DuckDB division: got %s want %s", got, want)
+	}
+	lite := BaseGenerator{Engine: constants.FormatSQLite}
+	if got, want := lite.intDiv("count(*)", "6"), "count(*) / 6"; got != want {
+		t.Errorf("incorrect SQLite division: got %s want %s", got, want)
+	}
+}
+
+func TestDevopsGroupByTime(t *testing.T) {
+	cases := []struct {
+		engine             string
+		expectedHumanLabel string
+		expectedHumanDesc  string
+		expectedSQLQuery   string
+	}{
+		{
+			engine:             constants.FormatDuckDB,
+			expectedHumanLabel: "DuckDB 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "DuckDB 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T00:05:58Z",
+			expectedSQLQuery: `SELECT date_trunc('minute', time) AS minute,
+        max(usage_user) as max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND time >= '1970-01-01 00:05:58.646325' AND time < '1970-01-01 00:05:59.646325'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			engine:             constants.FormatSQLite,
+			expectedHumanLabel: "SQLite 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "SQLite 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T00:05:58Z",
+			expectedSQLQuery: `SELECT strftime('%Y-%m-%d %H:%M:00', time) AS minute,
+        max(usage_user) as max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND time >= '1970-01-01 00:05:58.646325' AND time < '1970-01-01 00:05:59.646325'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+	}
+
+	for _, c := range cases {
+		rand.Seed(123) // Setting seed for testing purposes.
+		s := time.Unix(0, 0)
+		e := s.Add(time.Hour)
+		b := BaseGenerator{Engine: c.engine}
+		dq, err := b.NewDevops(s, e, 10)
+		if err != nil {
+			t.Fatalf("Error while creating devops generator")
+		}
+		d := dq.(*Devops)
+
+		q := d.GenerateEmptyQuery()
+		d.GroupByTime(q, 1, 1, time.Second)
+
+		verifyQuery(t, q, c.expectedHumanLabel, c.expectedHumanDesc, "cpu", c.expectedSQLQuery)
+	}
+}
+
+func TestLastPointPerHost(t *testing.T) {
+	cases := []struct {
+		engine             string
+		expectedHumanLabel string
+		expectedSQLQuery   string
+	}{
+		{
+			engine:             constants.FormatDuckDB,
+			expectedHumanLabel: "DuckDB last row per host",
+			expectedSQLQuery:   "SELECT DISTINCT ON (hostname) * FROM cpu ORDER BY hostname, time DESC",
+		},
+		{
+			engine:             constants.FormatSQLite,
+			expectedHumanLabel: "SQLite last row per host",
+			expectedSQLQuery:   "SELECT * FROM cpu c WHERE time = (SELECT max(time) FROM cpu WHERE hostname = c.hostname) ORDER BY hostname",
+		},
+	}
+
+	for _, c := range cases {
+		b := BaseGenerator{Engine: c.engine}
+		dq, err := b.NewDevops(time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour), 10)
+		if err != nil {
+			t.Fatalf("Error while creating devops generator")
+		}
+		d := dq.(*Devops)
+
+		q := d.GenerateEmptyQuery()
+		d.LastPointPerHost(q)
+
+		verifyQuery(t, q, c.expectedHumanLabel, c.expectedHumanLabel, "cpu", c.expectedSQLQuery)
+	}
+}
+
+func verifyQuery(t *testing.T, q query.Query, humanLabel, humanDesc, table, sqlQuery string) {
+	sq, ok := q.(*query.SQL)
+
+	if !ok {
+		t.Fatal("Filled query is not *query.SQL type")
+	}
+
+	if got := string(sq.HumanLabel); got != humanLabel {
+		t.Errorf("incorrect human label:\ngot\n%s\nwant\n%s", got, humanLabel)
+	}
+
+	if got := string(sq.HumanDescription); got != humanDesc {
+		t.Errorf("incorrect human description:\ngot\n%s\nwant\n%s", got, humanDesc)
+	}
+
+	if got := string(sq.Table); got != table {
+		t.Errorf("incorrect table:\ngot\n%s\nwant\n%s", got, table)
+	}
+
+	if got := string(sq.SqlQuery); got != sqlQuery {
+		t.Errorf("incorrect SQL query:\ndiff\n%s\ngot\n%s\nwant\n%s", diff.CharacterDiff(got, sqlQuery), got, sqlQuery)
+	}
+}
@@ -0,0 +1,329 @@
+package embedded
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// IoT produces DuckDB and SQLite specific queries for all the iot query types.
+// The last reading of a truck is selected with a correlated max(time)
+// subquery instead of a lateral join.
+type IoT struct {
+	*iot.Core
+	*BaseGenerator
+}
+
+func (i *IoT) getTrucksWhereWithNames(names []string) string {
+	nameClauses := make([]string, len(names))
+	for j, s := range names {
+		nameClauses[j] = fmt.Sprintf("'%s'", s)
+	}
+	return fmt.Sprintf("t.name IN (%s)", strings.Join(nameClauses, ","))
+}
+
+// getTruckWhereString gets multiple random truck names and creates a WHERE SQL statement for these names.
+func (i *IoT) getTruckWhereString(nTrucks int) string {
+	names, err := i.GetRandomTrucks(nTrucks)
+	panicIfErr(err)
+	return i.getTrucksWhereWithNames(names)
+}
+
+// LastLocByTruck finds the truck location for nTrucks.
+func (i *IoT) LastLocByTruck(qi query.Query, nTrucks int) {
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver, r.longitude, r.latitude
+		FROM tags t INNER JOIN readings r ON r.tags_id = t.id
+		WHERE r.time = (SELECT max(time) FROM readings WHERE tags_id = t.id)
+		AND %s`,
+		i.getTruckWhereString(nTrucks))
+
+	humanLabel := fmt.Sprintf("%s last location by specific truck", i.label())
+	humanDesc := fmt.Sprintf("%s: random %4d trucks", humanLabel, nTrucks)
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// LastLocPerTruck finds all the truck locations along with truck and driver names.
+func (i *IoT) LastLocPerTruck(qi query.Query) {
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver, r.longitude, r.latitude
+		FROM tags t INNER JOIN readings r ON r.tags_id = t.id
+		WHERE r.time = (SELECT max(time) FROM readings WHERE tags_id = t.id)
+		AND t.name IS NOT NULL
+		AND t.fleet = '%s'`,
+		i.GetRandomFleet())
+
+	humanLabel := fmt.Sprintf("%s last location per truck", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// TrucksWithLowFuel finds all trucks with low fuel (less than 10%).
+func (i *IoT) TrucksWithLowFuel(qi query.Query) {
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver, d.fuel_state
+		FROM tags t INNER JOIN diagnostics d ON d.tags_id = t.id
+		WHERE d.time = (SELECT max(time) FROM diagnostics WHERE tags_id = t.id)
+		AND t.name IS NOT NULL
+		AND d.fuel_state < 0.1
+		AND t.fleet = '%s'`,
+		i.GetRandomFleet())
+
+	humanLabel := fmt.Sprintf("%s trucks with low fuel", i.label())
+	humanDesc := fmt.Sprintf("%s: under 10 percent", humanLabel)
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.DiagnosticsTableName, sql)
+}
+
+// TrucksWithHighLoad finds all trucks that have load over 90%.
+func (i *IoT) TrucksWithHighLoad(qi query.Query) {
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver, d.current_load
+		FROM tags t INNER JOIN diagnostics d ON d.tags_id = t.id
+		WHERE d.time = (SELECT max(time) FROM diagnostics WHERE tags_id = t.id)
+		AND t.name IS NOT NULL
+		AND d.current_load/t.load_capacity > 0.9
+		AND t.fleet = '%s'`,
+		i.GetRandomFleet())
+
+	humanLabel := fmt.Sprintf("%s trucks with high load", i.label())
+	humanDesc := fmt.Sprintf("%s: over 90 percent", humanLabel)
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.DiagnosticsTableName, sql)
+}
+
+// StationaryTrucks finds all trucks that have low average velocity in a time window.
+func (i *IoT) StationaryTrucks(qi query.Query) {
+	interval := i.Interval.MustRandWindow(iot.StationaryDuration)
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver
+		FROM tags t
+		INNER JOIN readings r ON r.tags_id = t.id
+		WHERE time >= '%s' AND time < '%s'
+		AND t.name IS NOT NULL
+		AND t.fleet = '%s'
+		GROUP BY 1, 2
+		HAVING avg(r.velocity) < 1`,
+		timeLiteral(interval.Start()),
+		timeLiteral(interval.End()),
+		i.GetRandomFleet())
+
+	humanLabel := fmt.Sprintf("%s stationary trucks", i.label())
+	humanDesc := fmt.Sprintf("%s: with low avg velocity in last 10 minutes", humanLabel)
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// TrucksWithLongDrivingSessions finds all trucks that have not stopped at least 20 mins in the last 4 hours.
+func (i *IoT) TrucksWithLongDrivingSessions(qi query.Query) {
+	interval := i.Interval.MustRandWindow(iot.LongDrivingSessionDuration)
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver
+		FROM tags t
+		INNER JOIN
+			(SELECT %s AS ten_minutes, tags_id
+			FROM readings
+			WHERE time >= '%s' AND time < '%s'
+			GROUP BY ten_minutes, tags_id
+			HAVING avg(velocity) > 1) AS r ON t.id = r.tags_id
+		WHERE t.name IS NOT NULL
+		AND t.fleet = '%s'
+		GROUP BY name, driver
+		HAVING count(r.ten_minutes) > %d`,
+		i.timeBucket(10*oneMinute, "time"),
+		timeLiteral(interval.Start()),
+		timeLiteral(interval.End()),
+		i.GetRandomFleet(),
+		// Calculate number of 10 min intervals that is the max driving duration for the session if we rest 5 mins per hour.
+		tenMinutePeriods(5, iot.LongDrivingSessionDuration))
+
+	humanLabel := fmt.Sprintf("%s trucks with longer driving sessions", i.label())
+	humanDesc := fmt.Sprintf("%s: stopped less than 20 mins in 4 hour period", humanLabel)
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// TrucksWithLongDailySessions finds all trucks that have driven more than 10 hours in the last 24 hours.
+func (i *IoT) TrucksWithLongDailySessions(qi query.Query) {
+	interval := i.Interval.MustRandWindow(iot.DailyDrivingDuration)
+	sql := fmt.Sprintf(`SELECT t.name AS name, t.driver AS driver
+		FROM tags t
+		INNER JOIN
+			(SELECT %s AS ten_minutes, tags_id
+			FROM readings
+			WHERE time >= '%s' AND time < '%s'
+			GROUP BY ten_minutes, tags_id
+			HAVING avg(velocity) > 1) AS r ON t.id = r.tags_id
+		WHERE t.name IS NOT NULL
+		AND t.fleet = '%s'
+		GROUP BY name, driver
+		HAVING count(r.ten_minutes) > %d`,
+		i.timeBucket(10*oneMinute, "time"),
+		timeLiteral(interval.Start()),
+		timeLiteral(interval.End()),
+		i.GetRandomFleet(),
+		// Calculate number of 10 min intervals that is the max driving duration for the session if we rest 35 mins per hour.
+		tenMinutePeriods(35, iot.DailyDrivingDuration))
+
+	humanLabel := fmt.Sprintf("%s trucks with longer daily sessions", i.label())
+	humanDesc := fmt.Sprintf("%s: drove more than 10 hours in the last 24 hours", humanLabel)
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// AvgVsProjectedFuelConsumption calculates average and projected fuel consumption per fleet.
+func (i *IoT) AvgVsProjectedFuelConsumption(qi query.Query) {
+	sql := `SELECT t.fleet AS fleet, avg(r.fuel_consumption) AS avg_fuel_consumption,
+		avg(t.nominal_fuel_consumption) AS projected_fuel_consumption
+		FROM tags t
+		INNER JOIN readings r ON r.tags_id = t.id
+		WHERE r.velocity > 1
+		AND t.fleet IS NOT NULL
+		AND t.nominal_fuel_consumption IS NOT NULL
+		AND t.name IS NOT NULL
+		GROUP BY fleet`
+
+	humanLabel := fmt.Sprintf("%s average vs projected fuel consumption per fleet", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// AvgDailyDrivingDuration finds the average driving duration per driver.
+func (i *IoT) AvgDailyDrivingDuration(qi query.Query) {
+	sql := fmt.Sprintf(`WITH ten_minute_driving_sessions
+		AS (
+			SELECT %s AS ten_minutes, tags_id
+			FROM readings r
+			GROUP BY tags_id, ten_minutes
+			HAVING avg(velocity) > 1
+			), daily_total_session
+		AS (
+			SELECT %s AS day, tags_id, %s AS hours
+			FROM ten_minute_driving_sessions
+			GROUP BY day, tags_id
+			)
+		SELECT t.fleet AS fleet, t.name AS name, t.driver AS driver, avg(d.hours) AS avg_daily_hours
+		FROM daily_total_session d
+		INNER JOIN tags t ON t.id = d.tags_id
+		GROUP BY fleet, name, driver`,
+		i.timeBucket(10*oneMinute, "time"),
+		i.timeBucket(oneDay, "ten_minutes"),
+		i.intDiv("count(*)", "6"))
+
+	humanLabel := fmt.Sprintf("%s average driver driving duration per day", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// AvgDailyDrivingSession finds the average driving session without stopping per driver per day.
+func (i *IoT) AvgDailyDrivingSession(qi query.Query) {
+	sql := fmt.Sprintf(`WITH driver_status
+		AS (
+			SELECT tags_id, %s AS ten_minutes, avg(velocity) > 5 AS driving
+			FROM readings
+			GROUP BY tags_id, ten_minutes
+			), driver_status_change
+		AS (
+			SELECT tags_id, ten_minutes AS start, lead(ten_minutes) OVER (PARTITION BY tags_id ORDER BY ten_minutes) AS stop, driving
+			FROM (
+				SELECT tags_id, ten_minutes, driving, lag(driving) OVER (PARTITION BY tags_id ORDER BY ten_minutes) AS prev_driving
+				FROM driver_status
+				) x
+			WHERE x.driving <> x.prev_driving
+			)
+		SELECT t.name AS name, %s AS day, avg(%s) AS duration
+		FROM tags t
+		INNER JOIN driver_status_change d ON t.id = d.tags_id
+		WHERE t.name IS NOT NULL
+		AND d.driving = true
+		GROUP BY name, day
+		ORDER BY name, day`,
+		i.timeBucket(10*oneMinute, "time"),
+		i.timeBucket(oneDay, "start"),
+		i.secondsBetween("start", "stop"))
+
+	humanLabel := fmt.Sprintf("%s average driver driving session without stopping per day", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// AvgLoad finds the average load per truck model per fleet.
+func (i *IoT) AvgLoad(qi query.Query) {
+	sql := `SELECT t.fleet AS fleet, t.model AS model, t.load_capacity AS load_capacity, avg(d.avg_load / t.load_capacity) AS avg_load_percentage
+		FROM tags t
+		INNER JOIN (
+			SELECT tags_id, avg(current_load) AS avg_load
+			FROM diagnostics d
+			GROUP BY tags_id
+			) d ON t.id = d.tags_id
+		WHERE t.name IS NOT NULL
+		GROUP BY fleet, model, load_capacity`
+
+	humanLabel := fmt.Sprintf("%s average load per truck model per fleet", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// DailyTruckActivity returns the number of hours trucks has been active (not out-of-commission) per day per fleet per model.
+func (i *IoT) DailyTruckActivity(qi query.Query) {
+	sql := fmt.Sprintf(`SELECT t.fleet AS fleet, t.model AS model, y.day, %s AS daily_activity
+		FROM tags t
+		INNER JOIN (
+			SELECT %s AS day, %s AS ten_minutes, tags_id, count(*) AS ten_mins_per_day
+			FROM diagnostics
+			GROUP BY day, ten_minutes, tags_id
+			HAVING avg(status) < 1
+			) y ON y.tags_id = t.id
+		WHERE t.name IS NOT NULL
+		GROUP BY fleet, model, y.day
+		ORDER BY y.day`,
+		i.intDiv("sum(y.ten_mins_per_day)", "144"),
+		i.timeBucket(oneDay, "time"),
+		i.timeBucket(10*oneMinute, "time"))
+
+	humanLabel := fmt.Sprintf("%s daily truck activity per fleet per model", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.ReadingsTableName, sql)
+}
+
+// TruckBreakdownFrequency calculates the amount of times a truck model broke down in the last period.
+func (i *IoT) TruckBreakdownFrequency(qi query.Query) {
+	sql := fmt.Sprintf(`WITH breakdown_per_truck_per_ten_minutes
+		AS (
+			SELECT %s AS ten_minutes, tags_id, %s >= 0.5 AS broken_down
+			FROM diagnostics
+			GROUP BY ten_minutes, tags_id
+			), breakdowns_per_truck
+		AS (
+			SELECT ten_minutes, tags_id, broken_down, lead(broken_down) OVER (
+					PARTITION BY tags_id ORDER BY ten_minutes
+					) AS next_broken_down
+			FROM breakdown_per_truck_per_ten_minutes
+			)
+		SELECT t.model AS model, count(*)
+		FROM tags t
+		INNER JOIN breakdowns_per_truck b ON t.id = b.tags_id
+		WHERE t.name IS NOT NULL
+		AND broken_down = false AND next_broken_down = true
+		GROUP BY model`,
+		i.timeBucket(10*oneMinute, "time"),
+		i.intDiv("count(status = 0)", "count(*)"))
+
+	humanLabel := fmt.Sprintf("%s truck breakdown frequency per model", i.label())
+	humanDesc := humanLabel
+
+	i.fillInQuery(qi, humanLabel, humanDesc, iot.DiagnosticsTableName, sql)
+}
+
+// tenMinutePeriods calculates the number of 10 minute periods that can fit in
+// the time duration if we subtract the minutes specified by minutesPerHour value.
+// E.g.: 4 hours - 5 minutes per hour = 3 hours and 40 minutes = 22 ten minute periods
+func tenMinutePeriods(minutesPerHour float64, duration time.Duration) int {
+	durationMinutes := duration.Minutes()
+	leftover := minutesPerHour * duration.Hours()
+	return int((durationMinutes - leftover) / 10)
+}
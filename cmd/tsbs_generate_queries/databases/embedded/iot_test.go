@@ -0,0 +1,129 @@
+package embedded
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/iot"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func TestLastLocByTruck(t *testing.T) {
+	rand.Seed(123) // Setting seed for testing purposes.
+	b := BaseGenerator{Engine: constants.FormatSQLite}
+	ig, err := b.NewIoT(time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("Error while creating iot generator")
+	}
+	g := ig.(*IoT)
+
+	q := g.GenerateEmptyQuery()
+	g.LastLocByTruck(q, 2)
+
+	verifyQuery(t, q,
+		"SQLite last location by specific truck",
+		"SQLite last location by specific truck: random    2 trucks",
+		iot.ReadingsTableName,
+		`SELECT t.name AS name, t.driver AS driver, r.longitude, r.latitude
+		FROM tags t INNER JOIN readings r ON r.tags_id = t.id
+		WHERE r.time = (SELECT max(time) FROM readings WHERE tags_id = t.id)
+		AND t.name IN ('truck_5','truck_9')`)
+}
+
+func TestTruckBreakdownFrequency(t *testing.T) {
+	cases := []struct {
+		engine             string
+		expectedHumanLabel string
+		expectedSQLQuery   string
+	}{
+		{
+			engine:             constants.FormatDuckDB,
+			expectedHumanLabel: "DuckDB truck breakdown frequency per model",
+			expectedSQLQuery: `WITH breakdown_per_truck_per_ten_minutes
+		AS (
+			SELECT epoch_ms(CAST(floor(epoch(time) / 600) AS BIGINT) * 600000) AS ten_minutes, tags_id, count(status = 0) // count(*) >= 0.5 AS broken_down
+			FROM diagnostics
+			GROUP BY ten_minutes, tags_id
+			), breakdowns_per_truck
+		AS (
+			SELECT ten_minutes, tags_id, broken_down, lead(broken_down) OVER (
+					PARTITION BY tags_id ORDER BY ten_minutes
+					) AS next_broken_down
+			FROM breakdown_per_truck_per_ten_minutes
+			)
+		SELECT t.model AS model, count(*)
+		FROM tags t
+		INNER JOIN breakdowns_per_truck b ON t.id = b.tags_id
+		WHERE t.name IS NOT NULL
+		AND broken_down = false AND next_broken_down = true
+		GROUP BY model`,
+		},
+		{
+			engine:             constants.FormatSQLite,
+			expectedHumanLabel: "SQLite truck breakdown frequency per model",
+			expectedSQLQuery: `WITH breakdown_per_truck_per_ten_minutes
+		AS (
+			SELECT datetime((CAST(strftime('%s', time) AS INTEGER) / 600) * 600, 'unixepoch') AS ten_minutes, tags_id, count(status = 0) / count(*) >= 0.5 AS broken_down
+			FROM diagnostics
+			GROUP BY ten_minutes, tags_id
+			), breakdowns_per_truck
+		AS (
+			SELECT ten_minutes, tags_id, broken_down, lead(broken_down) OVER (
+					PARTITION BY tags_id ORDER BY ten_minutes
+					) AS next_broken_down
+			FROM breakdown_per_truck_per_ten_minutes
+			)
+		SELECT t.model AS model, count(*)
+		FROM tags t
+		INNER JOIN breakdowns_per_truck b ON t.id = b.tags_id
+		WHERE t.name IS NOT NULL
+		AND broken_down = false AND next_broken_down = true
+		GROUP BY model`,
+		},
+	}
+
+	for _, c := range cases {
+		b := BaseGenerator{Engine: c.engine}
+		ig, err := b.NewIoT(time.Unix(0, 0), time.Unix(0, 0).Add(25*time.Hour), 10)
+		if err != nil {
+			t.Fatalf("Error while creating iot generator")
+		}
+		g := ig.(*IoT)
+
+		q := g.GenerateEmptyQuery()
+		g.TruckBreakdownFrequency(q)
+
+		verifyQuery(t, q, c.expectedHumanLabel, c.expectedHumanLabel, iot.DiagnosticsTableName, c.expectedSQLQuery)
+	}
+}
+
+func TestTenMinutePeriods(t *testing.T) {
+	cases := []struct {
+		minutesPerHour float64
+		duration       time.Duration
+		result         int
+	}{
+		{
+			minutesPerHour: 5.0,
+			duration:       4 * time.Hour,
+			result:         22,
+		},
+		{
+			minutesPerHour: 35.0,
+			duration:       24 * time.Hour,
+			result:         60,
+		},
+		{
+			minutesPerHour: 0.0,
+			duration:       0 * time.Minute,
+			result:         0,
+		},
+	}
+
+	for _, c := range cases {
+		if got := tenMinutePeriods(c.minutesPerHour, c.duration); got != c.result {
+			t.Errorf("incorrect result for %.2f minutes per hour, duration %s: got %d want %d", c.minutesPerHour, c.duration.String(), got, c.result)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package flightsql
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// timeFmt is RFC 3339 in UTC with microseconds, like the TimescaleDB queries.
+const timeFmt = "2006-01-02T15:04:05.999999Z"
+
+const (
+	oneMinute = 60
+	oneHour   = oneMinute * 60
+)
+
+// BaseGenerator contains settings specific for the SQL dialect of FlightSQL
+// engines such as InfluxDB 3, which are based on Apache DataFusion. The
+// queries are the TimescaleDB ones, with time_bucket replaced by date_bin,
+// over the tables written from the InfluxDB line protocol: one table per
+// measurement with a column per tag and per field.
+type BaseGenerator struct {
+}
+
+// GenerateEmptyQuery returns an empty query.SQL.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewSQL()
+}
+
+// fillInQuery fills the query struct with data.
+func (g *BaseGenerator) fillInQuery(qi query.Query, humanLabel, humanDesc, table, sql string) {
+	q := qi.(*query.SQL)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Table = []byte(table)
+	q.SqlQuery = []byte(sql)
+}
+
+// timeBucket returns the expression binning column into buckets of the given
+// number of seconds.
+func timeBucket(seconds int, column string) string {
+	switch seconds {
+	case oneMinute:
+		return fmt.Sprintf("date_bin(INTERVAL '1 minute', %s)", column)
+	case oneHour:
+		return fmt.Sprintf("date_bin(INTERVAL '1 hour', %s)", column)
+	}
+	return fmt.Sprintf("date_bin(INTERVAL '%d seconds', %s)", seconds, column)
+}
+
+// timeLiteral formats t the way times are compared with in WHERE clauses.
+func timeLiteral(t time.Time) string {
+	return t.UTC().Format(timeFmt)
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+	}, nil
+}
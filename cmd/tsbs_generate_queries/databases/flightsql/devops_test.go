@@ -0,0 +1,126 @@
+package flightsql
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/diff"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestTimeBucket(t *testing.T) {
+	cases := []struct {
+		seconds int
+		want    string
+	}{
+		{seconds: oneMinute, want: "date_bin(INTERVAL '1 minute', time)"},
+		{seconds: oneHour, want: "date_bin(INTERVAL '1 hour', time)"},
+		{seconds: 10 * oneMinute, want: "date_bin(INTERVAL '600 seconds', time)"},
+	}
+
+	for _, c := range cases {
+		if got := timeBucket(c.seconds, "time"); got != c.want {
+			t.Errorf("%ds: incorrect output: got %s want %s", c.seconds, got, c.want)
+		}
+	}
+}
+
+func TestDevopsQueries(t *testing.T) {
+	cases := []struct {
+		desc               string
+		fn                 func(d *Devops, q query.Query)
+		expectedHumanLabel string
+		expectedHumanDesc  string
+		expectedSQLQuery   string
+	}{
+		{
+			desc: "GroupByTime",
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 1, time.Second)
+			},
+			expectedHumanLabel: "FlightSQL 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "FlightSQL 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T11:30:39Z",
+			expectedSQLQuery: `SELECT date_bin(INTERVAL '1 minute', time) AS minute,
+        max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND time >= '1970-01-01T11:30:39.646325Z' AND time < '1970-01-01T11:30:40.646325Z'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc: "GroupByTimeAndPrimaryTag",
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTimeAndPrimaryTag(q, 2)
+			},
+			expectedHumanLabel: "FlightSQL mean of 2 metrics, all hosts, random 12h0m0s by 1h",
+			expectedHumanDesc:  "FlightSQL mean of 2 metrics, all hosts, random 12h0m0s by 1h: 1970-01-01T06:16:22Z",
+			expectedSQLQuery: `SELECT date_bin(INTERVAL '1 hour', time) AS hour, hostname,
+        avg(usage_user) AS mean_usage_user, avg(usage_system) AS mean_usage_system
+        FROM cpu
+        WHERE time >= '1970-01-01T06:16:22.646325Z' AND time < '1970-01-01T18:16:22.646325Z'
+        GROUP BY hour, hostname
+        ORDER BY hour, hostname`,
+		},
+		{
+			desc: "LastPointPerHost",
+			fn: func(d *Devops, q query.Query) {
+				d.LastPointPerHost(q)
+			},
+			expectedHumanLabel: "FlightSQL last row per host",
+			expectedHumanDesc:  "FlightSQL last row per host",
+			expectedSQLQuery:   "SELECT DISTINCT ON (hostname) * FROM cpu ORDER BY hostname, time DESC",
+		},
+		{
+			desc: "HighCPUForHosts",
+			fn: func(d *Devops, q query.Query) {
+				d.HighCPUForHosts(q, 1)
+			},
+			expectedHumanLabel: "FlightSQL CPU over threshold, 1 host(s)",
+			expectedHumanDesc:  "FlightSQL CPU over threshold, 1 host(s): 1970-01-01T11:54:10Z",
+			expectedSQLQuery:   "SELECT * FROM cpu WHERE usage_user > 90.0 AND time >= '1970-01-01T11:54:10.138978Z' AND time < '1970-01-01T23:54:10.138978Z' AND hostname IN ('host_5')",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			s := time.Unix(0, 0)
+			e := s.Add(24 * time.Hour)
+			b := BaseGenerator{}
+			dq, err := b.NewDevops(s, e, 10)
+			if err != nil {
+				t.Fatalf("Error while creating devops generator")
+			}
+			d := dq.(*Devops)
+
+			q := d.GenerateEmptyQuery()
+			c.fn(d, q)
+
+			verifyQuery(t, q, c.expectedHumanLabel, c.expectedHumanDesc, "cpu", c.expectedSQLQuery)
+		})
+	}
+}
+
+func verifyQuery(t *testing.T, q query.Query, humanLabel, humanDesc, table, sqlQuery string) {
+	sq, ok := q.(*query.SQL)
+
+	if !ok {
+		t.Fatal("Filled query is not *query.SQL type")
+	}
+
+	if got := string(sq.HumanLabel); got != humanLabel {
+		t.Errorf("incorrect human label:\ngot\n%s\nwant\n%s", got, humanLabel)
+	}
+
+	if got := string(sq.HumanDescription); got != humanDesc {
+		t.Errorf("incorrect human description:\ngot\n%s\nwant\n%s", got, humanDesc)
+	}
+
+	if got := string(sq.Table); got != table {
+		t.Errorf("incorrect table:\ngot\n%s\nwant\n%s", got, table)
+	}
+
+	if got := string(sq.SqlQuery); got != sqlQuery {
+		t.Errorf("incorrect SQL query:\ndiff\n%s\ngot\n%s\nwant\n%s", diff.CharacterDiff(got, sqlQuery), got, sqlQuery)
+	}
+}
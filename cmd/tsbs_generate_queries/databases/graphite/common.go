@@ -0,0 +1,71 @@
+package graphite
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	iutils "github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/graphite"
+)
+
+// BaseGenerator contains settings specific for the Graphite render API.
+type BaseGenerator struct {
+	// Template the data was loaded with, empty if the series are tagged
+	Template string
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+	template, err := graphite.ParseTemplate(g.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+		template:      template,
+	}, nil
+}
+
+type queryInfo struct {
+	// render targets, one per metric
+	targets []string
+	// label to describe type of query
+	label string
+	// time range for query executing
+	interval *iutils.TimeInterval
+}
+
+// fillInQuery fills the query struct with data
+func (g *BaseGenerator) fillInQuery(qq query.Query, qi *queryInfo) {
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", qi.label, qi.interval.StartString()))
+	q.Method = []byte("GET")
+
+	v := url.Values{}
+	for _, target := range qi.targets {
+		v.Add("target", target)
+	}
+	v.Set("from", strconv.FormatInt(qi.interval.StartUnixNano()/1e9, 10))
+	v.Set("until", strconv.FormatInt(qi.interval.EndUnixNano()/1e9, 10))
+	v.Set("format", "json")
+	q.Path = []byte(fmt.Sprintf("/render?%s", v.Encode()))
+	q.Body = nil
+	q.StartTimestamp = qi.interval.StartUnixNano()
+	q.EndTimestamp = qi.interval.EndUnixNano()
+}
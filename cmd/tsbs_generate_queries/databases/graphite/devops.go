@@ -0,0 +1,160 @@
+package graphite
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/graphite"
+)
+
+// Devops produces Graphite render API queries for the devops query types.
+//
+// Every field is a separate series in Graphite, so each query has one render
+// target per metric. The series are selected with seriesByTag when the data
+// was loaded with the tagged-series syntax, or with a path pattern built from
+// the template the data was loaded with.
+type Devops struct {
+	*BaseGenerator
+	*devops.Core
+	template *graphite.Template
+}
+
+// mustGetRandomHosts is the form of GetRandomHosts that cannot error; if it does error,
+// it causes a panic.
+func (d *Devops) mustGetRandomHosts(nHosts int) []string {
+	hosts, err := d.GetRandomHosts(nHosts)
+	if err != nil {
+		panic(err.Error())
+	}
+	return hosts
+}
+
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	panic("GroupByOrderByLimit not supported in Graphite")
+}
+
+func (d *Devops) LastPointPerHost(qq query.Query) {
+	panic("LastPointPerHost not supported in Graphite")
+}
+
+func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	panic("HighCPUForHosts not supported in Graphite")
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu'
+// per minute for nhosts hosts,
+// e.g. for the tagged series:
+//
+// target=alias(summarize(maxSeries(seriesByTag('name=cpu.metric1','hostname=~^(hostname1|...|hostnameN)$')),'1min','max'),'metric1')
+// ...
+// target=alias(summarize(maxSeries(seriesByTag('name=cpu.metricN','hostname=~^(hostname1|...|hostnameN)$')),'1min','max'),'metricN')
+func (d *Devops) GroupByTime(qq query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	hosts := d.mustGetRandomHosts(nHosts)
+	targets := make([]string, len(metrics))
+	for i, m := range metrics {
+		targets[i] = fmt.Sprintf("alias(summarize(maxSeries(%s),'1min','max'),'%s')", d.selectSeries(m, hosts), m)
+	}
+	d.fillInQuery(qq, &queryInfo{
+		targets:  targets,
+		label:    fmt.Sprintf("Graphite %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange),
+		interval: d.Interval.MustRandWindow(timeRange),
+	})
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g. for the tagged series:
+//
+// target=aliasByTags(summarize(seriesByTag('name=cpu.metric1'),'1h','avg'),'name','hostname')
+// ...
+// target=aliasByTags(summarize(seriesByTag('name=cpu.metricN'),'1h','avg'),'name','hostname')
+//
+// Resultsets:
+// double-groupby-1
+// double-groupby-5
+// double-groupby-all
+func (d *Devops) GroupByTimeAndPrimaryTag(qq query.Query, numMetrics int) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	targets := make([]string, len(metrics))
+	for i, m := range metrics {
+		targets[i] = d.aliasByHost(fmt.Sprintf("summarize(%s,'1h','avg')", d.selectSeries(m, nil)))
+	}
+	d.fillInQuery(qq, &queryInfo{
+		targets:  targets,
+		label:    devops.GetDoubleGroupByLabel("Graphite", numMetrics),
+		interval: d.Interval.MustRandWindow(devops.DoubleGroupByDuration),
+	})
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. for the tagged series:
+//
+// target=alias(summarize(maxSeries(seriesByTag('name=cpu.metric1','hostname=~^(hostname1|...|hostnameN)$')),'1h','max'),'metric1')
+// ...
+func (d *Devops) MaxAllCPU(qq query.Query, nHosts int, duration time.Duration) {
+	hosts := d.mustGetRandomHosts(nHosts)
+	metrics := devops.GetAllCPUMetrics()
+	targets := make([]string, len(metrics))
+	for i, m := range metrics {
+		targets[i] = fmt.Sprintf("alias(summarize(maxSeries(%s),'1h','max'),'%s')", d.selectSeries(m, hosts), m)
+	}
+	d.fillInQuery(qq, &queryInfo{
+		targets:  targets,
+		label:    devops.GetMaxAllLabel("Graphite", nHosts),
+		interval: d.Interval.MustRandWindow(duration),
+	})
+}
+
+// selectSeries returns the expression selecting the series of a cpu metric
+// of the given hosts, or of all the hosts if there are none
+func (d *Devops) selectSeries(metric string, hosts []string) string {
+	if d.template == nil {
+		exprs := []string{fmt.Sprintf("'name=cpu.%s'", metric)}
+		if len(hosts) == 1 {
+			exprs = append(exprs, fmt.Sprintf("'hostname=%s'", hosts[0]))
+		} else if len(hosts) > 1 {
+			exprs = append(exprs, fmt.Sprintf("'hostname=~^(%s)$'", strings.Join(hosts, "|")))
+		}
+		return fmt.Sprintf("seriesByTag(%s)", strings.Join(exprs, ","))
+	}
+
+	values := map[string]string{
+		graphite.MeasurementKey: "cpu",
+		graphite.FieldKey:       metric,
+	}
+	if len(hosts) > 0 {
+		if d.template.NodeIndex("hostname") < 0 {
+			panic("the template must contain {hostname} to select hosts")
+		}
+		values["hostname"] = hosts[0]
+		if len(hosts) > 1 {
+			values["hostname"] = fmt.Sprintf("{%s}", strings.Join(hosts, ","))
+		}
+	}
+	return d.template.Glob(values)
+}
+
+// aliasByHost names the series of the expression after their metric and host
+func (d *Devops) aliasByHost(expr string) string {
+	if d.template == nil {
+		return fmt.Sprintf("aliasByTags(%s,'name','hostname')", expr)
+	}
+	hostIdx := d.template.NodeIndex("hostname")
+	if hostIdx < 0 {
+		panic("the template must contain {hostname} to group by host")
+	}
+	return fmt.Sprintf("aliasByNode(%s,%d,%d)", expr, d.template.NodeIndex(graphite.FieldKey), hostIdx)
+}
+
+// mustGetCPUMetricsSlice is the form of GetCPUMetricsSlice that cannot error; if it does error,
+// it causes a panic.
+func mustGetCPUMetricsSlice(numMetrics int) []string {
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	if err != nil {
+		panic(err.Error())
+	}
+	return metrics
+}
@@ -0,0 +1,165 @@
+package graphite
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestDevopsQueries(t *testing.T) {
+	testCases := map[string]struct {
+		template   string
+		fn         func(g *Devops, q *query.HTTP)
+		expTargets []string
+		expToFail  bool
+	}{
+		"GroupByTime_1_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, 1, time.Hour)
+			},
+			expTargets: []string{"alias(summarize(maxSeries(seriesByTag('name=cpu.usage_user','hostname=host_5')),'1min','max'),'usage_user')"},
+		},
+		"GroupByTime_5_2": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 5, 2, time.Hour)
+			},
+			expTargets: []string{
+				"alias(summarize(maxSeries(seriesByTag('name=cpu.usage_user','hostname=~^(host_5|host_9|host_3|host_1|host_7)$')),'1min','max'),'usage_user')",
+				"alias(summarize(maxSeries(seriesByTag('name=cpu.usage_system','hostname=~^(host_5|host_9|host_3|host_1|host_7)$')),'1min','max'),'usage_system')",
+			},
+		},
+		"GroupByTime_5_2_template": {
+			template: "tsbs.{region}.{hostname}.{measurement}.{field}",
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 5, 2, time.Hour)
+			},
+			expTargets: []string{
+				"alias(summarize(maxSeries(tsbs.*.{host_5,host_9,host_3,host_1,host_7}.cpu.usage_user),'1min','max'),'usage_user')",
+				"alias(summarize(maxSeries(tsbs.*.{host_5,host_9,host_3,host_1,host_7}.cpu.usage_system),'1min','max'),'usage_system')",
+			},
+		},
+		"GroupByTime_template_without_hostname": {
+			template: "{measurement}.{field}",
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, 1, time.Hour)
+			},
+			expToFail: true,
+		},
+		"GroupByTimeAndPrimaryTag": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTimeAndPrimaryTag(q, 2)
+			},
+			expTargets: []string{
+				"aliasByTags(summarize(seriesByTag('name=cpu.usage_user'),'1h','avg'),'name','hostname')",
+				"aliasByTags(summarize(seriesByTag('name=cpu.usage_system'),'1h','avg'),'name','hostname')",
+			},
+		},
+		"GroupByTimeAndPrimaryTag_template": {
+			template: "{region}.{hostname}.{measurement}.{field}",
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTimeAndPrimaryTag(q, 1)
+			},
+			expTargets: []string{"aliasByNode(summarize(*.*.cpu.usage_user,'1h','avg'),3,1)"},
+		},
+		"MaxAllCPU": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.MaxAllCPU(q, 1, devops.MaxAllDuration)
+			},
+			expTargets: func() []string {
+				var targets []string
+				for _, m := range devops.GetAllCPUMetrics() {
+					targets = append(targets, "alias(summarize(maxSeries(seriesByTag('name=cpu."+m+"','hostname=host_5')),'1h','max'),'"+m+"')")
+				}
+				return targets
+			}(),
+		},
+		"GroupByOrderByLimit": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByOrderByLimit(q)
+			},
+			expToFail: true,
+		},
+		"LastPointPerHost": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.LastPointPerHost(q)
+			},
+			expToFail: true,
+		},
+		"HighCPUForHosts": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.HighCPUForHosts(q, 6)
+			},
+			expToFail: true,
+		},
+		"GroupByTime_negative_metrics": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, -1, time.Hour)
+			},
+			expToFail: true,
+		},
+	}
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			g := acquireGenerator(t, tc.template, time.Hour*24, 10)
+			rand.Seed(123) // Setting seed for testing purposes.
+			q := g.GenerateEmptyQuery().(*query.HTTP)
+			if tc.expToFail {
+				func() {
+					defer func() {
+						if recover() == nil {
+							t.Errorf("expected to panic")
+						}
+					}()
+					tc.fn(g, q)
+				}()
+				return
+			}
+
+			tc.fn(g, q)
+			path := string(q.Path)
+			if !strings.HasPrefix(path, "/render?") {
+				t.Fatalf("incorrect path: %s", path)
+			}
+			vals, err := url.ParseQuery(strings.TrimPrefix(path, "/render?"))
+			if err != nil {
+				t.Fatalf("unexpected err while parsing query: %s", err)
+			}
+			checkEqual(t, "targets", strings.Join(tc.expTargets, "\n"), strings.Join(vals["target"], "\n"))
+			checkEqual(t, "format", "json", vals.Get("format"))
+			checkEqual(t, "method", http.MethodGet, string(q.Method))
+			if vals.Get("from") == "" || vals.Get("until") == "" {
+				t.Errorf("missing time range: %v", vals)
+			}
+		})
+	}
+}
+
+func TestNewDevopsInvalidTemplate(t *testing.T) {
+	b := &BaseGenerator{Template: "{hostname}.{measurement}"}
+	if _, err := b.NewDevops(time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour), 10); err == nil {
+		t.Errorf("expected error on a template without {field}")
+	}
+}
+
+func checkEqual(t *testing.T, name, a, b string) {
+	if a != b {
+		t.Fatalf("values for %q are not equal \na: %q \nb: %q", name, a, b)
+	}
+}
+
+func acquireGenerator(t *testing.T, template string, interval time.Duration, scale int) *Devops {
+	b := &BaseGenerator{Template: template}
+	s := time.Unix(0, 0)
+	e := s.Add(interval)
+	g, err := b.NewDevops(s, e, scale)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	return g.(*Devops)
+}
@@ -147,3 +147,11 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	influxql := fmt.Sprintf("SELECT * from cpu where usage_user > 90.0 %s and time >= '%s' and time < '%s'", hostWhereClause, interval.StartString(), interval.EndString())
 	d.fillInQuery(qi, humanLabel, humanDesc, influxql)
 }
+
+// TemplateQuery fills in a query of a user-defined template, its text being
+// InfluxQL.
+func (d *Devops) TemplateQuery(qi query.Query, tq *devops.TemplateQuery) {
+	humanLabel := devops.GetTemplateLabel("Influx", tq.Name)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, tq.TimeInterval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, tq.Text)
+}
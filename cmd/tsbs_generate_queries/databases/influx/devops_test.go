@@ -407,6 +407,36 @@ type testCase struct {
 	expectedQuery      string
 }
 
+func TestTemplateQuery(t *testing.T) {
+	tmpl, err := devops.ParseTemplate([]byte(`
+templates:
+- name: max-cpu
+  hosts: 2
+  queries:
+    influx: SELECT max(usage_user) FROM cpu WHERE ({{range $i, $h := .Hosts}}{{if $i}} OR {{end}}hostname = '{{$h}}'{{end}}) AND time >= '{{.TimeStart}}' AND time < '{{.TimeEnd}}' GROUP BY time({{.Interval}})
+`), "max-cpu", "influx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rand.Seed(123) // Setting seed for testing purposes.
+	s := time.Unix(0, 0)
+	e := s.Add(24 * time.Hour)
+	b := BaseGenerator{}
+	dq, err := b.NewDevops(s, e, 10)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	d := dq.(*Devops)
+
+	q := d.GenerateEmptyQuery()
+	devops.NewTemplate(tmpl)(d).Fill(q)
+
+	verifyQuery(t, q, "Influx template max-cpu", "Influx template max-cpu: 1970-01-01T21:47:30Z",
+		"/query?q=SELECT+max%28usage_user%29+FROM+cpu+WHERE+%28hostname+%3D+%27host_5%27+OR+hostname+%3D+%27host_9%27%29"+
+			"+AND+time+%3E%3D+%271970-01-01T21%3A47%3A30Z%27+AND+time+%3C+%271970-01-01T22%3A47%3A30Z%27+GROUP+BY+time%281m%29")
+}
+
 func runTestCases(t *testing.T, testFunc func(*Devops, testCase) query.Query, s time.Time, e time.Time, cases []testCase) {
 	rand.Seed(123) // Setting seed for testing purposes.
 
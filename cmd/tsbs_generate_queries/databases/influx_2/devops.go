@@ -141,6 +141,57 @@ func (d *Devops) MaxAllCPU(qi query.Query, nHosts int, duration time.Duration) {
 	d.fillInQuery(qi, humanLabel, humanDesc, fluxString)
 }
 
+// GroupByTimeRollup selects the MAX for numMetrics metrics per minute for
+// nhosts hosts, like GroupByTime, from the cpu_1m measurement written by the
+// task of the 1m rollup
+func (d *Devops) GroupByTimeRollup(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.MustRandWindow(timeRange)
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	databases.PanicIfErr(err)
+	metricsFilter := d.getMetricsFilterClause(getRollupColumns(metrics))
+	hostsFilter := d.getHostFilterClause(nHosts)
+
+	humanLabel := devops.GetGroupByTimeRollupLabel("Influx 2.x", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	fluxString := fmt.Sprintf(`
+	from(bucket: "benchmark")
+	|> range(start: %s, stop: %s)
+	|> filter(fn: (r) => r._measurement == "%s" and %s and %s)
+	|> aggregateWindow(every: 1m, fn: max, createEmpty: false)
+	|> yield()
+	`, interval.StartString(), interval.EndString(), devops.RollupTableName1m, metricsFilter, hostsFilter)
+	d.fillInQuery(qi, humanLabel, humanDesc, fluxString)
+}
+
+// MaxAllCPURollup selects the MAX of all metrics per hour for nhosts hosts,
+// like MaxAllCPU, from the cpu_1h measurement written by the task of the 1h
+// rollup
+func (d *Devops) MaxAllCPURollup(qi query.Query, nHosts int, duration time.Duration) {
+	interval := d.Interval.MustRandWindow(duration)
+	hostsFilterClause := d.getHostFilterClause(nHosts)
+	metricsFilterClause := d.getMetricsFilterClause(getRollupColumns(devops.GetAllCPUMetrics()))
+
+	humanLabel := devops.GetMaxAllRollupLabel("Influx 2.x", nHosts, duration)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	fluxString := fmt.Sprintf(`
+	from(bucket: "benchmark")
+	|> range(start: %s, stop: %s)
+	|> filter(fn: (r) => r._measurement == "%s" and %s and %s)
+	|> aggregateWindow(every: 1h, fn: max)
+	|> yield(name: "max")
+	`, interval.StartString(), interval.EndString(), devops.RollupTableName1h, metricsFilterClause, hostsFilterClause)
+	d.fillInQuery(qi, humanLabel, humanDesc, fluxString)
+}
+
+// getRollupColumns returns the fields of the rollup measurements for metrics
+func getRollupColumns(metrics []string) []string {
+	columns := make([]string, len(metrics))
+	for i, m := range metrics {
+		columns[i] = devops.GetRollupColumn(m)
+	}
+	return columns
+}
+
 // LastPointPerHost finds the last row for every host in the dataset
 func (d *Devops) LastPointPerHost(qi query.Query) {
 	humanLabel := "Influx 2.x last row per host"
@@ -184,3 +235,11 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	`, interval.StartString(), interval.EndString(), hostsFilterClause)
 	d.fillInQuery(qi, humanLabel, humanDesc, fluxString)
 }
+
+// TemplateQuery fills in a query of a user-defined template, its text being
+// Flux.
+func (d *Devops) TemplateQuery(qi query.Query, tq *devops.TemplateQuery) {
+	humanLabel := devops.GetTemplateLabel("Influx 2.x", tq.Name)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, tq.TimeInterval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, tq.Text)
+}
@@ -255,6 +255,41 @@ func TestMaxAllCPU(t *testing.T) {
 	runTestCases(t, testFunc, start, end, cases)
 }
 
+func TestMaxAllCPURollup(t *testing.T) {
+	cases := []testCase{
+		{
+			desc:    "zero hosts",
+			input:   0,
+			fail:    true,
+			failMsg: "number of hosts cannot be < 1; got 0",
+		},
+		{
+			desc:               "1 host",
+			input:              1,
+			expectedHumanLabel: "Influx 2.x max of all CPU metrics from the 1h rollup, random    1 hosts, random 8h0m0s by 1h",
+			expectedHumanDesc:  "Influx 2.x max of all CPU metrics from the 1h rollup, random    1 hosts, random 8h0m0s by 1h: 1970-01-01T00:54:10Z",
+			expectedQuery: `
+	from(bucket: "benchmark")
+	|> range(start: 1970-01-01T00:54:10Z, stop: 1970-01-01T08:54:10Z)
+	|> filter(fn: (r) => r._measurement == "cpu_1h" and (r._field == 'max_usage_user' or r._field == 'max_usage_system' or r._field == 'max_usage_idle' or r._field == 'max_usage_nice' or r._field == 'max_usage_iowait' or r._field == 'max_usage_irq' or r._field == 'max_usage_softirq' or r._field == 'max_usage_steal' or r._field == 'max_usage_guest' or r._field == 'max_usage_guest_nice') and (r.hostname == 'host_3'))
+	|> aggregateWindow(every: 1h, fn: max)
+	|> yield(name: "max")
+	`,
+		},
+	}
+
+	testFunc := func(d *Devops, c testCase) query.Query {
+		q := d.GenerateEmptyQuery()
+		d.MaxAllCPURollup(q, c.input, devops.MaxAllDuration)
+		return q
+	}
+
+	start := time.Unix(0, 0)
+	end := start.Add(devops.MaxAllDuration).Add(time.Hour)
+
+	runTestCases(t, testFunc, start, end, cases)
+}
+
 func TestLastPointPerHost(t *testing.T) {
 	expectedHumanLabel := "Influx 2.x last row per host"
 	expectedHumanDesc := "Influx 2.x last row per host: cpu"
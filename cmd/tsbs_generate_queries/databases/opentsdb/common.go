@@ -0,0 +1,85 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	iutils "github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// BaseGenerator contains settings specific for the OpenTSDB query API.
+type BaseGenerator struct {
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+	}, nil
+}
+
+// queryRequest is the body of a POST request to /api/query
+type queryRequest struct {
+	Start   int64      `json:"start"`
+	End     int64      `json:"end"`
+	Queries []subQuery `json:"queries"`
+}
+
+// subQuery selects and aggregates the series of one metric
+type subQuery struct {
+	Aggregator string   `json:"aggregator"`
+	Metric     string   `json:"metric"`
+	Downsample string   `json:"downsample,omitempty"`
+	Filters    []filter `json:"filters,omitempty"`
+}
+
+type filter struct {
+	Type    string `json:"type"`
+	Tagk    string `json:"tagk"`
+	Filter  string `json:"filter"`
+	GroupBy bool   `json:"groupBy"`
+}
+
+type queryInfo struct {
+	// sub queries, one per metric
+	queries []subQuery
+	// label to describe type of query
+	label string
+	// time range for query executing
+	interval *iutils.TimeInterval
+}
+
+// fillInQuery fills the query struct with data
+func (g *BaseGenerator) fillInQuery(qq query.Query, qi *queryInfo) {
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", qi.label, qi.interval.StartString()))
+	q.Method = []byte("POST")
+	q.Path = []byte("/api/query")
+
+	body, err := json.Marshal(&queryRequest{
+		Start:   qi.interval.StartUnixMillis(),
+		End:     qi.interval.EndUnixMillis(),
+		Queries: qi.queries,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("could not marshal query: %v", err))
+	}
+	q.Body = body
+	q.StartTimestamp = qi.interval.StartUnixNano()
+	q.EndTimestamp = qi.interval.EndUnixNano()
+}
@@ -0,0 +1,96 @@
+package opentsdb
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Devops produces OpenTSDB /api/query requests for the devops single-groupby
+// and max-all query types.
+//
+// The opentsdb target stores each field as a metric named
+// <measurement>.<field>, so a query has one sub query per metric.
+type Devops struct {
+	*BaseGenerator
+	*devops.Core
+}
+
+// mustGetRandomHosts is the form of GetRandomHosts that cannot error; if it does error,
+// it causes a panic.
+func (d *Devops) mustGetRandomHosts(nHosts int) []string {
+	hosts, err := d.GetRandomHosts(nHosts)
+	if err != nil {
+		panic(err.Error())
+	}
+	return hosts
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu'
+// per minute for nhosts hosts,
+// e.g. in pseudo-JSON:
+//
+// {"start": ..., "end": ..., "queries": [
+// {"aggregator": "max", "metric": "cpu.metric1", "downsample": "1m-max",
+// "filters": [{"type": "literal_or", "tagk": "hostname", "filter": "hostname1|...|hostnameN", "groupBy": false}]},
+// ...
+// ]}
+func (d *Devops) GroupByTime(qq query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	hosts := d.mustGetRandomHosts(nHosts)
+	d.fillInQuery(qq, &queryInfo{
+		queries:  maxQueries(metrics, hosts, "1m-max"),
+		label:    fmt.Sprintf("OpenTSDB %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange),
+		interval: d.Interval.MustRandWindow(timeRange),
+	})
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. in pseudo-JSON:
+//
+// {"start": ..., "end": ..., "queries": [
+// {"aggregator": "max", "metric": "cpu.metric1", "downsample": "1h-max",
+// "filters": [{"type": "literal_or", "tagk": "hostname", "filter": "hostname1|...|hostnameN", "groupBy": false}]},
+// ...
+// ]}
+func (d *Devops) MaxAllCPU(qq query.Query, nHosts int, duration time.Duration) {
+	hosts := d.mustGetRandomHosts(nHosts)
+	d.fillInQuery(qq, &queryInfo{
+		queries:  maxQueries(devops.GetAllCPUMetrics(), hosts, "1h-max"),
+		label:    devops.GetMaxAllLabel("OpenTSDB", nHosts),
+		interval: d.Interval.MustRandWindow(duration),
+	})
+}
+
+// maxQueries returns a sub query per cpu metric with the MAX of the given
+// hosts, downsampled with the given downsampler
+func maxQueries(metrics, hosts []string, downsample string) []subQuery {
+	hostFilter := filter{
+		Type:   "literal_or",
+		Tagk:   "hostname",
+		Filter: strings.Join(hosts, "|"),
+	}
+	queries := make([]subQuery, len(metrics))
+	for i, m := range metrics {
+		queries[i] = subQuery{
+			Aggregator: "max",
+			Metric:     "cpu." + m,
+			Downsample: downsample,
+			Filters:    []filter{hostFilter},
+		}
+	}
+	return queries
+}
+
+// mustGetCPUMetricsSlice is the form of GetCPUMetricsSlice that cannot error; if it does error,
+// it causes a panic.
+func mustGetCPUMetricsSlice(numMetrics int) []string {
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	if err != nil {
+		panic(err.Error())
+	}
+	return metrics
+}
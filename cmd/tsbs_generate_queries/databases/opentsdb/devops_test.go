@@ -0,0 +1,94 @@
+package opentsdb
+
+import (
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestDevopsQueries(t *testing.T) {
+	testCases := map[string]struct {
+		fn        func(g *Devops, q *query.HTTP)
+		expBody   string
+		expToFail bool
+	}{
+		"GroupByTime_1_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, 1, time.Hour)
+			},
+			expBody: `{"start":17650138,"end":21250138,"queries":[` +
+				`{"aggregator":"max","metric":"cpu.usage_user","downsample":"1m-max","filters":[{"type":"literal_or","tagk":"hostname","filter":"host_5","groupBy":false}]}]}`,
+		},
+		"GroupByTime_5_2": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 5, 2, time.Hour)
+			},
+			expBody: `{"start":25937568,"end":29537568,"queries":[` +
+				`{"aggregator":"max","metric":"cpu.usage_user","downsample":"1m-max","filters":[{"type":"literal_or","tagk":"hostname","filter":"host_5|host_9|host_3|host_1|host_7","groupBy":false}]},` +
+				`{"aggregator":"max","metric":"cpu.usage_system","downsample":"1m-max","filters":[{"type":"literal_or","tagk":"hostname","filter":"host_5|host_9|host_3|host_1|host_7","groupBy":false}]}]}`,
+		},
+		"MaxAllCPU": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.MaxAllCPU(q, 1, devops.MaxAllDuration)
+			},
+		},
+		"GroupByTime_negative_metrics": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, -1, time.Hour)
+			},
+			expToFail: true,
+		},
+		"GroupByTime_too_many_hosts": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 100, 1, time.Hour)
+			},
+			expToFail: true,
+		},
+	}
+	g := acquireGenerator(t, time.Hour*24, 10)
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			q := g.GenerateEmptyQuery().(*query.HTTP)
+			if tc.expToFail {
+				func() {
+					defer func() {
+						if recover() == nil {
+							t.Errorf("expected to panic")
+						}
+					}()
+					tc.fn(g, q)
+				}()
+				return
+			}
+
+			tc.fn(g, q)
+			checkEqual(t, "method", http.MethodPost, string(q.Method))
+			checkEqual(t, "path", "/api/query", string(q.Path))
+			if tc.expBody != "" {
+				checkEqual(t, "body", tc.expBody, string(q.Body))
+			}
+		})
+	}
+}
+
+func checkEqual(t *testing.T, name, a, b string) {
+	if a != b {
+		t.Fatalf("values for %q are not equal \na: %q \nb: %q", name, a, b)
+	}
+}
+
+func acquireGenerator(t *testing.T, interval time.Duration, scale int) *Devops {
+	b := &BaseGenerator{}
+	s := time.Unix(0, 0)
+	e := s.Add(interval)
+	g, err := b.NewDevops(s, e, scale)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	return g.(*Devops)
+}
@@ -0,0 +1,77 @@
+package pgwire
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// BaseGenerator contains settings specific for the databases queried over the
+// PostgreSQL wire protocol with tsbs_run_queries_pgwire.
+type BaseGenerator struct {
+	// Dialect is the name of the SQL dialect to generate, see DialectNames.
+	Dialect string
+}
+
+// GenerateEmptyQuery returns an empty query.SQL.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewSQL()
+}
+
+// fillInQuery fills the query struct with data.
+func (g *BaseGenerator) fillInQuery(qi query.Query, humanLabel, humanDesc, table, sql string) {
+	q := qi.(*query.SQL)
+	q.HumanLabel = []byte(humanLabel)
+	q.HumanDescription = []byte(humanDesc)
+	q.Table = []byte(table)
+	q.SqlQuery = []byte(sql)
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	dialect, err := GetDialect(g.Dialect)
+	if err != nil {
+		return nil, err
+	}
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+		dialect:       dialect,
+	}, nil
+}
+
+// timeRange returns the condition selecting the rows in [start, end).
+func (d *Dialect) timeRange(start, end time.Time) string {
+	return fmt.Sprintf("%[1]s >= %[2]s AND %[1]s < %[3]s", d.TimeColumn, d.timeLiteral(start), d.timeLiteral(end))
+}
+
+// bucketColumn returns the select expression of the time bucket of the given
+// number of seconds, named alias.
+func (d *Dialect) bucketColumn(seconds int, alias string) string {
+	if d.SampleBy {
+		return fmt.Sprintf("%s AS %s", d.TimeColumn, alias)
+	}
+	return fmt.Sprintf("%s AS %s", d.bucket(seconds, d.TimeColumn), alias)
+}
+
+// groupByBucket returns the clause grouping the rows by the time bucket of
+// the given number of seconds and by the other keys. With SAMPLE BY the keys
+// are implied by the select list.
+func (d *Dialect) groupByBucket(seconds int, alias string, keys ...string) string {
+	if d.SampleBy {
+		return "SAMPLE BY " + sampleByUnit(seconds)
+	}
+	clause := "GROUP BY " + alias
+	for _, k := range keys {
+		clause += ", " + k
+	}
+	return clause
+}
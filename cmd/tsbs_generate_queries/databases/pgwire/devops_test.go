@@ -0,0 +1,280 @@
+package pgwire
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/andreyvit/diff"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestNewDevopsUnknownDialect(t *testing.T) {
+	b := BaseGenerator{Dialect: "influxql"}
+	_, err := b.NewDevops(time.Unix(0, 0), time.Unix(0, 0).Add(time.Hour), 10)
+	if err == nil {
+		t.Fatal("expected an error for an unknown dialect")
+	}
+	want := "unknown pgwire dialect 'influxql', choose one of [cratedb greptimedb postgres questdb timescaledb]"
+	if err.Error() != want {
+		t.Errorf("incorrect error: got %s want %s", err.Error(), want)
+	}
+}
+
+func TestDevopsQueries(t *testing.T) {
+	cases := []struct {
+		desc               string
+		dialect            string
+		fn                 func(d *Devops, q query.Query)
+		expectedHumanLabel string
+		expectedHumanDesc  string
+		expectedSQLQuery   string
+	}{
+		{
+			desc:    "GroupByTime time_bucket",
+			dialect: DialectTimescaleDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 1, time.Second)
+			},
+			expectedHumanLabel: "TimescaleDB 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "TimescaleDB 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T11:30:39Z",
+			expectedSQLQuery: `SELECT time_bucket('60 seconds', time) AS minute,
+        max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND time >= '1970-01-01T11:30:39.646325Z' AND time < '1970-01-01T11:30:40.646325Z'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc:    "GroupByTime date_trunc",
+			dialect: DialectPostgres,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 1, time.Second)
+			},
+			expectedHumanLabel: "Postgres 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "Postgres 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T11:30:39Z",
+			expectedSQLQuery: `SELECT date_trunc('minute', time) AS minute,
+        max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND time >= '1970-01-01T11:30:39.646325Z' AND time < '1970-01-01T11:30:40.646325Z'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc:    "GroupByTime date_trunc with tags object",
+			dialect: DialectCrateDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 1, time.Second)
+			},
+			expectedHumanLabel: "CrateDB 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "CrateDB 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T11:30:39Z",
+			expectedSQLQuery: `SELECT date_trunc('minute', ts) AS minute,
+        max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE tags['hostname'] IN ('host_9') AND ts >= 41439646 AND ts < 41440646
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc:    "GroupByTime SAMPLE BY",
+			dialect: DialectQuestDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 1, time.Second)
+			},
+			expectedHumanLabel: "QuestDB 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "QuestDB 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T11:30:39Z",
+			expectedSQLQuery: `SELECT timestamp AS minute,
+        max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND timestamp >= '1970-01-01T11:30:39.646325Z' AND timestamp < '1970-01-01T11:30:40.646325Z'
+        SAMPLE BY 1m`,
+		},
+		{
+			desc:    "GroupByTime date_bin",
+			dialect: DialectGreptimeDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTime(q, 1, 1, time.Second)
+			},
+			expectedHumanLabel: "GreptimeDB 1 cpu metric(s), random    1 hosts, random 1s by 1m",
+			expectedHumanDesc:  "GreptimeDB 1 cpu metric(s), random    1 hosts, random 1s by 1m: 1970-01-01T11:30:39Z",
+			expectedSQLQuery: `SELECT date_bin(INTERVAL '60 seconds', greptime_timestamp) AS minute,
+        max(usage_user) AS max_usage_user
+        FROM cpu
+        WHERE hostname IN ('host_9') AND greptime_timestamp >= '1970-01-01T11:30:39.646325Z' AND greptime_timestamp < '1970-01-01T11:30:40.646325Z'
+        GROUP BY minute ORDER BY minute ASC`,
+		},
+		{
+			desc:    "GroupByOrderByLimit",
+			dialect: DialectPostgres,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByOrderByLimit(q)
+			},
+			expectedHumanLabel: "Postgres max cpu over last 5 min-intervals (random end)",
+			expectedHumanDesc:  "Postgres max cpu over last 5 min-intervals (random end): 1970-01-01T21:16:22Z",
+			expectedSQLQuery: `SELECT date_trunc('minute', time) AS minute, max(usage_user)
+        FROM cpu
+        WHERE time < '1970-01-01T21:16:22.646325Z'
+        GROUP BY minute ORDER BY minute DESC
+        LIMIT 5`,
+		},
+		{
+			desc:    "GroupByOrderByLimit SAMPLE BY",
+			dialect: DialectQuestDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByOrderByLimit(q)
+			},
+			expectedHumanLabel: "QuestDB max cpu over last 5 min-intervals (random end)",
+			expectedHumanDesc:  "QuestDB max cpu over last 5 min-intervals (random end): 1970-01-01T21:16:22Z",
+			expectedSQLQuery: `SELECT timestamp AS minute, max(usage_user)
+        FROM cpu
+        WHERE timestamp < '1970-01-01T21:16:22.646325Z'
+        SAMPLE BY 1m
+        LIMIT -5`,
+		},
+		{
+			desc:    "GroupByTimeAndPrimaryTag",
+			dialect: DialectCrateDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTimeAndPrimaryTag(q, 1)
+			},
+			expectedHumanLabel: "CrateDB mean of 1 metrics, all hosts, random 12h0m0s by 1h",
+			expectedHumanDesc:  "CrateDB mean of 1 metrics, all hosts, random 12h0m0s by 1h: 1970-01-01T06:16:22Z",
+			expectedSQLQuery: `SELECT date_trunc('hour', ts) AS hour, tags['hostname'] AS hostname,
+        avg(usage_user) AS mean_usage_user
+        FROM cpu
+        WHERE ts >= 22582646 AND ts < 65782646
+        GROUP BY hour, tags['hostname'] ORDER BY hour, hostname`,
+		},
+		{
+			desc:    "GroupByTimeAndPrimaryTag SAMPLE BY",
+			dialect: DialectQuestDB,
+			fn: func(d *Devops, q query.Query) {
+				d.GroupByTimeAndPrimaryTag(q, 1)
+			},
+			expectedHumanLabel: "QuestDB mean of 1 metrics, all hosts, random 12h0m0s by 1h",
+			expectedHumanDesc:  "QuestDB mean of 1 metrics, all hosts, random 12h0m0s by 1h: 1970-01-01T06:16:22Z",
+			expectedSQLQuery: `SELECT timestamp AS hour, hostname,
+        avg(usage_user) AS mean_usage_user
+        FROM cpu
+        WHERE timestamp >= '1970-01-01T06:16:22.646325Z' AND timestamp < '1970-01-01T18:16:22.646325Z'
+        SAMPLE BY 1h`,
+		},
+		{
+			desc:    "LastPointPerHost",
+			dialect: DialectQuestDB,
+			fn: func(d *Devops, q query.Query) {
+				d.LastPointPerHost(q)
+			},
+			expectedHumanLabel: "QuestDB last row per host",
+			expectedHumanDesc:  "QuestDB last row per host",
+			expectedSQLQuery:   "SELECT * FROM cpu LATEST BY hostname",
+		},
+		{
+			desc:    "HighCPUForHosts",
+			dialect: DialectCrateDB,
+			fn: func(d *Devops, q query.Query) {
+				d.HighCPUForHosts(q, 1)
+			},
+			expectedHumanLabel: "CrateDB CPU over threshold, 1 host(s)",
+			expectedHumanDesc:  "CrateDB CPU over threshold, 1 host(s): 1970-01-01T11:54:10Z",
+			expectedSQLQuery:   "SELECT * FROM cpu WHERE usage_user > 90.0 AND ts >= 42850138 AND ts < 86050138 AND tags['hostname'] IN ('host_5')",
+		},
+		{
+			desc:    "HighCPUForHosts all hosts",
+			dialect: DialectGreptimeDB,
+			fn: func(d *Devops, q query.Query) {
+				d.HighCPUForHosts(q, 0)
+			},
+			expectedHumanLabel: "GreptimeDB CPU over threshold, all hosts",
+			expectedHumanDesc:  "GreptimeDB CPU over threshold, all hosts: 1970-01-01T06:16:22Z",
+			expectedSQLQuery:   "SELECT * FROM cpu WHERE usage_user > 90.0 AND greptime_timestamp >= '1970-01-01T06:16:22.646325Z' AND greptime_timestamp < '1970-01-01T18:16:22.646325Z'",
+		},
+		{
+			desc:    "TemplateQuery",
+			dialect: DialectQuestDB,
+			fn: func(d *Devops, q query.Query) {
+				tmpl, err := devops.ParseTemplate([]byte(`
+templates:
+- name: max-cpu
+  hosts: 2
+  metrics: 2
+  queries:
+    pgwire: SELECT timestamp, max({{.Metrics.Join "), max("}}) FROM cpu WHERE hostname IN ({{.Hosts.Quote}}) AND timestamp >= '{{.TimeStart}}' AND timestamp < '{{.TimeEnd}}' SAMPLE BY {{.Interval}}
+`), "max-cpu", "pgwire")
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				tq, err := d.RenderTemplate(tmpl)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				d.TemplateQuery(q, tq)
+			},
+			expectedHumanLabel: "QuestDB template max-cpu",
+			expectedHumanDesc:  "QuestDB template max-cpu: 1970-01-01T21:47:30Z",
+			expectedSQLQuery: "SELECT timestamp, max(usage_user), max(usage_system) FROM cpu WHERE hostname IN ('host_5','host_9')" +
+				" AND timestamp >= '1970-01-01T21:47:30Z' AND timestamp < '1970-01-01T22:47:30Z' SAMPLE BY 1m",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			s := time.Unix(0, 0)
+			e := s.Add(24 * time.Hour)
+			b := BaseGenerator{Dialect: c.dialect}
+			dq, err := b.NewDevops(s, e, 10)
+			if err != nil {
+				t.Fatalf("Error while creating devops generator: %v", err)
+			}
+			d := dq.(*Devops)
+
+			q := d.GenerateEmptyQuery()
+			c.fn(d, q)
+
+			verifyQuery(t, q, c.expectedHumanLabel, c.expectedHumanDesc, "cpu", c.expectedSQLQuery)
+		})
+	}
+}
+
+func TestDialectBucket(t *testing.T) {
+	cases := []struct {
+		dialect string
+		seconds int
+		want    string
+	}{
+		{DialectPostgres, oneMinute, "date_trunc('minute', time)"},
+		{DialectPostgres, oneHour, "date_trunc('hour', time)"},
+		{DialectPostgres, 300, "to_timestamp(floor(extract(epoch from time) / 300) * 300)"},
+		{DialectCrateDB, oneHour, "date_trunc('hour', ts)"},
+		{DialectCrateDB, 300, "date_bin('300 seconds'::interval, ts, 0)"},
+	}
+	for _, c := range cases {
+		d := dialects[c.dialect]
+		if got := d.bucket(c.seconds, d.TimeColumn); got != c.want {
+			t.Errorf("%s: incorrect bucket of %d seconds: got %s want %s", c.dialect, c.seconds, got, c.want)
+		}
+	}
+}
+
+func verifyQuery(t *testing.T, q query.Query, humanLabel, humanDesc, table, sqlQuery string) {
+	sq, ok := q.(*query.SQL)
+
+	if !ok {
+		t.Fatal("Filled query is not *query.SQL type")
+	}
+
+	if got := string(sq.HumanLabel); got != humanLabel {
+		t.Errorf("incorrect human label:\ngot\n%s\nwant\n%s", got, humanLabel)
+	}
+
+	if got := string(sq.HumanDescription); got != humanDesc {
+		t.Errorf("incorrect human description:\ngot\n%s\nwant\n%s", got, humanDesc)
+	}
+
+	if got := string(sq.Table); got != table {
+		t.Errorf("incorrect table:\ngot\n%s\nwant\n%s", got, table)
+	}
+
+	if got := string(sq.SqlQuery); got != sqlQuery {
+		t.Errorf("incorrect SQL query:\ndiff\n%s\ngot\n%s\nwant\n%s", diff.CharacterDiff(got, sqlQuery), got, sqlQuery)
+	}
+}
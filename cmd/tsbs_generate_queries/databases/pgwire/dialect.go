@@ -0,0 +1,164 @@
+package pgwire
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Names of the SQL dialects spoken over the PostgreSQL wire protocol.
+const (
+	DialectTimescaleDB = "timescaledb"
+	DialectPostgres    = "postgres"
+	DialectCrateDB     = "cratedb"
+	DialectQuestDB     = "questdb"
+	DialectGreptimeDB  = "greptimedb"
+)
+
+// timeFmt is RFC 3339 in UTC with microseconds, like the TimescaleDB queries.
+const timeFmt = "2006-01-02T15:04:05.999999Z"
+
+const (
+	oneMinute = 60
+	oneHour   = oneMinute * 60
+)
+
+// Dialect describes how a database spells the parts of the devops queries
+// which differ between the Postgres compatible engines: the columns the
+// loaders create, how time is bucketed and how the last point is selected.
+type Dialect struct {
+	// Name is the label prefix of the queries, e.g. "CrateDB".
+	Name string
+	// TimeColumn is the column holding the time of a row.
+	TimeColumn string
+	// HostnameColumn is the expression of the hostname tag.
+	HostnameColumn string
+	// SampleBy is set when time is bucketed with a SAMPLE BY clause rather
+	// than by grouping on a bucketing function.
+	SampleBy bool
+	// bucket returns the expression truncating column to the given number of
+	// seconds; it is nil when SampleBy is set.
+	bucket func(seconds int, column string) string
+	// timeLiteral returns the SQL literal a time is compared with.
+	timeLiteral func(t time.Time) string
+	// lastPoint is the query selecting the last row of every host.
+	lastPoint string
+}
+
+var dialects = map[string]*Dialect{
+	// TimescaleDB with the hostname in the cpu table, i.e. loaded with
+	// --use-hypertable and --in-table-partition-tag.
+	DialectTimescaleDB: {
+		Name:           "TimescaleDB",
+		TimeColumn:     "time",
+		HostnameColumn: "hostname",
+		bucket: func(seconds int, column string) string {
+			return fmt.Sprintf("time_bucket('%d seconds', %s)", seconds, column)
+		},
+		timeLiteral: quotedTime,
+		lastPoint:   "SELECT DISTINCT ON (hostname) * FROM cpu ORDER BY hostname, time DESC",
+	},
+	// Plain PostgreSQL and the engines compatible with it, e.g. CockroachDB
+	// and YugabyteDB, loaded with tsbs_load_timescaledb --use-hypertable=false.
+	DialectPostgres: {
+		Name:           "Postgres",
+		TimeColumn:     "time",
+		HostnameColumn: "hostname",
+		bucket:         dateTrunc(epochBin),
+		timeLiteral:    quotedTime,
+		lastPoint:      "SELECT DISTINCT ON (hostname) * FROM cpu ORDER BY hostname, time DESC",
+	},
+	// CrateDB as loaded by tsbs_load_cratedb, the tags are an object column.
+	DialectCrateDB: {
+		Name:           "CrateDB",
+		TimeColumn:     "ts",
+		HostnameColumn: "tags['hostname']",
+		bucket:         dateTrunc(dateBin),
+		timeLiteral: func(t time.Time) string {
+			return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+		},
+		lastPoint: `SELECT * FROM (SELECT tags['hostname'] AS host, max(ts) AS max_ts FROM cpu GROUP BY tags['hostname']) t, cpu c
+        WHERE t.max_ts = c.ts AND t.host = c.tags['hostname']`,
+	},
+	// QuestDB as loaded by tsbs_load_questdb.
+	DialectQuestDB: {
+		Name:           "QuestDB",
+		TimeColumn:     "timestamp",
+		HostnameColumn: "hostname",
+		SampleBy:       true,
+		timeLiteral:    quotedTime,
+		lastPoint:      "SELECT * FROM cpu LATEST BY hostname",
+	},
+	// GreptimeDB loaded with the InfluxDB line protocol, which names the
+	// time index greptime_timestamp.
+	DialectGreptimeDB: {
+		Name:           "GreptimeDB",
+		TimeColumn:     "greptime_timestamp",
+		HostnameColumn: "hostname",
+		bucket: func(seconds int, column string) string {
+			return fmt.Sprintf("date_bin(INTERVAL '%d seconds', %s)", seconds, column)
+		},
+		timeLiteral: quotedTime,
+		lastPoint:   "SELECT DISTINCT ON (hostname) * FROM cpu ORDER BY hostname, greptime_timestamp DESC",
+	},
+}
+
+// GetDialect returns the dialect with the given name.
+func GetDialect(name string) (*Dialect, error) {
+	d, ok := dialects[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown pgwire dialect '%s', choose one of %v", name, DialectNames())
+	}
+	return d, nil
+}
+
+// DialectNames returns the sorted names of the supported dialects.
+func DialectNames() []string {
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// dateTrunc returns the bucket function truncating to a minute or an hour,
+// the buckets the devops queries use, and bucketing by any other number of
+// seconds with binSeconds.
+func dateTrunc(binSeconds func(seconds int, column string) string) func(seconds int, column string) string {
+	return func(seconds int, column string) string {
+		switch seconds {
+		case oneMinute:
+			return fmt.Sprintf("date_trunc('minute', %s)", column)
+		case oneHour:
+			return fmt.Sprintf("date_trunc('hour', %s)", column)
+		}
+		return binSeconds(seconds, column)
+	}
+}
+
+// epochBin buckets by seconds since the epoch, date_bin needing PostgreSQL 14.
+func epochBin(seconds int, column string) string {
+	return fmt.Sprintf("to_timestamp(floor(extract(epoch from %s) / %d) * %d)", column, seconds, seconds)
+}
+
+// dateBin buckets by seconds with date_bin from the epoch.
+func dateBin(seconds int, column string) string {
+	return fmt.Sprintf("date_bin('%d seconds'::interval, %s, 0)", seconds, column)
+}
+
+func quotedTime(t time.Time) string {
+	return "'" + t.UTC().Format(timeFmt) + "'"
+}
+
+// sampleByUnit returns the SAMPLE BY unit of the given number of seconds.
+func sampleByUnit(seconds int) string {
+	switch seconds {
+	case oneMinute:
+		return "1m"
+	case oneHour:
+		return "1h"
+	}
+	return fmt.Sprintf("%ds", seconds)
+}
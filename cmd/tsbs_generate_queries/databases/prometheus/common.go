@@ -0,0 +1,109 @@
+package prometheus
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/timescale/promscale/pkg/prompb"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	iutils "github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// BaseGenerator contains settings specific for the Prometheus query API.
+// The generated queries can be run against any server implementing the
+// Prometheus /api/v1/query_range endpoint (Prometheus, Promscale, Thanos, Mimir...).
+type BaseGenerator struct {
+	// UseRemoteRead generates remote-read requests instead of PromQL queries
+	UseRemoteRead bool
+}
+
+// GenerateEmptyQuery returns an empty query.HTTP.
+func (g *BaseGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+// NewDevops creates a new devops use case query generator.
+func (g *BaseGenerator) NewDevops(start, end time.Time, scale int) (utils.QueryGenerator, error) {
+	core, err := devops.NewCore(start, end, scale)
+	if err != nil {
+		return nil, err
+	}
+	return &Devops{
+		BaseGenerator: g,
+		Core:          core,
+	}, nil
+}
+
+type queryInfo struct {
+	// PromQL query
+	query string
+	// label to describe type of query
+	label string
+	// time range for query executing
+	interval *iutils.TimeInterval
+	// time period to group by in seconds
+	step string
+}
+
+// fillInQuery fills the query struct with data
+func (g *BaseGenerator) fillInQuery(qq query.Query, qi *queryInfo) {
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(qi.label)
+	if qi.interval != nil {
+		q.HumanDescription = []byte(fmt.Sprintf("%s: %s", qi.label, qi.interval.StartString()))
+	}
+	q.Method = []byte("GET")
+
+	v := url.Values{}
+	v.Set("query", qi.query)
+	v.Set("start", strconv.FormatInt(qi.interval.StartUnixNano()/1e9, 10))
+	v.Set("end", strconv.FormatInt(qi.interval.EndUnixNano()/1e9, 10))
+	v.Set("step", qi.step)
+	q.Path = []byte(fmt.Sprintf("/api/v1/query_range?%s", v.Encode()))
+	q.Body = nil
+}
+
+type readInfo struct {
+	// label to describe type of query
+	label string
+	// time range for query executing
+	interval *iutils.TimeInterval
+	// matchers selecting the series to read
+	matchers []*prompb.LabelMatcher
+	// hints describing the PromQL evaluation the read is for
+	hints *prompb.ReadHints
+}
+
+// fillInReadRequest fills the query struct with a protobuf encoded remote-read
+// request. The request is not snappy compressed, that is left to the runner.
+func (g *BaseGenerator) fillInReadRequest(qq query.Query, ri *readInfo) {
+	q := qq.(*query.HTTP)
+	q.HumanLabel = []byte(ri.label)
+	q.HumanDescription = []byte(fmt.Sprintf("%s: %s", ri.label, ri.interval.StartString()))
+	q.Method = []byte("POST")
+	q.Path = q.Path[:0]
+
+	ri.hints.StartMs = ri.interval.StartUnixMillis()
+	ri.hints.EndMs = ri.interval.EndUnixMillis()
+	req := &prompb.ReadRequest{
+		Queries: []*prompb.Query{{
+			StartTimestampMs: ri.interval.StartUnixMillis(),
+			EndTimestampMs:   ri.interval.EndUnixMillis(),
+			Matchers:         ri.matchers,
+			Hints:            ri.hints,
+		}},
+		AcceptedResponseTypes: []prompb.ReadRequest_ResponseType{prompb.ReadRequest_SAMPLES},
+	}
+	body, err := proto.Marshal(req)
+	if err != nil {
+		panic(fmt.Sprintf("could not marshal read request: %v", err))
+	}
+	q.Body = body
+	q.StartTimestamp = ri.interval.StartUnixNano()
+	q.EndTimestamp = ri.interval.EndUnixNano()
+}
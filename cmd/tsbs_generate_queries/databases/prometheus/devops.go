@@ -0,0 +1,225 @@
+package prometheus
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/timescale/promscale/pkg/prompb"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Devops produces PromQL queries, or remote-read requests, for all the devops query types.
+//
+// The prometheus target stores each field as a series named after the field
+// (e.g. usage_user) labeled with the host tags. Range functions such as
+// max_over_time drop the metric name, so queries over several metrics are
+// built as one aggregation per metric, each labeled with its metric name
+// and combined with 'or'.
+type Devops struct {
+	*BaseGenerator
+	*devops.Core
+}
+
+// mustGetRandomHosts is the form of GetRandomHosts that cannot error; if it does error,
+// it causes a panic.
+func (d *Devops) mustGetRandomHosts(nHosts int) []string {
+	hosts, err := d.GetRandomHosts(nHosts)
+	if err != nil {
+		panic(err.Error())
+	}
+	return hosts
+}
+
+func (d *Devops) GroupByOrderByLimit(qi query.Query) {
+	panic("GroupByOrderByLimit not supported in PromQL")
+}
+
+func (d *Devops) LastPointPerHost(qq query.Query) {
+	panic("LastPointPerHost not supported in PromQL")
+}
+
+func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
+	panic("HighCPUForHosts not supported in PromQL")
+}
+
+// GroupByTime selects the MAX for numMetrics metrics under 'cpu'
+// per minute for nhosts hosts,
+// e.g. in pseudo-PromQL:
+//
+// label_replace(max(max_over_time(metric1{hostname=~"hostname1|...|hostnameN"}[1m])), "__name__", "metric1", "", "")
+// or
+// ...
+// or
+// label_replace(max(max_over_time(metricN{hostname=~"hostname1|...|hostnameN"}[1m])), "__name__", "metricN", "", "")
+func (d *Devops) GroupByTime(qq query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	hosts := d.mustGetRandomHosts(nHosts)
+	if d.UseRemoteRead {
+		d.fillInReadRequest(qq, &readInfo{
+			label:    fmt.Sprintf("Prometheus remote-read %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange),
+			interval: d.Interval.MustRandWindow(timeRange),
+			matchers: getMatchers(metrics, hosts),
+			hints:    getHints("max_over_time", time.Minute, "__name__"),
+		})
+		return
+	}
+	qi := &queryInfo{
+		query:    perMetric(metrics, "max(max_over_time(%s[1m]))", hosts),
+		label:    fmt.Sprintf("Prometheus %d cpu metric(s), random %4d hosts, random %s by 1m", numMetrics, nHosts, timeRange),
+		interval: d.Interval.MustRandWindow(timeRange),
+		step:     "60",
+	}
+	d.fillInQuery(qq, qi)
+}
+
+// GroupByTimeAndPrimaryTag selects the AVG of numMetrics metrics under 'cpu' per device per hour for a day,
+// e.g. in pseudo-PromQL:
+//
+// label_replace(avg by (hostname) (avg_over_time(metric1[1h])), "__name__", "metric1", "", "")
+// or
+// ...
+//
+// Resultsets:
+// double-groupby-1
+// double-groupby-5
+// double-groupby-all
+func (d *Devops) GroupByTimeAndPrimaryTag(qq query.Query, numMetrics int) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	if d.UseRemoteRead {
+		d.fillInReadRequest(qq, &readInfo{
+			label:    devops.GetDoubleGroupByLabel("Prometheus remote-read", numMetrics),
+			interval: d.Interval.MustRandWindow(devops.DoubleGroupByDuration),
+			matchers: getMatchers(metrics, nil),
+			hints:    getHints("avg_over_time", time.Hour, "__name__", "hostname"),
+		})
+		return
+	}
+	qi := &queryInfo{
+		query:    perMetric(metrics, "avg by (hostname) (avg_over_time(%s[1h]))", nil),
+		label:    devops.GetDoubleGroupByLabel("Prometheus", numMetrics),
+		interval: d.Interval.MustRandWindow(devops.DoubleGroupByDuration),
+		step:     "3600",
+	}
+	d.fillInQuery(qq, qi)
+}
+
+// MaxAllCPU selects the MAX of all metrics under 'cpu' per hour for nhosts hosts,
+// e.g. in pseudo-PromQL:
+//
+// label_replace(max(max_over_time(metric1{hostname=~"hostname1|...|hostnameN"}[1h])), "__name__", "metric1", "", "")
+// or
+// ...
+func (d *Devops) MaxAllCPU(qq query.Query, nHosts int, duration time.Duration) {
+	hosts := d.mustGetRandomHosts(nHosts)
+	if d.UseRemoteRead {
+		d.fillInReadRequest(qq, &readInfo{
+			label:    devops.GetMaxAllLabel("Prometheus remote-read", nHosts),
+			interval: d.Interval.MustRandWindow(duration),
+			matchers: getMatchers(devops.GetAllCPUMetrics(), hosts),
+			hints:    getHints("max_over_time", time.Hour, "__name__"),
+		})
+		return
+	}
+	qi := &queryInfo{
+		query:    perMetric(devops.GetAllCPUMetrics(), "max(max_over_time(%s[1h]))", hosts),
+		label:    devops.GetMaxAllLabel("Prometheus", nHosts),
+		interval: d.Interval.MustRandWindow(duration),
+		step:     "3600",
+	}
+	d.fillInQuery(qq, qi)
+}
+
+// perMetric applies the aggregation format to the selector of every metric.
+// When there is more than one metric each result is labeled with its
+// metric name and the results are combined with 'or'.
+func perMetric(metrics []string, aggregation string, hosts []string) string {
+	if len(metrics) == 0 {
+		panic("BUG: must be at least one metric name in clause")
+	}
+	if len(metrics) == 1 {
+		return fmt.Sprintf(aggregation, getSelectClause(metrics[0], hosts))
+	}
+
+	parts := make([]string, len(metrics))
+	for i, m := range metrics {
+		agg := fmt.Sprintf(aggregation, getSelectClause(m, hosts))
+		parts[i] = fmt.Sprintf("label_replace(%s, '__name__', '%s', '', '')", agg, m)
+	}
+	return strings.Join(parts, " or ")
+}
+
+func getHostClause(hostnames []string) string {
+	if len(hostnames) == 0 {
+		return ""
+	}
+	if len(hostnames) == 1 {
+		return fmt.Sprintf("hostname='%s'", hostnames[0])
+	}
+	return fmt.Sprintf("hostname=~'%s'", strings.Join(hostnames, "|"))
+}
+
+func getSelectClause(metric string, hosts []string) string {
+	hostsClause := getHostClause(hosts)
+	if hostsClause == "" {
+		return metric
+	}
+	return fmt.Sprintf("%s{%s}", metric, hostsClause)
+}
+
+// getMatchers returns the remote-read label matchers selecting the given
+// metrics of the given hosts
+func getMatchers(metrics, hosts []string) []*prompb.LabelMatcher {
+	matchers := []*prompb.LabelMatcher{getMatcher(model.MetricNameLabel, metrics)}
+	if len(hosts) > 0 {
+		matchers = append(matchers, getMatcher("hostname", hosts))
+	}
+	return matchers
+}
+
+func getMatcher(name string, values []string) *prompb.LabelMatcher {
+	if len(values) == 1 {
+		return &prompb.LabelMatcher{Type: prompb.LabelMatcher_EQ, Name: name, Value: values[0]}
+	}
+	return &prompb.LabelMatcher{Type: prompb.LabelMatcher_RE, Name: name, Value: strings.Join(values, "|")}
+}
+
+// getHints returns the read hints of a range function aggregated by the given labels,
+// evaluated with a step equal to its range
+func getHints(fn string, rng time.Duration, by ...string) *prompb.ReadHints {
+	return &prompb.ReadHints{
+		StepMs:   rng.Milliseconds(),
+		Func:     fn,
+		RangeMs:  rng.Milliseconds(),
+		Grouping: by,
+		By:       true,
+	}
+}
+
+// mustGetCPUMetricsSlice is the form of GetCPUMetricsSlice that cannot error; if it does error,
+// it causes a panic.
+func mustGetCPUMetricsSlice(numMetrics int) []string {
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	if err != nil {
+		panic(err.Error())
+	}
+	return metrics
+}
+
+// TemplateQuery fills in a query of a user-defined template, its text being a
+// PromQL expression evaluated over the time window of the query every
+// interval.
+func (d *Devops) TemplateQuery(qq query.Query, tq *devops.TemplateQuery) {
+	if d.UseRemoteRead {
+		panic("TemplateQuery not supported with remote reads")
+	}
+	qi := &queryInfo{
+		query:    tq.Text,
+		label:    devops.GetTemplateLabel("Prometheus", tq.Name),
+		interval: tq.TimeInterval,
+		step:     fmt.Sprintf("%d", int64(tq.Interval/time.Second)),
+	}
+	d.fillInQuery(qq, qi)
+}
@@ -0,0 +1,227 @@
+package prometheus
+
+import (
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/timescale/promscale/pkg/prompb"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/devops"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+func TestDevopsQueries(t *testing.T) {
+	testCases := map[string]struct {
+		fn        func(g *Devops, q *query.HTTP)
+		expQuery  string
+		expStep   string
+		expToFail bool
+	}{
+		"GroupByTime_1_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, 1, time.Hour)
+			},
+			expQuery: "max(max_over_time(usage_user{hostname='host_5'}[1m]))",
+			expStep:  "60",
+		},
+		"GroupByTime_5_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 5, 1, time.Hour)
+			},
+			expQuery: "max(max_over_time(usage_user{hostname=~'host_5|host_9|host_3|host_1|host_7'}[1m]))",
+			expStep:  "60",
+		},
+		"GroupByTime_5_2": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 5, 2, time.Hour)
+			},
+			expQuery: "label_replace(max(max_over_time(usage_user{hostname=~'host_5|host_9|host_3|host_1|host_7'}[1m])), '__name__', 'usage_user', '', '')" +
+				" or label_replace(max(max_over_time(usage_system{hostname=~'host_5|host_9|host_3|host_1|host_7'}[1m])), '__name__', 'usage_system', '', '')",
+			expStep: "60",
+		},
+		"GroupByTimeAndPrimaryTag": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTimeAndPrimaryTag(q, 2)
+			},
+			expQuery: "label_replace(avg by (hostname) (avg_over_time(usage_user[1h])), '__name__', 'usage_user', '', '')" +
+				" or label_replace(avg by (hostname) (avg_over_time(usage_system[1h])), '__name__', 'usage_system', '', '')",
+			expStep: "3600",
+		},
+		"MaxAllCPU": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.MaxAllCPU(q, 1, devops.MaxAllDuration)
+			},
+			expQuery: "label_replace(max(max_over_time(usage_user{hostname='host_5'}[1h])), '__name__', 'usage_user', '', '')" +
+				" or label_replace(max(max_over_time(usage_system{hostname='host_5'}[1h])), '__name__', 'usage_system', '', '')" +
+				" or label_replace(max(max_over_time(usage_idle{hostname='host_5'}[1h])), '__name__', 'usage_idle', '', '')" +
+				" or label_replace(max(max_over_time(usage_nice{hostname='host_5'}[1h])), '__name__', 'usage_nice', '', '')" +
+				" or label_replace(max(max_over_time(usage_iowait{hostname='host_5'}[1h])), '__name__', 'usage_iowait', '', '')" +
+				" or label_replace(max(max_over_time(usage_irq{hostname='host_5'}[1h])), '__name__', 'usage_irq', '', '')" +
+				" or label_replace(max(max_over_time(usage_softirq{hostname='host_5'}[1h])), '__name__', 'usage_softirq', '', '')" +
+				" or label_replace(max(max_over_time(usage_steal{hostname='host_5'}[1h])), '__name__', 'usage_steal', '', '')" +
+				" or label_replace(max(max_over_time(usage_guest{hostname='host_5'}[1h])), '__name__', 'usage_guest', '', '')" +
+				" or label_replace(max(max_over_time(usage_guest_nice{hostname='host_5'}[1h])), '__name__', 'usage_guest_nice', '', '')",
+			expStep: "3600",
+		},
+		"GroupByOrderByLimit": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByOrderByLimit(q)
+			},
+			expToFail: true,
+		},
+		"LastPointPerHost": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.LastPointPerHost(q)
+			},
+			expToFail: true,
+		},
+		"HighCPUForHosts": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.HighCPUForHosts(q, 6)
+			},
+			expToFail: true,
+		},
+		"GroupByTime_negative_metrics": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, -1, time.Hour)
+			},
+			expToFail: true,
+		},
+		"GroupByTime_negative_hosts": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, -1, 1, time.Hour)
+			},
+			expToFail: true,
+		},
+	}
+	g := acquireGenerator(t, time.Hour*24, 10)
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			q := g.GenerateEmptyQuery().(*query.HTTP)
+			if tc.expToFail {
+				func() {
+					defer func() {
+						if recover() == nil {
+							t.Errorf("expected to panic")
+						}
+					}()
+					tc.fn(g, q)
+				}()
+				return
+			}
+
+			tc.fn(g, q)
+			vals, err := url.ParseQuery(string(q.Path))
+			if err != nil {
+				t.Fatalf("unexpected err while parsing query: %s", err)
+			}
+			checkEqual(t, "query", tc.expQuery, vals.Get("query"))
+			checkEqual(t, "step", tc.expStep, vals.Get("step"))
+			checkEqual(t, "method", http.MethodGet, string(q.Method))
+		})
+	}
+}
+
+func checkEqual(t *testing.T, name, a, b string) {
+	if a != b {
+		t.Fatalf("values for %q are not equal \na: %q \nb: %q", name, a, b)
+	}
+}
+
+func acquireGenerator(t *testing.T, interval time.Duration, scale int) *Devops {
+	b := &BaseGenerator{}
+	s := time.Unix(0, 0)
+	e := s.Add(interval)
+	g, err := b.NewDevops(s, e, scale)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	return g.(*Devops)
+}
+
+func TestDevopsRemoteRead(t *testing.T) {
+	testCases := map[string]struct {
+		fn          func(g *Devops, q *query.HTTP)
+		expMatchers []*prompb.LabelMatcher
+		expFunc     string
+		expRangeMs  int64
+		expGrouping []string
+	}{
+		"GroupByTime_1_1": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTime(q, 1, 1, time.Hour)
+			},
+			expMatchers: []*prompb.LabelMatcher{
+				{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "usage_user"},
+				{Type: prompb.LabelMatcher_EQ, Name: "hostname", Value: "host_5"},
+			},
+			expFunc:     "max_over_time",
+			expRangeMs:  60000,
+			expGrouping: []string{"__name__"},
+		},
+		"GroupByTimeAndPrimaryTag": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTimeAndPrimaryTag(q, 2)
+			},
+			expMatchers: []*prompb.LabelMatcher{
+				{Type: prompb.LabelMatcher_RE, Name: "__name__", Value: "usage_user|usage_system"},
+			},
+			expFunc:     "avg_over_time",
+			expRangeMs:  3600000,
+			expGrouping: []string{"__name__", "hostname"},
+		},
+		"MaxAllCPU": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.MaxAllCPU(q, 2, devops.MaxAllDuration)
+			},
+			expMatchers: []*prompb.LabelMatcher{
+				{Type: prompb.LabelMatcher_RE, Name: "__name__", Value: strings.Join(devops.GetAllCPUMetrics(), "|")},
+				{Type: prompb.LabelMatcher_RE, Name: "hostname", Value: "host_5|host_9"},
+			},
+			expFunc:     "max_over_time",
+			expRangeMs:  3600000,
+			expGrouping: []string{"__name__"},
+		},
+	}
+	g := acquireGenerator(t, time.Hour*24, 10)
+	g.UseRemoteRead = true
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			rand.Seed(123) // Setting seed for testing purposes.
+			q := g.GenerateEmptyQuery().(*query.HTTP)
+			tc.fn(g, q)
+			checkEqual(t, "method", http.MethodPost, string(q.Method))
+
+			var req prompb.ReadRequest
+			if err := proto.Unmarshal(q.Body, &req); err != nil {
+				t.Fatalf("could not decode read request: %v", err)
+			}
+			if len(req.Queries) != 1 {
+				t.Fatalf("incorrect number of queries: got %d want 1", len(req.Queries))
+			}
+			rq := req.Queries[0]
+			if rq.StartTimestampMs != q.StartTimestamp/1e6 || rq.EndTimestampMs != q.EndTimestamp/1e6 {
+				t.Errorf("incorrect time range: got %d-%d want %d-%d", rq.StartTimestampMs, rq.EndTimestampMs, q.StartTimestamp/1e6, q.EndTimestamp/1e6)
+			}
+			if len(rq.Matchers) != len(tc.expMatchers) {
+				t.Fatalf("incorrect number of matchers: got %v want %v", rq.Matchers, tc.expMatchers)
+			}
+			for i, m := range rq.Matchers {
+				exp := tc.expMatchers[i]
+				if m.Type != exp.Type || m.Name != exp.Name || m.Value != exp.Value {
+					t.Errorf("incorrect matcher %d: got %v want %v", i, m, exp)
+				}
+			}
+			checkEqual(t, "func", tc.expFunc, rq.Hints.Func)
+			if rq.Hints.RangeMs != tc.expRangeMs {
+				t.Errorf("incorrect range: got %d want %d", rq.Hints.RangeMs, tc.expRangeMs)
+			}
+			checkEqual(t, "grouping", strings.Join(tc.expGrouping, ","), strings.Join(rq.Hints.Grouping, ","))
+		})
+	}
+}
@@ -258,3 +258,66 @@ func (d *Devops) HighCPUForHosts(qi query.Query, nHosts int) {
 	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
 	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, sql)
 }
+
+// getRollupSelectClauses returns the max of the rollup columns of metrics,
+// named like the ones of the queries on the cpu table
+func (d *Devops) getRollupSelectClauses(metrics []string) []string {
+	selectClauses := make([]string, len(metrics))
+	for i, m := range metrics {
+		selectClauses[i] = fmt.Sprintf("max(%[1]s) as %[1]s", devops.GetRollupColumn(m))
+	}
+
+	return selectClauses
+}
+
+// GroupByTimeRollup selects the MAX for numMetrics metrics per minute for
+// nhosts hosts, like GroupByTime, from the 1m rollup of the cpu table
+func (d *Devops) GroupByTimeRollup(qi query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	interval := d.Interval.MustRandWindow(timeRange)
+	metrics, err := devops.GetCPUMetricsSlice(numMetrics)
+	panicIfErr(err)
+
+	sql := fmt.Sprintf(`SELECT time AS minute,
+        %s
+        FROM %s
+        WHERE %s AND time >= '%s' AND time < '%s'
+        GROUP BY minute ORDER BY minute ASC`,
+		strings.Join(d.getRollupSelectClauses(metrics), ", "),
+		devops.RollupTableName1m,
+		d.getHostWhereString(nHosts),
+		interval.Start().Format(goTimeFmt),
+		interval.End().Format(goTimeFmt))
+
+	humanLabel := devops.GetGroupByTimeRollupLabel("TimescaleDB", numMetrics, nHosts, timeRange)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.RollupTableName1m, sql)
+}
+
+// MaxAllCPURollup selects the MAX of all metrics per hour for nHosts hosts,
+// like MaxAllCPU, from the 1h rollup of the cpu table
+func (d *Devops) MaxAllCPURollup(qi query.Query, nHosts int, duration time.Duration) {
+	interval := d.Interval.MustRandWindow(duration)
+
+	sql := fmt.Sprintf(`SELECT time AS hour,
+        %s
+        FROM %s
+        WHERE %s AND time >= '%s' AND time < '%s'
+        GROUP BY hour ORDER BY hour`,
+		strings.Join(d.getRollupSelectClauses(devops.GetAllCPUMetrics()), ", "),
+		devops.RollupTableName1h,
+		d.getHostWhereString(nHosts),
+		interval.Start().Format(goTimeFmt),
+		interval.End().Format(goTimeFmt))
+
+	humanLabel := devops.GetMaxAllRollupLabel("TimescaleDB", nHosts, duration)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, interval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.RollupTableName1h, sql)
+}
+
+// TemplateQuery fills in a query of a user-defined template, its text being
+// SQL.
+func (d *Devops) TemplateQuery(qi query.Query, tq *devops.TemplateQuery) {
+	humanLabel := devops.GetTemplateLabel("TimescaleDB", tq.Name)
+	humanDesc := fmt.Sprintf("%s: %s", humanLabel, tq.TimeInterval.StartString())
+	d.fillInQuery(qi, humanLabel, humanDesc, devops.TableName, tq.Text)
+}
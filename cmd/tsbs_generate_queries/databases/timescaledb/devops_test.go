@@ -383,6 +383,61 @@ func TestMaxAllCPU(t *testing.T) {
 	verifyQuery(t, q, expectedHumanLabel, expectedHumanDesc, expectedHypertable, expectedSQLQuery)
 }
 
+func TestGroupByTimeRollup(t *testing.T) {
+	expectedHumanLabel := "TimescaleDB 2 cpu metric(s) from the 1m rollup, random    2 hosts, random 1h0m0s by 1m"
+	expectedHumanDesc := "TimescaleDB 2 cpu metric(s) from the 1m rollup, random    2 hosts, random 1h0m0s by 1m: 1970-01-01T00:16:22Z"
+	expectedHypertable := "cpu_1m"
+	expectedSQLQuery := `SELECT time AS minute,
+        max(max_usage_user) as max_usage_user, max(max_usage_system) as max_usage_system
+        FROM cpu_1m
+        WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_9','host_3')) AND time >= '1970-01-01 00:16:22.646325 +0000' AND time < '1970-01-01 01:16:22.646325 +0000'
+        GROUP BY minute ORDER BY minute ASC`
+
+	rand.Seed(123) // Setting seed for testing purposes.
+	s := time.Unix(0, 0)
+	e := s.Add(2 * time.Hour)
+	b := BaseGenerator{
+		UseTags: true,
+	}
+	dq, err := b.NewDevops(s, e, 10)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	d := dq.(*Devops)
+
+	q := d.GenerateEmptyQuery()
+	d.GroupByTimeRollup(q, 2, 2, time.Hour)
+	verifyQuery(t, q, expectedHumanLabel, expectedHumanDesc, expectedHypertable, expectedSQLQuery)
+}
+
+func TestMaxAllCPURollup(t *testing.T) {
+	expectedHumanLabel := "TimescaleDB max of all CPU metrics from the 1h rollup, random    1 hosts, random 720h0m0s by 1h"
+	expectedHumanDesc := "TimescaleDB max of all CPU metrics from the 1h rollup, random    1 hosts, random 720h0m0s by 1h: 1970-01-01T00:16:22Z"
+	expectedHypertable := "cpu_1h"
+	expectedSQLQuery := `SELECT time AS hour,
+        max(max_usage_user) as max_usage_user, max(max_usage_system) as max_usage_system, max(max_usage_idle) as max_usage_idle, ` +
+		"max(max_usage_nice) as max_usage_nice, max(max_usage_iowait) as max_usage_iowait, max(max_usage_irq) as max_usage_irq, " +
+		"max(max_usage_softirq) as max_usage_softirq, max(max_usage_steal) as max_usage_steal, max(max_usage_guest) as max_usage_guest, " +
+		`max(max_usage_guest_nice) as max_usage_guest_nice
+        FROM cpu_1h
+        WHERE hostname IN ('host_9') AND time >= '1970-01-01 00:16:22.646325 +0000' AND time < '1970-01-31 00:16:22.646325 +0000'
+        GROUP BY hour ORDER BY hour`
+
+	rand.Seed(123) // Setting seed for testing purposes.
+	s := time.Unix(0, 0)
+	e := s.Add(devops.MaxAllRollupLongDuration).Add(time.Hour)
+	b := BaseGenerator{}
+	dq, err := b.NewDevops(s, e, 10)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	d := dq.(*Devops)
+
+	q := d.GenerateEmptyQuery()
+	d.MaxAllCPURollup(q, 1, devops.MaxAllRollupLongDuration)
+	verifyQuery(t, q, expectedHumanLabel, expectedHumanDesc, expectedHypertable, expectedSQLQuery)
+}
+
 func TestLastPointPerHost(t *testing.T) {
 	cases := []struct {
 		desc               string
@@ -510,6 +565,46 @@ func TestHighCPUForHosts(t *testing.T) {
 	}
 }
 
+func TestTemplateQuery(t *testing.T) {
+	tmpl, err := devops.ParseTemplate([]byte(`
+templates:
+- name: max-cpu
+  hosts: 3
+  duration: 12h
+  interval: 1h
+  queries:
+    timescaledb: |
+      SELECT time_bucket('{{.Interval.Seconds}} seconds', time) AS hour, max({{.Metrics}})
+      FROM cpu
+      WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ({{.Hosts.Quote}}))
+      AND time >= '{{.TimeStart}}' AND time < '{{.TimeEnd}}'
+      GROUP BY hour ORDER BY hour
+`), "max-cpu", "timescaledb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	rand.Seed(123) // Setting seed for testing purposes.
+	s := time.Unix(0, 0)
+	e := s.Add(24 * time.Hour)
+	b := BaseGenerator{}
+	dq, err := b.NewDevops(s, e, 10)
+	if err != nil {
+		t.Fatalf("Error while creating devops generator")
+	}
+	d := dq.(*Devops)
+
+	q := d.GenerateEmptyQuery()
+	devops.NewTemplate(tmpl)(d).Fill(q)
+
+	verifyQuery(t, q, "TimescaleDB template max-cpu", "TimescaleDB template max-cpu: 1970-01-01T04:37:12Z", "cpu",
+		`SELECT time_bucket('3600 seconds', time) AS hour, max(usage_user)
+FROM cpu
+WHERE tags_id IN (SELECT id FROM tags WHERE hostname IN ('host_5','host_9','host_3'))
+AND time >= '1970-01-01T04:37:12Z' AND time < '1970-01-01T16:37:12Z'
+GROUP BY hour ORDER BY hour`)
+}
+
 func verifyQuery(t *testing.T, q query.Query, humanLabel, humanDesc, hypertable, sqlQuery string) {
 	tsq, ok := q.(*query.TimescaleDB)
 
@@ -103,6 +103,47 @@ func (d *Devops) MaxAllCPU(qq query.Query, nHosts int, duration time.Duration) {
 	d.fillInQuery(qq, qi)
 }
 
+// GroupByTimeRollup selects the MAX for numMetrics metrics per minute for
+// nhosts hosts, like GroupByTime, from the metrics recorded by the rules of
+// the 1m rollup
+func (d *Devops) GroupByTimeRollup(qq query.Query, nHosts, numMetrics int, timeRange time.Duration) {
+	metrics := mustGetCPUMetricsSlice(numMetrics)
+	hosts := d.mustGetRandomHosts(nHosts)
+	selectClause := getSelectClause(getRollupMetrics(devops.RollupTableName1m, metrics), hosts)
+	qi := &queryInfo{
+		query:    fmt.Sprintf("max(max_over_time(%s[1m])) by (__name__)", selectClause),
+		label:    devops.GetGroupByTimeRollupLabel("VictoriaMetrics", numMetrics, nHosts, timeRange),
+		interval: d.Interval.MustRandWindow(timeRange),
+		step:     "60",
+	}
+	d.fillInQuery(qq, qi)
+}
+
+// MaxAllCPURollup selects the MAX of all metrics per hour for nHosts hosts,
+// like MaxAllCPU, from the metrics recorded by the rules of the 1h rollup
+func (d *Devops) MaxAllCPURollup(qq query.Query, nHosts int, duration time.Duration) {
+	hosts := d.mustGetRandomHosts(nHosts)
+	selectClause := getSelectClause(getRollupMetrics(devops.RollupTableName1h, devops.GetAllCPUMetrics()), hosts)
+	qi := &queryInfo{
+		query:    fmt.Sprintf("max(max_over_time(%s[1h])) by (__name__)", selectClause),
+		label:    devops.GetMaxAllRollupLabel("VictoriaMetrics", nHosts, duration),
+		interval: d.Interval.MustRandWindow(duration),
+		step:     "3600",
+	}
+	d.fillInQuery(qq, qi)
+}
+
+// getRollupMetrics returns the names of the metrics recorded by the rules of
+// the rollup for metrics, without the cpu_ prefix getSelectClause adds, e.g.
+// 1m_max_usage_user for cpu_1m_max_usage_user
+func getRollupMetrics(rollup string, metrics []string) []string {
+	rollupMetrics := make([]string, len(metrics))
+	for i, metric := range metrics {
+		rollupMetrics[i] = strings.TrimPrefix(rollup, devops.TableName+"_") + "_" + devops.GetRollupColumn(metric)
+	}
+	return rollupMetrics
+}
+
 func getHostClause(hostnames []string) string {
 	if len(hostnames) == 0 {
 		return ""
@@ -139,3 +180,16 @@ func mustGetCPUMetricsSlice(numMetrics int) []string {
 	}
 	return metrics
 }
+
+// TemplateQuery fills in a query of a user-defined template, its text being a
+// PromQL expression evaluated over the time window of the query every
+// interval.
+func (d *Devops) TemplateQuery(qq query.Query, tq *devops.TemplateQuery) {
+	qi := &queryInfo{
+		query:    tq.Text,
+		label:    devops.GetTemplateLabel("VictoriaMetrics", tq.Name),
+		interval: tq.TimeInterval,
+		step:     fmt.Sprintf("%d", int64(tq.Interval/time.Second)),
+	}
+	d.fillInQuery(qq, qi)
+}
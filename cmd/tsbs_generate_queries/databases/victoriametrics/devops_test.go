@@ -53,6 +53,42 @@ func Test_what(t *testing.T) {
 			expQuery: "max(max_over_time({__name__=~'cpu_(usage_user|usage_system|usage_idle|usage_nice|usage_iowait|usage_irq|usage_softirq|usage_steal|usage_guest|usage_guest_nice)', hostname=~'host_5|host_9|host_3|host_1|host_7'}[1h])) by (__name__)",
 			expStep:  "3600",
 		},
+		"GroupByTimeRollup": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.GroupByTimeRollup(q, 5, 2, time.Hour)
+			},
+			expQuery: "max(max_over_time({__name__=~'cpu_(1m_max_usage_user|1m_max_usage_system)', hostname=~'host_5|host_9|host_3|host_1|host_7'}[1m])) by (__name__)",
+			expStep:  "60",
+		},
+		"MaxAllCPURollup": {
+			fn: func(g *Devops, q *query.HTTP) {
+				g.MaxAllCPURollup(q, 1, 12*time.Hour)
+			},
+			expQuery: "max(max_over_time({__name__=~'cpu_(1h_max_usage_user|1h_max_usage_system|1h_max_usage_idle|1h_max_usage_nice|1h_max_usage_iowait|1h_max_usage_irq|1h_max_usage_softirq|1h_max_usage_steal|1h_max_usage_guest|1h_max_usage_guest_nice)', hostname='host_5'}[1h])) by (__name__)",
+			expStep:  "3600",
+		},
+		"TemplateQuery": {
+			fn: func(g *Devops, q *query.HTTP) {
+				tmpl, err := devops.ParseTemplate([]byte(`
+templates:
+- name: max-cpu
+  hosts: 2
+  interval: 5m
+  queries:
+    victoriametrics: max(max_over_time(cpu_usage_user{hostname=~"{{.Hosts.Join "|"}}"}[{{.Interval}}])) by (hostname)
+`), "max-cpu", "victoriametrics")
+				if err != nil {
+					panic(err)
+				}
+				tq, err := g.RenderTemplate(tmpl)
+				if err != nil {
+					panic(err)
+				}
+				g.TemplateQuery(q, tq)
+			},
+			expQuery: `max(max_over_time(cpu_usage_user{hostname=~"host_5|host_9"}[5m])) by (hostname)`,
+			expStep:  "300",
+		},
 		"GroupByOrderByLimit": {
 			fn: func(g *Devops, q *query.HTTP) {
 				g.GroupByOrderByLimit(q)
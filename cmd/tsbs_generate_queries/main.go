@@ -15,6 +15,7 @@ import (
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
 	"github.com/timescale/tsbs/internal/inputs"
 	internalUtils "github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
 )
 
 var useCaseMatrix = map[string]map[string]utils.QueryFillerMaker{
@@ -35,6 +36,13 @@ var useCaseMatrix = map[string]map[string]utils.QueryFillerMaker{
 		devops.LabelHighCPU + "-all":          devops.NewHighCPU(0),
 		devops.LabelHighCPU + "-1":            devops.NewHighCPU(1),
 		devops.LabelLastpoint:                 devops.NewLastPointPerHost,
+
+		devops.LabelSingleGroupby + "-1-1-12" + devops.LabelRollupSuffix: devops.NewSingleGroupbyRollup(1, 1, 12),
+		devops.LabelSingleGroupby + "-5-1-12" + devops.LabelRollupSuffix: devops.NewSingleGroupbyRollup(5, 1, 12),
+		devops.LabelMaxAll + "-1" + devops.LabelRollupSuffix:             devops.NewMaxAllCPURollup(1, devops.MaxAllDuration),
+		devops.LabelMaxAll + "-8" + devops.LabelRollupSuffix:             devops.NewMaxAllCPURollup(8, devops.MaxAllDuration),
+		devops.LabelMaxAll + "-32-24" + devops.LabelRollupSuffix:         devops.NewMaxAllCPURollup(32, 24*time.Hour),
+		devops.LabelMaxAll + "-30d" + devops.LabelRollupSuffix:           devops.NewMaxAllCPURollup(8, devops.MaxAllRollupLongDuration),
 	},
 	"iot": {
 		iot.LabelLastLoc:                       iot.NewLastLocPerTruck,
@@ -70,6 +78,7 @@ func init() {
 				fmt.Fprintf(os.Stderr, "  use case: %s, query type: %s\n", uc, qt)
 			}
 		}
+		fmt.Fprintf(os.Stderr, "  use case: devops or cpu-only, query type: %s (with --query-templates)\n", devops.LabelTemplate)
 	}
 
 	conf.AddToFlagSet(pflag.CommandLine)
@@ -92,9 +101,48 @@ func init() {
 }
 
 func main() {
+	if err := applyDatasetManifest(); err != nil {
+		fmt.Printf("error: %v\n", err)
+		return
+	}
+	if err := addTemplateQueryType(); err != nil {
+		fmt.Printf("error: %v\n", err)
+		return
+	}
 	qg := inputs.NewQueryGenerator(useCaseMatrix)
 	err := qg.Generate(conf)
 	if err != nil {
 		fmt.Printf("error: %v\n", err)
 	}
 }
+
+// applyDatasetManifest takes the use case, scale and time range from the
+// manifest of the dataset, if given, in place of the flags not set.
+func applyDatasetManifest() error {
+	if conf.DatasetManifest == "" {
+		return nil
+	}
+	m, err := common.ReadDatasetManifest(conf.DatasetManifest)
+	if err != nil {
+		return err
+	}
+	return conf.ApplyDatasetManifest(m, viper.IsSet)
+}
+
+// addTemplateQueryType adds the template query type, generating the queries of
+// the chosen user-defined template, to the devops and cpu-only use cases. It
+// is only known once the template file is read.
+func addTemplateQueryType() error {
+	if conf.QueryType != devops.LabelTemplate {
+		return nil
+	}
+	if conf.QueryTemplateFile == "" {
+		return fmt.Errorf("the %s query type requires --query-templates", devops.LabelTemplate)
+	}
+	t, err := devops.LoadTemplate(conf.QueryTemplateFile, conf.QueryTemplate, conf.Format)
+	if err != nil {
+		return err
+	}
+	useCaseMatrix["devops"][devops.LabelTemplate] = devops.NewTemplate(t)
+	return nil
+}
@@ -12,6 +12,10 @@ import (
 
 const (
 	errMoreItemsThanScale = "cannot get random permutation with more items than scale"
+
+	// maxDistributedMisses is the number of duplicates GetDistributedSubset
+	// picks with a distribution before picking uniformly
+	maxDistributedMisses = 1000
 )
 
 // Core is the common component of all generators for all systems
@@ -21,6 +25,10 @@ type Core struct {
 
 	// Scale is the cardinality of the dataset in terms of devices/hosts
 	Scale int
+
+	// Items is the distribution devices/hosts are picked with, nil for
+	// uniformly
+	Items internalutils.Distribution
 }
 
 // NewCore returns a new Core for the given time range and cardinality
@@ -33,6 +41,15 @@ func NewCore(start, end time.Time, scale int) (*Core, error) {
 	return &Core{Interval: ti, Scale: scale}, nil
 }
 
+// SetAccessDistributions sets the distributions the devices/hosts and the
+// time windows of the queries are picked with. With a skewed distribution the
+// lowest numbered devices/hosts and the most recent windows are the hottest.
+// A nil distribution picks uniformly.
+func (c *Core) SetAccessDistributions(items, windows internalutils.Distribution) {
+	c.Items = items
+	c.Interval.SetWindowDistribution(windows)
+}
+
 // PanicUnimplementedQuery generates a panic for the provided query generator.
 func PanicUnimplementedQuery(dg utils.QueryGenerator) {
 	panic(fmt.Sprintf("database (%v) does not implement query", reflect.TypeOf(dg)))
@@ -64,3 +81,35 @@ func GetRandomSubsetPerm(numItems int, totalItems int) ([]int, error) {
 	}
 	return res, nil
 }
+
+// GetDistributedSubset returns a subset of numItems of the numbers from 0 to
+// totalItems, without duplicates, picked with the given distribution. With a
+// nil distribution it is GetRandomSubsetPerm. As picking the cold items of a
+// skewed distribution gets unlikely once the hot ones are taken, the rest of
+// the items are picked uniformly after too many duplicates.
+func GetDistributedSubset(numItems int, totalItems int, dist internalutils.Distribution) ([]int, error) {
+	if dist == nil {
+		return GetRandomSubsetPerm(numItems, totalItems)
+	}
+	if numItems > totalItems {
+		return nil, fmt.Errorf(errMoreItemsThanScale)
+	}
+
+	seen := map[int]bool{}
+	res := make([]int, 0, numItems)
+	for misses := 0; len(res) < numItems; {
+		var n int
+		if misses < maxDistributedMisses {
+			n = dist.Pick(totalItems)
+		} else {
+			n = rand.Intn(totalItems)
+		}
+		if seen[n] {
+			misses++
+			continue
+		}
+		seen[n] = true
+		res = append(res, n)
+	}
+	return res, nil
+}
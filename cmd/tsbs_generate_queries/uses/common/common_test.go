@@ -75,3 +75,69 @@ func TestGetRandomSubsetPermError(t *testing.T) {
 		t.Errorf("incorrect output:\ngot\n%s\nwant\n%s", got, errMoreItemsThanScale)
 	}
 }
+
+func TestGetDistributedSubset(t *testing.T) {
+	hotset := &utils.Hotset{HotPercent: 10, HitPercent: 100}
+	cases := []struct {
+		desc   string
+		scale  int
+		nItems int
+		dist   utils.Distribution
+	}{
+		{desc: "uniform", scale: 10, nItems: 5},
+		{desc: "zipf", scale: 100, nItems: 5, dist: utils.NewZipf(1.2)},
+		{desc: "zipf all items", scale: 100, nItems: 100, dist: utils.NewZipf(3)},
+		{desc: "hotset larger than hot items", scale: 100, nItems: 20, dist: hotset},
+	}
+
+	for _, c := range cases {
+		ret, err := GetDistributedSubset(c.nItems, c.scale, c.dist)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: got %v", c.desc, err)
+		}
+		if len(ret) != c.nItems {
+			t.Errorf("%s: return list not long enough: got %d want %d", c.desc, len(ret), c.nItems)
+		}
+		sort.Ints(ret)
+		prev := -1
+		for _, x := range ret {
+			if x == prev || x < 0 || x >= c.scale {
+				t.Errorf("%s: duplicate or out of range int %d in sorted result", c.desc, x)
+			}
+			prev = x
+		}
+	}
+
+	// all the hot items come first
+	for i := 0; i < 100; i++ {
+		ret, _ := GetDistributedSubset(5, 100, hotset)
+		for _, x := range ret {
+			if x >= 10 {
+				t.Fatalf("cold item %d picked among %v", x, ret)
+			}
+		}
+	}
+
+	if _, err := GetDistributedSubset(11, 10, hotset); err == nil || err.Error() != errMoreItemsThanScale {
+		t.Errorf("incorrect error for more items than scale: %v", err)
+	}
+}
+
+func TestCoreSetAccessDistributions(t *testing.T) {
+	s := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	e := s.Add(24 * time.Hour)
+	c, err := NewCore(s, e, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hotset := &utils.Hotset{HotPercent: 1, HitPercent: 100}
+	c.SetAccessDistributions(hotset, hotset)
+	if c.Items != hotset {
+		t.Errorf("items distribution not set")
+	}
+	for i := 0; i < 100; i++ {
+		if x := c.Interval.MustRandWindow(time.Hour); x.Start().Before(e.Add(-2 * time.Hour)) {
+			t.Fatalf("window not in the most recent slot: %v", x.Start())
+		}
+	}
+}
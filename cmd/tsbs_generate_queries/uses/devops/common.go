@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/common"
+	internalutils "github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/query"
 )
 
@@ -50,9 +51,10 @@ func NewCore(start, end time.Time, scale int) (*Core, error) {
 
 }
 
-// GetRandomHosts returns a random set of nHosts from a given Core
+// GetRandomHosts returns a random set of nHosts from a given Core, picked
+// with its access distribution
 func (d *Core) GetRandomHosts(nHosts int) ([]string, error) {
-	return getRandomHosts(nHosts, d.Scale)
+	return getRandomHosts(nHosts, d.Scale, d.Items)
 }
 
 // cpuMetrics is the list of metric names for CPU
@@ -120,6 +122,13 @@ type HighCPUFiller interface {
 	HighCPUForHosts(query.Query, int)
 }
 
+// TemplateFiller is a type that can fill in a query of a user-defined
+// template. RenderTemplate is implemented by Core.
+type TemplateFiller interface {
+	RenderTemplate(*QueryTemplate) (*TemplateQuery, error)
+	TemplateQuery(query.Query, *TemplateQuery)
+}
+
 // GetDoubleGroupByLabel returns the Query human-readable label for DoubleGroupBy queries
 func GetDoubleGroupByLabel(dbName string, numMetrics int) string {
 	return fmt.Sprintf("%s mean of %d metrics, all hosts, random %s by 1h", dbName, numMetrics, DoubleGroupByDuration)
@@ -144,9 +153,9 @@ func GetMaxAllLabel(dbName string, nHosts int) string {
 }
 
 // getRandomHosts returns a subset of numHosts hostnames of a permutation of hostnames,
-// numbered from 0 to totalHosts.
+// numbered from 0 to totalHosts, picked with the given distribution (nil for uniformly).
 // Ex.: host_12, host_7, host_25 for numHosts=3 and totalHosts=30 (3 out of 30)
-func getRandomHosts(numHosts int, totalHosts int) ([]string, error) {
+func getRandomHosts(numHosts int, totalHosts int, dist internalutils.Distribution) ([]string, error) {
 	if numHosts < 1 {
 		return nil, fmt.Errorf("number of hosts cannot be < 1; got %d", numHosts)
 	}
@@ -154,7 +163,7 @@ func getRandomHosts(numHosts int, totalHosts int) ([]string, error) {
 		return nil, fmt.Errorf("number of hosts (%d) larger than total hosts. See --scale (%d)", numHosts, totalHosts)
 	}
 
-	randomNumbers, err := common.GetRandomSubsetPerm(numHosts, totalHosts)
+	randomNumbers, err := common.GetDistributedSubset(numHosts, totalHosts, dist)
 	if err != nil {
 		return nil, err
 	}
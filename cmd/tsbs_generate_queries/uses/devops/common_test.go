@@ -57,7 +57,7 @@ func TestCoreGetRandomHosts(t *testing.T) {
 	coreHosts := strings.Join(hosts, ",")
 
 	rand.Seed(100) // Resetting seed to get a deterministic output.
-	hosts, err = getRandomHosts(n, scale)
+	hosts, err = getRandomHosts(n, scale, nil)
 	if err != nil {
 		t.Fatalf("unexpected error for getRandomHosts: %v", err)
 	}
@@ -195,7 +195,7 @@ func TestGetRandomHosts(t *testing.T) {
 	for _, c := range cases {
 		rand.Seed(100) // always reset the random number generator
 		if c.shouldErr {
-			hosts, err := getRandomHosts(c.nHosts, c.scale)
+			hosts, err := getRandomHosts(c.nHosts, c.scale, nil)
 			if hosts != nil {
 				t.Errorf("%s: errored but with non-nil return: %v", c.desc, hosts)
 			}
@@ -203,7 +203,7 @@ func TestGetRandomHosts(t *testing.T) {
 				t.Errorf("%s: incorrect error:\ngot\n%s\nwant\n%s", c.desc, got, c.errMsg)
 			}
 		} else {
-			hosts, err := getRandomHosts(c.nHosts, c.scale)
+			hosts, err := getRandomHosts(c.nHosts, c.scale, nil)
 			if err != nil {
 				t.Fatalf("%s: unexpected error: got %v", c.desc, err)
 			} else if got := strings.Join(hosts, ","); got != c.want {
@@ -268,3 +268,17 @@ func TestGetMaxAllLabel(t *testing.T) {
 		t.Errorf("incorrect output: got %s want %s", got, want)
 	}
 }
+
+func TestGetRollupLabels(t *testing.T) {
+	want := "Foo 5 cpu metric(s) from the 1m rollup, random    1 hosts, random 12h0m0s by 1m"
+	if got := GetGroupByTimeRollupLabel("Foo", 5, 1, 12*time.Hour); got != want {
+		t.Errorf("incorrect output: got %s want %s", got, want)
+	}
+	want = "Foo max of all CPU metrics from the 1h rollup, random    8 hosts, random 720h0m0s by 1h"
+	if got := GetMaxAllRollupLabel("Foo", 8, MaxAllRollupLongDuration); got != want {
+		t.Errorf("incorrect output: got %s want %s", got, want)
+	}
+	if got := GetRollupColumn("usage_user"); got != "max_usage_user" {
+		t.Errorf("incorrect rollup column: got %s", got)
+	}
+}
@@ -0,0 +1,113 @@
+package devops
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/common"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+const (
+	// LabelRollupSuffix is the suffix of the query types reading the rollups
+	// of the cpu table instead of the table itself, e.g. cpu-max-all-8-rollup
+	LabelRollupSuffix = "-rollup"
+
+	// RollupTableName1m and RollupTableName1h are the names of the rollups of
+	// the cpu table with the max of each metric per host per minute and hour,
+	// created by the loaders of the databases supporting them.
+	RollupTableName1m = TableName + "_1m"
+	RollupTableName1h = TableName + "_1h"
+
+	// MaxAllRollupLongDuration is how big the time range for the long-range
+	// MaxAll rollup query is, e.g. for a dashboard of the last month
+	MaxAllRollupLongDuration = 30 * 24 * time.Hour
+)
+
+// GetRollupColumn returns the name of the column of the rollups with the max
+// of a cpu metric, e.g. max_usage_user.
+func GetRollupColumn(metric string) string {
+	return "max_" + metric
+}
+
+// RollupFiller is a type that can fill in the queries reading the 1m and 1h
+// rollups of the cpu table
+type RollupFiller interface {
+	GroupByTimeRollup(query.Query, int, int, time.Duration)
+	MaxAllCPURollup(query.Query, int, time.Duration)
+}
+
+// GetGroupByTimeRollupLabel returns the Query human-readable label for
+// GroupByTimeRollup queries
+func GetGroupByTimeRollupLabel(dbName string, numMetrics, nHosts int, timeRange time.Duration) string {
+	return fmt.Sprintf("%s %d cpu metric(s) from the 1m rollup, random %4d hosts, random %s by 1m", dbName, numMetrics, nHosts, timeRange)
+}
+
+// GetMaxAllRollupLabel returns the Query human-readable label for
+// MaxAllCPURollup queries
+func GetMaxAllRollupLabel(dbName string, nHosts int, duration time.Duration) string {
+	return fmt.Sprintf("%s max of all CPU metrics from the 1h rollup, random %4d hosts, random %s by 1h", dbName, nHosts, duration)
+}
+
+// SingleGroupbyRollup contains info for filling in single groupby queries
+// reading the 1m rollup
+type SingleGroupbyRollup struct {
+	core    utils.QueryGenerator
+	metrics int
+	hosts   int
+	hours   int
+}
+
+// NewSingleGroupbyRollup produces a new function that produces a new
+// SingleGroupbyRollup
+func NewSingleGroupbyRollup(metrics, hosts, hours int) utils.QueryFillerMaker {
+	return func(core utils.QueryGenerator) utils.QueryFiller {
+		return &SingleGroupbyRollup{
+			core:    core,
+			metrics: metrics,
+			hosts:   hosts,
+			hours:   hours,
+		}
+	}
+}
+
+// Fill fills in the query.Query with query details
+func (d *SingleGroupbyRollup) Fill(q query.Query) query.Query {
+	fc, ok := d.core.(RollupFiller)
+	if !ok {
+		common.PanicUnimplementedQuery(d.core)
+	}
+	fc.GroupByTimeRollup(q, d.hosts, d.metrics, time.Duration(int64(d.hours)*int64(time.Hour)))
+	return q
+}
+
+// MaxAllCPURollup contains info for filling in "max all" queries reading the
+// 1h rollup
+type MaxAllCPURollup struct {
+	core     utils.QueryGenerator
+	hosts    int
+	duration time.Duration
+}
+
+// NewMaxAllCPURollup produces a new function that produces a new
+// MaxAllCPURollup
+func NewMaxAllCPURollup(hosts int, duration time.Duration) utils.QueryFillerMaker {
+	return func(core utils.QueryGenerator) utils.QueryFiller {
+		return &MaxAllCPURollup{
+			core:     core,
+			hosts:    hosts,
+			duration: duration,
+		}
+	}
+}
+
+// Fill fills in the query.Query with query details
+func (d *MaxAllCPURollup) Fill(q query.Query) query.Query {
+	fc, ok := d.core.(RollupFiller)
+	if !ok {
+		common.PanicUnimplementedQuery(d.core)
+	}
+	fc.MaxAllCPURollup(q, d.hosts, d.duration)
+	return q
+}
@@ -0,0 +1,301 @@
+package devops
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/common"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
+	internalutils "github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// LabelTemplate is the query type of the queries of user-defined templates
+	LabelTemplate = "template"
+
+	defaultTemplateDuration = time.Hour
+	defaultTemplateInterval = time.Minute
+)
+
+// QueryTemplate is a user-defined query, with its text in the query language
+// of each database format, e.g.
+//
+//	templates:
+//	- name: max-cpu-hosts
+//	  hosts: 8
+//	  metrics: 2
+//	  duration: 12h
+//	  interval: 1h
+//	  queries:
+//	    timescaledb: |
+//	      SELECT time_bucket('{{.Interval.Seconds}} seconds', time) AS hour, {{.Metrics}}
+//	      FROM cpu WHERE hostname IN ({{.Hosts.Quote}})
+//	      AND time >= '{{.TimeStart}}' AND time < '{{.TimeEnd}}'
+//	      GROUP BY hour
+//	    victoriametrics: max(max_over_time(cpu_usage_user{hostname=~"{{.Hosts.Join "|"}}"}[{{.Interval}}])) by (hostname)
+//
+// The texts are Go templates filled in with a TemplateData.
+type QueryTemplate struct {
+	Name string `yaml:"name"`
+	// Hosts is the number of random hosts of a query, 1 by default
+	Hosts int `yaml:"hosts"`
+	// Metrics is the number of CPU metrics of a query, 1 by default
+	Metrics int `yaml:"metrics"`
+	// Duration is the length of the random time window of a query, 1h by default
+	Duration time.Duration `yaml:"duration"`
+	// Interval is the time bucket of a query, 1m by default
+	Interval time.Duration `yaml:"interval"`
+	// Queries are the texts of the query per database format
+	Queries map[string]string `yaml:"queries"`
+
+	// text is the parsed text of the format generated
+	text *template.Template
+}
+
+// templateFile is the YAML file of the query templates.
+type templateFile struct {
+	Templates []*QueryTemplate `yaml:"templates"`
+}
+
+// LoadTemplate reads the query template name for the database format from the
+// YAML file fileName. The name can be empty when the file has a single
+// template.
+func LoadTemplate(fileName, name, format string) (*QueryTemplate, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read query templates: %v", err)
+	}
+	return ParseTemplate(data, name, format)
+}
+
+// ParseTemplate reads the query template name for the database format from
+// the contents of a YAML file, see LoadTemplate.
+func ParseTemplate(data []byte, name, format string) (*QueryTemplate, error) {
+	var f templateFile
+	if err := yaml.UnmarshalStrict(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid query templates: %v", err)
+	}
+
+	var t *QueryTemplate
+	names := make([]string, 0, len(f.Templates))
+	for _, ft := range f.Templates {
+		if ft.Name == name || (name == "" && len(f.Templates) == 1) {
+			t = ft
+		}
+		names = append(names, ft.Name)
+	}
+	if t == nil {
+		if name == "" {
+			return nil, fmt.Errorf("the name of the query template is required, choose one of %v", names)
+		}
+		return nil, fmt.Errorf("unknown query template '%s', choose one of %v", name, names)
+	}
+
+	text, ok := t.Queries[format]
+	if !ok {
+		return nil, fmt.Errorf("query template '%s' has no query for format '%s'", t.Name, format)
+	}
+	var err error
+	t.text, err = template.New(t.Name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid query template '%s' for format '%s': %v", t.Name, format, err)
+	}
+
+	if t.Hosts == 0 {
+		t.Hosts = 1
+	}
+	if t.Metrics == 0 {
+		t.Metrics = 1
+	}
+	if t.Duration == 0 {
+		t.Duration = defaultTemplateDuration
+	}
+	if t.Interval == 0 {
+		t.Interval = defaultTemplateInterval
+	}
+	if t.Hosts < 0 || t.Duration < 0 || t.Interval < 0 {
+		return nil, fmt.Errorf("query template '%s' has a negative number of hosts, duration or interval", t.Name)
+	}
+	if _, err = GetCPUMetricsSlice(t.Metrics); err != nil {
+		return nil, fmt.Errorf("query template '%s': %v", t.Name, err)
+	}
+	// unknown placeholders are reported before generating any query
+	if err = t.text.Execute(ioutil.Discard, &TemplateData{}); err != nil {
+		return nil, fmt.Errorf("invalid query template '%s' for format '%s': %v", t.Name, format, err)
+	}
+	return t, nil
+}
+
+// TemplateData are the values of the placeholders of the query templates.
+type TemplateData struct {
+	// Hosts are the random hosts of the query
+	Hosts TemplateList
+	// Metrics are the CPU metrics of the query
+	Metrics TemplateList
+	// TimeStart and TimeEnd are the random time window of the query
+	TimeStart, TimeEnd TemplateTime
+	// Interval is the time bucket of the query
+	Interval TemplateDuration
+}
+
+// TemplateList is a list of names, printed separated by commas, e.g.
+// usage_user,usage_system.
+type TemplateList []string
+
+func (l TemplateList) String() string {
+	return l.Join(",")
+}
+
+// Join returns the names separated by sep, e.g. host_1|host_2 with "|".
+func (l TemplateList) Join(sep string) string {
+	return strings.Join(l, sep)
+}
+
+// Quote returns the names within single quotes, e.g. 'host_1','host_2' for
+// the IN lists of SQL.
+func (l TemplateList) Quote() TemplateList {
+	return l.quote("'")
+}
+
+// DoubleQuote returns the names within double quotes, e.g. "host_1","host_2".
+func (l TemplateList) DoubleQuote() TemplateList {
+	return l.quote(`"`)
+}
+
+func (l TemplateList) quote(q string) TemplateList {
+	quoted := make(TemplateList, len(l))
+	for i, s := range l {
+		quoted[i] = q + s + q
+	}
+	return quoted
+}
+
+// TemplateTime is a time, printed in RFC3339, e.g. 2016-01-01T10:20:52Z. The
+// methods of time.Time give other formats, e.g. {{.TimeStart.Unix}} or
+// {{.TimeStart.Format "2006-01-02 15:04:05"}}.
+type TemplateTime struct {
+	time.Time
+}
+
+func (t TemplateTime) String() string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// UnixMilli returns the time in milliseconds since the epoch.
+func (t TemplateTime) UnixMilli() int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// TemplateDuration is a duration, printed in its largest whole unit out of
+// hours, minutes, seconds and milliseconds, e.g. 1h or 90s, as in PromQL,
+// InfluxQL and Flux. {{.Interval.Seconds}} gives it in seconds.
+type TemplateDuration struct {
+	time.Duration
+}
+
+func (d TemplateDuration) String() string {
+	units := []struct {
+		unit   time.Duration
+		suffix string
+	}{
+		{time.Hour, "h"},
+		{time.Minute, "m"},
+		{time.Second, "s"},
+		{time.Millisecond, "ms"},
+	}
+	for _, u := range units {
+		if d.Duration%u.unit == 0 {
+			return fmt.Sprintf("%d%s", d.Duration/u.unit, u.suffix)
+		}
+	}
+	return d.Duration.String()
+}
+
+// TemplateQuery is a query rendered from a QueryTemplate.
+type TemplateQuery struct {
+	// Name is the name of the template
+	Name string
+	// Text is the query in the query language of the database
+	Text string
+	// TimeInterval is the random time window of the query
+	TimeInterval *internalutils.TimeInterval
+	// Interval is the time bucket of the query
+	Interval time.Duration
+}
+
+// RenderTemplate fills in the placeholders of the query template t with
+// random hosts and a random time window.
+func (d *Core) RenderTemplate(t *QueryTemplate) (*TemplateQuery, error) {
+	hosts, err := d.GetRandomHosts(t.Hosts)
+	if err != nil {
+		return nil, err
+	}
+	metrics, err := GetCPUMetricsSlice(t.Metrics)
+	if err != nil {
+		return nil, err
+	}
+	interval, err := d.Interval.RandWindow(t.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &TemplateData{
+		Hosts:     hosts,
+		Metrics:   metrics,
+		TimeStart: TemplateTime{interval.Start()},
+		TimeEnd:   TemplateTime{interval.End()},
+		Interval:  TemplateDuration{t.Interval},
+	}
+	var text bytes.Buffer
+	if err = t.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("cannot render query template '%s': %v", t.Name, err)
+	}
+	return &TemplateQuery{
+		Name:         t.Name,
+		Text:         strings.TrimSpace(text.String()),
+		TimeInterval: interval,
+		Interval:     t.Interval,
+	}, nil
+}
+
+// GetTemplateLabel returns the Query human-readable label for the queries of a
+// QueryTemplate
+func GetTemplateLabel(dbName, name string) string {
+	return fmt.Sprintf("%s template %s", dbName, name)
+}
+
+// Template produces a QueryFiller for the queries of a user-defined template
+type Template struct {
+	core     utils.QueryGenerator
+	template *QueryTemplate
+}
+
+// NewTemplate produces a new function that produces a new Template
+func NewTemplate(t *QueryTemplate) utils.QueryFillerMaker {
+	return func(core utils.QueryGenerator) utils.QueryFiller {
+		return &Template{
+			core:     core,
+			template: t,
+		}
+	}
+}
+
+// Fill fills in the query.Query with query details
+func (d *Template) Fill(q query.Query) query.Query {
+	fc, ok := d.core.(TemplateFiller)
+	if !ok {
+		common.PanicUnimplementedQuery(d.core)
+	}
+	tq, err := fc.RenderTemplate(d.template)
+	if err != nil {
+		panic(err.Error())
+	}
+	fc.TemplateQuery(q, tq)
+	return q
+}
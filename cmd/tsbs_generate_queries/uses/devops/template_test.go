@@ -0,0 +1,269 @@
+package devops
+
+import (
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+const testTemplates = `
+templates:
+- name: max-cpu
+  hosts: 2
+  metrics: 3
+  duration: 2h
+  interval: 1h
+  queries:
+    timescaledb: >-
+      SELECT {{.Metrics}} FROM cpu WHERE hostname IN ({{.Hosts.Quote}})
+      AND time >= '{{.TimeStart}}' AND time < '{{.TimeEnd}}' -- {{.Interval}}
+    victoriametrics: max(cpu_usage_user{hostname=~"{{.Hosts.Join "|"}}"}[{{.Interval}}])
+- name: defaults
+  queries:
+    timescaledb: SELECT 1
+`
+
+func TestParseTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(testTemplates), "max-cpu", "timescaledb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tmpl.Name != "max-cpu" || tmpl.Hosts != 2 || tmpl.Metrics != 3 || tmpl.Duration != 2*time.Hour || tmpl.Interval != time.Hour {
+		t.Errorf("incorrect template: got %+v", tmpl)
+	}
+
+	tmpl, err = ParseTemplate([]byte(testTemplates), "defaults", "timescaledb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tmpl.Hosts != 1 || tmpl.Metrics != 1 || tmpl.Duration != defaultTemplateDuration || tmpl.Interval != defaultTemplateInterval {
+		t.Errorf("incorrect defaults: got %+v", tmpl)
+	}
+
+	single := "templates:\n- name: only\n  queries:\n    influx: SELECT 1\n"
+	tmpl, err = ParseTemplate([]byte(single), "", "influx")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tmpl.Name != "only" {
+		t.Errorf("incorrect template for a single one: got %s", tmpl.Name)
+	}
+}
+
+func TestParseTemplateErrors(t *testing.T) {
+	cases := []struct {
+		desc   string
+		yaml   string
+		name   string
+		format string
+		want   string
+	}{
+		{
+			desc:   "unknown key",
+			yaml:   "templates:\n- name: t\n  host: 2\n",
+			name:   "t",
+			format: "timescaledb",
+			want:   "invalid query templates: ",
+		},
+		{
+			desc:   "no name",
+			yaml:   testTemplates,
+			format: "timescaledb",
+			want:   "the name of the query template is required, choose one of [max-cpu defaults]",
+		},
+		{
+			desc:   "unknown name",
+			yaml:   testTemplates,
+			name:   "min-cpu",
+			format: "timescaledb",
+			want:   "unknown query template 'min-cpu', choose one of [max-cpu defaults]",
+		},
+		{
+			desc:   "no query for the format",
+			yaml:   testTemplates,
+			name:   "defaults",
+			format: "influx",
+			want:   "query template 'defaults' has no query for format 'influx'",
+		},
+		{
+			desc:   "bad syntax",
+			yaml:   "templates:\n- name: t\n  queries:\n    influx: SELECT {{.Hosts\n",
+			name:   "t",
+			format: "influx",
+			want:   "invalid query template 't' for format 'influx': ",
+		},
+		{
+			desc:   "unknown placeholder",
+			yaml:   "templates:\n- name: t\n  queries:\n    influx: SELECT {{.Host}}\n",
+			name:   "t",
+			format: "influx",
+			want:   "invalid query template 't' for format 'influx': ",
+		},
+		{
+			desc:   "too many metrics",
+			yaml:   "templates:\n- name: t\n  metrics: 11\n  queries:\n    influx: SELECT 1\n",
+			name:   "t",
+			format: "influx",
+			want:   "query template 't': " + errTooManyMetrics,
+		},
+		{
+			desc:   "negative hosts",
+			yaml:   "templates:\n- name: t\n  hosts: -1\n  queries:\n    influx: SELECT 1\n",
+			name:   "t",
+			format: "influx",
+			want:   "query template 't' has a negative number of hosts, duration or interval",
+		},
+	}
+	for _, c := range cases {
+		_, err := ParseTemplate([]byte(c.yaml), c.name, c.format)
+		if err == nil {
+			t.Errorf("%s: unexpected lack of error", c.desc)
+		} else if got := err.Error(); !strings.HasPrefix(got, c.want) {
+			t.Errorf("%s: incorrect error: got\n%s\nwant prefix\n%s", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-templates")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileName := filepath.Join(dir, "templates.yaml")
+	if err = ioutil.WriteFile(fileName, []byte(testTemplates), 0644); err != nil {
+		t.Fatalf("could not write templates: %v", err)
+	}
+	tmpl, err := LoadTemplate(fileName, "max-cpu", "victoriametrics")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tmpl.Name != "max-cpu" {
+		t.Errorf("incorrect template: got %s", tmpl.Name)
+	}
+
+	_, err = LoadTemplate(filepath.Join(dir, "missing.yaml"), "max-cpu", "victoriametrics")
+	if err == nil {
+		t.Errorf("unexpected lack of error for a missing file")
+	}
+}
+
+func TestTemplateValues(t *testing.T) {
+	hosts := TemplateList{"host_1", "host_2"}
+	if got := hosts.String(); got != "host_1,host_2" {
+		t.Errorf("incorrect list: got %s", got)
+	}
+	if got := hosts.Join("|"); got != "host_1|host_2" {
+		t.Errorf("incorrect joined list: got %s", got)
+	}
+	if got := hosts.Quote().String(); got != "'host_1','host_2'" {
+		t.Errorf("incorrect quoted list: got %s", got)
+	}
+	if got := hosts.DoubleQuote().Join(" or "); got != `"host_1" or "host_2"` {
+		t.Errorf("incorrect double quoted list: got %s", got)
+	}
+
+	tm := TemplateTime{time.Date(2016, 1, 1, 10, 20, 52, 5e8, time.UTC)}
+	if got := tm.String(); got != "2016-01-01T10:20:52Z" {
+		t.Errorf("incorrect time: got %s", got)
+	}
+	if got := tm.UnixMilli(); got != 1451643652500 {
+		t.Errorf("incorrect time in milliseconds: got %d", got)
+	}
+
+	durations := map[time.Duration]string{
+		time.Hour:               "1h",
+		24 * time.Hour:          "24h",
+		90 * time.Minute:        "90m",
+		90 * time.Second:        "90s",
+		1500 * time.Millisecond: "1500ms",
+		time.Microsecond:        "1µs",
+	}
+	for d, want := range durations {
+		if got := (TemplateDuration{d}).String(); got != want {
+			t.Errorf("incorrect duration for %v: got %s want %s", d, got, want)
+		}
+	}
+}
+
+func TestCoreRenderTemplate(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(testTemplates), "max-cpu", "timescaledb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rand.Seed(123)
+	s := time.Unix(0, 0).UTC()
+	c, err := NewCore(s, s.Add(24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	tq, err := c.RenderTemplate(tmpl)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := "SELECT usage_user,usage_system,usage_idle FROM cpu WHERE hostname IN ('host_5','host_9')" +
+		" AND time >= '1970-01-01T13:47:30Z' AND time < '1970-01-01T15:47:30Z' -- 1h"
+	if tq.Text != want {
+		t.Errorf("incorrect text:\ngot\n%s\nwant\n%s", tq.Text, want)
+	}
+	if tq.Name != "max-cpu" || tq.Interval != time.Hour {
+		t.Errorf("incorrect query: got %+v", tq)
+	}
+	if got := tq.TimeInterval.Duration(); got != 2*time.Hour {
+		t.Errorf("incorrect time window: got %v", got)
+	}
+
+	tmpl.Duration = 48 * time.Hour
+	if _, err = c.RenderTemplate(tmpl); err == nil {
+		t.Errorf("unexpected lack of error for a window longer than the dataset")
+	}
+}
+
+type testTemplateFiller struct {
+	*Core
+	got *TemplateQuery
+}
+
+func (f *testTemplateFiller) GenerateEmptyQuery() query.Query {
+	return query.NewSQL()
+}
+
+func (f *testTemplateFiller) TemplateQuery(q query.Query, tq *TemplateQuery) {
+	f.got = tq
+	q.(*query.SQL).SqlQuery = []byte(tq.Text)
+}
+
+func TestTemplateFill(t *testing.T) {
+	tmpl, err := ParseTemplate([]byte(testTemplates), "defaults", "timescaledb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	s := time.Unix(0, 0)
+	c, err := NewCore(s, s.Add(24*time.Hour), 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	f := &testTemplateFiller{Core: c}
+
+	q := NewTemplate(tmpl)(f).Fill(f.GenerateEmptyQuery())
+	if got := string(q.(*query.SQL).SqlQuery); got != "SELECT 1" {
+		t.Errorf("incorrect query: got %s", got)
+	}
+	if f.got == nil || f.got.Name != "defaults" {
+		t.Errorf("incorrect template query: got %+v", f.got)
+	}
+}
+
+func TestGetTemplateLabel(t *testing.T) {
+	if got := GetTemplateLabel("TimescaleDB", "max-cpu"); got != "TimescaleDB template max-cpu" {
+		t.Errorf("incorrect label: got %s", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/uses/common"
+	internalutils "github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/query"
 )
 
@@ -71,15 +72,16 @@ func NewCore(start, end time.Time, scale int) (*Core, error) {
 
 }
 
-// GetRandomTrucks returns a random set of nTrucks from a given Core
+// GetRandomTrucks returns a random set of nTrucks from a given Core, picked
+// with its access distribution
 func (c *Core) GetRandomTrucks(nTrucks int) ([]string, error) {
-	return getRandomTrucks(nTrucks, c.Scale)
+	return getRandomTrucks(nTrucks, c.Scale, c.Items)
 }
 
 // getRandomTruckNames returns a subset of numTrucks names of a permutation of truck names,
-// numbered from 0 to totalTrucks.
+// numbered from 0 to totalTrucks, picked with the given distribution (nil for uniformly).
 // Ex.: truck_12, truck_7, truck_25 for numTrucks=3 and totalTrucks=30 (3 out of 30)
-func getRandomTrucks(numTrucks int, totalTrucks int) ([]string, error) {
+func getRandomTrucks(numTrucks int, totalTrucks int, dist internalutils.Distribution) ([]string, error) {
 	if numTrucks < 1 {
 		return nil, fmt.Errorf("number of trucks cannot be < 1; got %d", numTrucks)
 	}
@@ -87,7 +89,7 @@ func getRandomTrucks(numTrucks int, totalTrucks int) ([]string, error) {
 		return nil, fmt.Errorf("number of trucks (%d) larger than total trucks. See --scale (%d)", numTrucks, totalTrucks)
 	}
 
-	randomNumbers, err := common.GetRandomSubsetPerm(numTrucks, totalTrucks)
+	randomNumbers, err := common.GetDistributedSubset(numTrucks, totalTrucks, dist)
 	if err != nil {
 		return nil, err
 	}
@@ -16,19 +16,26 @@ type LoaderConfig struct {
 }
 
 type RunnerConfig struct {
-	DBName          string `yaml:"db-name" mapstructure:"db-name"`
-	BatchSize       uint   `yaml:"batch-size" mapstructure:"batch-size"`
-	Workers         uint
-	Limit           uint64
-	DoLoad          bool          `yaml:"do-load" mapstructure:"do-load"`
-	DoCreateDB      bool          `yaml:"do-create-db" mapstructure:"do-create-db"`
-	DoAbortOnExist  bool          `yaml:"do-abort-on-exist" mapstructure:"do-abort-on-exist"`
-	ReportingPeriod time.Duration `yaml:"reporting-period" mapstructure:"reporting-period"`
-	Seed            int64
-	HashWorkers     bool   `yaml:"hash-workers" mapstructure:"hash-workers"`
-	InsertIntervals string `yaml:"insert-intervals" mapstructure:"insert-intervals"`
-	FlowControl     bool   `yaml:"flow-control" mapstructure:"flow-control"`
-	ChannelCapacity uint   `yaml:"channel-capacity" mapstructure:"channel-capacity"`
+	DBName           string `yaml:"db-name" mapstructure:"db-name"`
+	BatchSize        uint   `yaml:"batch-size" mapstructure:"batch-size"`
+	Workers          uint
+	Limit            uint64
+	DoLoad           bool          `yaml:"do-load" mapstructure:"do-load"`
+	DoCreateDB       bool          `yaml:"do-create-db" mapstructure:"do-create-db"`
+	DoAbortOnExist   bool          `yaml:"do-abort-on-exist" mapstructure:"do-abort-on-exist"`
+	ReportingPeriod  time.Duration `yaml:"reporting-period" mapstructure:"reporting-period"`
+	Seed             int64
+	HashWorkers      bool          `yaml:"hash-workers" mapstructure:"hash-workers"`
+	InsertIntervals  string        `yaml:"insert-intervals" mapstructure:"insert-intervals"`
+	FlowControl      bool          `yaml:"flow-control" mapstructure:"flow-control"`
+	ChannelCapacity  uint          `yaml:"channel-capacity" mapstructure:"channel-capacity"`
+	BatchRetries     uint          `yaml:"batch-retries" mapstructure:"batch-retries"`
+	RetryBackoff     time.Duration `yaml:"retry-backoff" mapstructure:"retry-backoff"`
+	MaxFailedBatches uint64        `yaml:"max-failed-batches" mapstructure:"max-failed-batches"`
+	TargetRate       float64       `yaml:"target-rate" mapstructure:"target-rate"`
+	TargetRateStart  float64       `yaml:"target-rate-start" mapstructure:"target-rate-start"`
+	TargetRateRamp   time.Duration `yaml:"target-rate-ramp" mapstructure:"target-rate-ramp"`
+	TargetRateSteps  uint          `yaml:"target-rate-steps" mapstructure:"target-rate-steps"`
 }
 
 type DataSourceConfig struct {
@@ -51,4 +58,6 @@ type SimulatorDataSourceConfig struct {
 	Limit                 uint64        `yaml:"max-data-points" mapstructure:"max-data-points"`
 	LogInterval           time.Duration `yaml:"log-interval" mapstructure:"log-interval"`
 	MaxMetricCountPerHost uint64        `yaml:"max-metric-count" mapstructure:"max-metric-count"`
+	Realtime              bool          `yaml:"realtime" mapstructure:"realtime"`
+	RealtimeDuration      time.Duration `yaml:"realtime-duration" mapstructure:"realtime-duration"`
 }
@@ -58,6 +58,41 @@ func addLoaderRunnerFlags(fs *pflag.FlagSet) {
 		false,
 		"Whether to use flow-control when scanning the data and sending to the workers",
 	)
+	fs.Uint(
+		"loader.runner.batch-retries",
+		3,
+		"Number of times to retry inserting a batch that failed before counting it as failed",
+	)
+	fs.Duration(
+		"loader.runner.retry-backoff",
+		time.Second,
+		"Time to wait before retrying a failed batch, doubled after each failed retry",
+	)
+	fs.Uint64(
+		"loader.runner.max-failed-batches",
+		0,
+		"Abort the load after this many batches failed all retries (0 = never abort)",
+	)
+	fs.Float64(
+		"loader.runner.target-rate",
+		0,
+		"Combined insert rate of all workers in metrics/sec (0 = insert as fast as possible)",
+	)
+	fs.Float64(
+		"loader.runner.target-rate-start",
+		0,
+		"Insert rate in metrics/sec at the start of the run when ramping up to target-rate",
+	)
+	fs.Duration(
+		"loader.runner.target-rate-ramp",
+		0,
+		"Time to ramp up from target-rate-start to target-rate (0 = no ramp)",
+	)
+	fs.Uint(
+		"loader.runner.target-rate-steps",
+		0,
+		"Number of equal steps in which to ramp up the insert rate (0 = linear ramp)",
+	)
 	fs.Uint(
 		"loader.runner.channel-capacity",
 		load.DefaultChannelCapacityFlagVal,
@@ -101,4 +136,16 @@ func addDataSourceFlags(fs *pflag.FlagSet) {
 		defaultScale,
 		"Scaling value specific to use case (e.g., devices in 'devops', trucks in iot).")
 	fs.Duration("data-source.simulator.log-interval", defaultLogInterval, "Duration between data points")
+	fs.Bool(
+		"data-source.simulator.realtime",
+		false,
+		"Simulate data starting at the current time and emit each epoch when its wall-clock time arrives. "+
+			"timestamp-start and timestamp-end are ignored. Use a batch-size no larger than the points in an epoch "+
+			"(log-interval x scale) so batches are not held back",
+	)
+	fs.Duration(
+		"data-source.simulator.realtime-duration",
+		0,
+		"How long to run when data-source.simulator.realtime=true (0 = run until stopped)",
+	)
 }
@@ -61,19 +61,26 @@ func parseRunnerConfig(v *viper.Viper) (*RunnerConfig, error) {
 
 func convertRunnerConfigToInternalRep(r *RunnerConfig) *load.BenchmarkRunnerConfig {
 	return &load.BenchmarkRunnerConfig{
-		DBName:          r.DBName,
-		BatchSize:       r.BatchSize,
-		Workers:         r.Workers,
-		Limit:           r.Limit,
-		DoLoad:          r.DoLoad,
-		DoCreateDB:      r.DoCreateDB,
-		DoAbortOnExist:  r.DoAbortOnExist,
-		ReportingPeriod: r.ReportingPeriod,
-		Seed:            r.Seed,
-		HashWorkers:     r.HashWorkers,
-		InsertIntervals: r.InsertIntervals,
-		NoFlowControl:   !r.FlowControl,
-		ChannelCapacity: r.ChannelCapacity,
+		DBName:           r.DBName,
+		BatchSize:        r.BatchSize,
+		Workers:          r.Workers,
+		Limit:            r.Limit,
+		DoLoad:           r.DoLoad,
+		DoCreateDB:       r.DoCreateDB,
+		DoAbortOnExist:   r.DoAbortOnExist,
+		ReportingPeriod:  r.ReportingPeriod,
+		Seed:             r.Seed,
+		HashWorkers:      r.HashWorkers,
+		InsertIntervals:  r.InsertIntervals,
+		NoFlowControl:    !r.FlowControl,
+		ChannelCapacity:  r.ChannelCapacity,
+		BatchRetries:     r.BatchRetries,
+		RetryBackoff:     r.RetryBackoff,
+		MaxFailedBatches: r.MaxFailedBatches,
+		TargetRate:       r.TargetRate,
+		TargetRateStart:  r.TargetRateStart,
+		TargetRateRamp:   r.TargetRateRamp,
+		TargetRateSteps:  r.TargetRateSteps,
 	}
 }
 
@@ -137,6 +144,8 @@ func convertDataSourceConfigToInternalRepresentation(format string, d *DataSourc
 			Limit:                 d.Simulator.Limit,
 			LogInterval:           d.Simulator.LogInterval,
 			MaxMetricCountPerHost: d.Simulator.MaxMetricCountPerHost,
+			Realtime:              d.Simulator.Realtime,
+			RealtimeDuration:      d.Simulator.RealtimeDuration,
 			InterleavedNumGroups:  1,
 		}
 	}
@@ -46,6 +46,7 @@ func init() {
 		LogBatches: viper.GetBool("log-batches"),
 		Debug:      viper.GetInt("debug"),
 		DbName:     loaderConf.DBName,
+		Rollups:    viper.GetBool("rollups"),
 	}
 
 	loader = load.GetBenchmarkRunner(loaderConf)
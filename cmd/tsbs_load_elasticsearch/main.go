@@ -0,0 +1,53 @@
+// tsbs_load_elasticsearch loads an Elasticsearch or OpenSearch cluster with data from stdin or file.
+package main
+
+import (
+	"fmt"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets/elasticsearch"
+)
+
+// Parse args:
+func initProgramOptions() (*elasticsearch.SpecificConfig, load.BenchmarkRunner, *load.BenchmarkRunnerConfig) {
+	target := elasticsearch.NewTarget()
+
+	loaderConf := load.BenchmarkRunnerConfig{}
+	loaderConf.AddToFlagSet(pflag.CommandLine)
+	target.TargetSpecificFlags("", pflag.CommandLine)
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&loaderConf); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+	var esConf elasticsearch.SpecificConfig
+	if err := viper.Unmarshal(&esConf); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+	if len(esConf.ServerURLs) == 0 {
+		panic("missing `urls` flag")
+	}
+
+	loader := load.GetBenchmarkRunner(loaderConf)
+	return &esConf, loader, &loaderConf
+}
+
+func main() {
+	esConf, loader, loaderConf := initProgramOptions()
+
+	benchmark, err := elasticsearch.NewBenchmark(loaderConf.DBName, esConf, &source.DataSourceConfig{
+		Type: source.FileDataSourceType,
+		File: &source.FileDataSourceConfig{Location: loaderConf.FileName},
+	})
+	if err != nil {
+		panic(err)
+	}
+	loader.RunBenchmark(benchmark)
+}
@@ -0,0 +1,126 @@
+// tsbs_load_graphite loads a Graphite carbon daemon with data from stdin.
+//
+// The data is sent over TCP with the carbon plaintext or pickle protocol.
+// The caller is responsible for assuring that the database is empty before
+// bulk load.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/graphite"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+// Program option vars:
+var (
+	protocol   string
+	carbonAddr string
+	template   *graphite.Template
+)
+
+// Global vars
+var (
+	loader  load.BenchmarkRunner
+	config  load.BenchmarkRunnerConfig
+	bufPool sync.Pool
+	target  targets.ImplementedTarget
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	target = initializers.GetTarget(constants.FormatGraphite)
+	config = load.BenchmarkRunnerConfig{}
+	// Not all the default flags apply to Graphite
+	// config.AddToFlagSet(pflag.CommandLine)
+	pflag.CommandLine.Uint("batch-size", 10000, "Number of items to batch together in a single insert")
+	pflag.CommandLine.Uint("workers", 1, "Number of parallel clients inserting")
+	pflag.CommandLine.Uint64("limit", 0, "Number of items to insert (0 = all of them).")
+	pflag.CommandLine.Bool("do-load", true, "Whether to write data. Set this flag to false to check input read speed.")
+	pflag.CommandLine.Duration("reporting-period", 10*time.Second, "Period to report write stats")
+	pflag.CommandLine.String("file", "", "File name to read data from")
+	pflag.CommandLine.Int64("seed", 0, "PRNG seed (default: 0, which uses the current timestamp)")
+	pflag.CommandLine.String("insert-intervals", "", "Time to wait between each insert, default '' => all workers insert ASAP. '1,2' = worker 1 waits 1s between inserts, worker 2 and others wait 2s")
+	pflag.CommandLine.Uint("batch-retries", 3, "Number of times to retry inserting a batch that failed before counting it as failed")
+	pflag.CommandLine.Duration("retry-backoff", time.Second, "Time to wait before retrying a failed batch, doubled after each failed retry")
+	pflag.CommandLine.Uint64("max-failed-batches", 0, "Abort the load after this many batches failed all retries (0 = never abort)")
+	pflag.CommandLine.Float64("target-rate", 0, "Combined insert rate of all workers in metrics/sec (0 = insert as fast as possible)")
+	pflag.CommandLine.Float64("target-rate-start", 0, "Insert rate in metrics/sec at the start of the run when ramping up to --target-rate")
+	pflag.CommandLine.Duration("target-rate-ramp", 0, "Time to ramp up from --target-rate-start to --target-rate (0 = no ramp)")
+	pflag.CommandLine.Uint("target-rate-steps", 0, "Number of equal steps in which to ramp up the insert rate (0 = linear ramp)")
+	target.TargetSpecificFlags("", pflag.CommandLine)
+	pflag.Parse()
+
+	err := utils.SetupConfigFile()
+
+	if err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	protocol = viper.GetString("protocol")
+	switch protocol {
+	case graphite.ProtocolPlaintext:
+		carbonAddr = viper.GetString("plaintext-addr")
+	case graphite.ProtocolPickle:
+		carbonAddr = viper.GetString("pickle-addr")
+	default:
+		log.Fatalf("unknown protocol %q, must be %s or %s", protocol, graphite.ProtocolPlaintext, graphite.ProtocolPickle)
+	}
+	template, err = graphite.ParseTemplate(viper.GetString("template"))
+	if err != nil {
+		log.Fatalf("invalid template: %v", err)
+	}
+	config.HashWorkers = false
+	loader = load.GetBenchmarkRunner(config)
+}
+
+type benchmark struct{}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return &fileDataSource{scanner: bufio.NewScanner(load.GetBufferedReader(config.FileName))}
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	return &factory{}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{}
+}
+
+// GetDBCreator returns nil, carbon creates the series when they are first written
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return nil
+}
+
+func main() {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+
+	loader.RunBenchmark(&benchmark{})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// pickleFrameMetrics is the number of metrics sent in one pickle message, the
+// default MAX_DATAPOINTS_PER_MESSAGE of carbon-relay. Carbon rejects messages
+// larger than 1MB.
+const pickleFrameMetrics = 500
+
+// Pickle protocol 2 opcodes
+const (
+	pickleProto      = 0x80
+	pickleEmptyList  = ']'
+	pickleMark       = '('
+	pickleAppends    = 'e'
+	pickleBinUnicode = 'X'
+	pickleBinInt     = 'J'
+	pickleLong1      = 0x8a
+	pickleBinFloat   = 'G'
+	pickleTuple2     = 0x86
+	pickleStop       = '.'
+)
+
+// pickleEncoder encodes metrics as carbon pickle messages: a 4 byte big
+// endian length followed by a pickled list of (path, (timestamp, value))
+// tuples.
+type pickleEncoder struct {
+	buf []byte
+	// offset of the length header of the open frame
+	frameStart int
+	metrics    int
+}
+
+// reset starts encoding into buf, which is overwritten
+func (e *pickleEncoder) reset(buf []byte) {
+	e.buf = buf[:0]
+	e.metrics = 0
+}
+
+func (e *pickleEncoder) add(path []byte, ts int64, value float64) {
+	if e.metrics%pickleFrameMetrics == 0 {
+		if e.metrics > 0 {
+			e.closeFrame()
+		}
+		e.frameStart = len(e.buf)
+		e.buf = append(e.buf, 0, 0, 0, 0, pickleProto, 2, pickleEmptyList, pickleMark)
+	}
+	e.metrics++
+
+	e.buf = append(e.buf, pickleBinUnicode)
+	e.buf = appendUint32LE(e.buf, uint32(len(path)))
+	e.buf = append(e.buf, path...)
+	if ts >= math.MinInt32 && ts <= math.MaxInt32 {
+		e.buf = append(e.buf, pickleBinInt)
+		e.buf = appendUint32LE(e.buf, uint32(int32(ts)))
+	} else {
+		e.buf = append(e.buf, pickleLong1, 8)
+		e.buf = appendUint32LE(e.buf, uint32(ts))
+		e.buf = appendUint32LE(e.buf, uint32(ts>>32))
+	}
+	e.buf = append(e.buf, pickleBinFloat)
+	e.buf = appendUint64BE(e.buf, math.Float64bits(value))
+	e.buf = append(e.buf, pickleTuple2, pickleTuple2)
+}
+
+// bytes closes the open message and returns all the encoded messages
+func (e *pickleEncoder) bytes() []byte {
+	if e.metrics > 0 && e.frameStart < len(e.buf) {
+		e.closeFrame()
+	}
+	return e.buf
+}
+
+func (e *pickleEncoder) closeFrame() {
+	e.buf = append(e.buf, pickleAppends, pickleStop)
+	binary.BigEndian.PutUint32(e.buf[e.frameStart:], uint32(len(e.buf)-e.frameStart-4))
+	e.frameStart = len(e.buf)
+}
+
+func appendUint32LE(buf []byte, v uint32) []byte {
+	return append(buf, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64BE(buf []byte, v uint64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	return append(buf, b[:]...)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/graphite"
+)
+
+type processor struct {
+	conn *net.TCPConn
+
+	// reused buffers to encode a batch
+	out    []byte
+	path   []byte
+	tags   []graphite.Tag
+	pickle pickleEncoder
+}
+
+func (p *processor) Init(numWorker int, _, _ bool) {
+	if err := p.connect(); err != nil {
+		fatal("%s\n", err.Error())
+	}
+}
+
+func (p *processor) connect() error {
+	tcpAddr, err := net.ResolveTCPAddr("tcp", carbonAddr)
+	if err != nil {
+		return fmt.Errorf("Failed to resolve %s: %s", carbonAddr, err.Error())
+	}
+	p.conn, err = net.DialTCP("tcp", nil, tcpAddr)
+	if err != nil {
+		return fmt.Errorf("Failed connect to %s: %s", carbonAddr, err.Error())
+	}
+	return nil
+}
+
+func (p *processor) Close(_ bool) {
+	if p.conn != nil {
+		p.conn.Close()
+	}
+}
+
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
+	batch := b.(*batch)
+
+	if doLoad {
+		out := batch.buf.Bytes()
+		// the lines of the batch can be sent as they are unless they need
+		// to be converted
+		if protocol == graphite.ProtocolPickle || template != nil {
+			var err error
+			if out, err = p.encode(out); err != nil {
+				return 0, 0, err
+			}
+		}
+		// the connection a write failed on is closed, the retry writes the
+		// batch on a new one
+		if p.conn == nil {
+			if err := p.connect(); err != nil {
+				return 0, 0, err
+			}
+		}
+		if _, err := p.conn.Write(out); err != nil {
+			p.conn.Close()
+			p.conn = nil
+			return 0, 0, fmt.Errorf("error writing: %v", err)
+		}
+	}
+
+	metricCnt := batch.metrics
+	rowCnt := batch.rows
+
+	// Return the batch buffer to the pool.
+	batch.buf.Reset()
+	bufPool.Put(batch.buf)
+	return metricCnt, uint64(rowCnt), nil
+}
+
+// encode converts the plaintext lines to the configured protocol, flattening
+// the series names with the template if there is one
+func (p *processor) encode(lines []byte) ([]byte, error) {
+	p.out = p.out[:0]
+	p.pickle.reset(p.out)
+	for len(lines) > 0 {
+		var line []byte
+		if nl := bytes.IndexByte(lines, '\n'); nl >= 0 {
+			line, lines = lines[:nl], lines[nl+1:]
+		} else {
+			line, lines = lines, nil
+		}
+		if len(line) == 0 {
+			continue
+		}
+
+		fields := bytes.Split(line, []byte(" "))
+		if len(fields) != 3 {
+			return nil, fmt.Errorf(errNotThreeTuplesFmt, line)
+		}
+		path := fields[0]
+		if template != nil {
+			measurement, field, tags, err := graphite.ParseTaggedName(path, p.tags)
+			p.tags = tags
+			if err != nil {
+				return nil, err
+			}
+			p.path = template.AppendPath(p.path[:0], measurement, field, tags)
+			path = p.path
+		}
+
+		if protocol != graphite.ProtocolPickle {
+			p.out = append(p.out, path...)
+			p.out = append(p.out, ' ')
+			p.out = append(p.out, fields[1]...)
+			p.out = append(p.out, ' ')
+			p.out = append(p.out, fields[2]...)
+			p.out = append(p.out, '\n')
+			continue
+		}
+		value, err := strconv.ParseFloat(string(fields[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value in line %s: %v", line, err)
+		}
+		ts, err := strconv.ParseInt(string(fields[2]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp in line %s: %v", line, err)
+		}
+		p.pickle.add(path, ts, value)
+	}
+	if protocol == graphite.ProtocolPickle {
+		p.out = p.pickle.bytes()
+	}
+	return p.out, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/targets/graphite"
+)
+
+// mockServer accepts a single connection and records everything written to it
+type mockServer struct {
+	ln       net.Listener
+	received chan []byte
+}
+
+func mockServerStart(t *testing.T) *mockServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start server listen socket: %s", err.Error())
+	}
+	ms := &mockServer{ln: ln, received: make(chan []byte, 1)}
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			// listen socket is closed
+			ms.received <- nil
+			return
+		}
+		defer conn.Close()
+		data, _ := ioutil.ReadAll(conn)
+		ms.received <- data
+	}()
+	return ms
+}
+
+func newTestBatch() *batch {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 1024))
+		},
+	}
+	b := (&factory{}).New().(*batch)
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader(testData))}
+	for item := ds.NextItem(); item.Data != nil; item = ds.NextItem() {
+		b.Append(item)
+	}
+	return b
+}
+
+func TestProcessorProcessBatch(t *testing.T) {
+	pathTemplate, err := graphite.ParseTemplate("{hostname}.{measurement}.{field}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	cases := []struct {
+		desc     string
+		protocol string
+		template *graphite.Template
+		doLoad   bool
+		want     string
+	}{
+		{
+			desc:     "no load",
+			protocol: graphite.ProtocolPlaintext,
+		}, {
+			desc:     "tagged plaintext",
+			protocol: graphite.ProtocolPlaintext,
+			doLoad:   true,
+			want:     testData,
+		}, {
+			desc:     "plaintext with template",
+			protocol: graphite.ProtocolPlaintext,
+			template: pathTemplate,
+			doLoad:   true,
+			want: "host_0.cpu.usage_user 1 1451606400\n" +
+				"host_0.cpu.usage_system 2 1451606400\n" +
+				"host_1.cpu.usage_user 3 1451606400\n" +
+				"host_1.mem.used 4 1451606400\n" +
+				"host_0.cpu.usage_user 5 1451606410\n",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ms := mockServerStart(t)
+			carbonAddr = ms.ln.Addr().String()
+			protocol = c.protocol
+			template = c.template
+			defer func() { template = nil }()
+
+			b := newTestBatch()
+			p := &processor{}
+			p.Init(0, true, true)
+			mCnt, rCnt, err := p.ProcessBatch(b, c.doLoad)
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if mCnt != 5 || rCnt != 4 {
+				t.Errorf("incorrect counts: got %d metrics, %d rows want 5 metrics, 4 rows", mCnt, rCnt)
+			}
+			p.Close(true)
+			got := <-ms.received
+			ms.ln.Close()
+			if string(got) != c.want {
+				t.Errorf("incorrect data sent: got\n%s\nwant\n%s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestProcessorProcessBatchReconnect(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start server listen socket: %s", err.Error())
+	}
+	defer ln.Close()
+	received := make(chan []byte, 2)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			data, _ := ioutil.ReadAll(conn)
+			conn.Close()
+			received <- data
+		}
+	}()
+	carbonAddr = ln.Addr().String()
+	protocol = graphite.ProtocolPlaintext
+
+	b := newTestBatch()
+	p := &processor{}
+	p.Init(0, true, true)
+	// the connection is lost before the batch is written
+	p.conn.Close()
+	if _, _, err := p.ProcessBatch(b, true); err == nil {
+		t.Fatalf("expected an error writing on a closed connection")
+	}
+	if got := <-received; len(got) != 0 {
+		t.Errorf("unexpected data sent on the closed connection: %s", got)
+	}
+	// the retry writes the batch on a new connection
+	mCnt, rCnt, err := p.ProcessBatch(b, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mCnt != 5 || rCnt != 4 {
+		t.Errorf("incorrect counts: got %d metrics, %d rows want 5 metrics, 4 rows", mCnt, rCnt)
+	}
+	p.Close(true)
+	if got := <-received; string(got) != testData {
+		t.Errorf("incorrect data sent: got\n%s\nwant\n%s", got, testData)
+	}
+}
+
+func TestProcessorEncodePickle(t *testing.T) {
+	protocol = graphite.ProtocolPickle
+	defer func() { protocol = graphite.ProtocolPlaintext }()
+
+	var lines strings.Builder
+	numMetrics := pickleFrameMetrics + 1
+	for i := 0; i < numMetrics; i++ {
+		fmt.Fprintf(&lines, "cpu.usage_user;hostname=host_%d 1.5 1451606400\n", i)
+	}
+	p := &processor{}
+	out, err := p.encode([]byte(lines.String()))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// two messages, the second with a single metric
+	frames := 0
+	for len(out) > 0 {
+		size := int(binary.BigEndian.Uint32(out))
+		if size+4 > len(out) {
+			t.Fatalf("message of %d bytes doesn't fit the remaining %d bytes", size, len(out)-4)
+		}
+		msg := out[4 : 4+size]
+		if msg[0] != pickleProto || msg[len(msg)-1] != pickleStop {
+			t.Errorf("message %d is not a protocol 2 pickle", frames)
+		}
+		wantCount := pickleFrameMetrics
+		if frames == 1 {
+			wantCount = 1
+		}
+		if got := bytes.Count(msg, []byte("cpu.usage_user;hostname=")); got != wantCount {
+			t.Errorf("message %d: incorrect number of metrics: got %d want %d", frames, got, wantCount)
+		}
+		out = out[4+size:]
+		frames++
+	}
+	if frames != 2 {
+		t.Errorf("incorrect number of messages: got %d want 2", frames)
+	}
+
+	if _, err := p.encode([]byte("cpu.usage_user nan? 1451606400\n")); err == nil {
+		t.Errorf("expected error on an invalid value")
+	}
+}
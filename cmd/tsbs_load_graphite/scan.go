@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+const errNotThreeTuplesFmt = "parse error: line does not have 3 tuples: %s"
+
+var newLine = []byte("\n")
+
+// point holds the lines of all the fields of one row of the data set
+type point struct {
+	lines   []byte
+	metrics uint64
+}
+
+func (p *point) add(line []byte) {
+	p.lines = append(p.lines, line...)
+	p.lines = append(p.lines, newLine...)
+	p.metrics++
+}
+
+// firstLine returns the first line of the point, without the new line
+func (p *point) firstLine() []byte {
+	return p.lines[:bytes.IndexByte(p.lines, '\n')]
+}
+
+// fileDataSource reads the plaintext lines and groups the consecutive lines
+// of the same measurement, tags and timestamp into a point
+type fileDataSource struct {
+	scanner *bufio.Scanner
+	// first line of the next point, already read by the scanner
+	pending    []byte
+	hasPending bool
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	p := &point{}
+	if d.hasPending {
+		p.add(d.pending)
+		d.hasPending = false
+	}
+	for d.scanner.Scan() {
+		line := d.scanner.Bytes()
+		if !validLine(line) {
+			fatal(errNotThreeTuplesFmt, line)
+			return data.LoadedPoint{}
+		}
+		if p.metrics > 0 && !samePoint(p.firstLine(), line) {
+			d.pending = append(d.pending[:0], line...)
+			d.hasPending = true
+			return data.NewLoadedPoint(p)
+		}
+		p.add(line)
+	}
+	if err := d.scanner.Err(); err != nil {
+		fatal("scan error: %v", err)
+		return data.LoadedPoint{}
+	}
+	if p.metrics == 0 { // nothing scanned & no error = EOF
+		return data.LoadedPoint{}
+	}
+	return data.NewLoadedPoint(p)
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders { return nil }
+
+// validLine checks the line is "<name> <value> <timestamp>"
+func validLine(line []byte) bool {
+	return bytes.Count(line, []byte(" ")) == 2
+}
+
+// samePoint checks whether two lines have the same measurement, tags and
+// timestamp, i.e. are fields of the same point
+func samePoint(a, b []byte) bool {
+	aName, aTs := splitLine(a)
+	bName, bTs := splitLine(b)
+	if !bytes.Equal(aTs, bTs) {
+		return false
+	}
+	aMeasurement, aTags := splitName(aName)
+	bMeasurement, bTags := splitName(bName)
+	return bytes.Equal(aMeasurement, bMeasurement) && bytes.Equal(aTags, bTags)
+}
+
+// splitLine returns the series name and timestamp of a line
+func splitLine(line []byte) (name, ts []byte) {
+	return line[:bytes.IndexByte(line, ' ')], line[bytes.LastIndexByte(line, ' ')+1:]
+}
+
+// splitName returns the measurement and the tags of a tagged series name
+func splitName(name []byte) (measurement, tags []byte) {
+	if semi := bytes.IndexByte(name, ';'); semi >= 0 {
+		name, tags = name[:semi], name[semi:]
+	}
+	if dot := bytes.IndexByte(name, '.'); dot >= 0 {
+		name = name[:dot]
+	}
+	return name, tags
+}
+
+type batch struct {
+	buf     *bytes.Buffer
+	rows    uint
+	metrics uint64
+}
+
+func (b *batch) Len() uint {
+	return b.rows
+}
+
+func (b *batch) Append(item data.LoadedPoint) {
+	p := item.Data.(*point)
+	b.rows++
+	b.metrics += p.metrics
+	b.buf.Write(p.lines)
+}
+
+type factory struct{}
+
+func (f *factory) New() targets.Batch {
+	return &batch{buf: bufPool.Get().(*bytes.Buffer)}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const testData = "cpu.usage_user;hostname=host_0 1 1451606400\n" +
+	"cpu.usage_system;hostname=host_0 2 1451606400\n" +
+	"cpu.usage_user;hostname=host_1 3 1451606400\n" +
+	"mem.used;hostname=host_1 4 1451606400\n" +
+	"cpu.usage_user;hostname=host_0 5 1451606410\n"
+
+func TestFileDataSourceNextItem(t *testing.T) {
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader(testData))}
+	want := []uint64{2, 1, 1, 1}
+	for i, metrics := range want {
+		item := ds.NextItem()
+		if item.Data == nil {
+			t.Fatalf("missing point %d", i)
+		}
+		p := item.Data.(*point)
+		if p.metrics != metrics {
+			t.Errorf("point %d: incorrect number of metrics: got %d want %d", i, p.metrics, metrics)
+		}
+		if got := uint64(bytes.Count(p.lines, newLine)); got != metrics {
+			t.Errorf("point %d: incorrect number of lines: got %d want %d", i, got, metrics)
+		}
+	}
+	if item := ds.NextItem(); item.Data != nil {
+		t.Errorf("expected end of data, got %s", item.Data.(*point).lines)
+	}
+}
+
+func TestFileDataSourceNextItemBadLine(t *testing.T) {
+	errMsg := ""
+	fatal = func(f string, args ...interface{}) {
+		errMsg = fmt.Sprintf(f, args...)
+	}
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader("bad_point\n"))}
+	ds.NextItem()
+	if errMsg == "" {
+		t.Errorf("fatal not called on a bad line")
+	}
+}
+
+func TestBatch(t *testing.T) {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+	f := &factory{}
+	b := f.New().(*batch)
+	if b.Len() != 0 {
+		t.Errorf("batch not initialized with count 0")
+	}
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader(testData))}
+	b.Append(ds.NextItem())
+	b.Append(ds.NextItem())
+	if b.Len() != 2 {
+		t.Errorf("batch count is not 2 after two appends")
+	}
+	if b.metrics != 3 {
+		t.Errorf("batch metric count is not 3 after two appends")
+	}
+	if want := strings.Join(strings.Split(testData, "\n")[:3], "\n") + "\n"; b.buf.String() != want {
+		t.Errorf("incorrect batch data: got\n%s\nwant\n%s", b.buf.String(), want)
+	}
+}
@@ -4,21 +4,15 @@ package main
 
 import (
 	"bytes"
-	"fmt"
 	"net/url"
-	"time"
 
+	"github.com/timescale/tsbs/pkg/targets/common"
 	"github.com/valyala/fasthttp"
 )
 
-const (
-	httpClientName        = "tsbs_load_influx"
-	headerContentEncoding = "Content-Encoding"
-	headerGzip            = "gzip"
-)
+const httpClientName = "tsbs_load_influx"
 
 var (
-	errBackoff          = fmt.Errorf("backpressure is needed")
 	backoffMagicWords0  = []byte("engine: cache maximum memory size exceeded")
 	backoffMagicWords1  = []byte("write failed: hinted handoff queue not empty")
 	backoffMagicWords2a = []byte("write failed: read message type: read tcp")
@@ -40,68 +34,25 @@ type HTTPWriterConfig struct {
 	DebugInfo string
 }
 
-// HTTPWriter is a Writer that writes to an InfluxDB HTTP server.
-type HTTPWriter struct {
-	client fasthttp.Client
-
-	c   HTTPWriterConfig
-	url []byte
-}
-
-// NewHTTPWriter returns a new HTTPWriter from the supplied HTTPWriterConfig.
-func NewHTTPWriter(c HTTPWriterConfig, consistency string) *HTTPWriter {
-	return &HTTPWriter{
-		client: fasthttp.Client{
-			Name: httpClientName,
-		},
-
-		c:   c,
-		url: []byte(c.Host + "/write?consistency=" + consistency + "&db=" + url.QueryEscape(c.Database)),
-	}
-}
-
-var (
-	methodPost = []byte("POST")
-	textPlain  = []byte("text/plain")
-)
-
-func (w *HTTPWriter) initializeReq(req *fasthttp.Request, body []byte, isGzip bool) {
-	req.Header.SetContentTypeBytes(textPlain)
-	req.Header.SetMethodBytes(methodPost)
-	req.Header.SetRequestURIBytes(w.url)
-	if isGzip {
-		req.Header.Add(headerContentEncoding, headerGzip)
+// writerConfig returns the configuration of the common.HTTPWriter writing
+// line protocol to the /write endpoint of an InfluxDB HTTP server.
+func writerConfig(c HTTPWriterConfig, consistency string) common.HTTPWriterConfig {
+	return common.HTTPWriterConfig{
+		URL:          c.Host + "/write?consistency=" + consistency + "&db=" + url.QueryEscape(c.Database),
+		ContentType:  "text/plain",
+		ClientName:   httpClientName,
+		Backpressure: backpressure,
+		DebugInfo:    c.DebugInfo,
 	}
-	req.SetBody(body)
 }
 
-func (w *HTTPWriter) executeReq(req *fasthttp.Request, resp *fasthttp.Response) (int64, error) {
-	start := time.Now()
-	err := w.client.Do(req, resp)
-	lat := time.Since(start).Nanoseconds()
-	if err == nil {
-		sc := resp.StatusCode()
-		if sc == 500 && backpressurePred(resp.Body()) {
-			err = errBackoff
-		} else if sc != fasthttp.StatusNoContent {
-			err = fmt.Errorf("[DebugInfo: %s] Invalid write response (status %d): %s", w.c.DebugInfo, sc, resp.Body())
-		}
-	}
-	return lat, err
+// NewHTTPWriter returns a new common.HTTPWriter from the supplied HTTPWriterConfig.
+func NewHTTPWriter(c HTTPWriterConfig, consistency string) *common.HTTPWriter {
+	return common.NewHTTPWriter(writerConfig(c, consistency))
 }
 
-// WriteLineProtocol writes the given byte slice to the HTTP server described in the Writer's HTTPWriterConfig.
-// It returns the latency in nanoseconds and any error received while sending the data over HTTP,
-// or it returns a new error if the HTTP response isn't as expected.
-func (w *HTTPWriter) WriteLineProtocol(body []byte, isGzip bool) (int64, error) {
-	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	w.initializeReq(req, body, isGzip)
-
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
-
-	return w.executeReq(req, resp)
+func backpressure(statusCode int, body []byte) bool {
+	return statusCode == fasthttp.StatusInternalServerError && backpressurePred(body)
 }
 
 func backpressurePred(body []byte) bool {
@@ -10,7 +10,7 @@ import (
 	"testing"
 	"time"
 
-	"github.com/valyala/fasthttp"
+	"github.com/timescale/tsbs/pkg/targets/common"
 )
 
 const (
@@ -72,19 +72,11 @@ func shutdownHTTPServer(c chan struct{}) {
 	<-c                   // wait for clean shutdown
 }
 
-func testWriterMatchesConfig(w *HTTPWriter, conf HTTPWriterConfig, consistency string) error {
-	// Check HTTP Config is the same
-	if got := w.c.Host; got != conf.Host {
-		return fmt.Errorf("incorrect config host: got %s want %s", got, conf.Host)
-	}
-	if got := w.c.Database; got != conf.Database {
-		return fmt.Errorf("incorrect config host: got %s want %s", got, conf.Database)
-	}
-
+func testWriterMatchesConfig(w *common.HTTPWriter, conf HTTPWriterConfig, consistency string) error {
 	// Check URL is accurate
-	got := string(w.url)
-	if !strings.Contains(got, conf.Host) {
-		return fmt.Errorf("url does not contain correct host: looking for %s in %s", conf.Host, got)
+	got := w.URL()
+	if !strings.HasPrefix(got, conf.Host) {
+		return fmt.Errorf("url does not start with correct host: looking for %s in %s", conf.Host, got)
 	}
 	if !strings.Contains(got, consistency) {
 		return fmt.Errorf("url does not contain correct consistency: looking for %s in %s", consistency, got)
@@ -98,56 +90,19 @@ func testWriterMatchesConfig(w *HTTPWriter, conf HTTPWriterConfig, consistency s
 
 func TestNewHTTPWriter(t *testing.T) {
 	w := NewHTTPWriter(testConf, testConsistency)
-	// Check client name
-	if got := w.client.Name; got != httpClientName {
-		t.Errorf("name of http client is incorrect: got %s want %s", got, httpClientName)
-	}
-
 	err := testWriterMatchesConfig(w, testConf, testConsistency)
 	if err != nil {
 		t.Error(err)
 	}
 }
 
-func TestHTTPWriterInitializeReq(t *testing.T) {
-	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	w := NewHTTPWriter(testConf, testConsistency)
-	body := "this is a test body"
-	w.initializeReq(req, []byte(body), false)
-
-	if got := string(req.Body()); got != body {
-		t.Errorf("non-gzip: body not correct: got '%s' want '%s'", got, body)
-	}
-	if got := string(req.Header.Method()); got != string(methodPost) {
-		t.Errorf("non-gzip: method not correct: got %s want %s", got, string(methodPost))
-	}
-	if got := string(req.Header.RequestURI()); got != string(w.url) {
-		t.Errorf("non-gzip: URI is not correct: got %s want %s", got, string(w.url))
-	}
-	if got := string(req.Header.Peek(headerContentEncoding)); got != "" {
-		t.Errorf("non-gzip: Content-Encoding is not empty: got %s", got)
-	}
-
-	w.initializeReq(req, []byte(body), true)
-	if got := string(req.Header.Peek(headerContentEncoding)); got != headerGzip {
-		t.Errorf("gzip: Content-Encoding is not correct: got %s want %s", got, headerGzip)
-	}
-}
-
-func TestHTTPWriterExecuteReq(t *testing.T) {
+func TestHTTPWriterWrite(t *testing.T) {
 	c := launchHTTPServer()
+	defer shutdownHTTPServer(c)
 
 	// Success case test, make sure no error and positive latency
-	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	w := NewHTTPWriter(testConf, testConsistency)
-	body := "this is a test body"
-	normalURL := w.url // save for later modification
-	w.initializeReq(req, []byte(body), false)
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
-	lat, err := w.executeReq(req, resp)
+	wc := writerConfig(testConf, testConsistency)
+	lat, err := common.NewHTTPWriter(wc).Write([]byte("this is a test body"), false)
 	if err != nil {
 		t.Errorf("unexpected error received: %v", err)
 	}
@@ -155,37 +110,19 @@ func TestHTTPWriterExecuteReq(t *testing.T) {
 		t.Errorf("latency is unrealistic (<= 0): %d", lat)
 	}
 
-	// Backoff case test, make sure its a backoff error and positive latency
-	resp = fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
-	w.url = []byte(fmt.Sprintf("%s&%s=true", string(normalURL), shouldBackoffParam))
-	req = fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	w.initializeReq(req, []byte(body), false)
-	lat, err = w.executeReq(req, resp)
-	if err != errBackoff {
+	// Backoff case test, make sure its a backoff error
+	backoffConf := wc
+	backoffConf.URL = fmt.Sprintf("%s&%s=true", wc.URL, shouldBackoffParam)
+	if _, err = common.NewHTTPWriter(backoffConf).Write([]byte("this is a test body"), false); err != common.ErrBackoff {
 		t.Errorf("unexpected error response received (not backoff error): %v", err)
 	}
-	if lat <= 0 {
-		t.Errorf("latency is unrealistic (<= 0): %d", lat)
-	}
 
-	// Unexpected response case test, make sure its an error and positive latency
-	resp = fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
-	w.url = []byte(fmt.Sprintf("%s&%s=true", string(normalURL), shouldInvalidParam))
-	req = fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	w.initializeReq(req, []byte(body), false)
-	lat, err = w.executeReq(req, resp)
-	if err == nil {
-		t.Errorf("unexpected non-error response received")
+	// Unexpected response case test, make sure its an error
+	invalidConf := wc
+	invalidConf.URL = fmt.Sprintf("%s&%s=true", wc.URL, shouldInvalidParam)
+	if _, err = common.NewHTTPWriter(invalidConf).Write([]byte("this is a test body"), false); err == nil || err == common.ErrBackoff {
+		t.Errorf("unexpected non-error response received: %v", err)
 	}
-	if lat <= 0 {
-		t.Errorf("latency is unrealistic (<= 0): %d", lat)
-	}
-
-	shutdownHTTPServer(c)
 }
 
 func TestBackpressurePred(t *testing.T) {
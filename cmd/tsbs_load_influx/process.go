@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/common"
 	"time"
 
 	"github.com/valyala/fasthttp"
@@ -17,7 +18,7 @@ var printFn = fmt.Printf
 type processor struct {
 	backingOffChan chan bool
 	backingOffDone chan struct{}
-	httpWriter     *HTTPWriter
+	httpWriter     *common.HTTPWriter
 }
 
 func (p *processor) Init(numWorker int, _, _ bool) {
@@ -31,7 +32,7 @@ func (p *processor) Init(numWorker int, _, _ bool) {
 	p.initWithHTTPWriter(numWorker, w)
 }
 
-func (p *processor) initWithHTTPWriter(numWorker int, w *HTTPWriter) {
+func (p *processor) initWithHTTPWriter(numWorker int, w *common.HTTPWriter) {
 	p.backingOffChan = make(chan bool, backingOffChanCap)
 	p.backingOffDone = make(chan struct{})
 	p.httpWriter = w
@@ -43,7 +44,7 @@ func (p *processor) Close(_ bool) {
 	<-p.backingOffDone
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	batch := b.(*batch)
 
 	// Write the batch: try until backoff is not needed.
@@ -53,15 +54,15 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 			if useGzip {
 				compressedBatch := bufPool.Get().(*bytes.Buffer)
 				fasthttp.WriteGzip(compressedBatch, batch.buf.Bytes())
-				_, err = p.httpWriter.WriteLineProtocol(compressedBatch.Bytes(), true)
+				_, err = p.httpWriter.Write(compressedBatch.Bytes(), true)
 				// Return the compressed batch buffer to the pool.
 				compressedBatch.Reset()
 				bufPool.Put(compressedBatch)
 			} else {
-				_, err = p.httpWriter.WriteLineProtocol(batch.buf.Bytes(), false)
+				_, err = p.httpWriter.Write(batch.buf.Bytes(), false)
 			}
 
-			if err == errBackoff {
+			if err == common.ErrBackoff {
 				p.backingOffChan <- true
 				time.Sleep(backoff)
 			} else {
@@ -70,7 +71,7 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 			}
 		}
 		if err != nil {
-			fatal("Error writing: %s\n", err.Error())
+			return 0, 0, fmt.Errorf("error writing: %v", err)
 		}
 	}
 	metricCnt := batch.metrics
@@ -79,7 +80,7 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 	// Return the batch buffer to the pool.
 	batch.buf.Reset()
 	bufPool.Put(batch.buf)
-	return metricCnt, uint64(rowCnt)
+	return metricCnt, uint64(rowCnt), nil
 }
 
 func (p *processor) processBackoffMessages(workerID int) {
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"fmt"
+	"net/url"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets/common"
 )
 
 func emptyLog(_ string, _ ...interface{}) (int, error) {
@@ -22,24 +24,24 @@ func TestProcessorInit(t *testing.T) {
 	p := &processor{}
 	p.Init(0, false, false)
 	p.Close(true)
-	if got := p.httpWriter.c.Host; got != daemonURLs[0] {
+	if got := p.httpWriter.URL(); !strings.HasPrefix(got, daemonURLs[0]+"/") {
 		t.Errorf("incorrect host: got %s want %s", got, daemonURLs[0])
 	}
-	if got := p.httpWriter.c.Database; got != loader.DatabaseName() {
-		t.Errorf("incorrect database: got %s want %s", got, loader.DatabaseName())
+	if got, want := p.httpWriter.URL(), "db="+url.QueryEscape(loader.DatabaseName()); !strings.HasSuffix(got, want) {
+		t.Errorf("incorrect database: got %s want %s", got, want)
 	}
 
 	p = &processor{}
 	p.Init(1, false, false)
 	p.Close(true)
-	if got := p.httpWriter.c.Host; got != daemonURLs[1] {
+	if got := p.httpWriter.URL(); !strings.HasPrefix(got, daemonURLs[1]+"/") {
 		t.Errorf("incorrect host: got %s want %s", got, daemonURLs[1])
 	}
 
 	p = &processor{}
 	p.Init(len(daemonURLs), false, false)
 	p.Close(true)
-	if got := p.httpWriter.c.Host; got != daemonURLs[0] {
+	if got := p.httpWriter.URL(); !strings.HasPrefix(got, daemonURLs[0]+"/") {
 		t.Errorf("incorrect host: got %s want %s", got, daemonURLs[0])
 	}
 
@@ -102,7 +104,7 @@ func TestProcessorProcessBatch(t *testing.T) {
 		doLoad        bool
 		useGzip       bool
 		shouldBackoff bool
-		shouldFatal   bool
+		shouldErr     bool
 	}{
 		{
 			doLoad:  false,
@@ -122,46 +124,40 @@ func TestProcessorProcessBatch(t *testing.T) {
 			shouldBackoff: true,
 		},
 		{
-			doLoad:      true,
-			shouldFatal: true,
+			doLoad:    true,
+			shouldErr: true,
 		},
 	}
 
 	for _, c := range cases {
 		var ch chan struct{}
-		fatalCalled := false
-		if c.shouldFatal {
-			fatal = func(format string, args ...interface{}) {
-				fatalCalled = true
-			}
-		} else {
-			fatal = func(format string, args ...interface{}) {
-				t.Errorf("fatal called for case %v unexpectedly\n", c)
-				fmt.Printf(format, args...)
-			}
+		if !c.shouldErr {
 			ch = launchHTTPServer()
 		}
 
 		p := &processor{}
-		w := NewHTTPWriter(testConf, testConsistency)
+		wc := writerConfig(testConf, testConsistency)
 
 		// If the case should backoff, we tell our dummy server to do so by
 		// modifying the URL params. This should keep ProcessBatch in a loop
 		// until it gets a response that is not a backoff (every other response from the server).
 		if c.shouldBackoff {
-			normalURL := string(w.url)
-			w.url = []byte(fmt.Sprintf("%s&%s=true", normalURL, shouldBackoffParam))
+			wc.URL = fmt.Sprintf("%s&%s=true", wc.URL, shouldBackoffParam)
 		}
+		w := common.NewHTTPWriter(wc)
 
 		p.initWithHTTPWriter(0, w)
 		useGzip = c.useGzip
-		mCnt, rCnt := p.ProcessBatch(b, c.doLoad)
-		if c.shouldFatal {
-			if !fatalCalled {
-				t.Errorf("fatal was not called when it should have been")
+		mCnt, rCnt, err := p.ProcessBatch(b, c.doLoad)
+		if c.shouldErr {
+			if err == nil {
+				t.Errorf("error was not returned when it should have been")
 			}
 			continue
 		} else {
+			if err != nil {
+				t.Errorf("unexpected error for case %v: %v", c, err)
+			}
 			if mCnt != b.metrics {
 				t.Errorf("process batch returned less metrics than batch: got %d want %d", mCnt, b.metrics)
 			}
@@ -32,6 +32,7 @@ var (
 	consistency       string
 	token             string
 	org               string
+	doRollups         bool
 )
 
 // Global vars
@@ -79,6 +80,7 @@ func init() {
 	useGzip = viper.GetBool("gzip")
 	token = viper.GetString("token")
 	org = viper.GetString("org")
+	doRollups = viper.GetBool("rollups")
 
 	if _, ok := consistencyChoices[consistency]; !ok {
 		log.Fatalf("invalid consistency settings")
@@ -120,6 +122,9 @@ func (b *benchmark) GetProcessor() targets.Processor {
 }
 
 func (b *benchmark) GetDBCreator() targets.DBCreator {
+	if doRollups {
+		return &rollupCreator{&dbCreator{}}
+	}
 	return &dbCreator{}
 }
 
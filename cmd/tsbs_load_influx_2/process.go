@@ -44,7 +44,7 @@ func (p *processor) Close(_ bool) {
 	<-p.backingOffDone
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	batch := b.(*batch)
 
 	// Write the batch: try until backoff is not needed.
@@ -71,7 +71,7 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 			}
 		}
 		if err != nil {
-			fatal("Error writing: %s\n", err.Error())
+			return 0, 0, fmt.Errorf("error writing: %v", err)
 		}
 	}
 	metricCnt := batch.metrics
@@ -80,7 +80,7 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 	// Return the batch buffer to the pool.
 	batch.buf.Reset()
 	bufPool.Put(batch.buf)
-	return metricCnt, uint64(rowCnt)
+	return metricCnt, uint64(rowCnt), nil
 }
 
 func (p *processor) processBackoffMessages(workerID int) {
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// rollupMeasurement is the measurement rolled up with --rollups
+const rollupMeasurement = "cpu"
+
+// rollups are the rollups of the cpu measurement, with the max of each field
+// per host per minute and hour, written to the cpu_1m and cpu_1h measurements
+// of the bucket with the fields prefixed with max_, as the queries generated
+// for them read.
+var rollups = []struct {
+	name  string
+	every string
+}{
+	{name: rollupMeasurement + "_1m", every: "1m"},
+	{name: rollupMeasurement + "_1h", every: "1h"},
+}
+
+// rollupCreator is the dbCreator when the rollups of the cpu measurement are
+// created, as tasks rolling up the data of the bucket as it comes in. The
+// loaded data being in the past, the tasks do not see it: once it is loaded,
+// their query is run over the whole bucket instead, so that the time it takes
+// is reported.
+type rollupCreator struct {
+	*dbCreator
+}
+
+// rollupTaskName returns the name of the task of the rollup name of bucket
+func rollupTaskName(bucket, name string) string {
+	return fmt.Sprintf("tsbs_%s_%s", bucket, name)
+}
+
+// rollupFlux returns the Flux script rolling up the cpu measurement of bucket
+// every interval from start, either a duration relative to the run of a task
+// or 0 for all the data
+func rollupFlux(bucket, name, every, start string) string {
+	return fmt.Sprintf(`from(bucket: "%[1]s")
+	|> range(start: %[4]s)
+	|> filter(fn: (r) => r._measurement == "%[5]s")
+	|> aggregateWindow(every: %[3]s, fn: max, timeSrc: "_start", createEmpty: false)
+	|> set(key: "_measurement", value: "%[2]s")
+	|> map(fn: (r) => ({r with _field: "max_" + r._field}))
+	|> to(bucket: "%[1]s")
+`, bucket, name, every, start, rollupMeasurement)
+}
+
+// PostCreateDB creates the tasks of the rollups, replacing the ones of a
+// previous run
+func (d *rollupCreator) PostCreateDB(dbName string) error {
+	for _, r := range rollups {
+		name := rollupTaskName(dbName, r.name)
+		if err := d.deleteTasks(name); err != nil {
+			return err
+		}
+		flux := fmt.Sprintf("option task = {name: \"%s\", every: %s}\n\n%s", name, r.every, rollupFlux(dbName, r.name, r.every, "-task.every"))
+		body, err := json.Marshal(map[string]string{"orgID": org, "flux": flux, "status": "active"})
+		if err != nil {
+			return err
+		}
+		if _, err := d.do("POST", "/api/v2/tasks", body, http.StatusCreated); err != nil {
+			return fmt.Errorf("could not create task %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// deleteTasks deletes the tasks named name
+func (d *rollupCreator) deleteTasks(name string) error {
+	body, err := d.do("GET", "/api/v2/tasks?"+url.Values{"name": {name}, "orgID": {org}}.Encode(), nil, http.StatusOK)
+	if err != nil {
+		return fmt.Errorf("could not list tasks: %v", err)
+	}
+	var listing struct {
+		Tasks []struct {
+			ID string `json:"id"`
+		} `json:"tasks"`
+	}
+	if err := json.Unmarshal(body, &listing); err != nil {
+		return fmt.Errorf("could not list tasks: %v", err)
+	}
+	for _, t := range listing.Tasks {
+		if _, err := d.do("DELETE", "/api/v2/tasks/"+t.ID, nil, http.StatusNoContent); err != nil {
+			return fmt.Errorf("could not delete task %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// PostLoad runs the queries of the tasks of the rollups over all the data
+// loaded
+func (d *rollupCreator) PostLoad(dbName string) error {
+	for _, r := range rollups {
+		body, err := json.Marshal(map[string]string{"query": rollupFlux(dbName, r.name, r.every, "0"), "type": "flux"})
+		if err != nil {
+			return err
+		}
+		resp, err := d.do("POST", "/api/v2/query?"+url.Values{"orgID": {org}}.Encode(), body, http.StatusOK)
+		if err != nil {
+			return fmt.Errorf("could not roll up %s: %v", r.name, err)
+		}
+		// errors during the query come as a table of the CSV response
+		if strings.Contains(string(resp), ",error,reference") {
+			return fmt.Errorf("could not roll up %s: %s", r.name, resp)
+		}
+	}
+	return nil
+}
+
+// do sends the API request with the token, returning the body of the response
+// when its status is the expected one
+func (d *rollupCreator) do(method, path string, body []byte, status int) ([]byte, error) {
+	req, err := http.NewRequest(method, d.daemonURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Token "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != status {
+		return nil, fmt.Errorf("%s %s returned HTTP status %d: %s", method, path, resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
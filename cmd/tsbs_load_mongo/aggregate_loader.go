@@ -3,7 +3,6 @@ package main
 import (
 	"fmt"
 	"hash/fnv"
-	"log"
 	"sync"
 	"time"
 
@@ -120,7 +119,7 @@ func (p *aggProcessor) Init(_ int, doLoad, _ bool) {
 //      ]
 //    ]
 //  }
-func (p *aggProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *aggProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	docToEvents := make(map[string][]*point)
 	batch := b.(*batch)
 
@@ -174,8 +173,10 @@ func (p *aggProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint6
 
 	if doLoad {
 		// Checks if any new documents need to be made and does so
-		bulk := p.collection.Bulk()
-		bulk = insertNewAggregateDocs(p.collection, bulk, p.createQueue)
+		bulk, err := insertNewAggregateDocs(p.collection, p.collection.Bulk(), p.createQueue)
+		if err != nil {
+			return 0, 0, fmt.Errorf("bulk aggregate docs err: %v", err)
+		}
 		p.createQueue = p.createQueue[:0]
 
 		// For each document, create one 'set' command for all records
@@ -198,9 +199,9 @@ func (p *aggProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint6
 		}
 
 		// All documents accounted for, finally run the operation
-		_, err := bulk.Run()
+		_, err = bulk.Run()
 		if err != nil {
-			log.Fatalf("Bulk aggregate update err: %s\n", err.Error())
+			return 0, 0, fmt.Errorf("bulk aggregate update err: %v", err)
 		}
 
 		for _, events := range docToEvents {
@@ -210,12 +211,12 @@ func (p *aggProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint6
 			}
 		}
 	}
-	return eventCnt, 0
+	return eventCnt, 0, nil
 }
 
 // insertNewAggregateDocs handles creating new aggregated documents when new devices
 // or time periods are encountered
-func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQueue []interface{}) *mgo.Bulk {
+func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQueue []interface{}) (*mgo.Bulk, error) {
 	b := bulk
 	if len(createQueue) > 0 {
 		off := 0
@@ -228,7 +229,7 @@ func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQu
 			b.Insert(createQueue[off:l]...)
 			_, err := b.Run()
 			if err != nil {
-				log.Fatalf("Bulk aggregate docs err: %s\n", err.Error())
+				return nil, err
 			}
 			b = collection.Bulk()
 
@@ -236,5 +237,5 @@ func insertNewAggregateDocs(collection *mgo.Collection, bulk *mgo.Bulk, createQu
 		}
 	}
 
-	return b
+	return b, nil
 }
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"sync"
 
 	"github.com/globalsign/mgo"
@@ -56,7 +56,7 @@ func (p *naiveProcessor) Init(_ int, doLoad, _ bool) {
 // ProcessBatch creates a new document for each incoming event for a simpler
 // approach to storing the data. This is _NOT_ the default since the aggregation method
 // is recommended by Mongo and other blogs
-func (p *naiveProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *naiveProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	batch := b.(*batch).arr
 	if cap(p.pvs) < len(batch) {
 		p.pvs = make([]interface{}, len(batch))
@@ -89,12 +89,12 @@ func (p *naiveProcessor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uin
 		bulk.Insert(p.pvs...)
 		_, err := bulk.Run()
 		if err != nil {
-			log.Fatalf("Bulk insert docs err: %s\n", err.Error())
+			return 0, 0, fmt.Errorf("bulk insert docs err: %v", err)
 		}
 	}
 	for _, p := range p.pvs {
 		spPool.Put(p)
 	}
 
-	return metricCnt, 0
+	return metricCnt, 0, nil
 }
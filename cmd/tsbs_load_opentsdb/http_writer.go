@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+
+	"github.com/timescale/tsbs/pkg/targets/common"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	httpClientName        = "tsbs_load_opentsdb"
+	headerContentEncoding = "Content-Encoding"
+	headerGzip            = "gzip"
+	putPath               = "/api/put"
+)
+
+var (
+	backoffMagicWords0 = []byte("PleaseThrottleException")
+	backoffMagicWords1 = []byte("Please throttle writes")
+	backoffMagicWords2 = []byte("timeout")
+)
+
+// HTTPWriterConfig is the configuration used to create an HTTPWriter.
+type HTTPWriterConfig struct {
+	// URL of the host, in form "http://example.com:4242"
+	Host string
+
+	// Debug label for more informative errors.
+	DebugInfo string
+}
+
+// NewHTTPWriter returns a new common.HTTPWriter writing JSON arrays of data
+// points to the /api/put endpoint of the OpenTSDB HTTP server described in
+// the supplied HTTPWriterConfig.
+func NewHTTPWriter(c HTTPWriterConfig) *common.HTTPWriter {
+	return common.NewHTTPWriter(common.HTTPWriterConfig{
+		URL:          c.Host + putPath,
+		ContentType:  "application/json",
+		ClientName:   httpClientName,
+		StatusCodes:  []int{fasthttp.StatusNoContent, fasthttp.StatusOK},
+		Backpressure: backpressure,
+		DebugInfo:    c.DebugInfo,
+	})
+}
+
+func backpressure(statusCode int, body []byte) bool {
+	return statusCode == fasthttp.StatusServiceUnavailable || (statusCode >= 500 && backpressurePred(body))
+}
+
+func backpressurePred(body []byte) bool {
+	return bytes.Contains(body, backoffMagicWords0) ||
+		bytes.Contains(body, backoffMagicWords1) ||
+		bytes.Contains(body, backoffMagicWords2)
+}
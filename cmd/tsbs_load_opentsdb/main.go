@@ -0,0 +1,108 @@
+// tsbs_load_opentsdb loads an OpenTSDB daemon, or any server implementing
+// its /api/put HTTP endpoint, with data from stdin.
+//
+// The caller is responsible for assuring that the database is empty before
+// bulk load.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/initializers"
+)
+
+// Program option vars:
+var (
+	daemonURLs []string
+	backoff    time.Duration
+	useGzip    bool
+)
+
+// Global vars
+var (
+	loader  load.BenchmarkRunner
+	config  load.BenchmarkRunnerConfig
+	bufPool sync.Pool
+	target  targets.ImplementedTarget
+)
+
+// allows for testing
+var fatal = log.Fatalf
+
+// Parse args:
+func init() {
+	target = initializers.GetTarget(constants.FormatOpenTSDB)
+	config = load.BenchmarkRunnerConfig{}
+	config.AddToFlagSet(pflag.CommandLine)
+	target.TargetSpecificFlags("", pflag.CommandLine)
+	var csvDaemonURLs string
+
+	pflag.Parse()
+
+	err := utils.SetupConfigFile()
+
+	if err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	csvDaemonURLs = viper.GetString("urls")
+	backoff = viper.GetDuration("backoff")
+	useGzip = viper.GetBool("gzip")
+
+	daemonURLs = strings.Split(csvDaemonURLs, ",")
+	if len(daemonURLs) == 0 {
+		log.Fatal("missing 'urls' flag")
+	}
+	config.HashWorkers = false
+	loader = load.GetBenchmarkRunner(config)
+}
+
+type benchmark struct{}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return &fileDataSource{scanner: bufio.NewScanner(load.GetBufferedReader(config.FileName))}
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	return &factory{}
+}
+
+func (b *benchmark) GetPointIndexer(_ uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{}
+}
+
+// GetDBCreator returns nil, OpenTSDB has no databases and creates the
+// metrics when they are first written (with tsd.core.auto_create_metrics)
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return nil
+}
+
+func main() {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+
+	loader.RunBenchmark(&benchmark{})
+}
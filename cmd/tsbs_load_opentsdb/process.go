@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/common"
+	"github.com/valyala/fasthttp"
+)
+
+const backingOffChanCap = 100
+
+// allows for testing
+var printFn = fmt.Printf
+
+type processor struct {
+	backingOffChan chan bool
+	backingOffDone chan struct{}
+	httpWriter     *common.HTTPWriter
+}
+
+func (p *processor) Init(numWorker int, _, _ bool) {
+	daemonURL := daemonURLs[numWorker%len(daemonURLs)]
+	cfg := HTTPWriterConfig{
+		DebugInfo: fmt.Sprintf("worker #%d, dest url: %s", numWorker, daemonURL),
+		Host:      daemonURL,
+	}
+	w := NewHTTPWriter(cfg)
+	p.initWithHTTPWriter(numWorker, w)
+}
+
+func (p *processor) initWithHTTPWriter(numWorker int, w *common.HTTPWriter) {
+	p.backingOffChan = make(chan bool, backingOffChanCap)
+	p.backingOffDone = make(chan struct{})
+	p.httpWriter = w
+	go p.processBackoffMessages(numWorker)
+}
+
+func (p *processor) Close(_ bool) {
+	close(p.backingOffChan)
+	<-p.backingOffDone
+}
+
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
+	batch := b.(*batch)
+
+	// Write the batch: try until backoff is not needed.
+	if doLoad {
+		var err error
+		for {
+			if useGzip {
+				compressedBatch := bufPool.Get().(*bytes.Buffer)
+				fasthttp.WriteGzip(compressedBatch, batch.body())
+				_, err = p.httpWriter.Write(compressedBatch.Bytes(), true)
+				// Return the compressed batch buffer to the pool.
+				compressedBatch.Reset()
+				bufPool.Put(compressedBatch)
+			} else {
+				_, err = p.httpWriter.Write(batch.body(), false)
+			}
+
+			if err == common.ErrBackoff {
+				p.backingOffChan <- true
+				time.Sleep(backoff)
+			} else {
+				p.backingOffChan <- false
+				break
+			}
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("error writing: %v", err)
+		}
+	}
+	metricCnt := batch.metrics
+	rowCnt := batch.rows
+
+	// Return the batch buffer to the pool.
+	batch.buf.Reset()
+	bufPool.Put(batch.buf)
+	return metricCnt, uint64(rowCnt), nil
+}
+
+func (p *processor) processBackoffMessages(workerID int) {
+	var totalBackoffSecs float64
+	var start time.Time
+	last := false
+	for this := range p.backingOffChan {
+		if this && !last {
+			start = time.Now()
+			last = true
+		} else if !this && last {
+			took := time.Now().Sub(start)
+			printFn("[worker %d] backoff took %.02fsec\n", workerID, took.Seconds())
+			totalBackoffSecs += took.Seconds()
+			last = false
+			start = time.Now()
+		}
+	}
+	printFn("[worker %d] backoffs took a total of %fsec of runtime\n", workerID, totalBackoffSecs)
+	p.backingOffDone <- struct{}{}
+}
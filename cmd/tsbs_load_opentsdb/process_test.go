@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+func emptyLog(_ string, _ ...interface{}) (int, error) {
+	return 0, nil
+}
+
+// putServer is a fake /api/put endpoint that asks for a backoff every other
+// request if backoff is set
+type putServer struct {
+	*httptest.Server
+	backoff    bool
+	requests   int64
+	dataPoints int64
+}
+
+func newPutServer(t *testing.T, status int) *putServer {
+	s := &putServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&s.requests, 1)
+		if r.URL.Path != putPath {
+			t.Errorf("incorrect path: got %s want %s", r.URL.Path, putPath)
+		}
+		if s.backoff && n%2 == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write(backoffMagicWords0)
+			return
+		}
+		body := r.Body
+		if r.Header.Get(headerContentEncoding) == headerGzip {
+			var err error
+			if body, err = gzip.NewReader(r.Body); err != nil {
+				t.Errorf("invalid gzip body: %v", err)
+			}
+		}
+		b, _ := ioutil.ReadAll(body)
+		var dps []map[string]interface{}
+		if err := json.Unmarshal(b, &dps); err != nil {
+			t.Errorf("invalid JSON body: %v", err)
+		}
+		atomic.AddInt64(&s.dataPoints, int64(len(dps)))
+		w.WriteHeader(status)
+	}))
+	return s
+}
+
+func TestProcessorInit(t *testing.T) {
+	daemonURLs = []string{"url1", "url2"}
+	printFn = emptyLog
+	for i, want := range []string{"url1", "url2", "url1"} {
+		p := &processor{}
+		p.Init(i, false, false)
+		p.Close(true)
+		if got := p.httpWriter.URL(); got != want+putPath {
+			t.Errorf("incorrect url: got %s want %s", got, want+putPath)
+		}
+	}
+}
+
+func TestProcessorProcessBatch(t *testing.T) {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024))
+		},
+	}
+	printFn = emptyLog
+	backoff = time.Millisecond
+
+	cases := []struct {
+		desc          string
+		doLoad        bool
+		useGzip       bool
+		shouldBackoff bool
+		status        int
+		shouldErr     bool
+	}{
+		{desc: "no load", status: http.StatusNoContent},
+		{desc: "load", doLoad: true, status: http.StatusNoContent},
+		{desc: "load with summary", doLoad: true, status: http.StatusOK},
+		{desc: "gzip", doLoad: true, useGzip: true, status: http.StatusNoContent},
+		{desc: "backoff", doLoad: true, shouldBackoff: true, status: http.StatusNoContent},
+		{desc: "invalid response", doLoad: true, status: http.StatusBadRequest, shouldErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			s := newPutServer(t, c.status)
+			defer s.Close()
+			s.backoff = c.shouldBackoff
+
+			b := (&factory{}).New().(*batch)
+			b.Append(data.LoadedPoint{Data: []byte(testLine0)})
+			b.Append(data.LoadedPoint{Data: []byte(testLine1)})
+
+			p := &processor{}
+			p.initWithHTTPWriter(0, NewHTTPWriter(HTTPWriterConfig{Host: s.URL}))
+			defer p.Close(true)
+			useGzip = c.useGzip
+			mCnt, rCnt, err := p.ProcessBatch(b, c.doLoad)
+			if c.shouldErr {
+				if err == nil {
+					t.Errorf("error was not returned when it should have been")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if mCnt != 3 || rCnt != 2 {
+				t.Errorf("incorrect counts: got %d metrics, %d rows want 3 metrics, 2 rows", mCnt, rCnt)
+			}
+
+			wantRequests, wantDataPoints := int64(0), int64(0)
+			if c.doLoad {
+				wantRequests, wantDataPoints = 1, 3
+			}
+			if c.shouldBackoff {
+				wantRequests++
+			}
+			if got := atomic.LoadInt64(&s.requests); got != wantRequests {
+				t.Errorf("incorrect number of requests: got %d want %d", got, wantRequests)
+			}
+			if got := atomic.LoadInt64(&s.dataPoints); got != wantDataPoints {
+				t.Errorf("incorrect number of data points: got %d want %d", got, wantDataPoints)
+			}
+		})
+	}
+}
+
+func TestBackpressurePred(t *testing.T) {
+	cases := []struct {
+		body string
+		want bool
+	}{
+		{body: "net.opentsdb.hbase.PleaseThrottleException: 10000 RPCs waiting", want: true},
+		{body: "Please throttle writes: 10000 RPCs waiting", want: true},
+		{body: "read timeout", want: true},
+		{body: "Unable to parse the given JSON", want: false},
+	}
+	for _, c := range cases {
+		if got := backpressurePred([]byte(c.body)); got != c.want {
+			t.Errorf("incorrect output for %q: got %v want %v", c.body, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+const errNotJSONArrayFmt = "parse error: line is not a JSON array: %s"
+
+var metricKey = []byte(`{"metric":`)
+
+type fileDataSource struct {
+	scanner *bufio.Scanner
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	ok := d.scanner.Scan()
+	if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+		return data.LoadedPoint{}
+	} else if !ok {
+		fatal("scan error: %v", d.scanner.Err())
+		return data.LoadedPoint{}
+	}
+	return data.NewLoadedPoint(d.scanner.Bytes())
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders { return nil }
+
+// batch merges the JSON arrays of its points into a single array. The closing
+// bracket is added when the batch is written, see body.
+type batch struct {
+	buf     *bytes.Buffer
+	rows    uint
+	metrics uint64
+}
+
+func (b *batch) Len() uint {
+	return b.rows
+}
+
+func (b *batch) Append(item data.LoadedPoint) {
+	that := item.Data.([]byte)
+	// Each line is a JSON array "[{...},{...}]" with an object per metric
+	if len(that) < 2 || that[0] != '[' || that[len(that)-1] != ']' {
+		fatal(errNotJSONArrayFmt, that)
+		return
+	}
+	b.rows++
+	b.metrics += uint64(bytes.Count(that, metricKey))
+
+	if b.buf.Len() == 0 {
+		b.buf.WriteByte('[')
+	} else {
+		b.buf.WriteByte(',')
+	}
+	b.buf.Write(that[1 : len(that)-1])
+}
+
+// body returns the JSON array of the data points of the batch. The batch can
+// still be appended to or written again afterwards.
+func (b *batch) body() []byte {
+	// appending to the bytes doesn't change the length of the buffer
+	return append(b.buf.Bytes(), ']')
+}
+
+type factory struct{}
+
+func (f *factory) New() targets.Batch {
+	return &batch{buf: bufPool.Get().(*bytes.Buffer)}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+const (
+	testLine0 = `[{"metric":"cpu.usage_user","timestamp":140,"value":1,"tags":{"hostname":"host_0"}},{"metric":"cpu.usage_system","timestamp":140,"value":2,"tags":{"hostname":"host_0"}}]`
+	testLine1 = `[{"metric":"cpu.usage_user","timestamp":150,"value":3,"tags":{"hostname":"host_0"}}]`
+)
+
+func TestBatch(t *testing.T) {
+	bufPool = sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+	f := &factory{}
+	b := f.New().(*batch)
+	if b.Len() != 0 {
+		t.Errorf("batch not initialized with count 0")
+	}
+	b.Append(data.LoadedPoint{Data: []byte(testLine0)})
+	if b.Len() != 1 {
+		t.Errorf("batch count is not 1 after first append")
+	}
+	if b.metrics != 2 {
+		t.Errorf("batch metric count is not 2 after first append")
+	}
+	b.Append(data.LoadedPoint{Data: []byte(testLine1)})
+	if b.Len() != 2 {
+		t.Errorf("batch count is not 2 after second append")
+	}
+	if b.metrics != 3 {
+		t.Errorf("batch metric count is not 3 after second append")
+	}
+
+	// the body is a single valid JSON array and can be requested again
+	for i := 0; i < 2; i++ {
+		var dps []map[string]interface{}
+		if err := json.Unmarshal(b.body(), &dps); err != nil {
+			t.Fatalf("batch body is not valid JSON: %v\n%s", err, b.body())
+		}
+		if len(dps) != 3 {
+			t.Errorf("incorrect number of data points in body: got %d want 3", len(dps))
+		}
+	}
+
+	errMsg := ""
+	fatal = func(f string, args ...interface{}) {
+		errMsg = fmt.Sprintf(f, args...)
+	}
+	b.Append(data.LoadedPoint{Data: []byte("bad_point")})
+	if errMsg == "" {
+		t.Errorf("batch append did not error with ill-formed point")
+	}
+}
+
+func TestFileDataSourceNextItem(t *testing.T) {
+	input := testLine0 + "\n" + testLine1 + "\n"
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader(input))}
+	for _, want := range []string{testLine0, testLine1} {
+		p := ds.NextItem()
+		if got := string(p.Data.([]byte)); got != want {
+			t.Errorf("incorrect result: got\n%s\nwant\n%s", got, want)
+		}
+	}
+	// nothing left, should be EOF
+	if p := ds.NextItem(); p.Data != nil {
+		t.Errorf("expected p to be nil, got %v", p)
+	}
+}
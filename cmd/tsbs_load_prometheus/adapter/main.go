@@ -2,19 +2,30 @@ package main
 
 import (
 	"flag"
+	"time"
 
 	"github.com/timescale/tsbs/cmd/tsbs_load_prometheus/adapter/noop"
 )
 
-var port int
+var (
+	port         int
+	readHosts    int
+	readInterval time.Duration
+)
 
 func init() {
 	flag.IntVar(&port, "port", 9876, "a port for adapter to listen on")
+	flag.IntVar(&readHosts, "read-hosts", 10, "number of hosts returned for remote-read requests not selecting the hostname")
+	flag.DurationVar(&readInterval, "read-interval", 10*time.Second, "interval between the samples returned for remote-read requests")
 }
 
-// Start noop Prometheus adapter. Useful for testing purposes
+// Start noop Prometheus adapter. Useful for testing purposes.
+// Remote-write requests are accepted on any path, remote-read requests on /read.
 func main() {
+	flag.Parse()
 	adapter := noop.NewAdapter(port)
+	adapter.ReadHosts = readHosts
+	adapter.ReadInterval = readInterval
 	err := adapter.Start()
 	if err != nil {
 		panic(err)
@@ -4,25 +4,46 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
 	"github.com/golang/snappy"
 	"github.com/prometheus/common/log"
+	"github.com/prometheus/common/model"
 	"github.com/timescale/promscale/pkg/prompb"
 )
 
+const (
+	defaultReadHosts    = 10
+	defaultReadInterval = 10 * time.Second
+)
+
 type Adapter struct {
 	port          int
 	ReqCounter    uint64
 	SampleCounter uint64
+
+	// ReadHosts is the number of hosts returned for read requests that don't
+	// select the hostname
+	ReadHosts int
+	// ReadInterval is the interval between the samples returned for read requests
+	ReadInterval time.Duration
+	// ReadReqCounter counts the read requests
+	ReadReqCounter uint64
 }
 
 func NewAdapter(port int) *Adapter {
-	return &Adapter{port: port}
+	return &Adapter{
+		port:         port,
+		ReadHosts:    defaultReadHosts,
+		ReadInterval: defaultReadInterval,
+	}
 }
 
 // Start starts no-op Prometheus adapter. This call will block go-routine
 func (adapter *Adapter) Start() error {
+	http.HandleFunc("/read", adapter.ReadHandler)
 	http.HandleFunc("/", adapter.Handler)
 	log.Info("msg", fmt.Sprintf("Starting noop adapter listening on: %d\n", adapter.port))
 	return http.ListenAndServe(fmt.Sprintf(":%d", adapter.port), nil)
@@ -30,24 +51,106 @@ func (adapter *Adapter) Start() error {
 
 // Handler counts number of requests and samples
 func (adapter *Adapter) Handler(rw http.ResponseWriter, req *http.Request) {
+	var protoReq prompb.WriteRequest
+	if !decodeRequest(rw, req, &protoReq) {
+		return
+	}
+	adapter.ReqCounter++
+	adapter.SampleCounter += uint64(len(protoReq.Timeseries))
+}
+
+// ReadHandler answers remote-read requests with generated data. A series is
+// returned for every combination of the metric names and hostnames selected by
+// the equality or alternation matchers of a query, with a sample every
+// ReadInterval between the start and end of the query.
+func (adapter *Adapter) ReadHandler(rw http.ResponseWriter, req *http.Request) {
+	var protoReq prompb.ReadRequest
+	if !decodeRequest(rw, req, &protoReq) {
+		return
+	}
+	adapter.ReadReqCounter++
+
+	resp := &prompb.ReadResponse{Results: make([]*prompb.QueryResult, len(protoReq.Queries))}
+	for i, q := range protoReq.Queries {
+		resp.Results[i] = adapter.generateResult(q)
+	}
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		log.Error("msg", "error while marshalling read response", "error", err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/x-protobuf")
+	rw.Header().Set("Content-Encoding", "snappy")
+	if _, err := rw.Write(snappy.Encode(nil, data)); err != nil {
+		log.Error("msg", "error while writing read response", "error", err)
+	}
+}
+
+func (adapter *Adapter) generateResult(q *prompb.Query) *prompb.QueryResult {
+	names := []string{"metric"}
+	var hosts []string
+	for i := 0; i < adapter.ReadHosts; i++ {
+		hosts = append(hosts, fmt.Sprintf("host_%d", i))
+	}
+	for _, m := range q.Matchers {
+		var values []string
+		switch m.Type {
+		case prompb.LabelMatcher_EQ:
+			values = []string{m.Value}
+		case prompb.LabelMatcher_RE:
+			values = strings.Split(m.Value, "|")
+		default:
+			continue
+		}
+		switch m.Name {
+		case model.MetricNameLabel:
+			names = values
+		case "hostname":
+			hosts = values
+		}
+	}
+
+	var samples []prompb.Sample
+	step := adapter.ReadInterval.Milliseconds()
+	for ts := q.StartTimestampMs; ts <= q.EndTimestampMs && step > 0; ts += step {
+		samples = append(samples, prompb.Sample{Timestamp: ts, Value: float64(ts % 100)})
+	}
+
+	result := &prompb.QueryResult{}
+	for _, name := range names {
+		for _, host := range hosts {
+			result.Timeseries = append(result.Timeseries, &prompb.TimeSeries{
+				Labels: []prompb.Label{
+					{Name: model.MetricNameLabel, Value: name},
+					{Name: "hostname", Value: host},
+				},
+				Samples: samples,
+			})
+		}
+	}
+	return result
+}
+
+// decodeRequest reads a snappy compressed protobuf request into msg. If the
+// request can't be decoded an error response is written and false returned.
+func decodeRequest(rw http.ResponseWriter, req *http.Request, msg proto.Message) bool {
 	compressed, err := ioutil.ReadAll(req.Body)
 	if err != nil {
 		log.Error("msg", "error while reading request", "error", err)
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
-		return
+		return false
 	}
 	decompressed, err := snappy.Decode(nil, compressed)
 	if err != nil {
 		log.Error("msg", "error while decompressing request", "error", err)
 		http.Error(rw, err.Error(), http.StatusInternalServerError)
-		return
+		return false
 	}
-	var protoReq prompb.WriteRequest
-	if err := proto.Unmarshal(decompressed, &protoReq); err != nil {
+	if err := proto.Unmarshal(decompressed, msg); err != nil {
 		log.Error("msg", "error while unmarshalling protobuf request", "error", err)
 		http.Error(rw, err.Error(), http.StatusBadRequest)
-		return
+		return false
 	}
-	adapter.ReqCounter++
-	adapter.SampleCounter += uint64(len(protoReq.Timeseries))
+	return true
 }
@@ -0,0 +1,83 @@
+package noop
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/timescale/promscale/pkg/prompb"
+	"github.com/timescale/tsbs/pkg/targets/prometheus"
+)
+
+func TestReadHandler(t *testing.T) {
+	adapter := NewAdapter(0)
+	adapter.ReadHosts = 3
+	server := httptest.NewServer(http.HandlerFunc(adapter.ReadHandler))
+	defer server.Close()
+
+	client, err := prometheus.NewClient(server.URL, time.Second)
+	if err != nil {
+		t.Fatalf("could not create client: %v", err)
+	}
+
+	testCases := []struct {
+		desc        string
+		matchers    []*prompb.LabelMatcher
+		wantSeries  int
+		wantSamples int
+	}{
+		{
+			desc: "single metric, single host",
+			matchers: []*prompb.LabelMatcher{
+				{Type: prompb.LabelMatcher_EQ, Name: "__name__", Value: "usage_user"},
+				{Type: prompb.LabelMatcher_EQ, Name: "hostname", Value: "host_1"},
+			},
+			wantSeries:  1,
+			wantSamples: 7,
+		},
+		{
+			desc: "two metrics, all hosts",
+			matchers: []*prompb.LabelMatcher{
+				{Type: prompb.LabelMatcher_RE, Name: "__name__", Value: "usage_user|usage_system"},
+			},
+			wantSeries:  6,
+			wantSamples: 42,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			resp, size, err := client.Read(context.Background(), &prompb.ReadRequest{
+				Queries: []*prompb.Query{{
+					StartTimestampMs: 0,
+					EndTimestampMs:   time.Minute.Milliseconds(),
+					Matchers:         tc.matchers,
+				}},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if size == 0 {
+				t.Errorf("response size not returned")
+			}
+			if len(resp.Results) != 1 {
+				t.Fatalf("incorrect number of results: got %d want 1", len(resp.Results))
+			}
+			series := resp.Results[0].Timeseries
+			samples := 0
+			for _, s := range series {
+				samples += len(s.Samples)
+			}
+			if len(series) != tc.wantSeries {
+				t.Errorf("incorrect number of series: got %d want %d", len(series), tc.wantSeries)
+			}
+			if samples != tc.wantSamples {
+				t.Errorf("incorrect number of samples: got %d want %d", samples, tc.wantSamples)
+			}
+		})
+	}
+	if adapter.ReadReqCounter != uint64(len(testCases)) {
+		t.Errorf("incorrect read request count: got %d want %d", adapter.ReadReqCounter, len(testCases))
+	}
+}
@@ -54,6 +54,13 @@ func init() {
 	pflag.CommandLine.Int64("seed", 0, "PRNG seed (default: 0, which uses the current timestamp)")
 	pflag.CommandLine.String("insert-intervals", "", "Time to wait between each insert, default '' => all workers insert ASAP. '1,2' = worker 1 waits 1s between inserts, worker 2 and others wait 2s")
 	pflag.CommandLine.Bool("hash-workers", false, "Whether to consistently hash insert data to the same workers (i.e., the data for a particular host always goes to the same worker)")
+	pflag.CommandLine.Uint("batch-retries", 3, "Number of times to retry inserting a batch that failed before counting it as failed")
+	pflag.CommandLine.Duration("retry-backoff", time.Second, "Time to wait before retrying a failed batch, doubled after each failed retry")
+	pflag.CommandLine.Uint64("max-failed-batches", 0, "Abort the load after this many batches failed all retries (0 = never abort)")
+	pflag.CommandLine.Float64("target-rate", 0, "Combined insert rate of all workers in metrics/sec (0 = insert as fast as possible)")
+	pflag.CommandLine.Float64("target-rate-start", 0, "Insert rate in metrics/sec at the start of the run when ramping up to --target-rate")
+	pflag.CommandLine.Duration("target-rate-ramp", 0, "Time to ramp up from --target-rate-start to --target-rate (0 = no ramp)")
+	pflag.CommandLine.Uint("target-rate-steps", 0, "Number of equal steps in which to ramp up the insert rate (0 = linear ramp)")
 	target.TargetSpecificFlags("", pflag.CommandLine)
 	pflag.Parse()
 
@@ -15,29 +15,44 @@ type processor struct {
 }
 
 func (p *processor) Init(numWorker int, _, _ bool) {
+	if err := p.connect(); err != nil {
+		fatal("%s\n", err.Error())
+	}
+}
+
+func (p *processor) connect() error {
 	tcpAddr, err := net.ResolveTCPAddr("tcp4", questdbILPBindTo)
 	if err != nil {
-		fatal("Failed to resolve %s: %s\n", questdbILPBindTo, err.Error())
+		return fmt.Errorf("Failed to resolve %s: %s", questdbILPBindTo, err.Error())
 	}
 	p.ilpConn, err = net.DialTCP("tcp", nil, tcpAddr)
 	if err != nil {
-		fatal("Failed connect to %s: %s\n", questdbILPBindTo, err.Error())
+		return fmt.Errorf("Failed connect to %s: %s", questdbILPBindTo, err.Error())
 	}
+	return nil
 }
 
 func (p *processor) Close(_ bool) {
-	defer p.ilpConn.Close()
+	if p.ilpConn != nil {
+		p.ilpConn.Close()
+	}
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	batch := b.(*batch)
 
-	// Write the batch: try until backoff is not needed.
 	if doLoad {
-		var err error
-		_, err = p.ilpConn.Write(batch.buf.Bytes())
-		if err != nil {
-			fatal("Error writing: %s\n", err.Error())
+		// the connection a write failed on is closed, the retry writes the
+		// batch on a new one
+		if p.ilpConn == nil {
+			if err := p.connect(); err != nil {
+				return 0, 0, err
+			}
+		}
+		if _, err := p.ilpConn.Write(batch.buf.Bytes()); err != nil {
+			p.ilpConn.Close()
+			p.ilpConn = nil
+			return 0, 0, fmt.Errorf("error writing: %v", err)
 		}
 	}
 
@@ -47,5 +62,5 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 	// Return the batch buffer to the pool.
 	batch.buf.Reset()
 	bufPool.Put(batch.buf)
-	return metricCnt, uint64(rowCnt)
+	return metricCnt, uint64(rowCnt), nil
 }
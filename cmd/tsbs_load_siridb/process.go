@@ -34,11 +34,11 @@ func (p *processor) Close(doLoad bool) {
 	}
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rows uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rows uint64, err error) {
 	batch := b.(*batch)
 	if doLoad {
 		if err := p.connection.Connect(dbUser, dbPass, loader.DatabaseName()); err != nil {
-			fatal(err)
+			return 0, 0, err
 		}
 		series := make([]byte, 0)
 		series = append(series, byte(253)) // qpack: "open map"
@@ -52,7 +52,7 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, row
 		}
 		start := time.Now()
 		if _, err := p.connection.InsertBin(series, uint16(writeTimeout)); err != nil {
-			fatal(err)
+			return 0, 0, err
 		}
 		if logBatches {
 			now := time.Now()
@@ -65,5 +65,5 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, row
 	batch.series = map[string][]byte{}
 	batch.batchCnt = 0
 	batch.metricCnt = 0
-	return metricCount, 0
+	return metricCount, 0, nil
 }
@@ -72,6 +72,7 @@ func initProgramOptions() (*timescaledb.LoadingOptions, load.BenchmarkRunner, *l
 
 	opts.ForceTextFormat = viper.GetBool("force-text-format")
 	opts.UseInsert = viper.GetBool("use-insert")
+	opts.Rollups = viper.GetBool("rollups")
 
 	loader := load.GetBenchmarkRunner(loaderConf)
 	return &opts, loader, &loaderConf
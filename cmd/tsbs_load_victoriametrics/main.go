@@ -36,7 +36,12 @@ func initProgramOptions() (*victoriametrics.SpecificConfig, load.BenchmarkRunner
 	vmURLs := strings.Split(urls, ",")
 
 	loader := load.GetBenchmarkRunner(loaderConf)
-	return &victoriametrics.SpecificConfig{ServerURLs: vmURLs}, loader, &loaderConf
+	return &victoriametrics.SpecificConfig{
+		ServerURLs:       vmURLs,
+		Rollups:          viper.GetBool("rollups"),
+		RollupsQueryURL:  strings.TrimSuffix(viper.GetString("rollups-query-url"), "/"),
+		RollupsImportURL: strings.TrimSuffix(viper.GetString("rollups-import-url"), "/"),
+	}, loader, &loaderConf
 }
 
 func main() {
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net"
+
+	"github.com/timescale/tsbs/pkg/targets/otlp/noop"
+)
+
+var (
+	grpcAddr string
+	httpAddr string
+)
+
+func init() {
+	flag.StringVar(&grpcAddr, "grpc-addr", ":4317", "address for the OTLP/gRPC receiver to listen on")
+	flag.StringVar(&httpAddr, "http-addr", ":4318", "address for the OTLP/HTTP receiver to listen on")
+}
+
+// Start noop OTLP receiver. Useful for testing purposes
+func main() {
+	flag.Parse()
+	receiver := noop.NewReceiver()
+	go func() {
+		if err := receiver.ListenAndServeHTTP(httpAddr); err != nil {
+			log.Fatal(err)
+		}
+	}()
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := receiver.ServeGRPC(lis); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -38,15 +39,16 @@ func NewHTTPClient(host string) *HTTPClient {
 }
 
 // Do performs the action specified by the given Query. It uses fasthttp, and
-// tries to minimize heap allocations.
-func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64, err error) {
+// tries to minimize heap allocations. It returns an error only when ctx is
+// done before the response is read.
+func (w *HTTPClient) Do(ctx context.Context, q *query.HTTP, opts *HTTPClientDoOptions) (lag float64, err error) {
 	// populate uri from the reusable byte slice:
 	w.uri = w.uri[:0]
 	w.uri = append(w.uri, w.Host...)
 	w.uri = append(w.uri, q.Path...)
 
 	// populate a request with data from the Query:
-	req, err := http.NewRequest(string(q.Method), string(w.uri), bytes.NewReader(q.Body))
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), string(w.uri), bytes.NewReader(q.Body))
 	if err != nil {
 		panic(err)
 	}
@@ -54,7 +56,9 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 	// Perform the request while tracking latency:
 	start := time.Now()
 	resp, err := w.client.Do(req)
-	if err != nil {
+	if err != nil && ctx.Err() != nil {
+		return 0, err
+	} else if err != nil {
 		panic(err)
 	}
 	defer resp.Body.Close()
@@ -69,6 +73,8 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 		if err == io.EOF {
 			err = nil
 			break
+		} else if err != nil && ctx.Err() != nil {
+			return 0, err
 		} else if err != nil {
 			panic(err)
 		}
@@ -8,6 +8,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -124,6 +125,12 @@ func (p *processor) Init(workerNumber int) {
 }
 
 func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor, the CQL queries being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 	cq := q.(*query.Cassandra)
 	hlq := &HLQuery{*cq}
 	hlq.ForceUTC()
@@ -137,7 +144,7 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 			labels[i] = append(l, " (warm)"...)
 		}
 	}
-	qpLagMs, reqLagMs, err := p.qe.Do(hlq, *p.opts)
+	qpLagMs, reqLagMs, err := p.qe.Do(ctx, hlq, *p.opts)
 	if err != nil {
 		return nil, err
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
@@ -41,8 +42,8 @@ type HLQueryExecutorDoOptions struct {
 
 // Do takes a high-level query, constructs a query plan using the client-side
 // index contained within the query executor, executes that query plan, then
-// aggregates the results.
-func (qe *HLQueryExecutor) Do(q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagMs, requestLagMs float64, err error) {
+// aggregates the results. The CQL queries are cancelled when ctx is done.
+func (qe *HLQueryExecutor) Do(ctx context.Context, q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagMs, requestLagMs float64, err error) {
 	if opts.Debug >= 1 {
 		fmt.Printf("[hlqe] Do: %s\n", q)
 	}
@@ -81,7 +82,7 @@ func (qe *HLQueryExecutor) Do(q *HLQuery, opts HLQueryExecutorDoOptions) (qpLagM
 	// execute the query plan:
 	var results []CQLResult
 	execStart := time.Now()
-	results, err = qp.Execute(qe.session)
+	results, err = qp.Execute(ctx, qe.session)
 	requestLagMs = float64(time.Now().Sub(execStart).Nanoseconds()) / 1e6
 	if err != nil {
 		return
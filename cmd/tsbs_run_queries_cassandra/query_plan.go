@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"sort"
@@ -14,7 +15,7 @@ import (
 
 // A QueryPlan is a strategy used to fulfill an HLQuery.
 type QueryPlan interface {
-	Execute(*gocql.Session) ([]CQLResult, error)
+	Execute(context.Context, *gocql.Session) ([]CQLResult, error)
 	DebugQueries(int)
 }
 
@@ -43,7 +44,7 @@ func NewQueryPlanWithServerAggregation(aggrLabel string, bucketedCQLQueries map[
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
 //
 // TODO(rw): support parallel execution.
-func (qp *QueryPlanWithServerAggregation) Execute(session *gocql.Session) ([]CQLResult, error) {
+func (qp *QueryPlanWithServerAggregation) Execute(ctx context.Context, session *gocql.Session) ([]CQLResult, error) {
 	// sort the time interval buckets we'll use:
 	sortedKeys := make([]*utils.TimeInterval, 0, len(qp.BucketedCQLQueries))
 	for k := range qp.BucketedCQLQueries {
@@ -66,7 +67,7 @@ func (qp *QueryPlanWithServerAggregation) Execute(session *gocql.Session) ([]CQL
 			// For server-side aggregation, this will return only
 			// one row; for exclusive client-side aggregation this
 			// will return a sequence.
-			iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+			iter := session.Query(q.PreparableQueryString, q.Args...).WithContext(ctx).Iter()
 			var x float64
 			for iter.Scan(&x) {
 				agg.Put(x)
@@ -163,11 +164,11 @@ func csiDebugQueries(cqlQueries []CQLQuery, label string, level int) {
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
 //
 // TODO(rw): support parallel execution.
-func (qp *QueryPlanWithoutServerAggregation) Execute(session *gocql.Session) ([]CQLResult, error) {
+func (qp *QueryPlanWithoutServerAggregation) Execute(ctx context.Context, session *gocql.Session) ([]CQLResult, error) {
 	// for each query, execute it, then put each result row into the
 	// client-side aggregator that matches its time bucket:
 	for _, q := range qp.CQLQueries {
-		iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+		iter := session.Query(q.PreparableQueryString, q.Args...).WithContext(ctx).Iter()
 
 		var timestampNs int64
 		var value float64
@@ -241,7 +242,7 @@ func (a int64arr) Less(i, j int) bool { return a[i] < a[j] }
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
 //
 // TODO(rw): support parallel execution.
-func (qp *QueryPlanNoAggregation) Execute(session *gocql.Session) ([]CQLResult, error) {
+func (qp *QueryPlanNoAggregation) Execute(ctx context.Context, session *gocql.Session) ([]CQLResult, error) {
 	res := make(map[int64]map[string][]float64)
 	// Useful index for placing values in a row correctly
 	fieldPos := make(map[string]int)
@@ -266,7 +267,7 @@ func (qp *QueryPlanNoAggregation) Execute(session *gocql.Session) ([]CQLResult,
 		// First pass of all queries
 		for _, q := range qp.cqlQueries {
 			if q.Field == whereParts[0] { // only handle queries for where clause field
-				iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+				iter := session.Query(q.PreparableQueryString, q.Args...).WithContext(ctx).Iter()
 
 				var timestampNs int64
 				var value float64
@@ -295,7 +296,7 @@ func (qp *QueryPlanNoAggregation) Execute(session *gocql.Session) ([]CQLResult,
 		// Second pass for non-where clause fields
 		for _, q := range qp.cqlQueries {
 			if q.Field != whereParts[0] {
-				iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+				iter := session.Query(q.PreparableQueryString, q.Args...).WithContext(ctx).Iter()
 
 				var timestampNs int64
 				var value float64
@@ -396,7 +397,7 @@ func NewQueryPlanForEvery(fields []string, forEveryTag string, forEveryNum int64
 // Execute runs all CQLQueries in the QueryPlan and collects the results.
 //
 // TODO(rw): support parallel execution.
-func (qp *QueryPlanForEvery) Execute(session *gocql.Session) ([]CQLResult, error) {
+func (qp *QueryPlanForEvery) Execute(ctx context.Context, session *gocql.Session) ([]CQLResult, error) {
 	res := make(map[string]map[int64][]float64)
 	seriesTracker := make(map[string]int)
 
@@ -407,7 +408,7 @@ func (qp *QueryPlanForEvery) Execute(session *gocql.Session) ([]CQLResult, error
 	}
 
 	for _, q := range qp.cqlQueries {
-		iter := session.Query(q.PreparableQueryString, q.Args...).Iter()
+		iter := session.Query(q.PreparableQueryString, q.Args...).WithContext(ctx).Iter()
 
 		rm := r.FindSubmatch([]byte(q.Args[0].(string)))
 		key := string(rm[1])
@@ -5,6 +5,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -25,12 +26,18 @@ var (
 	user      string
 	password  string
 
-	showExplain bool
+	showExplain   bool
+	planSampler   *query.PlanSampler
+	queryLogStats bool
 )
 
 // Global vars:
 var (
-	runner *query.BenchmarkRunner
+	runner   *query.BenchmarkRunner
+	balancer *query.EndpointBalancer
+	// runID tells the queries of this run apart from the ones of previous
+	// runs in system.query_log
+	runID = time.Now().UnixNano()
 )
 
 // Parse args:
@@ -45,6 +52,9 @@ func init() {
 		"Comma separated list of ClickHouse hosts (pass multiple values for sharding reads on a multi-node setup)")
 	pflag.String("user", "default", "User to connect to ClickHouse as")
 	pflag.String("password", "", "Password to connect to ClickHouse")
+	pflag.Uint("explain-sample", 0, "Capture the EXPLAIN indexes = 1 output of the first N queries of each query type, 0 = none")
+	pflag.String("explain-dir", "plans", "Directory to write the plans captured with --explain-sample to, one subdirectory per query type")
+	pflag.Bool("query-log-stats", false, "Look up the result bytes and server time of every query in system.query_log, flushing the logs after each query")
 
 	pflag.Parse()
 
@@ -62,6 +72,8 @@ func init() {
 	hosts = viper.GetString("hosts")
 	user = viper.GetString("user")
 	password = viper.GetString("password")
+	planSampler = query.NewPlanSampler(viper.GetUint("explain-sample"), viper.GetString("explain-dir"))
+	queryLogStats = viper.GetBool("query-log-stats")
 
 	// Parse comma separated string of hosts and put in a slice (for multi-node setups)
 	for _, host := range strings.Split(hosts, ",") {
@@ -69,6 +81,14 @@ func init() {
 	}
 
 	runner = query.NewBenchmarkRunner(config)
+	balancer, err = runner.EndpointBalancer(hostsList)
+	if err != nil {
+		panic(err)
+	}
+	runner.SetCacheHooks(map[string]query.CacheHook{
+		"drop-mark-cache":         systemQueryHook("SYSTEM DROP MARK CACHE"),
+		"drop-uncompressed-cache": systemQueryHook("SYSTEM DROP UNCOMPRESSED CACHE"),
+	})
 }
 
 func main() {
@@ -78,19 +98,17 @@ func main() {
 // Get the connection string for a connection to PostgreSQL.
 
 // If we're running queries against multiple nodes we need to balance the queries
-// across replicas. Each worker is assigned a sequence number -- we'll use that
-// to evenly distribute hosts to worker connections
-func getConnectString(workerNumber int) string {
-	// Round robin the host/worker assignment by assigning a host based on workerNumber % totalNumberOfHosts
-	host := hostsList[workerNumber%len(hostsList)]
-
+// across replicas, with the strategy of the balancer. Each worker connects to
+// the hosts it sends queries to.
+func getConnectString(host string) string {
 	return fmt.Sprintf("tcp://%s:9000?username=%s&password=%s&database=%s", host, user, password, runner.DatabaseName())
 }
 
 // prettyPrintResponse prints a Query and its response in JSON format with two
 // keys: 'query' which has a value of the SQL used to generate the second key
-// 'results' which is an array of each row in the return set.
-func prettyPrintResponse(rows *sqlx.Rows, q *query.ClickHouse) {
+// 'results' which is an array of each row in the return set. It returns the
+// number of rows.
+func prettyPrintResponse(rows *sqlx.Rows, q *query.ClickHouse) int64 {
 	resp := make(map[string]interface{})
 	resp["query"] = string(q.SqlQuery)
 
@@ -110,6 +128,7 @@ func prettyPrintResponse(rows *sqlx.Rows, q *query.ClickHouse) {
 	}
 
 	fmt.Println(string(line) + "\n")
+	return int64(len(results))
 }
 
 type queryExecutorOptions struct {
@@ -120,8 +139,12 @@ type queryExecutorOptions struct {
 
 // query.Processor interface implementation
 type processor struct {
-	db   *sqlx.DB
-	opts *queryExecutorOptions
+	// dbs has the connection of each host, opened on first use
+	dbs          []*sqlx.DB
+	workerNumber int
+	opts         *queryExecutorOptions
+	// seq numbers the queries of the worker, to find them in system.query_log
+	seq uint64
 }
 
 // query.Processor interface implementation
@@ -131,7 +154,8 @@ func newProcessor() query.Processor {
 
 // query.Processor interface implementation
 func (p *processor) Init(workerNumber int) {
-	p.db = sqlx.MustConnect("clickhouse", getConnectString(workerNumber))
+	p.dbs = make([]*sqlx.DB, len(hostsList))
+	p.workerNumber = workerNumber
 	p.opts = &queryExecutorOptions{
 		// ClickHouse could not do EXPLAIN
 		showExplain:   false,
@@ -140,8 +164,22 @@ func (p *processor) Init(workerNumber int) {
 	}
 }
 
+// db returns the connection of the host with index i
+func (p *processor) db(i int) *sqlx.DB {
+	if p.dbs[i] == nil {
+		p.dbs[i] = sqlx.MustConnect("clickhouse", getConnectString(hostsList[i]))
+	}
+	return p.dbs[i]
+}
+
 // query.Processor interface implementation
 func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the query being cancelled
+// when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 	// No need to run again for EXPLAIN
 	if isWarm && p.opts.showExplain {
 		return nil, nil
@@ -149,32 +187,144 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 
 	// Ensure ClickHouse query
 	chQuery := q.(*query.ClickHouse)
+	host := balancer.Pick(p.workerNumber)
+	defer balancer.Done(host)
+	db := p.db(host)
 
 	start := time.Now()
 
 	// SqlQuery is []byte, so cast is needed
 	sql := string(chQuery.SqlQuery)
+	tagged := sql
+	var tag string
+	if queryLogStats {
+		p.seq++
+		tag = fmt.Sprintf("/* tsbs %d-%d-%d */", runID, p.workerNumber, p.seq)
+		tagged = tag + " " + sql
+	}
 
 	// Main action - run the query
-	rows, err := p.db.Queryx(sql)
+	rows, err := db.QueryxContext(ctx, tagged)
 	if err != nil {
-		return nil, err
+		return nil, query.WithEndpoint(hostsList[host], err)
 	}
 
 	// Print some extra info if needed
 	if p.opts.debug {
 		fmt.Println(sql)
 	}
+	nRows := int64(0)
 	if p.opts.printResponse {
-		prettyPrintResponse(rows, chQuery)
+		nRows = prettyPrintResponse(rows, chQuery)
+	}
+	for rows.Next() {
+		nRows++
 	}
 
 	// Finalize the query
 	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, query.WithEndpoint(hostsList[host], err)
+	}
 	took := float64(time.Since(start).Nanoseconds()) / 1e6
 
+	var logged *queryLogEntry
+	if queryLogStats {
+		if logged, err = lookupQueryLog(ctx, db, tag); err != nil {
+			return nil, err
+		}
+	}
+
+	// The plan is captured after the timed run, so that it is not slowed down,
+	// and before the stat is taken, not to leak it when it fails
+	if !isWarm && planSampler.Sample(q) {
+		if err := capturePlan(ctx, db, q, sql); err != nil {
+			return nil, err
+		}
+	}
+
 	stat := query.GetStat()
-	stat.Init(q.HumanLabelName(), took)
+	stat.Init(q.HumanLabelName(), took).SetEndpoint(hostsList[host]).SetRows(nRows)
+	if logged != nil {
+		stat.SetBytes(int64(logged.ResultBytes)).SetServerTime(float64(logged.DurationMs))
+	}
 
 	return []*query.Stat{stat}, err
 }
+
+// queryLogEntry is the part of a system.query_log row reported with the
+// latency of a query
+type queryLogEntry struct {
+	ResultBytes uint64 `db:"result_bytes"`
+	DurationMs  uint64 `db:"query_duration_ms"`
+}
+
+// lookupQueryLog returns the system.query_log entry of the query starting with
+// tag, once completed. The native protocol does not return the progress and
+// profile information of a query to the driver, and query_log is only flushed
+// every few seconds, so the logs are flushed first.
+func lookupQueryLog(ctx context.Context, db *sqlx.DB, tag string) (*queryLogEntry, error) {
+	if _, err := db.ExecContext(ctx, "SYSTEM FLUSH LOGS"); err != nil {
+		return nil, fmt.Errorf("could not flush the query log: %v", err)
+	}
+	// the tag only has digits besides the comment markers, it is inlined
+	var entry queryLogEntry
+	err := db.GetContext(ctx, &entry, fmt.Sprintf(`SELECT result_bytes, query_duration_ms FROM system.query_log
+		WHERE type = 'QueryFinish' AND event_date >= yesterday() AND startsWith(query, '%s')
+		LIMIT 1`, tag))
+	if err != nil {
+		return nil, fmt.Errorf("could not find query %s in system.query_log, log_queries must be enabled: %v", tag, err)
+	}
+	return &entry, nil
+}
+
+// capturePlan writes the plan of the query, with the indexes it uses, and
+// the rows EXPLAIN ESTIMATE expects it to read from the MergeTree tables.
+// ClickHouse does not report the planning time.
+func capturePlan(ctx context.Context, db *sqlx.DB, q query.Query, sql string) error {
+	var lines []string
+	if err := db.SelectContext(ctx, &lines, "EXPLAIN indexes = 1 "+sql); err != nil {
+		return fmt.Errorf("could not explain query: %v", err)
+	}
+	var estimates []struct {
+		Database string `db:"database"`
+		Table    string `db:"table"`
+		Parts    uint64 `db:"parts"`
+		Rows     uint64 `db:"rows"`
+		Marks    uint64 `db:"marks"`
+	}
+	if err := db.SelectContext(ctx, &estimates, "EXPLAIN ESTIMATE "+sql); err != nil {
+		return fmt.Errorf("could not estimate the rows of query: %v", err)
+	}
+
+	plan := planSampler.NewPlan(q, sql)
+	plan.Plan = lines
+	rows := int64(0)
+	for _, e := range estimates {
+		rows += int64(e.Rows)
+	}
+	plan.SetRowsScanned(rows)
+	return planSampler.Write(plan)
+}
+
+// systemQueryHook returns a cold cache hook running the SYSTEM query on each
+// host, e.g. SYSTEM DROP MARK CACHE
+func systemQueryHook(sql string) query.CacheHook {
+	return query.CacheHook{
+		Run: func() error {
+			for _, host := range hostsList {
+				db, err := sqlx.Connect("clickhouse", getConnectString(host))
+				if err != nil {
+					return err
+				}
+				_, err = db.Exec(sql)
+				db.Close()
+				if err != nil {
+					return fmt.Errorf("could not run %s on %s: %v", sql, host, err)
+				}
+			}
+			return nil
+		},
+		Cold: true,
+	}
+}
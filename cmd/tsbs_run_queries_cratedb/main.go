@@ -23,6 +23,7 @@ var (
 	pass        string
 	port        int
 	showExplain bool
+	planSampler *query.PlanSampler
 )
 
 var runner *query.BenchmarkRunner
@@ -36,6 +37,8 @@ func init() {
 	pflag.String("pass", "", "Password for user connecting to CrateDB")
 	pflag.Int("port", 5432, "A port to connect to database instances")
 	pflag.Bool("show-explain", false, "Print out the EXPLAIN output for sample query")
+	pflag.Uint("explain-sample", 0, "Capture the EXPLAIN ANALYZE output of the first N queries of each query type, 0 = none")
+	pflag.String("explain-dir", "plans", "Directory to write the plans captured with --explain-sample to, one subdirectory per query type")
 
 	pflag.Parse()
 
@@ -54,6 +57,7 @@ func init() {
 	pass = viper.GetString("pass")
 	port = viper.GetInt("port")
 	showExplain = viper.GetBool("show-explain")
+	planSampler = query.NewPlanSampler(viper.GetUint("explain-sample"), viper.GetString("explain-dir"))
 
 	runner = query.NewBenchmarkRunner(config)
 
@@ -101,6 +105,12 @@ func newProcessor() (query.Processor, error) {
 }
 
 func (p *processor) Init(workerNumber int) {
+	p.connect()
+}
+
+// connect opens the connection of the processor, again after a query timed
+// out, pgx closing the connection of a cancelled query
+func (p *processor) connect() {
 	conn, err := pgx.ConnectConfig(context.Background(), p.connCfg)
 	if err != nil {
 		panic(err)
@@ -109,10 +119,19 @@ func (p *processor) Init(workerNumber int) {
 }
 
 func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor, the query being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 	// No need to run again for EXPLAIN
 	if isWarm && p.opts.showExplain {
 		return nil, nil
 	}
+	if p.conn.IsClosed() {
+		p.connect()
+	}
 	tq := q.(*query.CrateDB)
 
 	start := time.Now()
@@ -120,7 +139,7 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 	if showExplain {
 		qry = "EXPLAIN ANALYZE " + qry
 	}
-	rows, err := p.conn.Query(context.Background(), qry)
+	rows, err := p.conn.Query(ctx, qry)
 	if err != nil {
 		return nil, err
 	}
@@ -135,15 +154,42 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 	} else if p.opts.printResponse {
 		prettyPrintResponse(rows, tq)
 	}
-	defer rows.Close()
+	// the connection is busy until the rows are closed, which reads them
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
 	took := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	// The plan is captured after the timed run, so that it is not slowed down,
+	// and before the stat is taken, not to leak it when it fails
+	if !isWarm && planSampler.Sample(q) {
+		if err := p.capturePlan(q, string(tq.SqlQuery)); err != nil {
+			return nil, err
+		}
+	}
+
 	stat := query.GetStat()
 	stat.Init(q.HumanLabelName(), took)
 
 	return []*query.Stat{stat}, err
 }
 
+// capturePlan runs the query again with EXPLAIN ANALYZE and writes its plan.
+// CrateDB reports its timings inside the plan, there are no planning time and
+// rows scanned to extract.
+func (p *processor) capturePlan(q query.Query, qry string) error {
+	var explain interface{}
+	err := p.conn.QueryRow(context.Background(), "EXPLAIN ANALYZE "+qry).Scan(&explain)
+	if err != nil {
+		return errors.Wrap(err, "could not explain query")
+	}
+	plan := planSampler.NewPlan(q, qry)
+	plan.Plan = explain
+	return planSampler.Write(plan)
+}
+
 // prettyPrintResponse prints a Query and its response in JSON format with two
 // keys: 'query' which has a value of the SQL used to generate the second key
 // 'results' which is an array of each row in the return set.
@@ -0,0 +1,191 @@
+// tsbs_run_queries_elasticsearch speed tests an Elasticsearch or OpenSearch
+// search API using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin, and makes concurrent requests
+// to the _search endpoint of the provided URLs. The JSON responses are
+// parsed to count the returned hits and aggregation buckets, reported as the
+// rows of each query type along with the response sizes and the time the
+// server took.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Program option vars:
+var (
+	esURLs []string
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("urls", "http://localhost:9200",
+		"Comma-separated list of Elasticsearch or OpenSearch URLs")
+
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	urls := viper.GetString("urls")
+	if len(urls) == 0 {
+		log.Fatalf("missing `urls` flag")
+	}
+	esURLs = strings.Split(urls, ",")
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, newProcessor)
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+type processor struct {
+	url string
+
+	prettyPrintResponses bool
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNum int) {
+	p.url = strings.TrimSuffix(esURLs[workerNum%len(esURLs)], "/")
+	p.prettyPrintResponses = runner.DoPrintResponses()
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+	lag, res, err := p.do(ctx, hq)
+	if err != nil {
+		return nil, err
+	}
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag).SetRows(int64(res.rows)).SetBytes(int64(res.size)).SetServerTime(float64(res.took))
+	return []*query.Stat{stat}, nil
+}
+
+// searchResponse is the part of a _search response needed to count the
+// returned hits and buckets
+type searchResponse struct {
+	// Took is the time the server took to run the search, in milliseconds
+	Took int64 `json:"took"`
+	Hits struct {
+		Hits []json.RawMessage `json:"hits"`
+	} `json:"hits"`
+	Aggregations map[string]interface{} `json:"aggregations"`
+}
+
+// searchResult is what a search returned: its hits and buckets, the size of
+// the response and the milliseconds the server took
+type searchResult struct {
+	rows uint64
+	size int
+	took int64
+}
+
+func (p *processor) do(ctx context.Context, q *query.HTTP) (float64, searchResult, error) {
+	// populate a request with data from the Query:
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), p.url+string(q.Path), bytes.NewReader(q.Body))
+	if err != nil {
+		return 0, searchResult{}, fmt.Errorf("error while creating request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, searchResult{}, fmt.Errorf("query execution error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, searchResult{}, fmt.Errorf("error while reading response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, searchResult{}, fmt.Errorf("non-200 statuscode received: %d; Body: %s", resp.StatusCode, string(body))
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	var result searchResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		return lag, searchResult{}, fmt.Errorf("error while parsing response: %s", err)
+	}
+	hits := uint64(len(result.Hits.Hits))
+	buckets := countBuckets(result.Aggregations)
+	if runner.DebugLevel() > 0 {
+		fmt.Fprintf(os.Stderr, "ID %d: %d hits, %d buckets\n", q.GetID(), hits, buckets)
+	}
+
+	// Pretty print JSON responses, if applicable:
+	if p.prettyPrintResponses {
+		var pretty bytes.Buffer
+		prefix := fmt.Sprintf("ID %d: ", q.GetID())
+		if err := json.Indent(&pretty, body, prefix, "  "); err != nil {
+			return lag, searchResult{}, err
+		}
+		_, err = fmt.Fprintf(os.Stderr, "%s%s\n", prefix, pretty.Bytes())
+		if err != nil {
+			return lag, searchResult{}, err
+		}
+	}
+	return lag, searchResult{rows: hits + buckets, size: len(body), took: result.Took}, nil
+}
+
+// countBuckets returns the number of buckets of the aggregations, including
+// the buckets of the sub-aggregations
+func countBuckets(aggs map[string]interface{}) uint64 {
+	var cnt uint64
+	for key, v := range aggs {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			cnt += countBuckets(v)
+		case []interface{}:
+			if key != "buckets" {
+				continue
+			}
+			for _, b := range v {
+				cnt++
+				if bucket, ok := b.(map[string]interface{}); ok {
+					cnt += countBuckets(bucket)
+				}
+			}
+		}
+	}
+	return cnt
+}
@@ -0,0 +1,217 @@
+// tsbs_run_queries_embedded speed tests the embedded DuckDB and SQLite
+// databases using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin or file, and executes them
+// in-process against the database file written by tsbs_load with the duckdb
+// or sqlite format. The latency of a query includes reading all of its rows,
+// which are counted. Nothing goes over the network, there are no response
+// bytes to report.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/embedded"
+)
+
+// Program option vars:
+var (
+	engineName  string
+	dir         string
+	showExplain bool
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+	engine embedded.Engine
+
+	// the database is opened once and shared by all workers, an embedded
+	// database can only be opened once per process
+	db     *sql.DB
+	dbOnce sync.Once
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("engine", constants.FormatDuckDB, "Embedded engine to query, one of: duckdb, sqlite")
+	pflag.String("dir", ".", "Directory of the database file, the file itself is named <db-name>.<engine>")
+	pflag.Bool("show-explain", false, "Print out the EXPLAIN output for sample query")
+
+	pflag.Parse()
+
+	err := utils.SetupConfigFile()
+
+	if err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	engineName = viper.GetString("engine")
+	dir = viper.GetString("dir")
+	showExplain = viper.GetBool("show-explain")
+
+	engine, err = embedded.GetEngine(engineName)
+	if err != nil {
+		panic(err)
+	}
+
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	runner.Run(&query.SQLPool, newProcessor)
+}
+
+func openDB() *sql.DB {
+	dbOnce.Do(func() {
+		var err error
+		path := embedded.Path(dir, runner.DatabaseName(), engine.Name())
+		db, err = engine.Open(path)
+		if err != nil {
+			panic(fmt.Sprintf("could not open %s: %v", path, err))
+		}
+	})
+	return db
+}
+
+// prettyPrintResponse prints a Query and its response in JSON format with two
+// keys: 'query' which has a value of the SQL used to generate the second key
+// 'results' which is an array of each row in the return set.
+func prettyPrintResponse(cols []string, results [][]interface{}, q *query.SQL) {
+	resp := make(map[string]interface{})
+	resp["query"] = string(q.SqlQuery)
+
+	rows := make([]map[string]interface{}, 0, len(results))
+	for _, values := range results {
+		row := make(map[string]interface{}, len(cols))
+		for i, col := range cols {
+			if b, ok := values[i].([]byte); ok {
+				row[col] = string(b)
+			} else {
+				row[col] = values[i]
+			}
+		}
+		rows = append(rows, row)
+	}
+	resp["results"] = rows
+
+	line, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(string(line) + "\n")
+}
+
+type queryExecutorOptions struct {
+	showExplain   bool
+	debug         bool
+	printResponse bool
+}
+
+// query.Processor interface implementation
+type processor struct {
+	db   *sql.DB
+	opts *queryExecutorOptions
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNumber int) {
+	p.db = openDB()
+	p.opts = &queryExecutorOptions{
+		showExplain:   showExplain,
+		debug:         runner.DebugLevel() > 0,
+		printResponse: runner.DoPrintResponses(),
+	}
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, both engines interrupting
+// the query when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	// No need to run again for EXPLAIN
+	if isWarm && p.opts.showExplain {
+		return nil, nil
+	}
+	sqlQuery := q.(*query.SQL)
+	qry := string(sqlQuery.SqlQuery)
+	if p.opts.showExplain {
+		qry = "EXPLAIN " + qry
+	}
+	if p.opts.debug {
+		fmt.Println(qry)
+	}
+
+	start := time.Now()
+	rows, err := p.db.QueryContext(ctx, qry)
+	if err != nil {
+		return nil, err
+	}
+	cols, results, n, err := readRows(rows, p.opts.printResponse || p.opts.showExplain)
+	if err != nil {
+		return nil, err
+	}
+	took := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	if p.opts.printResponse || p.opts.showExplain {
+		prettyPrintResponse(cols, results, sqlQuery)
+	}
+
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), took).SetRows(n)
+
+	return []*query.Stat{stat}, nil
+}
+
+// readRows reads all the rows of the result, which is when the engines do
+// most of their work, returning how many there were. It keeps them if keep is
+// set.
+func readRows(rows *sql.Rows, keep bool) ([]string, [][]interface{}, int64, error) {
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var results [][]interface{}
+	n := int64(0)
+	for rows.Next() {
+		n++
+		values := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range values {
+			ptrs[i] = &values[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, n, err
+		}
+		if keep {
+			results = append(results, values)
+		}
+	}
+	return cols, results, n, rows.Err()
+}
@@ -0,0 +1,178 @@
+// tsbs_run_queries_flightsql speed tests a FlightSQL server, e.g. InfluxDB 3,
+// using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin or file, and executes them with
+// the FlightSQL protocol over gRPC, streaming the Arrow record batches of
+// the results. The latency of a query includes receiving all of its batches,
+// and the rows and bytes received are reported per query type.
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/flight/flightsql"
+	"github.com/apache/arrow/go/v12/arrow/util"
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+)
+
+// Program option vars:
+var (
+	addrs          []string
+	useTLS         bool
+	token          string
+	databaseHeader string
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("addrs", "localhost:8181", "Comma-separated list of FlightSQL server host:port addresses")
+	pflag.Bool("tls", false, "Whether to connect with TLS")
+	pflag.String("token", "", "Token sent as a bearer authorization with every request")
+	pflag.String("database-header", "database", "gRPC metadata key the database name is sent with, empty to not send it")
+
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	addrsStr := viper.GetString("addrs")
+	if len(addrsStr) == 0 {
+		panic("missing `addrs` flag")
+	}
+	addrs = strings.Split(addrsStr, ",")
+	useTLS = viper.GetBool("tls")
+	token = viper.GetString("token")
+	databaseHeader = viper.GetString("database-header")
+
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	runner.Run(&query.SQLPool, newProcessor)
+}
+
+// query.Processor interface implementation
+type processor struct {
+	client *flightsql.Client
+	// md is the authorization and database metadata of every request
+	md []string
+
+	debug         bool
+	printResponse bool
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNumber int) {
+	creds := insecure.NewCredentials()
+	if useTLS {
+		creds = credentials.NewTLS(&tls.Config{})
+	}
+	addr := addrs[workerNumber%len(addrs)]
+	client, err := flightsql.NewClient(addr, nil, nil, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		panic(fmt.Sprintf("could not connect to %s: %v", addr, err))
+	}
+	p.client = client
+
+	var md []string
+	if token != "" {
+		md = append(md, "authorization", "Bearer "+token)
+	}
+	if databaseHeader != "" {
+		md = append(md, databaseHeader, runner.DatabaseName())
+	}
+	p.md = md
+	p.debug = runner.DebugLevel() > 0
+	p.printResponse = runner.DoPrintResponses()
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the gRPC calls being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	tq := q.(*query.SQL)
+	sql := string(tq.SqlQuery)
+
+	start := time.Now()
+	rows, batches, bytes, err := p.execute(metadata.AppendToOutgoingContext(ctx, p.md...), sql)
+	if err != nil {
+		return nil, fmt.Errorf("query %q failed: %v", sql, err)
+	}
+	took := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	if p.debug {
+		fmt.Fprintf(os.Stderr, "ID %d: %d rows in %d record batches, %d bytes\n", q.GetID(), rows, batches, bytes)
+	}
+
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), took).SetRows(int64(rows)).SetBytes(int64(bytes))
+	return []*query.Stat{stat}, nil
+}
+
+// execute runs the query and reads the record batches of every endpoint of
+// the result, returning the number of rows, batches and bytes received. The
+// endpoints are read from the same server, their locations are ignored.
+func (p *processor) execute(ctx context.Context, sql string) (rows, batches, bytes uint64, err error) {
+	info, err := p.client.Execute(ctx, sql)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	for _, ep := range info.Endpoint {
+		rdr, err := p.client.DoGet(ctx, ep.GetTicket())
+		if err != nil {
+			return rows, batches, bytes, err
+		}
+		for rdr.Next() {
+			rec := rdr.Record()
+			rows += uint64(rec.NumRows())
+			batches++
+			bytes += uint64(util.TotalRecordSize(rec))
+			if p.printResponse {
+				fmt.Printf("query: %s\n", sql)
+				if err := array.RecordToJSON(rec, os.Stdout); err != nil {
+					rdr.Release()
+					return rows, batches, bytes, err
+				}
+			}
+		}
+		err = rdr.Err()
+		rdr.Release()
+		if err != nil {
+			return rows, batches, bytes, err
+		}
+	}
+	return rows, batches, bytes, nil
+}
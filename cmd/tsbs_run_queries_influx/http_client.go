@@ -1,6 +1,8 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -56,8 +58,10 @@ func NewHTTPClient(host string) *HTTPClient {
 }
 
 // Do performs the action specified by the given Query. It uses fasthttp, and
-// tries to minimize heap allocations.
-func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64, err error) {
+// tries to minimize heap allocations. It returns the body of the response
+// along with the latency, or an error when ctx is done before the response is
+// read.
+func (w *HTTPClient) Do(ctx context.Context, q *query.HTTP, opts *HTTPClientDoOptions) (lag float64, body []byte, err error) {
 	// populate uri from the reusable byte slice:
 	w.uri = w.uri[:0]
 	w.uri = append(w.uri, w.Host...)
@@ -70,7 +74,7 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 	}
 
 	// populate a request with data from the Query:
-	req, err := http.NewRequest(string(q.Method), string(w.uri), nil)
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), string(w.uri), nil)
 	if err != nil {
 		panic(err)
 	}
@@ -78,7 +82,9 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 	// Perform the request while tracking latency:
 	start := time.Now()
 	resp, err := w.client.Do(req)
-	if err != nil {
+	if err != nil && ctx.Err() != nil {
+		return 0, nil, err
+	} else if err != nil {
 		panic(err)
 	}
 	defer resp.Body.Close()
@@ -86,10 +92,11 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 		panic("http request did not return status 200 OK")
 	}
 
-	var body []byte
 	body, err = ioutil.ReadAll(resp.Body)
 
-	if err != nil {
+	if err != nil && ctx.Err() != nil {
+		return 0, nil, err
+	} else if err != nil {
 		panic(err)
 	}
 
@@ -132,5 +139,34 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 		}
 	}
 
-	return lag, err
+	return lag, body, err
+}
+
+// influxResponse is a response of InfluxDB to a query, or a chunk of it,
+// with only the values of the series the rows are counted from.
+type influxResponse struct {
+	Results []struct {
+		Series []struct {
+			Values []json.RawMessage `json:"values"`
+		} `json:"series"`
+	} `json:"results"`
+}
+
+// countRows returns the number of values of the series in the body of a
+// response, made of one JSON object per chunk when the response is chunked.
+func countRows(body []byte) (int64, error) {
+	rows := int64(0)
+	dec := json.NewDecoder(bytes.NewReader(body))
+	for dec.More() {
+		var r influxResponse
+		if err := dec.Decode(&r); err != nil {
+			return 0, err
+		}
+		for _, result := range r.Results {
+			for _, series := range result.Series {
+				rows += int64(len(series.Values))
+			}
+		}
+	}
+	return rows, nil
 }
@@ -6,6 +6,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"strings"
@@ -24,7 +25,8 @@ var (
 
 // Global vars:
 var (
-	runner *query.BenchmarkRunner
+	runner   *query.BenchmarkRunner
+	balancer *query.EndpointBalancer
 )
 
 // Parse args:
@@ -33,7 +35,7 @@ func init() {
 	config.AddToFlagSet(pflag.CommandLine)
 	var csvDaemonUrls string
 
-	pflag.String("urls", "http://localhost:8086", "Daemon URLs, comma-separated. Will be used in a round-robin fashion.")
+	pflag.String("urls", "http://localhost:8086", "Daemon URLs, comma-separated. The queries are spread over them as --balance sets.")
 	pflag.Uint64("chunk-response-size", 0, "Number of series to chunk results into. 0 means no chunking.")
 
 	pflag.Parse()
@@ -57,6 +59,10 @@ func init() {
 	}
 
 	runner = query.NewBenchmarkRunner(config)
+	balancer, err = runner.EndpointBalancer(daemonUrls)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func main() {
@@ -64,8 +70,10 @@ func main() {
 }
 
 type processor struct {
-	w    *HTTPClient
-	opts *HTTPClientDoOptions
+	// ws has the client of each URL, created on first use
+	ws           []*HTTPClient
+	workerNumber int
+	opts         *HTTPClientDoOptions
 }
 
 func newProcessor() query.Processor { return &processor{} }
@@ -77,17 +85,38 @@ func (p *processor) Init(workerNumber int) {
 		chunkSize:            chunkSize,
 		database:             runner.DatabaseName(),
 	}
-	url := daemonUrls[workerNumber%len(daemonUrls)]
-	p.w = NewHTTPClient(url)
+	p.ws = make([]*HTTPClient, len(daemonUrls))
+	p.workerNumber = workerNumber
+}
+
+// w returns the client of the URL with index i
+func (p *processor) w(i int) *HTTPClient {
+	if p.ws[i] == nil {
+		p.ws[i] = NewHTTPClient(daemonUrls[i])
+	}
+	return p.ws[i]
 }
 
-func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, _ bool) ([]*query.Stat, error) {
 	hq := q.(*query.HTTP)
-	lag, err := p.w.Do(hq, p.opts)
+	url := balancer.Pick(p.workerNumber)
+	defer balancer.Done(url)
+	w := p.w(url)
+	lag, body, err := w.Do(ctx, hq, p.opts)
+	if err != nil {
+		return nil, query.WithEndpoint(daemonUrls[url], err)
+	}
+	rows, err := countRows(body)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("could not decode the response to query %d: %v", q.GetID(), err)
 	}
 	stat := query.GetStat()
-	stat.Init(q.HumanLabelName(), lag)
+	stat.Init(q.HumanLabelName(), lag).SetEndpoint(daemonUrls[url]).SetRows(rows).SetBytes(int64(len(body)))
 	return []*query.Stat{stat}, nil
 }
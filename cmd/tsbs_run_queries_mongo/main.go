@@ -5,6 +5,7 @@
 package main
 
 import (
+	"context"
 	"encoding/gob"
 	"fmt"
 	"log"
@@ -18,6 +19,10 @@ import (
 	"github.com/timescale/tsbs/pkg/query"
 )
 
+// mongoExceededTimeLimit is the code of the error returned when maxTimeMS
+// expires
+const mongoExceededTimeLimit = 50
+
 // Program option vars:
 var (
 	daemonURL string
@@ -84,10 +89,20 @@ func (p *processor) Init(workerNumber int) {
 	p.collection = db.C("point_data")
 }
 
-func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor. mgo takes no context,
+// the deadline of ctx is sent as the maxTimeMS of the aggregation instead, for
+// the server to abort it.
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, _ bool) ([]*query.Stat, error) {
 	mq := q.(*query.Mongo)
 	start := time.Now().UnixNano()
 	pipe := p.collection.Pipe(mq.BsonDoc).AllowDiskUse()
+	if deadline, ok := ctx.Deadline(); ok {
+		pipe = pipe.SetMaxTime(time.Until(deadline))
+	}
 	iter := pipe.Iter()
 	if runner.DebugLevel() > 0 {
 		fmt.Println(mq.BsonDoc)
@@ -104,6 +119,9 @@ func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
 		fmt.Println(cnt)
 	}
 	err := iter.Close()
+	if qerr, ok := err.(*mgo.QueryError); ok && qerr.Code == mongoExceededTimeLimit {
+		return nil, fmt.Errorf("query %d: %v: %w", q.GetID(), err, context.DeadlineExceeded)
+	}
 
 	took := time.Now().UnixNano() - start
 	lag := float64(took) / 1e6 // milliseconds
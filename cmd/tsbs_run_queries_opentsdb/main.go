@@ -0,0 +1,158 @@
+// tsbs_run_queries_opentsdb speed tests an OpenTSDB query API using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin, and makes concurrent requests
+// to the /api/query endpoint of the provided URLs. The JSON responses are
+// parsed to count the returned data points, reported as the rows of each
+// query type along with the response sizes.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Program option vars:
+var (
+	opentsdbURLs []string
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("urls", "http://localhost:4242",
+		"Comma-separated list of OpenTSDB URLs")
+
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	urls := viper.GetString("urls")
+	if len(urls) == 0 {
+		log.Fatalf("missing `urls` flag")
+	}
+	opentsdbURLs = strings.Split(urls, ",")
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, newProcessor)
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+type processor struct {
+	url string
+
+	prettyPrintResponses bool
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNum int) {
+	p.url = strings.TrimSuffix(opentsdbURLs[workerNum%len(opentsdbURLs)], "/")
+	p.prettyPrintResponses = runner.DoPrintResponses()
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+	lag, dataPoints, size, err := p.do(ctx, hq)
+	if err != nil {
+		return nil, err
+	}
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag).SetRows(int64(dataPoints)).SetBytes(int64(size))
+	return []*query.Stat{stat}, nil
+}
+
+// querySeries is the part of a series in a /api/query response needed to
+// count the returned data points
+type querySeries struct {
+	Metric string                     `json:"metric"`
+	DPS    map[string]json.RawMessage `json:"dps"`
+}
+
+// do runs the query and returns its latency, the number of data points
+// returned and the size of the response
+func (p *processor) do(ctx context.Context, q *query.HTTP) (float64, uint64, int, error) {
+	// populate a request with data from the Query:
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), p.url+string(q.Path), bytes.NewReader(q.Body))
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error while creating request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("query execution error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error while reading response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, 0, fmt.Errorf("non-200 statuscode received: %d; Body: %s", resp.StatusCode, string(body))
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	var result []querySeries
+	if err := json.Unmarshal(body, &result); err != nil {
+		return lag, 0, 0, fmt.Errorf("error while parsing response: %s", err)
+	}
+	var dataPoints uint64
+	for _, s := range result {
+		dataPoints += uint64(len(s.DPS))
+	}
+	if runner.DebugLevel() > 0 {
+		fmt.Fprintf(os.Stderr, "ID %d: %d series, %d data points\n", q.GetID(), len(result), dataPoints)
+	}
+
+	// Pretty print JSON responses, if applicable:
+	if p.prettyPrintResponses {
+		var pretty bytes.Buffer
+		prefix := fmt.Sprintf("ID %d: ", q.GetID())
+		if err := json.Indent(&pretty, body, prefix, "  "); err != nil {
+			return lag, 0, 0, err
+		}
+		_, err = fmt.Fprintf(os.Stderr, "%s%s\n", prefix, pretty.Bytes())
+		if err != nil {
+			return lag, 0, 0, err
+		}
+	}
+	return lag, dataPoints, len(body), nil
+}
@@ -0,0 +1,275 @@
+// tsbs_run_queries_pgwire speed tests any database speaking the PostgreSQL
+// wire protocol, e.g. QuestDB, CrateDB, CockroachDB, YugabyteDB or
+// GreptimeDB, using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin or file, generated with the
+// pgwire format and the dialect of the database, and runs them over a single
+// pool of pgx connections shared by the workers. The latency of a query
+// includes reading all of its rows, and the rows and bytes received are
+// reported per query type.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgconn/stmtcache"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+const (
+	protocolExtended = "extended"
+	protocolSimple   = "simple"
+
+	// statementCacheSize is the number of statements prepared or described
+	// per connection, the least recently used are deallocated
+	statementCacheSize = 512
+)
+
+// Program option vars:
+var (
+	postgresConnect string
+	protocol        string
+	prepare         bool
+	poolSize        int
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+	pool   *pgxpool.Pool
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("postgres", "host=localhost port=5432 user=postgres sslmode=disable",
+		"PostgreSQL connection string, as key/value pairs or a URL. The database is set by --db-name.")
+	pflag.String("protocol", protocolExtended, "Query protocol: extended or simple. Some databases only support the simple one.")
+	pflag.Bool("prepare", false, "Extended protocol only: prepare named statements and cache them per connection rather than only describing them")
+	pflag.Int("pool-size", 0, "Maximum number of connections in the pool (0 = one per worker)")
+
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	postgresConnect = viper.GetString("postgres")
+	protocol = viper.GetString("protocol")
+	prepare = viper.GetBool("prepare")
+	poolSize = viper.GetInt("pool-size")
+
+	if protocol != protocolExtended && protocol != protocolSimple {
+		panic(fmt.Sprintf("invalid protocol '%s', choose %s or %s", protocol, protocolExtended, protocolSimple))
+	}
+	if prepare && protocol == protocolSimple {
+		panic("prepared statements require the extended protocol")
+	}
+	if poolSize == 0 {
+		poolSize = int(config.Workers)
+	}
+
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	var err error
+	pool, err = newPool()
+	if err != nil {
+		panic(err)
+	}
+	defer pool.Close()
+
+	runner.Run(&query.SQLPool, newProcessor)
+}
+
+// newPool connects the pool shared by all the workers to the benchmark
+// database with the configured protocol.
+func newPool() (*pgxpool.Pool, error) {
+	cfg, err := pgxpool.ParseConfig(postgresConnect)
+	if err != nil {
+		return nil, fmt.Errorf("invalid connection string: %v", err)
+	}
+	cfg.ConnConfig.Database = runner.DatabaseName()
+	if poolSize > 0 {
+		cfg.MaxConns = int32(poolSize)
+	}
+
+	switch {
+	case protocol == protocolSimple:
+		// the queries are sent on the underlying connection, see executeSimple
+	case prepare:
+		cfg.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModePrepare, statementCacheSize)
+		}
+	default:
+		cfg.ConnConfig.BuildStatementCache = func(conn *pgconn.PgConn) stmtcache.Cache {
+			return stmtcache.New(conn, stmtcache.ModeDescribe, statementCacheSize)
+		}
+	}
+
+	p, err := pgxpool.ConnectConfig(context.Background(), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("could not connect: %v", err)
+	}
+	return p, nil
+}
+
+// query.Processor interface implementation
+type processor struct {
+	debug         bool
+	printResponse bool
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNumber int) {
+	p.debug = runner.DebugLevel() > 0
+	p.printResponse = runner.DoPrintResponses()
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the query being cancelled
+// when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	tq := q.(*query.SQL)
+	sql := string(tq.SqlQuery)
+
+	start := time.Now()
+	rows, bytes, err := p.execute(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("query %q failed: %v", sql, err)
+	}
+	took := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	if p.debug {
+		fmt.Fprintf(os.Stderr, "ID %d: %d rows, %d bytes\n", q.GetID(), rows, bytes)
+	}
+
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), took).SetRows(int64(rows)).SetBytes(int64(bytes))
+	return []*query.Stat{stat}, nil
+}
+
+// execute runs the query and reads all of its rows, returning how many were
+// received and the size of their values, in bytes.
+func (p *processor) execute(ctx context.Context, sql string) (uint64, uint64, error) {
+	if protocol == protocolSimple {
+		return p.executeSimple(ctx, sql)
+	}
+	rows, err := pool.Query(ctx, sql)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+
+	var n, size uint64
+	var results []map[string]interface{}
+	for rows.Next() {
+		n++
+		for _, v := range rows.RawValues() {
+			size += uint64(len(v))
+		}
+		if !p.printResponse {
+			continue
+		}
+		values, err := rows.Values()
+		if err != nil {
+			return n, size, err
+		}
+		row := make(map[string]interface{}, len(values))
+		for i, fd := range rows.FieldDescriptions() {
+			row[string(fd.Name)] = values[i]
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return n, size, err
+	}
+
+	if p.printResponse {
+		prettyPrintResponse(sql, results)
+	}
+	return n, size, nil
+}
+
+// executeSimple runs the query with the simple protocol on the underlying
+// connection. pgx refuses simple queries on servers which do not report
+// standard_conforming_strings, as it could not sanitize their arguments, but
+// the generated queries have none.
+func (p *processor) executeSimple(ctx context.Context, sql string) (uint64, uint64, error) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer conn.Release()
+
+	var n, size uint64
+	var results []map[string]interface{}
+	mrr := conn.Conn().PgConn().Exec(ctx, sql)
+	for mrr.NextResult() {
+		rr := mrr.ResultReader()
+		for rr.NextRow() {
+			n++
+			for _, v := range rr.Values() {
+				size += uint64(len(v))
+			}
+			if !p.printResponse {
+				continue
+			}
+			row := make(map[string]interface{}, len(rr.Values()))
+			for i, fd := range rr.FieldDescriptions() {
+				row[string(fd.Name)] = string(rr.Values()[i])
+			}
+			results = append(results, row)
+		}
+		if _, err := rr.Close(); err != nil {
+			mrr.Close()
+			return n, size, err
+		}
+	}
+	if err := mrr.Close(); err != nil {
+		return n, size, err
+	}
+
+	if p.printResponse {
+		prettyPrintResponse(sql, results)
+	}
+	return n, size, nil
+}
+
+// prettyPrintResponse prints a query and its response in JSON format with two
+// keys: 'query' which has a value of the SQL used to generate the second key
+// 'results' which is an array of each row in the return set.
+func prettyPrintResponse(sql string, results []map[string]interface{}) {
+	resp := map[string]interface{}{
+		"query":   sql,
+		"results": results,
+	}
+	line, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(line) + "\n")
+}
@@ -0,0 +1,178 @@
+// tsbs_run_queries_prometheus speed tests a Prometheus-compatible query API using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin, and makes concurrent requests
+// to the /api/v1/query_range endpoint of the provided URLs. Any server
+// implementing the Prometheus HTTP API can be tested (Prometheus, Promscale,
+// Thanos, Mimir...). The JSON responses are parsed to count the returned
+// samples, reported as the rows of each query type along with the response
+// sizes.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+)
+
+// Program option vars:
+var (
+	promURLs []string
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("urls", "http://localhost:9090",
+		"Comma-separated list of Prometheus-compatible query API URLs")
+
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	urls := viper.GetString("urls")
+	if len(urls) == 0 {
+		log.Fatalf("missing `urls` flag")
+	}
+	promURLs = strings.Split(urls, ",")
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, newProcessor)
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+type processor struct {
+	url string
+
+	prettyPrintResponses bool
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNum int) {
+	p.url = promURLs[workerNum%len(promURLs)]
+	p.prettyPrintResponses = runner.DoPrintResponses()
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+	lag, samples, size, err := p.do(ctx, hq)
+	if err != nil {
+		return nil, err
+	}
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag).SetRows(int64(samples)).SetBytes(int64(size))
+	return []*query.Stat{stat}, nil
+}
+
+// queryResponse is the part of a /api/v1/query_range response needed to
+// count the returned series and samples
+type queryResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType"`
+	Error     string `json:"error"`
+	Data      struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Values []json.RawMessage `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// do runs the query and returns its latency, the number of samples returned
+// and the size of the response
+func (p *processor) do(ctx context.Context, q *query.HTTP) (float64, uint64, int, error) {
+	// populate a request with data from the Query:
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), p.url+string(q.Path), nil)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error while creating request: %s", err)
+	}
+
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("query execution error: %s", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("error while reading response body: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, 0, fmt.Errorf("non-200 statuscode received: %d; Body: %s", resp.StatusCode, string(body))
+	}
+	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
+
+	series, samples, err := countResult(body)
+	if err != nil {
+		return lag, 0, 0, err
+	}
+	if runner.DebugLevel() > 0 {
+		fmt.Fprintf(os.Stderr, "ID %d: %d series, %d samples\n", q.GetID(), series, samples)
+	}
+
+	// Pretty print JSON responses, if applicable:
+	if p.prettyPrintResponses {
+		var pretty bytes.Buffer
+		prefix := fmt.Sprintf("ID %d: ", q.GetID())
+		if err := json.Indent(&pretty, body, prefix, "  "); err != nil {
+			return lag, 0, 0, err
+		}
+		_, err = fmt.Fprintf(os.Stderr, "%s%s\n", prefix, pretty.Bytes())
+		if err != nil {
+			return lag, 0, 0, err
+		}
+	}
+	return lag, samples, len(body), nil
+}
+
+// countResult parses a query_range response and returns the number of series
+// and samples in it
+func countResult(body []byte) (series, samples uint64, err error) {
+	var resp queryResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return 0, 0, fmt.Errorf("error while parsing response: %s", err)
+	}
+	if resp.Status != "success" {
+		return 0, 0, fmt.Errorf("query failed: %s: %s", resp.ErrorType, resp.Error)
+	}
+	for _, r := range resp.Data.Result {
+		samples += uint64(len(r.Values))
+	}
+	return uint64(len(resp.Data.Result)), samples, nil
+}
@@ -0,0 +1,162 @@
+// tsbs_run_queries_prometheus_remote_read speed tests a Prometheus remote-read endpoint using requests from stdin or file.
+//
+// It reads encoded Query objects from stdin, and makes concurrent snappy
+// compressed protobuf remote-read requests to the provided URLs. The
+// responses are decoded to measure the sample throughput in addition to
+// the query latency, the samples and compressed bytes of each query type
+// being reported with its latencies.
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/golang/protobuf/proto"
+	"github.com/spf13/pflag"
+	"github.com/timescale/promscale/pkg/prompb"
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/query"
+	"github.com/timescale/tsbs/pkg/targets/prometheus"
+)
+
+// Program option vars:
+var (
+	readURLs []string
+	timeout  time.Duration
+)
+
+// Global vars:
+var (
+	runner *query.BenchmarkRunner
+
+	seriesCnt  uint64
+	samplesCnt uint64
+	bytesCnt   uint64
+	// readNanos is the total time spent in read requests by all workers
+	readNanos int64
+)
+
+// Parse args:
+func init() {
+	var config query.BenchmarkRunnerConfig
+	config.AddToFlagSet(pflag.CommandLine)
+
+	pflag.String("urls", "http://localhost:9090/api/v1/read",
+		"Comma-separated list of remote-read endpoint URLs")
+	pflag.Duration("read-timeout", 30*time.Second, "Timeout of a remote-read request")
+
+	pflag.Parse()
+
+	if err := utils.SetupConfigFile(); err != nil {
+		panic(fmt.Errorf("fatal error config file: %s", err))
+	}
+	if err := viper.Unmarshal(&config); err != nil {
+		panic(fmt.Errorf("unable to decode config: %s", err))
+	}
+
+	urls := viper.GetString("urls")
+	if len(urls) == 0 {
+		log.Fatalf("missing `urls` flag")
+	}
+	readURLs = strings.Split(urls, ",")
+	timeout = viper.GetDuration("read-timeout")
+	runner = query.NewBenchmarkRunner(config)
+}
+
+func main() {
+	runner.Run(&query.HTTPPool, newProcessor)
+
+	samples := atomic.LoadUint64(&samplesCnt)
+	took := time.Duration(atomic.LoadInt64(&readNanos))
+	fmt.Printf("read %d series with %d samples (%d compressed bytes)\n",
+		atomic.LoadUint64(&seriesCnt), samples, atomic.LoadUint64(&bytesCnt))
+	if took > 0 {
+		fmt.Printf("decoded sample throughput per worker: %0.2f samples/sec\n", float64(samples)/took.Seconds())
+	}
+}
+
+func newProcessor() query.Processor {
+	return &processor{}
+}
+
+// query.Processor interface implementation
+type processor struct {
+	client *prometheus.Client
+
+	prettyPrintResponses bool
+}
+
+// query.Processor interface implementation
+func (p *processor) Init(workerNum int) {
+	var err error
+	p.client, err = prometheus.NewClient(readURLs[workerNum%len(readURLs)], timeout)
+	if err != nil {
+		log.Fatalf("could not create remote-read client: %v", err)
+	}
+	p.prettyPrintResponses = runner.DoPrintResponses()
+}
+
+// query.Processor interface implementation
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
+	hq := q.(*query.HTTP)
+	lag, samples, size, err := p.do(ctx, hq)
+	if err != nil {
+		return nil, err
+	}
+	stat := query.GetStat()
+	stat.Init(q.HumanLabelName(), lag).SetRows(int64(samples)).SetBytes(int64(size))
+	return []*query.Stat{stat}, nil
+}
+
+// do runs the read request and returns its latency, the number of samples
+// decoded and the compressed size of the response
+func (p *processor) do(ctx context.Context, q *query.HTTP) (float64, uint64, int, error) {
+	var req prompb.ReadRequest
+	if err := proto.Unmarshal(q.Body, &req); err != nil {
+		return 0, 0, 0, fmt.Errorf("could not decode read request: %v", err)
+	}
+
+	start := time.Now()
+	resp, size, err := p.client.Read(ctx, &req)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("query execution error: %s", err)
+	}
+	took := time.Since(start)
+	lag := float64(took.Nanoseconds()) / 1e6 // milliseconds
+
+	var series, samples uint64
+	for _, r := range resp.Results {
+		series += uint64(len(r.Timeseries))
+		for _, ts := range r.Timeseries {
+			samples += uint64(len(ts.Samples))
+		}
+	}
+	atomic.AddUint64(&seriesCnt, series)
+	atomic.AddUint64(&samplesCnt, samples)
+	atomic.AddUint64(&bytesCnt, uint64(size))
+	atomic.AddInt64(&readNanos, took.Nanoseconds())
+	if runner.DebugLevel() > 0 {
+		fmt.Fprintf(os.Stderr, "ID %d: %d series, %d samples, %d bytes\n", q.GetID(), series, samples, size)
+	}
+
+	if p.prettyPrintResponses {
+		prefix := fmt.Sprintf("ID %d: ", q.GetID())
+		_, err = fmt.Fprintf(os.Stderr, "%s%s\n", prefix, proto.MarshalTextString(resp))
+		if err != nil {
+			return lag, 0, 0, err
+		}
+	}
+	return lag, samples, size, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -13,7 +14,8 @@ import (
 	"github.com/timescale/tsbs/pkg/query"
 )
 
-var bytesSlash = []byte("/") // heap optimization
+var bytesSlash = []byte("/")               // heap optimization
+var bytesTimings = []byte("&timings=true") // heap optimization
 
 // HTTPClient is a reusable HTTP Client.
 type HTTPClient struct {
@@ -59,15 +61,19 @@ func NewHTTPClient(host string) *HTTPClient {
 }
 
 // Do performs the action specified by the given Query. It uses fasthttp, and
-// tries to minimize heap allocations.
-func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64, err error) {
+// tries to minimize heap allocations. It returns the body of the response
+// along with the latency, or an error when ctx is done before the response is
+// read.
+func (w *HTTPClient) Do(ctx context.Context, q *query.HTTP, opts *HTTPClientDoOptions) (lag float64, body []byte, err error) {
 	// populate uri from the reusable byte slice:
 	w.uri = w.uri[:0]
 	w.uri = append(w.uri, w.Host...)
 	w.uri = append(w.uri, q.Path...)
+	// have the server report how long it took, see questdbResponse
+	w.uri = append(w.uri, bytesTimings...)
 
 	// populate a request with data from the Query:
-	req, err := http.NewRequest(string(q.Method), string(w.uri), nil)
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), string(w.uri), nil)
 	if err != nil {
 		panic(err)
 	}
@@ -75,7 +81,9 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 	// Perform the request while tracking latency:
 	start := time.Now()
 	resp, err := w.client.Do(req)
-	if err != nil {
+	if err != nil && ctx.Err() != nil {
+		return 0, nil, err
+	} else if err != nil {
 		panic(err)
 	}
 	defer resp.Body.Close()
@@ -83,10 +91,11 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 		panic("http request did not return status 200 OK")
 	}
 
-	var body []byte
 	body, err = ioutil.ReadAll(resp.Body)
 
-	if err != nil {
+	if err != nil && ctx.Err() != nil {
+		return 0, nil, err
+	} else if err != nil {
 		panic(err)
 	}
 
@@ -129,5 +138,15 @@ func (w *HTTPClient) Do(q *query.HTTP, opts *HTTPClientDoOptions) (lag float64,
 		}
 	}
 
-	return lag, err
+	return lag, body, err
+}
+
+// questdbResponse is a response of QuestDB to a query, with only the number
+// of rows and the timings of the server, in nanoseconds.
+type questdbResponse struct {
+	Count   int64 `json:"count"`
+	Timings *struct {
+		Compiler int64 `json:"compiler"`
+		Execute  int64 `json:"execute"`
+	} `json:"timings"`
 }
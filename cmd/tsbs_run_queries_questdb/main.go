@@ -6,6 +6,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -23,12 +24,14 @@ import (
 
 // Program option vars:
 var (
-	daemonUrls []string
+	daemonUrls  []string
+	planSampler *query.PlanSampler
 )
 
 // Global vars:
 var (
-	runner *query.BenchmarkRunner
+	runner   *query.BenchmarkRunner
+	balancer *query.EndpointBalancer
 )
 
 // Parse args:
@@ -37,7 +40,9 @@ func init() {
 	config.AddToFlagSet(pflag.CommandLine)
 	var csvDaemonUrls string
 
-	pflag.String("urls", "http://localhost:9000/", "Daemon URLs, comma-separated. Will be used in a round-robin fashion.")
+	pflag.String("urls", "http://localhost:9000/", "Daemon URLs, comma-separated. The queries are spread over them as --balance sets.")
+	pflag.Uint("explain-sample", 0, "Capture the EXPLAIN output of the first N queries of each query type, 0 = none")
+	pflag.String("explain-dir", "plans", "Directory to write the plans captured with --explain-sample to, one subdirectory per query type")
 
 	pflag.Parse()
 
@@ -52,6 +57,7 @@ func init() {
 	}
 
 	csvDaemonUrls = viper.GetString("urls")
+	planSampler = query.NewPlanSampler(viper.GetUint("explain-sample"), viper.GetString("explain-dir"))
 
 	daemonUrls = strings.Split(csvDaemonUrls, ",")
 	if len(daemonUrls) == 0 {
@@ -59,9 +65,9 @@ func init() {
 	}
 
 	// Add an index to the hostname column in the cpu table
-	r, err := execQuery(daemonUrls[0], "show columns from cpu")
+	r, err := execQuery(context.Background(), daemonUrls[0], "show columns from cpu")
 	if err == nil && r.Count != 0 {
-		r, err := execQuery(daemonUrls[0], "ALTER TABLE cpu ALTER COLUMN hostname ADD INDEX")
+		r, err := execQuery(context.Background(), daemonUrls[0], "ALTER TABLE cpu ALTER COLUMN hostname ADD INDEX")
 		_ = r
 		//	       fmt.Println("error:", err)
 		//	       fmt.Printf("%+v\n", r)
@@ -71,6 +77,10 @@ func init() {
 	}
 
 	runner = query.NewBenchmarkRunner(config)
+	balancer, err = runner.EndpointBalancer(daemonUrls)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func main() {
@@ -78,8 +88,10 @@ func main() {
 }
 
 type processor struct {
-	w    *HTTPClient
-	opts *HTTPClientDoOptions
+	// ws has the client of each URL, created on first use
+	ws           []*HTTPClient
+	workerNumber int
+	opts         *HTTPClientDoOptions
 }
 
 func newProcessor() query.Processor { return &processor{} }
@@ -89,21 +101,73 @@ func (p *processor) Init(workerNumber int) {
 		Debug:                runner.DebugLevel(),
 		PrettyPrintResponses: runner.DoPrintResponses(),
 	}
-	url := daemonUrls[workerNumber%len(daemonUrls)]
-	p.w = NewHTTPClient(url)
+	p.ws = make([]*HTTPClient, len(daemonUrls))
+	p.workerNumber = workerNumber
+}
+
+// w returns the client of the URL with index i
+func (p *processor) w(i int) *HTTPClient {
+	if p.ws[i] == nil {
+		p.ws[i] = NewHTTPClient(daemonUrls[i])
+	}
+	return p.ws[i]
 }
 
-func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 	hq := q.(*query.HTTP)
-	lag, err := p.w.Do(hq, p.opts)
+	url := balancer.Pick(p.workerNumber)
+	defer balancer.Done(url)
+	w := p.w(url)
+	lag, body, err := w.Do(ctx, hq, p.opts)
 	if err != nil {
-		return nil, err
+		return nil, query.WithEndpoint(daemonUrls[url], err)
+	}
+	var r questdbResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return nil, fmt.Errorf("could not decode the response to query %d: %v", q.GetID(), err)
 	}
+	// The plan is captured after the timed run, so that it is not slowed down,
+	// and before the stat is taken, not to leak it when it fails
+	if !isWarm && planSampler.Sample(q) {
+		if err := capturePlan(ctx, w.HostString, q, string(hq.RawQuery)); err != nil {
+			return nil, err
+		}
+	}
+
 	stat := query.GetStat()
-	stat.Init(q.HumanLabelName(), lag)
+	stat.Init(q.HumanLabelName(), lag).SetEndpoint(daemonUrls[url]).SetRows(r.Count).SetBytes(int64(len(body)))
+	if r.Timings != nil {
+		stat.SetServerTime(float64(r.Timings.Compiler+r.Timings.Execute) / 1e6)
+	}
+
 	return []*query.Stat{stat}, nil
 }
 
+// capturePlan writes the plan of the query returned by EXPLAIN, one line of
+// text per row. QuestDB does not run the query for EXPLAIN, so there are no
+// planning time and rows scanned.
+func capturePlan(ctx context.Context, uriRoot string, q query.Query, sql string) error {
+	r, err := execQuery(ctx, uriRoot, "EXPLAIN "+sql)
+	if err != nil {
+		return fmt.Errorf("could not explain query: %v", err)
+	}
+	lines := make([]string, 0, len(r.Dataset))
+	for _, row := range r.Dataset {
+		if cols, ok := row.([]interface{}); ok && len(cols) > 0 {
+			lines = append(lines, fmt.Sprint(cols[0]))
+		}
+	}
+	plan := planSampler.NewPlan(q, sql)
+	plan.Plan = lines
+	return planSampler.Write(plan)
+}
+
 type QueryResponseColumns struct {
 	Name string
 	Type string
@@ -117,13 +181,17 @@ type QueryResponse struct {
 	Error   string
 }
 
-func execQuery(uriRoot string, query string) (QueryResponse, error) {
+func execQuery(ctx context.Context, uriRoot string, query string) (QueryResponse, error) {
 	var qr QueryResponse
 	if strings.HasSuffix(uriRoot, "/") {
 		uriRoot = uriRoot[:len(uriRoot)-1]
 	}
 	uriRoot = uriRoot + "/exec?query=" + url.QueryEscape(query)
-	resp, err := http.Get(uriRoot)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uriRoot, nil)
+	if err != nil {
+		return qr, err
+	}
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return qr, err
 	}
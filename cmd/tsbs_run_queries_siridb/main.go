@@ -5,8 +5,10 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"math"
 	"strconv"
 	"strings"
 	"time"
@@ -176,6 +178,13 @@ func (p *processor) Init(numWorker int) {
 }
 
 func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor. The connector takes
+// no context, the deadline of ctx shortens its timeout instead, rounded up to
+// whole seconds.
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 
 	// No need to run again for EXPLAIN
 	if isWarm && p.opts.showExplain {
@@ -189,8 +198,22 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 	var res interface{}
 	var err error
 
+	timeout := uint16(writeTimeout)
+	if deadline, ok := ctx.Deadline(); ok {
+		// clamped before the conversion, which would wrap around
+		if secs := math.Min(math.Ceil(time.Until(deadline).Seconds()), math.MaxUint16); secs < float64(timeout) {
+			timeout = uint16(math.Max(secs, 0))
+		}
+		if timeout == 0 {
+			timeout = 1
+		}
+	}
+
 	if siridbConnector.IsConnected() {
-		if res, err = siridbConnector.Query(qry, uint16(writeTimeout)); err != nil {
+		if res, err = siridbConnector.Query(qry, timeout); err != nil {
+			if ctx.Err() != nil {
+				return nil, err
+			}
 			log.Fatal(err)
 		}
 	} else {
@@ -6,11 +6,13 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/blagojts/viper"
@@ -34,12 +36,19 @@ var (
 	port            string
 	showExplain     bool
 	forceTextFormat bool
+	planSampler     *query.PlanSampler
 )
 
 // Global vars:
 var (
-	runner *query.BenchmarkRunner
-	driver string
+	runner   *query.BenchmarkRunner
+	balancer *query.EndpointBalancer
+	driver   string
+
+	// processors are the processors of the workers, whose connections the
+	// discard-all cache hook resets
+	processors   []*processor
+	processorsMu sync.Mutex
 )
 
 // Parse args:
@@ -55,6 +64,8 @@ func init() {
 	pflag.String("port", "5432", "Which port to connect to on the database host")
 
 	pflag.Bool("show-explain", false, "Print out the EXPLAIN output for sample query")
+	pflag.Uint("explain-sample", 0, "Capture the EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) output of the first N queries of each query type, 0 = none")
+	pflag.String("explain-dir", "plans", "Directory to write the plans captured with --explain-sample to, one subdirectory per query type")
 	pflag.Bool("force-text-format", false, "Send/receive data in text format")
 
 	pflag.Parse()
@@ -76,6 +87,7 @@ func init() {
 	port = viper.GetString("port")
 	showExplain = viper.GetBool("show-explain")
 	forceTextFormat = viper.GetBool("force-text-format")
+	planSampler = query.NewPlanSampler(viper.GetUint("explain-sample"), viper.GetString("explain-dir"))
 
 	runner = query.NewBenchmarkRunner(config)
 
@@ -93,6 +105,14 @@ func init() {
 	for _, host := range strings.Split(hosts, ",") {
 		hostList = append(hostList, host)
 	}
+	balancer, err = runner.EndpointBalancer(hostList)
+	if err != nil {
+		panic(err)
+	}
+	runner.SetCacheHooks(map[string]query.CacheHook{
+		"discard-all": {Run: discardAll, Cold: true},
+		"pg-prewarm":  {Run: pgPrewarm},
+	})
 }
 
 func main() {
@@ -102,16 +122,14 @@ func main() {
 // Get the connection string for a connection to PostgreSQL.
 
 // If we're running queries against multiple nodes we need to balance the queries
-// across replicas. Each worker is assigned a sequence number -- we'll use that
-// to evenly distribute hosts to worker connections
-func getConnectString(workerNumber int) string {
+// across replicas, with the strategy of the balancer. Each worker connects to
+// the hosts it sends queries to.
+func getConnectString(host string) string {
 	// User might be passing in host=hostname the connect string out of habit which may override the
 	// multi host configuration. Same for dbname= and user=. This sanitizes that.
 	re := regexp.MustCompile(`(host|dbname|user)=\S*\b`)
 	connectString := re.ReplaceAllString(postgresConnect, "")
 
-	// Round robin the host/worker assignment by assigning a host based on workerNumber % totalNumberOfHosts
-	host := hostList[workerNumber%len(hostList)]
 	connectString = fmt.Sprintf("host=%s dbname=%s user=%s %s", host, runner.DatabaseName(), user, connectString)
 
 	// For optional parameters, ensure they exist then interpolate them into the connectString
@@ -130,11 +148,13 @@ func getConnectString(workerNumber int) string {
 
 // prettyPrintResponse prints a Query and its response in JSON format with two
 // keys: 'query' which has a value of the SQL used to generate the second key
-// 'results' which is an array of each row in the return set.
-func prettyPrintResponse(rows *sql.Rows, q *query.TimescaleDB) {
+// 'results' which is an array of each row in the return set. It returns the
+// number of rows.
+func prettyPrintResponse(rows *sql.Rows, q *query.TimescaleDB) int64 {
 	resp := make(map[string]interface{})
+	results := mapRows(rows)
 	resp["query"] = string(q.SqlQuery)
-	resp["results"] = mapRows(rows)
+	resp["results"] = results
 
 	line, err := json.MarshalIndent(resp, "", "  ")
 	if err != nil {
@@ -142,6 +162,7 @@ func prettyPrintResponse(rows *sql.Rows, q *query.TimescaleDB) {
 	}
 
 	fmt.Println(string(line) + "\n")
+	return int64(len(results))
 }
 
 func mapRows(r *sql.Rows) []map[string]interface{} {
@@ -174,18 +195,23 @@ type queryExecutorOptions struct {
 }
 
 type processor struct {
-	db   *sql.DB
-	opts *queryExecutorOptions
+	// dbs has the connection pool of each host, opened on first use
+	dbs          []*sql.DB
+	workerNumber int
+	opts         *queryExecutorOptions
 }
 
-func newProcessor() query.Processor { return &processor{} }
+func newProcessor() query.Processor {
+	p := &processor{}
+	processorsMu.Lock()
+	processors = append(processors, p)
+	processorsMu.Unlock()
+	return p
+}
 
 func (p *processor) Init(workerNumber int) {
-	db, err := sql.Open(driver, getConnectString(workerNumber))
-	if err != nil {
-		panic(err)
-	}
-	p.db = db
+	p.dbs = make([]*sql.DB, len(hostList))
+	p.workerNumber = workerNumber
 	p.opts = &queryExecutorOptions{
 		showExplain:   showExplain,
 		debug:         runner.DebugLevel() > 0,
@@ -193,21 +219,42 @@ func (p *processor) Init(workerNumber int) {
 	}
 }
 
+// db returns the connection pool of the host with index i
+func (p *processor) db(i int) *sql.DB {
+	if p.dbs[i] == nil {
+		db, err := sql.Open(driver, getConnectString(hostList[i]))
+		if err != nil {
+			panic(err)
+		}
+		p.dbs[i] = db
+	}
+	return p.dbs[i]
+}
+
 func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor, the query being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 	// No need to run again for EXPLAIN
 	if isWarm && p.opts.showExplain {
 		return nil, nil
 	}
 	tq := q.(*query.TimescaleDB)
+	host := balancer.Pick(p.workerNumber)
+	defer balancer.Done(host)
+	db := p.db(host)
 
 	start := time.Now()
 	qry := string(tq.SqlQuery)
 	if showExplain {
 		qry = "EXPLAIN ANALYZE " + qry
 	}
-	rows, err := p.db.Query(qry)
+	rows, err := db.QueryContext(ctx, qry)
 	if err != nil {
-		return nil, err
+		return nil, query.WithEndpoint(hostList[host], err)
 	}
 
 	if p.opts.debug {
@@ -223,19 +270,118 @@ func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, err
 			text += s + "\n"
 		}
 		fmt.Printf("%s\n\n%s\n-----\n\n", qry, text)
-	} else if p.opts.printResponse {
-		prettyPrintResponse(rows, tq)
+	}
+	nRows := int64(0)
+	if p.opts.printResponse && !showExplain {
+		nRows = prettyPrintResponse(rows, tq)
 	}
 	// Fetching all the rows to confirm that the query is fully completed.
 	for rows.Next() {
+		nRows++
 	}
 	rows.Close()
 	if err := rows.Err(); err != nil {
-		return nil, err
+		return nil, query.WithEndpoint(hostList[host], err)
 	}
 	took := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	// The plan is captured after the timed run, so that it is not slowed down,
+	// and before the stat is taken, not to leak it when it fails
+	if !isWarm && planSampler.Sample(q) {
+		if err := capturePlan(ctx, db, q, string(tq.SqlQuery)); err != nil {
+			return nil, err
+		}
+	}
+
 	stat := query.GetStat()
-	stat.Init(q.HumanLabelName(), took)
+	stat.Init(q.HumanLabelName(), took).SetEndpoint(hostList[host])
+	if !showExplain {
+		stat.SetRows(nRows)
+	}
 
 	return []*query.Stat{stat}, err
 }
+
+// capturePlan runs the query again with EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON)
+// and writes its plan
+func capturePlan(ctx context.Context, db *sql.DB, q query.Query, qry string) error {
+	var explain []byte
+	err := db.QueryRowContext(ctx, "EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON) "+qry).Scan(&explain)
+	if err != nil {
+		return errors.Wrap(err, "could not explain query")
+	}
+	plan := planSampler.NewPlan(q, qry)
+	if err := query.ParsePostgresPlan(plan, explain); err != nil {
+		return err
+	}
+	return planSampler.Write(plan)
+}
+
+// discardAll is the discard-all cache hook: it runs DISCARD ALL on every
+// connection of the workers, dropping their prepared statements and cached
+// plans. The workers are idle while it runs.
+func discardAll() error {
+	processorsMu.Lock()
+	defer processorsMu.Unlock()
+	for _, p := range processors {
+		for _, db := range p.dbs {
+			if db == nil {
+				continue
+			}
+			if err := discardConns(db); err != nil {
+				return errors.Wrap(err, "could not discard the session state")
+			}
+		}
+	}
+	return nil
+}
+
+// discardConns runs DISCARD ALL on each open connection of the pool db. A
+// statement run on the pool only reaches one of them, so they are all taken
+// out of the pool, idle as they are, before running it on each.
+func discardConns(db *sql.DB) error {
+	ctx := context.Background()
+	n := db.Stats().OpenConnections
+	conns := make([]*sql.Conn, 0, n)
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+	for i := 0; i < n; i++ {
+		conn, err := db.Conn(ctx)
+		if err != nil {
+			return err
+		}
+		conns = append(conns, conn)
+	}
+	for _, conn := range conns {
+		if _, err := conn.ExecContext(ctx, "DISCARD ALL"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// pgPrewarm is the pg-prewarm cache hook: it loads the tables and indexes of
+// the benchmark, chunks included, into the shared buffers of each host with
+// the pg_prewarm extension, creating it if needed. It leaves the caches warm.
+func pgPrewarm() error {
+	for _, host := range hostList {
+		db, err := sql.Open(driver, getConnectString(host))
+		if err != nil {
+			return err
+		}
+		_, err = db.Exec("CREATE EXTENSION IF NOT EXISTS pg_prewarm")
+		if err == nil {
+			_, err = db.Exec(`SELECT pg_prewarm(c.oid) FROM pg_class c
+				JOIN pg_namespace n ON n.oid = c.relnamespace
+				WHERE n.nspname IN ('public', '_timescaledb_internal') AND c.relkind IN ('r', 'i', 'm')`)
+		}
+		db.Close()
+		if err != nil {
+			return errors.Wrapf(err, "could not prewarm %s", host)
+		}
+	}
+	return nil
+}
@@ -7,6 +7,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-sdk-go/service/timestreamquery"
@@ -118,7 +119,13 @@ func (p *processor) Init(_ int) {
 	}
 }
 
-func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
+func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// ProcessQueryContext implements query.ContextProcessor, the requests for the
+// pages of the result being cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, _ bool) ([]*query.Stat, error) {
 	tq := q.(*query.Timestream)
 
 	start := time.Now()
@@ -133,7 +140,7 @@ func (p *processor) ProcessQuery(q query.Query, _ bool) ([]*query.Stat, error) {
 	}
 	totalRows := 0
 	pageNum := 1
-	err := p._readSvc.QueryPages(queryInput,
+	err := p._readSvc.QueryPagesWithContext(ctx, queryInput,
 		func(page *timestreamquery.QueryOutput, lastPage bool) bool {
 			// process query response
 			// making sure all the returned data is read
@@ -7,6 +7,7 @@ package main
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -29,7 +30,8 @@ var (
 
 // Global vars:
 var (
-	runner *query.BenchmarkRunner
+	runner   *query.BenchmarkRunner
+	balancer *query.EndpointBalancer
 )
 
 // Parse args:
@@ -55,6 +57,11 @@ func init() {
 	}
 	vmURLs = strings.Split(urls, ",")
 	runner = query.NewBenchmarkRunner(config)
+	var err error
+	balancer, err = runner.EndpointBalancer(vmURLs)
+	if err != nil {
+		panic(err)
+	}
 }
 
 func main() {
@@ -67,48 +74,60 @@ func newProcessor() query.Processor {
 
 // query.Processor interface implementation
 type processor struct {
-	url string
+	workerNum int
 
 	prettyPrintResponses bool
 }
 
 // query.Processor interface implementation
 func (p *processor) Init(workerNum int) {
-	p.url = vmURLs[workerNum%len(vmURLs)]
+	p.workerNum = workerNum
 	p.prettyPrintResponses = runner.DoPrintResponses()
 }
 
 // query.Processor interface implementation
 func (p *processor) ProcessQuery(q query.Query, isWarm bool) ([]*query.Stat, error) {
+	return p.ProcessQueryContext(context.Background(), q, isWarm)
+}
+
+// query.ContextProcessor interface implementation, the request being
+// cancelled when ctx is done
+func (p *processor) ProcessQueryContext(ctx context.Context, q query.Query, isWarm bool) ([]*query.Stat, error) {
 	hq := q.(*query.HTTP)
-	lag, err := p.do(hq)
+	url := balancer.Pick(p.workerNum)
+	defer balancer.Done(url)
+	lag, body, err := p.do(ctx, vmURLs[url], hq)
 	if err != nil {
-		return nil, err
+		return nil, query.WithEndpoint(vmURLs[url], err)
+	}
+	rows, err := countPoints(body)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode the response to query %d: %s", q.GetID(), err)
 	}
 	stat := query.GetStat()
-	stat.Init(q.HumanLabelName(), lag)
+	stat.Init(q.HumanLabelName(), lag).SetEndpoint(vmURLs[url]).SetRows(rows).SetBytes(int64(len(body)))
 	return []*query.Stat{stat}, nil
 }
 
-func (p *processor) do(q *query.HTTP) (float64, error) {
+func (p *processor) do(ctx context.Context, url string, q *query.HTTP) (float64, []byte, error) {
 	// populate a request with data from the Query:
-	req, err := http.NewRequest(string(q.Method), p.url+string(q.Path), nil)
+	req, err := http.NewRequestWithContext(ctx, string(q.Method), url+string(q.Path), nil)
 	if err != nil {
-		return 0, fmt.Errorf("error while creating request: %s", err)
+		return 0, nil, fmt.Errorf("error while creating request: %s", err)
 	}
 
 	start := time.Now()
 	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
-		return 0, fmt.Errorf("query execution error: %s", err)
+		return 0, nil, fmt.Errorf("query execution error: %s", err)
 	}
 	defer resp.Body.Close()
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		return 0, fmt.Errorf("error while reading response body: %s", err)
+		return 0, nil, fmt.Errorf("error while reading response body: %s", err)
 	}
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("non-200 statuscode received: %d; Body: %s", resp.StatusCode, string(body))
+		return 0, nil, fmt.Errorf("non-200 statuscode received: %d; Body: %s", resp.StatusCode, string(body))
 	}
 	lag := float64(time.Since(start).Nanoseconds()) / 1e6 // milliseconds
 
@@ -117,12 +136,40 @@ func (p *processor) do(q *query.HTTP) (float64, error) {
 		var pretty bytes.Buffer
 		prefix := fmt.Sprintf("ID %d: ", q.GetID())
 		if err := json.Indent(&pretty, body, prefix, "  "); err != nil {
-			return lag, err
+			return lag, body, err
 		}
 		_, err = fmt.Fprintf(os.Stderr, "%s%s\n", prefix, pretty.Bytes())
 		if err != nil {
-			return lag, err
+			return lag, body, err
+		}
+	}
+	return lag, body, nil
+}
+
+// vmResponse is a response of VictoriaMetrics to a query, with only the
+// values of the series the points are counted from.
+type vmResponse struct {
+	Data struct {
+		Result []struct {
+			Value  json.RawMessage   `json:"value"`
+			Values []json.RawMessage `json:"values"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// countPoints returns the number of points of the series in the body of a
+// response, one per series for instant queries.
+func countPoints(body []byte) (int64, error) {
+	var r vmResponse
+	if err := json.Unmarshal(body, &r); err != nil {
+		return 0, err
+	}
+	points := int64(0)
+	for _, series := range r.Data.Result {
+		if len(series.Value) > 0 {
+			points++
 		}
+		points += int64(len(series.Values))
 	}
-	return lag, nil
+	return points, nil
 }
@@ -7,12 +7,15 @@ import (
 	"math/rand"
 	"os"
 	"sort"
+	"strconv"
+	"time"
 
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/serialize"
 	"github.com/timescale/tsbs/pkg/data/usecases"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
 	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/columnar"
 	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
@@ -20,6 +23,7 @@ import (
 const (
 	ErrNoConfig          = "no GeneratorConfig provided"
 	ErrInvalidDataConfig = "invalid config: DataGenerator needs a DataGeneratorConfig"
+	ErrColumnarNoDir     = "the parquet and arrow formats write one file per measurement, a directory must be given with --file"
 )
 
 // DataGenerator is a type of Generator for creating data that will be consumed
@@ -57,6 +61,14 @@ func (g *DataGenerator) init(config common.GeneratorConfig) error {
 	if g.Out == nil {
 		g.Out = os.Stdout
 	}
+	if isColumnar(g.config.Format) {
+		// the serializer writes the files into the File directory itself
+		if g.config.File == "" {
+			return fmt.Errorf(ErrColumnarNoDir)
+		}
+		g.bufOut, err = getBufferedWriter("", g.Out)
+		return err
+	}
 	g.bufOut, err = getBufferedWriter(g.config.File, g.Out)
 	if err != nil {
 		return err
@@ -84,7 +96,22 @@ func (g *DataGenerator) Generate(config common.GeneratorConfig, target targets.I
 		return err
 	}
 
-	return g.runSimulator(sim, serializer, g.config)
+	if err = g.runSimulator(sim, serializer, g.config); err != nil {
+		return err
+	}
+	return g.writeDatasetManifest()
+}
+
+// writeDatasetManifest writes the manifest of the generated data, if asked for.
+func (g *DataGenerator) writeDatasetManifest() error {
+	if g.config.DatasetManifest == "" {
+		return nil
+	}
+	m, err := common.NewDatasetManifest(g.config)
+	if err != nil {
+		return err
+	}
+	return common.WriteDatasetManifest(g.config.DatasetManifest, m)
 }
 
 func (g *DataGenerator) CreateSimulator(config *common.DataGeneratorConfig) (common.Simulator, error) {
@@ -93,12 +120,21 @@ func (g *DataGenerator) CreateSimulator(config *common.DataGeneratorConfig) (com
 		return nil, err
 	}
 	rand.Seed(g.config.Seed)
+	if g.config.Realtime {
+		start, end := common.RealtimeInterval(time.Now(), g.config.LogInterval, g.config.RealtimeDuration)
+		g.config.TimeStart = start.Format(time.RFC3339Nano)
+		g.config.TimeEnd = end.Format(time.RFC3339Nano)
+	}
 	scfg, err := usecases.GetSimulatorConfig(g.config)
 	if err != nil {
 		return nil, err
 	}
 
-	return scfg.NewSimulator(g.config.LogInterval, g.config.Limit), nil
+	sim := scfg.NewSimulator(g.config.LogInterval, g.config.Limit)
+	if g.config.Realtime {
+		return common.NewRealtimeSimulator(sim), nil
+	}
+	return sim, nil
 }
 
 func (g *DataGenerator) runSimulator(sim common.Simulator, serializer serialize.PointSerializer, dgc *common.DataGeneratorConfig) error {
@@ -124,6 +160,9 @@ func (g *DataGenerator) runSimulator(sim common.Simulator, serializer serialize.
 
 		currGroupID = (currGroupID + 1) % dgc.InterleavedNumGroups
 	}
+	if c, ok := serializer.(io.Closer); ok {
+		return c.Close()
+	}
 	return nil
 }
 
@@ -133,12 +172,24 @@ func (g *DataGenerator) getSerializer(sim common.Simulator, target targets.Imple
 		fallthrough
 	case constants.FormatClickhouse:
 		fallthrough
+	case constants.FormatDuckDB, constants.FormatSQLite:
+		fallthrough
 	case constants.FormatTimescaleDB:
 		g.writeHeader(sim.Headers())
+	case constants.FormatParquet, constants.FormatArrow:
+		opts := columnar.Options{Dir: g.config.File, RowGroupSize: g.config.RowGroupSize}
+		if g.config.InterleavedNumGroups > 1 {
+			opts.Part = strconv.Itoa(int(g.config.InterleavedGroupID))
+		}
+		return columnar.NewSerializer(target.TargetName(), sim.Headers(), opts)
 	}
 	return target.Serializer(), nil
 }
 
+func isColumnar(format string) bool {
+	return format == constants.FormatParquet || format == constants.FormatArrow
+}
+
 //TODO should be implemented in targets package
 func (g *DataGenerator) writeHeader(headers *common.GeneratedDataHeaders) {
 	g.bufOut.WriteString("tags")
@@ -5,7 +5,10 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 	"time"
 
@@ -118,6 +121,101 @@ func TestDataGeneratorGenerate(t *testing.T) {
 	}
 }
 
+func TestDataGeneratorGenerateManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-manifest")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:      123,
+			Format:    constants.FormatTimescaleDB,
+			Use:       common.UseCaseCPUOnly,
+			Scale:     10,
+			TimeStart: defaultTimeStart,
+			TimeEnd:   defaultTimeEnd,
+		},
+		Limit:                3,
+		LogInterval:          time.Second,
+		InterleavedNumGroups: 1,
+		DatasetManifest:      filepath.Join(dir, "manifest.json"),
+	}
+	dg := &DataGenerator{Out: &bytes.Buffer{}}
+	mockTarget := &mockTarget{name: constants.FormatTimescaleDB, serializer: &mockSerializer{}}
+	if err = dg.Generate(c, mockTarget); err != nil {
+		t.Fatalf("unexpected error when generating: got %v", err)
+	}
+
+	m, err := common.ReadDatasetManifest(c.DatasetManifest)
+	if err != nil {
+		t.Fatalf("unexpected error reading the manifest: %v", err)
+	}
+	want := &common.DatasetManifest{
+		Format:       constants.FormatTimescaleDB,
+		Use:          common.UseCaseCPUOnly,
+		Scale:        10,
+		InitialScale: 10,
+		Seed:         123,
+		TimeStart:    time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		TimeEnd:      time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC),
+		LogInterval:  time.Second,
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("incorrect manifest: got\n%+v\nwant\n%+v", m, want)
+	}
+}
+
+func TestDataGeneratorGenerateColumnar(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-columnar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	c := &common.DataGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Seed:      123,
+			Format:    constants.FormatParquet,
+			Use:       common.UseCaseDevops,
+			Scale:     1,
+			TimeStart: defaultTimeStart,
+			TimeEnd:   defaultTimeEnd,
+		},
+		Limit:                50,
+		InitialScale:         1,
+		LogInterval:          time.Second,
+		InterleavedNumGroups: 1,
+	}
+	dg := &DataGenerator{}
+	target := &mockTarget{name: constants.FormatParquet}
+
+	// Test that the output directory is required
+	err = dg.Generate(c, target)
+	if err == nil || err.Error() != ErrColumnarNoDir {
+		t.Errorf("incorrect error without a directory: got %v want %s", err, ErrColumnarNoDir)
+	}
+
+	c.File = dir
+	if err := dg.Generate(c, target); err != nil {
+		t.Fatalf("unexpected error when generating: got %v", err)
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// one file per devops measurement
+	if len(files) != 9 {
+		t.Errorf("incorrect number of files: got %d want 9", len(files))
+	}
+	for _, f := range files {
+		if f.Size() == 0 {
+			t.Errorf("file %s is empty", f.Name())
+		}
+	}
+}
+
 var keyIteration = []byte("iteration")
 
 type testSimulator struct {
@@ -2,7 +2,6 @@ package inputs
 
 import (
 	"bufio"
-	"encoding/gob"
 	"fmt"
 	"io"
 	"math/rand"
@@ -13,6 +12,7 @@ import (
 	queryUtils "github.com/timescale/tsbs/cmd/tsbs_generate_queries/utils"
 	internalUtils "github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/query"
 	"github.com/timescale/tsbs/pkg/query/config"
 	"github.com/timescale/tsbs/pkg/query/factories"
 )
@@ -28,6 +28,7 @@ const (
 	errUnknownUseCaseFmt        = "use case '%s' is undefined"
 	errCannotParseTimeFmt       = "cannot parse time from string '%s': %v"
 	errBadUseFmt                = "invalid use case specified: '%v'"
+	errNoAccessDistributionFmt  = "query generator for format '%s' does not support access distributions"
 )
 
 // DevopsGeneratorMaker creates a query generator for devops use case
@@ -40,6 +41,12 @@ type IoTGeneratorMaker interface {
 	NewIoT(start, end time.Time, scale int) (queryUtils.QueryGenerator, error)
 }
 
+// AccessDistributionSetter is a query generator whose hosts or trucks and time
+// windows can be picked with skewed distributions
+type AccessDistributionSetter interface {
+	SetAccessDistributions(items, windows internalUtils.Distribution)
+}
+
 // QueryGenerator is a type of Generator for creating queries to test against a
 // database. The output is specific to the type of database (due to each using
 // different querying techniques, e.g. SQL or REST), but is consumed by TSBS
@@ -86,6 +93,9 @@ func (g *QueryGenerator) Generate(config common.GeneratorConfig) error {
 	if err != nil {
 		return err
 	}
+	if err := setAccessDistributions(useGen, g.conf); err != nil {
+		return err
+	}
 
 	filler := g.useCaseMatrix[g.conf.Use][g.conf.QueryType](useGen)
 
@@ -201,10 +211,37 @@ func (g *QueryGenerator) getUseCaseGenerator(c *config.QueryGeneratorConfig) (qu
 	}
 }
 
+// setAccessDistributions sets the host and time distributions of the config
+// on the use case generator, unless they are both uniform.
+func setAccessDistributions(useGen queryUtils.QueryGenerator, c *config.QueryGeneratorConfig) error {
+	items, err := internalUtils.ParseDistribution(c.HostDistribution)
+	if err != nil {
+		return err
+	}
+	windows, err := internalUtils.ParseDistribution(c.TimeDistribution)
+	if err != nil {
+		return err
+	}
+	if items == nil && windows == nil {
+		return nil
+	}
+
+	setter, ok := useGen.(AccessDistributionSetter)
+	if !ok {
+		return fmt.Errorf(errNoAccessDistributionFmt, c.Format)
+	}
+	setter.SetAccessDistributions(items, windows)
+	return nil
+}
+
 func (g *QueryGenerator) runQueryGeneration(useGen queryUtils.QueryGenerator, filler queryUtils.QueryFiller, c *config.QueryGeneratorConfig) error {
 	stats := make(map[string]int64)
 	currentGroup := uint(0)
-	enc := gob.NewEncoder(g.bufOut)
+	format, err := query.FileFormat(c.QueryFormat, c.File)
+	if err != nil {
+		return err
+	}
+	enc := query.NewEncoder(format, g.bufOut)
 	defer g.bufOut.Flush()
 
 	rand.Seed(g.conf.Seed)
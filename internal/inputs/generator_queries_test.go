@@ -3,7 +3,6 @@ package inputs
 import (
 	"bufio"
 	"bytes"
-	"encoding/gob"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -27,6 +26,7 @@ import (
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
 	"github.com/timescale/tsbs/pkg/query"
 	"github.com/timescale/tsbs/pkg/query/config"
+	"github.com/timescale/tsbs/pkg/query/factories"
 	"github.com/timescale/tsbs/pkg/targets/constants"
 )
 
@@ -89,6 +89,38 @@ func TestQueryGeneratorConfigValidate(t *testing.T) {
 			t.Errorf("incorrect error for group id > num groups: got\n%s\nwant\n%s", got, want)
 		}
 	}
+	c.InterleavedGroupID = 0
+
+	// Test access distributions validation
+	c.HostDistribution = "zipf"
+	err = c.Validate()
+	if err == nil {
+		t.Errorf("unexpected lack of error for bad host distribution")
+	}
+	c.HostDistribution = "zipf:1.5"
+
+	c.TimeDistribution = "hotset:0"
+	err = c.Validate()
+	if err == nil {
+		t.Errorf("unexpected lack of error for bad time distribution")
+	}
+	c.TimeDistribution = "hotset:5:80"
+	err = c.Validate()
+	if err != nil {
+		t.Errorf("unexpected error for correct distributions: %v", err)
+	}
+
+	// Test query format validation
+	c.QueryFormat = "csv"
+	err = c.Validate()
+	if err == nil {
+		t.Errorf("unexpected lack of error for bad query format")
+	}
+	c.QueryFormat = query.FileFormatJSONL
+	err = c.Validate()
+	if err != nil {
+		t.Errorf("unexpected error for correct query format: %v", err)
+	}
 }
 
 func TestNewQueryGenerator(t *testing.T) {
@@ -443,9 +475,9 @@ func getTestConfigAndGenerator() (*config.QueryGeneratorConfig, *QueryGenerator)
 	return c, g
 }
 
-func checkGeneratedOutput(t *testing.T, buf *bytes.Buffer) {
+func checkGeneratedOutput(t *testing.T, buf *bytes.Buffer, format string) {
 	r := bufio.NewReader(buf)
-	decoder := gob.NewDecoder(r)
+	decoder := query.NewDecoder(format, r)
 	i := 0
 	for {
 		var q query.TimescaleDB
@@ -533,7 +565,7 @@ func TestQueryGeneratorRunQueryGeneration(t *testing.T) {
 			t.Errorf("unexpected error: got %v", err)
 		}
 
-		checkGeneratedOutput(t, &buf)
+		checkGeneratedOutput(t, &buf, query.FileFormatGob)
 
 		// Check that the proper debug output was written
 		wantDebug := strings.TrimSpace(strings.Join(c.wantDebug, "\n"))
@@ -543,6 +575,35 @@ func TestQueryGeneratorRunQueryGeneration(t *testing.T) {
 	}
 }
 
+func TestQueryGeneratorRunQueryGenerationJSONL(t *testing.T) {
+	config, g := getTestConfigAndGenerator()
+	config.QueryFormat = query.FileFormatJSONL
+	err := g.init(config)
+	if err != nil {
+		t.Fatalf("Error initializing query generator: %s", err)
+	}
+
+	var buf bytes.Buffer
+	g.bufOut = bufio.NewWriter(&buf)
+	g.DebugOut = ioutil.Discard
+
+	useGen, err := g.getUseCaseGenerator(config)
+	if err != nil {
+		t.Fatalf("could not get use case gen: %v", err)
+	}
+	filler := g.useCaseMatrix[config.Use][config.QueryType](useGen)
+
+	err = g.runQueryGeneration(useGen, filler, config)
+	if err != nil {
+		t.Errorf("unexpected error: got %v", err)
+	}
+
+	if got := strings.Count(buf.String(), "\n"); got != len(wantQueries) {
+		t.Errorf("incorrect number of lines: got %d want %d", got, len(wantQueries))
+	}
+	checkGeneratedOutput(t, &buf, query.FileFormatJSONL)
+}
+
 type badWriter struct {
 	when  int
 	count int
@@ -621,5 +682,57 @@ func TestQueryGeneratorGenerate(t *testing.T) {
 	if err != nil {
 		t.Errorf("unexpected error when generating: got %v", err)
 	}
-	checkGeneratedOutput(t, &buf)
+	checkGeneratedOutput(t, &buf, query.FileFormatGob)
+}
+
+// noCoreGenerator is a query generator without a use case Core
+type noCoreGenerator struct{}
+
+func (g *noCoreGenerator) GenerateEmptyQuery() query.Query {
+	return query.NewHTTP()
+}
+
+func TestSetAccessDistributions(t *testing.T) {
+	tsStart, _ := internalUtils.ParseUTCTime(defaultTimeStart)
+	tsEnd, _ := internalUtils.ParseUTCTime(defaultTimeEnd)
+	c := &config.QueryGeneratorConfig{
+		BaseConfig: common.BaseConfig{
+			Format: constants.FormatTimescaleDB,
+		},
+		HostDistribution: "hotset:10",
+		TimeDistribution: "zipf:1.1",
+		PGWireDialect:    "postgres",
+		DbName:           "benchmark",
+	}
+
+	// every devops generator picks its hosts and windows with the Core
+	for format, factory := range factories.InitQueryFactories(c) {
+		maker, ok := factory.(DevopsGeneratorMaker)
+		if !ok {
+			continue
+		}
+		useGen, err := maker.NewDevops(tsStart, tsEnd, 10)
+		if err != nil {
+			t.Fatalf("unexpected error creating the %s generator: %v", format, err)
+		}
+		c.Format = format
+		if err := setAccessDistributions(useGen, c); err != nil {
+			t.Errorf("unexpected error for format %s: %v", format, err)
+		}
+	}
+
+	c.Format = "foo"
+	err := setAccessDistributions(&noCoreGenerator{}, c)
+	if err == nil {
+		t.Errorf("unexpected lack of error for a generator without a Core")
+	} else if got, want := err.Error(), fmt.Sprintf(errNoAccessDistributionFmt, "foo"); got != want {
+		t.Errorf("incorrect error: got\n%s\nwant\n%s", got, want)
+	}
+
+	// uniform distributions leave any generator alone
+	c.HostDistribution = internalUtils.DistributionUniform
+	c.TimeDistribution = ""
+	if err := setAccessDistributions(&noCoreGenerator{}, c); err != nil {
+		t.Errorf("unexpected error for uniform distributions: %v", err)
+	}
 }
@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Names of the access distributions.
+const (
+	DistributionUniform = "uniform"
+	DistributionZipf    = "zipf"
+	DistributionHotset  = "hotset"
+
+	// defaultHotsetHitPercent is the percentage of the picks going to the hot
+	// items when it is not given.
+	defaultHotsetHitPercent = 90
+
+	errBadDistributionFmt = "invalid access distribution '%s': %s"
+)
+
+// Distribution picks items out of a set of n with some skew, the item at
+// index 0 being the hottest. A nil Distribution means picking uniformly,
+// which is the default.
+type Distribution interface {
+	// Pick returns an index in [0, n).
+	Pick(n int) int
+}
+
+// ParseDistribution parses the description of an access distribution, one of:
+//
+//	uniform            every item is equally likely, a nil Distribution
+//	zipf:<s>           the probability of the item at index i is proportional to 1/(i+1)^s
+//	hotset:<p>[:<h>]   the first p percent of the items get h percent of the picks (default 90)
+func ParseDistribution(spec string) (Distribution, error) {
+	parts := strings.Split(spec, ":")
+	switch parts[0] {
+	case "", DistributionUniform:
+		if len(parts) > 1 {
+			return nil, fmt.Errorf(errBadDistributionFmt, spec, "uniform takes no parameter")
+		}
+		return nil, nil
+	case DistributionZipf:
+		if len(parts) != 2 {
+			return nil, fmt.Errorf(errBadDistributionFmt, spec, "expected zipf:<s>")
+		}
+		s, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || s <= 0 {
+			return nil, fmt.Errorf(errBadDistributionFmt, spec, "the zipf exponent must be a number > 0")
+		}
+		return NewZipf(s), nil
+	case DistributionHotset:
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf(errBadDistributionFmt, spec, "expected hotset:<percent>[:<hit percent>]")
+		}
+		hot, err := strconv.ParseFloat(parts[1], 64)
+		if err != nil || hot <= 0 || hot >= 100 {
+			return nil, fmt.Errorf(errBadDistributionFmt, spec, "the hot set percentage must be in (0, 100)")
+		}
+		hit := float64(defaultHotsetHitPercent)
+		if len(parts) == 3 {
+			hit, err = strconv.ParseFloat(parts[2], 64)
+			if err != nil || hit <= 0 || hit > 100 {
+				return nil, fmt.Errorf(errBadDistributionFmt, spec, "the hit percentage must be in (0, 100]")
+			}
+		}
+		return &Hotset{HotPercent: hot, HitPercent: hit}, nil
+	}
+	return nil, fmt.Errorf(errBadDistributionFmt, spec, "choose uniform, zipf:<s> or hotset:<percent>[:<hit percent>]")
+}
+
+// Zipf is a Distribution where the probability of the item at index i is
+// proportional to 1/(i+1)^S. Unlike rand.Zipf any S > 0 is allowed.
+type Zipf struct {
+	S float64
+	// cdfs caches the cumulative probabilities of the sizes picked from
+	cdfs map[int][]float64
+}
+
+// NewZipf returns a Zipf distribution with the exponent s.
+func NewZipf(s float64) *Zipf {
+	return &Zipf{S: s, cdfs: make(map[int][]float64)}
+}
+
+// Pick returns an index in [0, n).
+func (z *Zipf) Pick(n int) int {
+	cdf, ok := z.cdfs[n]
+	if !ok {
+		cdf = make([]float64, n)
+		sum := 0.0
+		for i := range cdf {
+			sum += 1 / math.Pow(float64(i+1), z.S)
+			cdf[i] = sum
+		}
+		for i := range cdf {
+			cdf[i] /= sum
+		}
+		z.cdfs[n] = cdf
+	}
+	i := sort.SearchFloat64s(cdf, rand.Float64())
+	if i == n {
+		// rounding of the last cumulative probability
+		i = n - 1
+	}
+	return i
+}
+
+// Hotset is a Distribution where the first HotPercent percent of the items,
+// at least one, get HitPercent percent of the picks, uniformly. The other
+// picks go uniformly to the rest of the items.
+type Hotset struct {
+	HotPercent float64
+	HitPercent float64
+}
+
+// Pick returns an index in [0, n).
+func (h *Hotset) Pick(n int) int {
+	hot := int(math.Ceil(float64(n) * h.HotPercent / 100))
+	if hot >= n {
+		return rand.Intn(n)
+	}
+	if rand.Float64()*100 < h.HitPercent {
+		return rand.Intn(hot)
+	}
+	return hot + rand.Intn(n-hot)
+}
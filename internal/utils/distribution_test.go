@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParseDistribution(t *testing.T) {
+	cases := []struct {
+		spec    string
+		want    Distribution
+		wantErr bool
+	}{
+		{spec: ""},
+		{spec: "uniform"},
+		{spec: "uniform:1", wantErr: true},
+		{spec: "zipf:1.2", want: NewZipf(1.2)},
+		{spec: "zipf:0.5", want: NewZipf(0.5)},
+		{spec: "zipf", wantErr: true},
+		{spec: "zipf:0", wantErr: true},
+		{spec: "zipf:foo", wantErr: true},
+		{spec: "hotset:10", want: &Hotset{HotPercent: 10, HitPercent: 90}},
+		{spec: "hotset:2.5:99", want: &Hotset{HotPercent: 2.5, HitPercent: 99}},
+		{spec: "hotset", wantErr: true},
+		{spec: "hotset:100", wantErr: true},
+		{spec: "hotset:10:0", wantErr: true},
+		{spec: "hotset:10:90:1", wantErr: true},
+		{spec: "gaussian", wantErr: true},
+	}
+
+	for _, c := range cases {
+		got, err := ParseDistribution(c.spec)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: unexpected lack of error", c.spec)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", c.spec, err)
+			continue
+		}
+		switch want := c.want.(type) {
+		case nil:
+			if got != nil {
+				t.Errorf("%s: got %v want a nil distribution", c.spec, got)
+			}
+		case *Zipf:
+			if z, ok := got.(*Zipf); !ok || z.S != want.S {
+				t.Errorf("%s: got %v want zipf with s %v", c.spec, got, want.S)
+			}
+		case *Hotset:
+			if h, ok := got.(*Hotset); !ok || *h != *want {
+				t.Errorf("%s: got %v want %v", c.spec, got, want)
+			}
+		}
+	}
+}
+
+// pickCounts picks n items 10000 times and counts how often each one is picked
+func pickCounts(t *testing.T, d Distribution, n int) []int {
+	counts := make([]int, n)
+	for i := 0; i < 10000; i++ {
+		x := d.Pick(n)
+		if x < 0 || x >= n {
+			t.Fatalf("picked %d out of %d items", x, n)
+		}
+		counts[x]++
+	}
+	return counts
+}
+
+func TestZipfPick(t *testing.T) {
+	rand.Seed(123)
+	counts := pickCounts(t, NewZipf(1.5), 100)
+	// with s = 1.5 the first item has about 41% of the picks, the second 15%
+	if counts[0] < 3900 || counts[0] > 4400 {
+		t.Errorf("first item picked %d times, want about 4150", counts[0])
+	}
+	for i := 1; i < 5; i++ {
+		if counts[i] >= counts[i-1] {
+			t.Errorf("item %d picked more than item %d: %d >= %d", i, i-1, counts[i], counts[i-1])
+		}
+	}
+
+	if got := NewZipf(1).Pick(1); got != 0 {
+		t.Errorf("incorrect pick out of 1 item: got %d", got)
+	}
+}
+
+func TestHotsetPick(t *testing.T) {
+	rand.Seed(123)
+	counts := pickCounts(t, &Hotset{HotPercent: 10, HitPercent: 90}, 100)
+	hot := 0
+	for _, c := range counts[:10] {
+		hot += c
+	}
+	if hot < 8800 || hot > 9200 {
+		t.Errorf("hot items picked %d times, want about 9000", hot)
+	}
+
+	// the hot set has at least one item
+	counts = pickCounts(t, &Hotset{HotPercent: 1, HitPercent: 100}, 10)
+	if counts[0] != 10000 {
+		t.Errorf("hot item picked %d times, want 10000", counts[0])
+	}
+
+	// everything is hot
+	counts = pickCounts(t, &Hotset{HotPercent: 60, HitPercent: 50}, 1)
+	if counts[0] != 10000 {
+		t.Errorf("only item picked %d times, want 10000", counts[0])
+	}
+}
@@ -12,6 +12,10 @@ const (
 	ErrEndBeforeStart = "end time before start time"
 
 	errWindowTooLargeFmt = "random window equal to or larger than TimeInterval: window %v, interval %v"
+
+	// maxWindowSlots bounds the number of slots the windows are placed in
+	// with a distribution
+	maxWindowSlots = 1 << 16
 )
 
 // TimeInterval represents an interval of time in UTC. That is, regardless of
@@ -20,6 +24,9 @@ const (
 type TimeInterval struct {
 	start time.Time
 	end   time.Time
+	// windows is the distribution random windows are placed with, nil
+	// for uniformly
+	windows Distribution
 }
 
 // NewTimeInterval creates a new TimeInterval for a given start and end. If end
@@ -28,7 +35,7 @@ func NewTimeInterval(start, end time.Time) (*TimeInterval, error) {
 	if end.Before(start) {
 		return nil, fmt.Errorf(ErrEndBeforeStart)
 	}
-	return &TimeInterval{start.UTC(), end.UTC()}, nil
+	return &TimeInterval{start: start.UTC(), end: end.UTC()}, nil
 }
 
 // Duration returns the time.Duration of the TimeInterval.
@@ -67,8 +74,17 @@ func (ti *TimeInterval) Overlap(other *TimeInterval) bool {
 	return true
 }
 
-// RandWindow creates a TimeInterval of duration `window` at a uniformly-random
-// start time within the time period represented by this TimeInterval.
+// SetWindowDistribution sets the distribution RandWindow places the windows
+// with. The possible start times are split in slots of the length of the
+// window, counted back from the end, so with a skewed distribution the
+// recent windows are the hottest. A nil distribution places them uniformly.
+func (ti *TimeInterval) SetWindowDistribution(d Distribution) {
+	ti.windows = d
+}
+
+// RandWindow creates a TimeInterval of duration `window` at a random start
+// time within the time period represented by this TimeInterval, uniformly
+// unless a distribution was set with SetWindowDistribution.
 func (ti *TimeInterval) RandWindow(window time.Duration) (*TimeInterval, error) {
 	lower := ti.start.UnixNano()
 	upper := ti.end.Add(-window).UnixNano()
@@ -78,6 +94,9 @@ func (ti *TimeInterval) RandWindow(window time.Duration) (*TimeInterval, error)
 
 	}
 
+	if ti.windows != nil {
+		lower, upper = ti.randSlot(lower, upper, window.Nanoseconds())
+	}
 	start := lower + rand.Int63n(upper-lower)
 	end := start + window.Nanoseconds()
 
@@ -93,6 +112,28 @@ func (ti *TimeInterval) RandWindow(window time.Duration) (*TimeInterval, error)
 	return x, nil
 }
 
+// randSlot picks with the window distribution one of the slots of width
+// nanoseconds in [lower, upper), slot 0 ending at upper. The last slot also
+// covers what remains down to lower. There are at most maxWindowSlots slots,
+// they are widened for short windows over long intervals.
+func (ti *TimeInterval) randSlot(lower, upper, width int64) (int64, int64) {
+	slots := (upper - lower) / width
+	if slots <= 1 {
+		return lower, upper
+	}
+	if slots > maxWindowSlots {
+		slots = maxWindowSlots
+		width = (upper - lower) / slots
+	}
+	k := int64(ti.windows.Pick(int(slots)))
+	slotUpper := upper - k*width
+	slotLower := slotUpper - width
+	if k == slots-1 {
+		slotLower = lower
+	}
+	return slotLower, slotUpper
+}
+
 // MustRandWindow is the form of RandWindow that cannot error; if it does error,
 // it causes a panic.
 func (ti *TimeInterval) MustRandWindow(window time.Duration) *TimeInterval {
@@ -318,3 +318,47 @@ func TestTimeIntervalMustRandWindow(t *testing.T) {
 		})
 	}
 }
+
+func TestTimeIntervalRandWindowDistribution(t *testing.T) {
+	start := time.Date(2016, time.January, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2016, time.January, 2, 0, 0, 0, 0, time.UTC)
+	ti, err := NewTimeInterval(start, end) // 1 day duration
+	if err != nil {
+		t.Fatalf("unexpected error creating TimeInterval: got %v", err)
+	}
+	// all the windows in the most recent of the 23 slots of 1 hour
+	ti.SetWindowDistribution(&Hotset{HotPercent: 1, HitPercent: 100})
+
+	lastStart := end.Add(-2 * time.Hour)
+	for i := 0; i < 1000; i++ {
+		x := ti.MustRandWindow(time.Hour)
+		if x.Duration() != time.Hour {
+			t.Fatalf("incorrect window duration: got %v", x.Duration())
+		}
+		if x.Start().Before(lastStart) || x.End().After(end) {
+			t.Fatalf("window not in the most recent slot: %v - %v", x.Start(), x.End())
+		}
+	}
+
+	// the last of the 3 slots of 5 hours covers the start of the interval
+	ti.SetWindowDistribution(&Hotset{HotPercent: 1, HitPercent: 0.0001})
+	earliest := end
+	for i := 0; i < 1000; i++ {
+		x := ti.MustRandWindow(5 * time.Hour)
+		if x.Start().Before(start) || x.End().After(end) {
+			t.Fatalf("window out of the interval: %v - %v", x.Start(), x.End())
+		}
+		if x.Start().Before(earliest) {
+			earliest = x.Start()
+		}
+	}
+	if earliest.After(start.Add(time.Hour)) {
+		t.Errorf("no window in the first hours of the interval, earliest start %v", earliest)
+	}
+
+	ti.SetWindowDistribution(nil)
+	x := ti.MustRandWindow(time.Hour)
+	if x.Start().Before(start) || x.End().After(end) {
+		t.Fatalf("window out of the interval: %v - %v", x.Start(), x.End())
+	}
+}
@@ -0,0 +1,112 @@
+package load
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HdrHistogram/hdrhistogram-go"
+)
+
+// latencyScaleFactor converts the microseconds stored in the histograms to milliseconds
+const latencyScaleFactor = 1e3
+
+// batchLatencies records the insert latency of every batch processed by the
+// workers, both for the whole run and for the current reporting period.
+// It is safe for concurrent use, and a nil *batchLatencies records nothing.
+type batchLatencies struct {
+	mutex  sync.Mutex
+	total  *hdrhistogram.Histogram
+	period *hdrhistogram.Histogram
+}
+
+func newBatchLatencies() *batchLatencies {
+	// Track latencies between 1 us and 3600000000 us (3600 secs)
+	// with a precision of 3 significant digits
+	return &batchLatencies{
+		total:  hdrhistogram.New(1, 3600000000, 3),
+		period: hdrhistogram.New(1, 3600000000, 3),
+	}
+}
+
+// record adds the latency of a single batch
+func (b *batchLatencies) record(took time.Duration) {
+	if b == nil {
+		return
+	}
+	us := took.Microseconds()
+	b.mutex.Lock()
+	_ = b.total.RecordValue(us)
+	_ = b.period.RecordValue(us)
+	b.mutex.Unlock()
+}
+
+// snapshotPeriod returns the quantiles of the batches recorded since the
+// previous call and starts a new period
+func (b *batchLatencies) snapshotPeriod() latencyQuantiles {
+	if b == nil {
+		return latencyQuantiles{}
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	q := newLatencyQuantiles(b.period)
+	b.period.Reset()
+	return q
+}
+
+// snapshotTotal returns the quantiles of all the batches recorded
+func (b *batchLatencies) snapshotTotal() latencyQuantiles {
+	if b == nil {
+		return latencyQuantiles{}
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return newLatencyQuantiles(b.total)
+}
+
+// latencyQuantiles holds the batch latency quantiles in milliseconds
+type latencyQuantiles struct {
+	count              int64
+	min, p50, p95, p99 float64
+	p999, max, mean    float64
+}
+
+func newLatencyQuantiles(hist *hdrhistogram.Histogram) latencyQuantiles {
+	q := latencyQuantiles{count: hist.TotalCount()}
+	if q.count == 0 {
+		return q
+	}
+	q.min = float64(hist.Min()) / latencyScaleFactor
+	q.p50 = float64(hist.ValueAtQuantile(50.0)) / latencyScaleFactor
+	q.p95 = float64(hist.ValueAtQuantile(95.0)) / latencyScaleFactor
+	q.p99 = float64(hist.ValueAtQuantile(99.0)) / latencyScaleFactor
+	q.p999 = float64(hist.ValueAtQuantile(99.9)) / latencyScaleFactor
+	q.max = float64(hist.Max()) / latencyScaleFactor
+	q.mean = hist.Mean() / latencyScaleFactor
+	return q
+}
+
+// csv formats the quantiles reported in the periodic report
+func (q latencyQuantiles) csv() string {
+	return fmt.Sprintf("%0.2f,%0.2f,%0.2f,%0.2f", q.p50, q.p95, q.p99, q.max)
+}
+
+// string formats the quantiles for the summary
+func (q latencyQuantiles) string() string {
+	return fmt.Sprintf("min: %0.2fms, p50: %0.2fms, p95: %0.2fms, p99: %0.2fms, max: %0.2fms, mean: %0.2fms, count: %d",
+		q.min, q.p50, q.p95, q.p99, q.max, q.mean, q.count)
+}
+
+// toMap returns the quantiles in the same form as the query benchmarks
+// store their latency quantiles in the results file
+func (q latencyQuantiles) toMap() map[string]float64 {
+	return map[string]float64{
+		"q0":   q.min,
+		"q50":  q.p50,
+		"q95":  q.p95,
+		"q99":  q.p99,
+		"q999": q.p999,
+		"q100": q.max,
+		"mean": q.mean,
+	}
+}
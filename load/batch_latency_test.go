@@ -0,0 +1,52 @@
+package load
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBatchLatencies(t *testing.T) {
+	b := newBatchLatencies()
+	for i := 1; i <= 100; i++ {
+		b.record(time.Duration(i) * time.Millisecond)
+	}
+
+	period := b.snapshotPeriod()
+	if period.count != 100 {
+		t.Errorf("incorrect period count: got %d want %d", period.count, 100)
+	}
+	if period.p50 < 49.9 || period.p50 > 50.1 {
+		t.Errorf("incorrect period p50: got %f want 50", period.p50)
+	}
+	if period.p99 < 98.9 || period.p99 > 99.1 {
+		t.Errorf("incorrect period p99: got %f want 99", period.p99)
+	}
+	if period.max < 99.9 || period.max > 100.1 {
+		t.Errorf("incorrect period max: got %f want 100", period.max)
+	}
+
+	// a new period starts after a snapshot, the total is kept
+	b.record(time.Second)
+	period = b.snapshotPeriod()
+	if period.count != 1 {
+		t.Errorf("incorrect period count after snapshot: got %d want %d", period.count, 1)
+	}
+	total := b.snapshotTotal()
+	if total.count != 101 {
+		t.Errorf("incorrect total count: got %d want %d", total.count, 101)
+	}
+	if total.max < 999 || total.max > 1001 {
+		t.Errorf("incorrect total max: got %f want 1000", total.max)
+	}
+	if got := b.snapshotPeriod().csv(); got != "0.00,0.00,0.00,0.00" {
+		t.Errorf("incorrect csv for empty period: got %s", got)
+	}
+}
+
+func TestBatchLatenciesNil(t *testing.T) {
+	var b *batchLatencies
+	b.record(time.Second)
+	if got := b.snapshotTotal().count; got != 0 {
+		t.Errorf("nil batchLatencies recorded a value")
+	}
+}
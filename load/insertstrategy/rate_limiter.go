@@ -0,0 +1,183 @@
+package insertstrategy
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxScheduleLag is the furthest the schedule of a RateLimiter may fall behind
+// the wall clock. When the load can't keep up with the requested rate, at most
+// this much missed time is made up by inserting faster than requested once it
+// catches up again.
+const maxScheduleLag = time.Second
+
+// RateProfile describes how the requested insert rate (in metrics per second)
+// changes during a run. The rate starts at StartRate and reaches TargetRate
+// after RampDuration, either linearly (Steps == 0) or in Steps equal steps.
+// After RampDuration the rate stays at TargetRate.
+type RateProfile struct {
+	TargetRate   float64
+	StartRate    float64
+	RampDuration time.Duration
+	Steps        uint
+}
+
+// Validate checks that the profile describes a positive rate at all times.
+func (p RateProfile) Validate() error {
+	if p.TargetRate <= 0 {
+		return fmt.Errorf("target rate must be positive, can't be %0.2f", p.TargetRate)
+	}
+	if p.RampDuration < 0 {
+		return fmt.Errorf("rate ramp duration can't be negative: %v", p.RampDuration)
+	}
+	if p.RampDuration > 0 && p.StartRate <= 0 {
+		return fmt.Errorf("start rate of a ramp must be positive, can't be %0.2f", p.StartRate)
+	}
+	return nil
+}
+
+// RateAt returns the requested rate sinceStart into the run.
+func (p RateProfile) RateAt(sinceStart time.Duration) float64 {
+	if p.RampDuration <= 0 || sinceStart >= p.RampDuration {
+		return p.TargetRate
+	}
+	if sinceStart < 0 {
+		sinceStart = 0
+	}
+	progress := sinceStart.Seconds() / p.RampDuration.Seconds()
+	if p.Steps == 0 {
+		return p.StartRate + (p.TargetRate-p.StartRate)*progress
+	}
+	return p.stepRate(uint(progress * float64(p.Steps)))
+}
+
+// Expected returns the number of metrics that should have been inserted
+// sinceStart into the run, i.e. the integral of the rate over time.
+func (p RateProfile) Expected(sinceStart time.Duration) float64 {
+	if sinceStart <= 0 {
+		return 0
+	}
+	if p.RampDuration <= 0 {
+		return p.TargetRate * sinceStart.Seconds()
+	}
+	rampSecs := math.Min(sinceStart.Seconds(), p.RampDuration.Seconds())
+	var expected float64
+	if p.Steps == 0 {
+		slope := (p.TargetRate - p.StartRate) / p.RampDuration.Seconds()
+		expected = p.StartRate*rampSecs + slope*rampSecs*rampSecs/2
+	} else {
+		stepSecs := p.RampDuration.Seconds() / float64(p.Steps)
+		for i := uint(0); i < p.Steps && float64(i)*stepSecs < rampSecs; i++ {
+			expected += p.stepRate(i) * math.Min(stepSecs, rampSecs-float64(i)*stepSecs)
+		}
+	}
+	if sinceStart > p.RampDuration {
+		expected += p.TargetRate * (sinceStart - p.RampDuration).Seconds()
+	}
+	return expected
+}
+
+// stepRate returns the rate of the i-th step of a stepped ramp. The first step
+// is at StartRate and the rate is increased evenly so that the step after the
+// last one is at TargetRate.
+func (p RateProfile) stepRate(i uint) float64 {
+	if i >= p.Steps {
+		return p.TargetRate
+	}
+	return p.StartRate + (p.TargetRate-p.StartRate)*float64(i)/float64(p.Steps)
+}
+
+// RateLimiter is a token bucket shared by all load workers that keeps the
+// combined insert rate of the workers at the rate requested by a RateProfile.
+// Workers report the metrics they inserted with Take, which blocks until the
+// schedule allows those metrics to have been inserted.
+type RateLimiter struct {
+	profile RateProfile
+	nowFn   nowProviderFn
+	sleepFn func(time.Duration)
+
+	mutex    sync.Mutex
+	start    time.Time
+	next     time.Time
+	lastTake time.Time
+	behind   time.Duration
+}
+
+// NewRateLimiter returns a RateLimiter following profile. The schedule starts
+// with the first call to Start or Take.
+func NewRateLimiter(profile RateProfile) (*RateLimiter, error) {
+	if err := profile.Validate(); err != nil {
+		return nil, err
+	}
+	return &RateLimiter{
+		profile: profile,
+		nowFn:   time.Now,
+		sleepFn: time.Sleep,
+	}, nil
+}
+
+// Profile returns the rate profile followed by the limiter.
+func (r *RateLimiter) Profile() RateProfile {
+	return r.profile
+}
+
+// Start starts the schedule at the given time. Calling it after the schedule
+// was started has no effect.
+func (r *RateLimiter) Start(at time.Time) {
+	r.mutex.Lock()
+	r.startLocked(at)
+	r.mutex.Unlock()
+}
+
+func (r *RateLimiter) startLocked(at time.Time) {
+	if !r.start.IsZero() {
+		return
+	}
+	r.start = at
+	r.next = at
+	r.lastTake = at
+}
+
+// Take accounts for numMetrics inserted metrics and blocks the calling worker
+// until the schedule catches up with them.
+func (r *RateLimiter) Take(numMetrics uint64) {
+	if numMetrics == 0 {
+		return
+	}
+	r.mutex.Lock()
+	now := r.nowFn()
+	r.startLocked(now)
+
+	// the schedule is behind the wall clock, the load can't keep up
+	if lag := now.Sub(r.next); lag > 0 {
+		sinceLastTake := now.Sub(r.lastTake)
+		if lag < sinceLastTake {
+			r.behind += lag
+		} else {
+			r.behind += sinceLastTake
+		}
+		if lag > maxScheduleLag {
+			r.next = now.Add(-maxScheduleLag)
+		}
+	}
+	r.lastTake = now
+
+	rate := r.profile.RateAt(r.next.Sub(r.start))
+	r.next = r.next.Add(time.Duration(float64(numMetrics) / rate * float64(time.Second)))
+	sleepUntil := r.next
+	r.mutex.Unlock()
+
+	if wait := sleepUntil.Sub(now); wait > 0 {
+		r.sleepFn(wait)
+	}
+}
+
+// BehindSchedule returns the approximate wall clock time during which the
+// inserts were behind the requested schedule.
+func (r *RateLimiter) BehindSchedule() time.Duration {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.behind
+}
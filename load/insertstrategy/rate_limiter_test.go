@@ -0,0 +1,134 @@
+package insertstrategy
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRateProfileValidate(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		profile   RateProfile
+		expectErr bool
+	}{
+		{
+			desc:      "Error on zero target rate",
+			expectErr: true,
+		}, {
+			desc:      "Error on negative ramp",
+			profile:   RateProfile{TargetRate: 10, StartRate: 1, RampDuration: -time.Second},
+			expectErr: true,
+		}, {
+			desc:      "Error on ramp without start rate",
+			profile:   RateProfile{TargetRate: 10, RampDuration: time.Second},
+			expectErr: true,
+		}, {
+			desc:    "Constant rate",
+			profile: RateProfile{TargetRate: 10},
+		}, {
+			desc:    "Ramp",
+			profile: RateProfile{TargetRate: 10, StartRate: 1, RampDuration: time.Second, Steps: 3},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			err := tc.profile.Validate()
+			if err != nil && !tc.expectErr {
+				t.Errorf("unexpected error: %v", err)
+			} else if err == nil && tc.expectErr {
+				t.Error("unexpected lack of error")
+			}
+		})
+	}
+}
+
+func TestRateProfileRateAtAndExpected(t *testing.T) {
+	testCases := []struct {
+		desc         string
+		profile      RateProfile
+		at           time.Duration
+		wantRate     float64
+		wantExpected float64
+	}{
+		{
+			desc:         "constant",
+			profile:      RateProfile{TargetRate: 100},
+			at:           10 * time.Second,
+			wantRate:     100,
+			wantExpected: 1000,
+		}, {
+			desc:         "middle of linear ramp",
+			profile:      RateProfile{TargetRate: 300, StartRate: 100, RampDuration: 10 * time.Second},
+			at:           5 * time.Second,
+			wantRate:     200,
+			wantExpected: 750,
+		}, {
+			desc:         "after linear ramp",
+			profile:      RateProfile{TargetRate: 300, StartRate: 100, RampDuration: 10 * time.Second},
+			at:           20 * time.Second,
+			wantRate:     300,
+			wantExpected: 2000 + 3000,
+		}, {
+			desc:         "second step",
+			profile:      RateProfile{TargetRate: 400, StartRate: 100, RampDuration: 9 * time.Second, Steps: 3},
+			at:           4 * time.Second,
+			wantRate:     200,
+			wantExpected: 300 + 200,
+		}, {
+			desc:         "after steps",
+			profile:      RateProfile{TargetRate: 400, StartRate: 100, RampDuration: 9 * time.Second, Steps: 3},
+			at:           10 * time.Second,
+			wantRate:     400,
+			wantExpected: 300 + 600 + 900 + 400,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			if got := tc.profile.RateAt(tc.at); math.Abs(got-tc.wantRate) > 1e-6 {
+				t.Errorf("incorrect rate: got %f want %f", got, tc.wantRate)
+			}
+			if got := tc.profile.Expected(tc.at); math.Abs(got-tc.wantExpected) > 1e-6 {
+				t.Errorf("incorrect expected metrics: got %f want %f", got, tc.wantExpected)
+			}
+		})
+	}
+}
+
+func TestRateLimiterTake(t *testing.T) {
+	limiter, err := NewRateLimiter(RateProfile{TargetRate: 100})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Unix(0, 0)
+	var slept []time.Duration
+	limiter.nowFn = func() time.Time { return now }
+	limiter.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	limiter.Start(now)
+	// 50 metrics at 100 metrics/sec should take half a second
+	limiter.Take(50)
+	if len(slept) != 1 || slept[0] != 500*time.Millisecond {
+		t.Fatalf("incorrect sleep: %v", slept)
+	}
+	if behind := limiter.BehindSchedule(); behind != 0 {
+		t.Errorf("unexpected time behind schedule: %v", behind)
+	}
+
+	// inserting took 2 seconds, the load is behind the schedule and doesn't wait
+	now = now.Add(2 * time.Second)
+	limiter.Take(50)
+	if len(slept) != 1 {
+		t.Errorf("unexpected sleep when behind schedule: %v", slept)
+	}
+	if behind := limiter.BehindSchedule(); behind != 2*time.Second {
+		t.Errorf("incorrect time behind schedule: got %v want %v", behind, 2*time.Second)
+	}
+	// the missed time is capped so that the load doesn't burst for too long
+	if want := now.Add(-maxScheduleLag).Add(500 * time.Millisecond); !limiter.next.Equal(want) {
+		t.Errorf("incorrect next schedule: got %v want %v", limiter.next, want)
+	}
+}
@@ -3,7 +3,6 @@ package load
 import (
 	"github.com/timescale/tsbs/pkg/targets"
 	"sync"
-	"sync/atomic"
 	"time"
 )
 
@@ -27,7 +26,8 @@ func (l *noFlowBenchmarkRunner) RunBenchmark(b targets.Benchmark) {
 		go l.work(b, wg, channels[i%numChannels], i)
 	}
 	// Start scan process - actual data read process
-	scanWithoutFlowControl(b.GetDataSource(), b.GetPointIndexer(numChannels), b.GetBatchFactory(), channels, l.BatchSize, l.Limit)
+	ds := &abortableDataSource{DataSource: b.GetDataSource(), l: &l.CommonBenchmarkRunner}
+	scanWithoutFlowControl(ds, b.GetPointIndexer(numChannels), b.GetBatchFactory(), channels, l.BatchSize, l.Limit)
 	for _, c := range channels {
 		close(c)
 	}
@@ -53,10 +53,12 @@ func (l *noFlowBenchmarkRunner) work(b targets.Benchmark, wg *sync.WaitGroup, c
 
 	// Process batches coming from the incoming queue (c)
 	for batch := range c {
+		// Once aborted the batches left are only drained
+		if l.isAborted() {
+			continue
+		}
 		startedWorkAt := time.Now()
-		metricCnt, rowCnt := proc.ProcessBatch(batch, l.DoLoad)
-		atomic.AddUint64(&l.metricCnt, metricCnt)
-		atomic.AddUint64(&l.rowCnt, rowCnt)
+		l.processBatch(proc, batch, workerNum)
 		l.timeToSleep(workerNum, startedWorkAt)
 	}
 
@@ -10,6 +10,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/targets"
 
 	"github.com/spf13/pflag"
@@ -21,7 +22,11 @@ const (
 	defaultBatchSize                = 10000
 	DefaultChannelCapacityFlagVal   = 0
 	defaultChannelCapacityPerWorker = 5
+	defaultBatchRetries             = 3
+	defaultRetryBackoff             = time.Second
+	maxRetryBackoff                 = time.Minute
 	errDBExistsFmt                  = "database \"%s\" exists: aborting."
+	errTooManyFailedBatchesFmt      = "%d batches failed to be inserted (max-failed-batches = %d): aborting."
 )
 
 // change for more useful testing
@@ -45,6 +50,24 @@ type BenchmarkRunnerConfig struct {
 	ChannelCapacity uint          `yaml:"channel-capacity" mapstructure:"channel-capacity" json:"channel-capacity"`
 	InsertIntervals string        `yaml:"insert-intervals" mapstructure:"insert-intervals" json:"insert-intervals"`
 	ResultsFile     string        `yaml:"results-file" mapstructure:"results-file" json:"results-file"`
+	// BatchRetries is the number of times a batch whose insert failed is retried
+	BatchRetries uint `yaml:"batch-retries" mapstructure:"batch-retries" json:"batch-retries"`
+	// RetryBackoff is the time to wait before the first retry of a failed batch,
+	// it is doubled after each subsequent failed attempt
+	RetryBackoff time.Duration `yaml:"retry-backoff" mapstructure:"retry-backoff" json:"retry-backoff"`
+	// MaxFailedBatches is the number of batches failing all retries that aborts
+	// the load, 0 means never abort
+	MaxFailedBatches uint64 `yaml:"max-failed-batches" mapstructure:"max-failed-batches" json:"max-failed-batches"`
+	// TargetRate is the combined insert rate of all workers in metrics per second,
+	// 0 means insert as fast as possible
+	TargetRate float64 `yaml:"target-rate" mapstructure:"target-rate" json:"target-rate"`
+	// TargetRateStart is the rate at the start of the run when ramping up to TargetRate
+	TargetRateStart float64 `yaml:"target-rate-start" mapstructure:"target-rate-start" json:"target-rate-start"`
+	// TargetRateRamp is the time it takes to go from TargetRateStart to TargetRate,
+	// 0 means the run starts at TargetRate
+	TargetRateRamp time.Duration `yaml:"target-rate-ramp" mapstructure:"target-rate-ramp" json:"target-rate-ramp"`
+	// TargetRateSteps is the number of equal steps of the ramp, 0 means a linear ramp
+	TargetRateSteps uint `yaml:"target-rate-steps" mapstructure:"target-rate-steps" json:"target-rate-steps"`
 	// deprecated, should not be used in other places other than tsbs_load_xx commands
 	FileName string `yaml:"file" mapstructure:"file" json:"file"`
 	Seed     int64  `yaml:"seed" mapstructure:"seed" json:"seed"`
@@ -65,6 +88,13 @@ func (c BenchmarkRunnerConfig) AddToFlagSet(fs *pflag.FlagSet) {
 	fs.String("insert-intervals", "", "Time to wait between each insert, default '' => all workers insert ASAP. '1,2' = worker 1 waits 1s between inserts, worker 2 and others wait 2s")
 	fs.Bool("hash-workers", false, "Whether to consistently hash insert data to the same workers (i.e., the data for a particular host always goes to the same worker)")
 	fs.String("results-file", "", "Write the test results summary json to this file")
+	fs.Uint("batch-retries", defaultBatchRetries, "Number of times to retry inserting a batch that failed before counting it as failed")
+	fs.Duration("retry-backoff", defaultRetryBackoff, "Time to wait before retrying a failed batch, doubled after each failed retry")
+	fs.Uint64("max-failed-batches", 0, "Abort the load after this many batches failed all retries (0 = never abort)")
+	fs.Float64("target-rate", 0, "Combined insert rate of all workers in metrics/sec (0 = insert as fast as possible)")
+	fs.Float64("target-rate-start", 0, "Insert rate in metrics/sec at the start of the run when ramping up to --target-rate")
+	fs.Duration("target-rate-ramp", 0, "Time to ramp up from --target-rate-start to --target-rate (0 = no ramp)")
+	fs.Uint("target-rate-steps", 0, "Number of equal steps in which to ramp up the insert rate (0 = linear ramp)")
 }
 
 type BenchmarkRunner interface {
@@ -78,8 +108,18 @@ type CommonBenchmarkRunner struct {
 	BenchmarkRunnerConfig
 	metricCnt      uint64
 	rowCnt         uint64
+	failedBatchCnt uint64
+	retryCnt       uint64
+	// aborted is set once MaxFailedBatches batches failed, the scanner then
+	// stops reading and the workers skip the batches left
+	aborted        uint32
+	latencies      *batchLatencies
 	initialRand    *rand.Rand
 	sleepRegulator insertstrategy.SleepRegulator
+	rateLimiter    *insertstrategy.RateLimiter
+	// postLoad is the DBCreator with work to do once the data is loaded
+	postLoad     targets.DBCreatorPostLoad
+	postLoadTook time.Duration
 }
 
 // GetBenchmarkRunnerWithBatchSize returns the singleton CommonBenchmarkRunner for use in a benchmark program
@@ -93,6 +133,7 @@ func GetBenchmarkRunner(c BenchmarkRunnerConfig) BenchmarkRunner {
 	}
 
 	loader.initialRand = rand.New(rand.NewSource(loader.Seed))
+	loader.latencies = newBatchLatencies()
 
 	var err error
 	if c.InsertIntervals == "" {
@@ -103,6 +144,17 @@ func GetBenchmarkRunner(c BenchmarkRunnerConfig) BenchmarkRunner {
 			panic(fmt.Sprintf("could not initialize BenchmarkRunner: %v", err))
 		}
 	}
+	if c.TargetRate > 0 {
+		loader.rateLimiter, err = insertstrategy.NewRateLimiter(insertstrategy.RateProfile{
+			TargetRate:   c.TargetRate,
+			StartRate:    c.TargetRateStart,
+			RampDuration: c.TargetRateRamp,
+			Steps:        c.TargetRateSteps,
+		})
+		if err != nil {
+			panic(fmt.Sprintf("could not initialize BenchmarkRunner: %v", err))
+		}
+	}
 	if !c.NoFlowControl {
 		return &loader
 	}
@@ -136,6 +188,9 @@ func (l *CommonBenchmarkRunner) preRun(b targets.Benchmark) (*sync.WaitGroup, *t
 	wg := &sync.WaitGroup{}
 	wg.Add(int(l.Workers))
 	start := time.Now()
+	if l.rateLimiter != nil {
+		l.rateLimiter.Start(start)
+	}
 	return wg, &start
 }
 
@@ -145,11 +200,36 @@ func (l *CommonBenchmarkRunner) postRun(wg *sync.WaitGroup, start *time.Time) {
 	end := time.Now()
 	took := end.Sub(*start)
 	l.summary(took)
+	if l.postLoad != nil && !l.isAborted() {
+		l.runPostLoad()
+	}
 	if l.BenchmarkRunnerConfig.ResultsFile != "" {
 		metricRate := float64(l.metricCnt) / took.Seconds()
 		rowRate := float64(l.rowCnt) / took.Seconds()
 		l.saveTestResult(took, *start, end, metricRate, rowRate)
 	}
+	if l.isAborted() {
+		fatal(errTooManyFailedBatchesFmt, l.failedBatchCnt, l.MaxFailedBatches)
+	}
+}
+
+// isAborted tells whether the load was aborted because too many batches failed
+func (l *CommonBenchmarkRunner) isAborted() bool {
+	return atomic.LoadUint32(&l.aborted) == 1
+}
+
+// abortableDataSource stops handing out items once the load is aborted, so
+// that the scanner sends out what it has batched and returns
+type abortableDataSource struct {
+	targets.DataSource
+	l *CommonBenchmarkRunner
+}
+
+func (ds *abortableDataSource) NextItem() data.LoadedPoint {
+	if ds.l.isAborted() {
+		return data.LoadedPoint{}
+	}
+	return ds.DataSource.NextItem()
 }
 
 func (l *CommonBenchmarkRunner) saveTestResult(took time.Duration, start time.Time, end time.Time, metricRate, rowRate float64) {
@@ -158,6 +238,18 @@ func (l *CommonBenchmarkRunner) saveTestResult(took time.Duration, start time.Ti
 	if l.rowCnt > 0 {
 		totals["rowRate"] = rowRate
 	}
+	totals["failedBatches"] = l.failedBatchCnt
+	totals["retriedBatches"] = l.retryCnt
+	latencies := l.latencies.snapshotTotal()
+	totals["batchCount"] = latencies.count
+	totals["batchLatencyQuantiles"] = latencies.toMap()
+	if l.postLoad != nil {
+		totals["postLoadMillis"] = l.postLoadTook.Milliseconds()
+	}
+	if l.rateLimiter != nil {
+		totals["requestedMetricRate"] = l.rateLimiter.Profile().Expected(took) / took.Seconds()
+		totals["behindScheduleSecs"] = l.rateLimiter.BehindSchedule().Seconds()
+	}
 
 	testResult := LoaderTestResult{
 		ResultFormatVersion: LoaderTestResultVersion,
@@ -200,7 +292,8 @@ func (l *CommonBenchmarkRunner) RunBenchmark(b targets.Benchmark) {
 	}
 
 	// Start scan process - actual data read process
-	scanWithFlowControl(channels, l.BatchSize, l.Limit, b.GetDataSource(), b.GetBatchFactory(), b.GetPointIndexer(uint(len(channels))))
+	ds := &abortableDataSource{DataSource: b.GetDataSource(), l: l}
+	scanWithFlowControl(channels, l.BatchSize, l.Limit, ds, b.GetBatchFactory(), b.GetPointIndexer(uint(len(channels))))
 	// After scan process completed (no more data to come) - begin shutdown process
 
 	// Close all communication channels to/from workers
@@ -256,10 +349,27 @@ func (l *CommonBenchmarkRunner) useDBCreator(dbc targets.DBCreator) func() {
 				panic(err)
 			}
 		}
+
+		switch dbcl := dbc.(type) {
+		case targets.DBCreatorPostLoad:
+			l.postLoad = dbcl
+		}
 	}
 	return closeFn
 }
 
+// runPostLoad does the work of the DBCreator once the data is loaded, e.g.
+// refreshing rollups, and prints how long it took apart from the loading
+func (l *CommonBenchmarkRunner) runPostLoad() {
+	start := time.Now()
+	if err := l.postLoad.PostLoad(l.DBName); err != nil {
+		log.Println("could not execute PostLoad:" + err.Error())
+		panic(err)
+	}
+	l.postLoadTook = time.Since(start)
+	printFn("post-load work took %0.3fsec\n", l.postLoadTook.Seconds())
+}
+
 // createChannels create channels from which workers would receive tasks
 func (l *CommonBenchmarkRunner) createChannels(numChannels, capacity uint) []*duplexChannel {
 	// Result - channels to be created
@@ -282,10 +392,13 @@ func (l *CommonBenchmarkRunner) work(b targets.Benchmark, wg *sync.WaitGroup, c
 	// Process batches coming from duplexChannel.toWorker queue
 	// and send ACKs into duplexChannel.toScanner queue
 	for batch := range c.toWorker {
+		// Once aborted the batches left are only acknowledged
+		if l.isAborted() {
+			c.sendToScanner()
+			continue
+		}
 		startedWorkAt := time.Now()
-		metricCnt, rowCnt := proc.ProcessBatch(batch, l.DoLoad)
-		atomic.AddUint64(&l.metricCnt, metricCnt)
-		atomic.AddUint64(&l.rowCnt, rowCnt)
+		l.processBatch(proc, batch, workerNum)
 		c.sendToScanner()
 		l.timeToSleep(workerNum, startedWorkAt)
 	}
@@ -299,6 +412,50 @@ func (l *CommonBenchmarkRunner) work(b targets.Benchmark, wg *sync.WaitGroup, c
 	wg.Done()
 }
 
+// processBatch hands the batch to the processor, retrying it with an exponential
+// backoff while the processor reports an error. The latency of the successful
+// attempt is recorded. A batch that fails all retries is counted as failed, and
+// the load is aborted as soon as the count reaches MaxFailedBatches: the scan
+// and the workers stop, and the results are reported before exiting.
+func (l *CommonBenchmarkRunner) processBatch(proc targets.Processor, batch targets.Batch, workerNum uint) {
+	backoff := l.RetryBackoff
+	for attempt := uint(0); ; attempt++ {
+		startedAt := time.Now()
+		metricCnt, rowCnt, err := proc.ProcessBatch(batch, l.DoLoad)
+		atomic.AddUint64(&l.metricCnt, metricCnt)
+		atomic.AddUint64(&l.rowCnt, rowCnt)
+		if err == nil {
+			l.latencies.record(time.Since(startedAt))
+			l.throttle(metricCnt)
+			return
+		}
+		if attempt >= l.BatchRetries {
+			log.Printf("worker %d: batch failed after %d attempts: %v", workerNum, attempt+1, err)
+			break
+		}
+		log.Printf("worker %d: batch failed, retrying in %v: %v", workerNum, backoff, err)
+		atomic.AddUint64(&l.retryCnt, 1)
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxRetryBackoff {
+			backoff = maxRetryBackoff
+		}
+	}
+
+	failed := atomic.AddUint64(&l.failedBatchCnt, 1)
+	if l.MaxFailedBatches > 0 && failed >= l.MaxFailedBatches && atomic.CompareAndSwapUint32(&l.aborted, 0, 1) {
+		log.Printf(errTooManyFailedBatchesFmt, failed, l.MaxFailedBatches)
+	}
+}
+
+// throttle blocks the worker until inserting metricCnt metrics fits the
+// requested --target-rate
+func (l *CommonBenchmarkRunner) throttle(metricCnt uint64) {
+	if l.rateLimiter != nil {
+		l.rateLimiter.Take(metricCnt)
+	}
+}
+
 func (l *CommonBenchmarkRunner) timeToSleep(workerNum uint, startedWorkAt time.Time) {
 	if l.sleepRegulator != nil {
 		l.sleepRegulator.Sleep(int(workerNum), startedWorkAt)
@@ -314,6 +471,17 @@ func (l *CommonBenchmarkRunner) summary(took time.Duration) {
 		rowRate := float64(l.rowCnt) / float64(took.Seconds())
 		printFn("loaded %d rows in %0.3fsec with %d workers (mean rate %0.2f rows/sec)\n", l.rowCnt, took.Seconds(), l.Workers, rowRate)
 	}
+	if l.failedBatchCnt > 0 || l.retryCnt > 0 {
+		printFn("%d batches failed to be inserted, %d batch retries\n", l.failedBatchCnt, l.retryCnt)
+	}
+	if latencies := l.latencies.snapshotTotal(); latencies.count > 0 {
+		printFn("batch latency %s\n", latencies.string())
+	}
+	if l.rateLimiter != nil {
+		requestedRate := l.rateLimiter.Profile().Expected(took) / took.Seconds()
+		printFn("requested mean rate %0.2f metrics/sec, achieved %0.2f metrics/sec (%0.2f%%), behind schedule for %0.3fsec\n",
+			requestedRate, metricRate, 100*metricRate/requestedRate, l.rateLimiter.BehindSchedule().Seconds())
+	}
 }
 
 // report handles periodic reporting of loading stats
@@ -323,10 +491,14 @@ func (l *CommonBenchmarkRunner) report(period time.Duration) {
 	prevColCount := uint64(0)
 	prevRowCount := uint64(0)
 
-	printFn("time,per. metric/s,metric total,overall metric/s,per. row/s,row total,overall row/s\n")
+	printFn("time,per. metric/s,metric total,overall metric/s,per. row/s,row total,overall row/s,failed batches,retried batches," +
+		"per. batch p50 ms,per. batch p95 ms,per. batch p99 ms,per. batch max ms\n")
 	for now := range time.NewTicker(period).C {
 		cCount := atomic.LoadUint64(&l.metricCnt)
 		rCount := atomic.LoadUint64(&l.rowCnt)
+		failed := atomic.LoadUint64(&l.failedBatchCnt)
+		retried := atomic.LoadUint64(&l.retryCnt)
+		latencies := l.latencies.snapshotPeriod().csv()
 
 		sinceStart := now.Sub(start)
 		took := now.Sub(prevTime)
@@ -335,9 +507,9 @@ func (l *CommonBenchmarkRunner) report(period time.Duration) {
 		if rCount > 0 {
 			rowrate := float64(rCount-prevRowCount) / float64(took.Seconds())
 			overallRowRate := float64(rCount) / float64(sinceStart.Seconds())
-			printFn("%d,%0.2f,%E,%0.2f,%0.2f,%E,%0.2f\n", now.Unix(), colrate, float64(cCount), overallColRate, rowrate, float64(rCount), overallRowRate)
+			printFn("%d,%0.2f,%E,%0.2f,%0.2f,%E,%0.2f,%d,%d,%s\n", now.Unix(), colrate, float64(cCount), overallColRate, rowrate, float64(rCount), overallRowRate, failed, retried, latencies)
 		} else {
-			printFn("%d,%0.2f,%E,%0.2f,-,-,-\n", now.Unix(), colrate, float64(cCount), overallColRate)
+			printFn("%d,%0.2f,%E,%0.2f,-,-,-,%d,%d,%s\n", now.Unix(), colrate, float64(cCount), overallColRate, failed, retried, latencies)
 		}
 
 		prevColCount = cCount
@@ -2,8 +2,12 @@ package load
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"github.com/timescale/tsbs/load/insertstrategy"
 	"github.com/timescale/tsbs/pkg/targets"
+	"io/ioutil"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,8 +24,24 @@ func (p *testProcessor) Init(workerNum int, _, _ bool) {
 	p.worker = workerNum
 }
 
-func (p *testProcessor) ProcessBatch(targets.Batch, bool) (metricCount, rowCount uint64) {
-	return 1, 0
+func (p *testProcessor) ProcessBatch(targets.Batch, bool) (metricCount, rowCount uint64, err error) {
+	return 1, 0, nil
+}
+
+// failingProcessor fails the first failures calls to ProcessBatch
+type failingProcessor struct {
+	failures int
+	calls    int
+}
+
+func (p *failingProcessor) Init(int, bool, bool) {}
+
+func (p *failingProcessor) ProcessBatch(targets.Batch, bool) (metricCount, rowCount uint64, err error) {
+	p.calls++
+	if p.calls <= p.failures {
+		return 0, 0, fmt.Errorf("insert failed")
+	}
+	return 2, 1, nil
 }
 
 func (p *testProcessor) Close(_ bool) {
@@ -70,6 +90,16 @@ func (c *testCreatorPost) PostCreateDB(string) error {
 	return nil
 }
 
+type testCreatorPostLoad struct {
+	testCreator
+	postLoadCalled bool
+}
+
+func (c *testCreatorPostLoad) PostLoad(string) error {
+	c.postLoadCalled = true
+	return nil
+}
+
 type testCreatorClose struct {
 	testCreator
 }
@@ -265,6 +295,32 @@ func TestUseDBCreator(t *testing.T) {
 	}
 }
 
+func TestPostLoad(t *testing.T) {
+	r := &CommonBenchmarkRunner{}
+	dbc := &testCreatorPostLoad{}
+	r.useDBCreator(dbc)
+	if r.postLoad != nil {
+		t.Errorf("PostLoad set up without loading")
+	}
+
+	r.DoLoad = true
+	r.useDBCreator(dbc)
+	if r.postLoad == nil {
+		t.Fatalf("PostLoad not set up when loading")
+	}
+	var b bytes.Buffer
+	printFn = func(s string, args ...interface{}) (n int, err error) {
+		return fmt.Fprintf(&b, s, args...)
+	}
+	r.runPostLoad()
+	if !dbc.postLoadCalled {
+		t.Errorf("PostLoad not called")
+	}
+	if got := b.String(); !strings.HasPrefix(got, "post-load work took ") {
+		t.Errorf("incorrect output: got %s", got)
+	}
+}
+
 func TestCreateChannelsAndPartitions(t *testing.T) {
 	cases := []struct {
 		desc        string
@@ -378,11 +434,156 @@ func TestWorkWithSleep(t *testing.T) {
 	}
 }
 
+func TestProcessBatchRetries(t *testing.T) {
+	cases := []struct {
+		desc        string
+		failures    int
+		retries     uint
+		maxFailed   uint64
+		wantCalls   int
+		wantMetrics uint64
+		wantRetries uint64
+		wantFailed  uint64
+		wantAborted bool
+	}{
+		{
+			desc:        "no failures",
+			retries:     3,
+			wantCalls:   1,
+			wantMetrics: 2,
+		},
+		{
+			desc:        "succeeds after retries",
+			failures:    2,
+			retries:     3,
+			wantCalls:   3,
+			wantMetrics: 2,
+			wantRetries: 2,
+		},
+		{
+			desc:        "fails all retries",
+			failures:    5,
+			retries:     2,
+			wantCalls:   3,
+			wantRetries: 2,
+			wantFailed:  1,
+		},
+		{
+			desc:       "no retries",
+			failures:   1,
+			wantCalls:  1,
+			wantFailed: 1,
+		},
+		{
+			desc:        "too many failed batches aborts",
+			failures:    1,
+			maxFailed:   1,
+			wantCalls:   1,
+			wantFailed:  1,
+			wantAborted: true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			br := &CommonBenchmarkRunner{
+				BenchmarkRunnerConfig: BenchmarkRunnerConfig{
+					BatchRetries:     c.retries,
+					RetryBackoff:     time.Millisecond,
+					MaxFailedBatches: c.maxFailed,
+				},
+			}
+			proc := &failingProcessor{failures: c.failures}
+			br.processBatch(proc, &testBatch{}, 0)
+			if proc.calls != c.wantCalls {
+				t.Errorf("incorrect number of ProcessBatch calls: got %d want %d", proc.calls, c.wantCalls)
+			}
+			if br.metricCnt != c.wantMetrics {
+				t.Errorf("incorrect metric count: got %d want %d", br.metricCnt, c.wantMetrics)
+			}
+			if br.retryCnt != c.wantRetries {
+				t.Errorf("incorrect retry count: got %d want %d", br.retryCnt, c.wantRetries)
+			}
+			if br.failedBatchCnt != c.wantFailed {
+				t.Errorf("incorrect failed batch count: got %d want %d", br.failedBatchCnt, c.wantFailed)
+			}
+			if got := br.isAborted(); got != c.wantAborted {
+				t.Errorf("incorrect aborted: got %v want %v", got, c.wantAborted)
+			}
+		})
+	}
+}
+
+func TestWorkAborted(t *testing.T) {
+	br := &CommonBenchmarkRunner{aborted: 1}
+	b := &testBenchmark{}
+	b.processors = append(b.processors, &testProcessor{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	c := newDuplexChannel(1)
+	c.sendToWorker(&testBatch{})
+	go br.work(b, &wg, c, 0)
+	<-c.toScanner
+	c.close()
+	wg.Wait()
+
+	if got := br.metricCnt; got != 0 {
+		t.Errorf("batch processed after abort: got %d metrics want 0", got)
+	}
+	if !b.processors[0].closed {
+		t.Errorf("processor 0 not closed")
+	}
+}
+
+func TestPostRunAborted(t *testing.T) {
+	isCalled := false
+	fatal = func(format string, args ...interface{}) {
+		isCalled = true
+	}
+	printFn = func(s string, args ...interface{}) (n int, err error) {
+		return 0, nil
+	}
+	dbc := &testCreatorPostLoad{}
+	resultsFile := filepath.Join(t.TempDir(), "results.json")
+	br := &CommonBenchmarkRunner{
+		BenchmarkRunnerConfig: BenchmarkRunnerConfig{
+			MaxFailedBatches: 1,
+			ResultsFile:      resultsFile,
+		},
+		failedBatchCnt: 1,
+		aborted:        1,
+		latencies:      newBatchLatencies(),
+		postLoad:       dbc,
+	}
+	start := time.Now()
+	br.postRun(&sync.WaitGroup{}, &start)
+
+	if !isCalled {
+		t.Errorf("fatal not called after an aborted load")
+	}
+	if dbc.postLoadCalled {
+		t.Errorf("PostLoad called after an aborted load")
+	}
+	file, err := ioutil.ReadFile(resultsFile)
+	if err != nil {
+		t.Fatalf("results not saved: %v", err)
+	}
+	var result LoaderTestResult
+	if err := json.Unmarshal(file, &result); err != nil {
+		t.Fatalf("invalid results: %v", err)
+	}
+	if got := result.Totals["failedBatches"]; got != float64(1) {
+		t.Errorf("incorrect failed batches in results: got %v want 1", got)
+	}
+}
+
 func TestSummary(t *testing.T) {
 	cases := []struct {
 		desc    string
 		metrics uint64
 		rows    uint64
+		failed  uint64
+		retries uint64
+		rate    float64
 		took    time.Duration
 		want    string
 	}{
@@ -414,12 +615,38 @@ func TestSummary(t *testing.T) {
 			took:    time.Second,
 			want:    "\nSummary:\nloaded 10 metrics in 1.000sec with 0 workers (mean rate 10.00 metrics/sec)\nloaded 1 rows in 1.000sec with 0 workers (mean rate 1.00 rows/sec)\n",
 		},
+		{
+			desc:    "include failures: 10 metrics, 0 rows, 1 second, 1 failed, 3 retries",
+			metrics: 10,
+			rows:    0,
+			failed:  1,
+			retries: 3,
+			took:    time.Second,
+			want:    "\nSummary:\nloaded 10 metrics in 1.000sec with 0 workers (mean rate 10.00 metrics/sec)\n1 batches failed to be inserted, 3 batch retries\n",
+		},
+		{
+			desc:    "include target rate: 10 metrics, 0 rows, 1 second, 20 metrics/sec requested",
+			metrics: 10,
+			rows:    0,
+			rate:    20,
+			took:    time.Second,
+			want:    "\nSummary:\nloaded 10 metrics in 1.000sec with 0 workers (mean rate 10.00 metrics/sec)\nrequested mean rate 20.00 metrics/sec, achieved 10.00 metrics/sec (50.00%), behind schedule for 0.000sec\n",
+		},
 	}
 
 	for _, c := range cases {
 		br := &CommonBenchmarkRunner{}
 		br.metricCnt = c.metrics
 		br.rowCnt = c.rows
+		br.failedBatchCnt = c.failed
+		br.retryCnt = c.retries
+		if c.rate > 0 {
+			var err error
+			br.rateLimiter, err = insertstrategy.NewRateLimiter(insertstrategy.RateProfile{TargetRate: c.rate})
+			if err != nil {
+				t.Fatalf("%s: could not create rate limiter: %v", c.desc, err)
+			}
+		}
 		var b bytes.Buffer
 		printFn = func(s string, args ...interface{}) (n int, err error) {
 			return fmt.Fprintf(&b, s, args...)
@@ -462,8 +689,8 @@ func TestReport(t *testing.T) {
 	m.Lock()
 	end := strings.TrimSpace(string(b.Bytes()))
 	m.Unlock()
-	if end[len(end)-1:len(end)] != "-" {
-		t.Errorf("TestReport: non-row report does not end in -")
+	if !strings.HasSuffix(end, "-,-,-,0,0,0.00,0.00,0.00,0.00") {
+		t.Errorf("TestReport: non-row report does not have - for row columns")
 	}
 
 	// update row count so line is different
@@ -475,7 +702,7 @@ func TestReport(t *testing.T) {
 	m.Lock()
 	end = strings.TrimSpace(string(b.Bytes()))
 	m.Unlock()
-	if end[len(end)-1:len(end)] == "-" {
-		t.Errorf("TestReport: row report ends in -")
+	if strings.Contains(end[strings.LastIndex(end, "\n")+1:], "-") {
+		t.Errorf("TestReport: row report has - for row columns")
 	}
 }
@@ -26,6 +26,7 @@ const (
 	errMaxMetricCountValue = "max metric count per host has to be greater than 0"
 	errLogIntervalZero     = "cannot have log interval of 0"
 	defaultLogInterval     = 10 * time.Second
+	defaultRowGroupSize    = 64 * 1024
 )
 
 // DataGeneratorConfig is the GeneratorConfig that should be used with a
@@ -40,6 +41,18 @@ type DataGeneratorConfig struct {
 	InterleavedGroupID    uint          `yaml:"interleaved-generation-group-id" mapstructure:"interleaved-generation-group-id"`
 	InterleavedNumGroups  uint          `yaml:"interleaved-generation-groups" mapstructure:"interleaved-generation-groups"`
 	MaxMetricCountPerHost uint64        `yaml:"max-metric-count" mapstructure:"max-metric-count"`
+	// Realtime makes a simulator created from this config start at the current
+	// time and emit each epoch when its wall-clock time arrives, TimeStart and
+	// TimeEnd are ignored. Only used when loading simulated data.
+	Realtime bool `yaml:"realtime" mapstructure:"realtime"`
+	// RealtimeDuration is how long a realtime simulator runs for, 0 means forever
+	RealtimeDuration time.Duration `yaml:"realtime-duration" mapstructure:"realtime-duration"`
+	// RowGroupSize is the number of rows per Parquet row group or Arrow record
+	// batch, only used by the columnar formats.
+	RowGroupSize int `yaml:"row-group-size" mapstructure:"row-group-size"`
+	// DatasetManifest is the file the DatasetManifest of the generated data is
+	// written to, none when empty.
+	DatasetManifest string `yaml:"dataset-manifest" mapstructure:"dataset-manifest"`
 }
 
 // Validate checks that the values of the DataGeneratorConfig are reasonable.
@@ -77,6 +90,8 @@ func (c *DataGeneratorConfig) AddToFlagSet(fs *pflag.FlagSet) {
 	fs.Uint("interleaved-generation-groups", 1,
 		"The number of round-robin serialization groups. Use this to scale up data generation to multiple processes.")
 	fs.Uint64("max-metric-count", 100, "Max number of metric fields to generate per host. Used only in devops-generic use-case")
+	fs.Int("row-group-size", defaultRowGroupSize, "Number of rows per Parquet row group or Arrow record batch. Used only by the parquet and arrow formats")
+	fs.String("dataset-manifest", "", "Write a JSON manifest of the generated data (use case, scale, time range, seed and log interval) to this path, to be given to tsbs_generate_queries")
 }
 
 const defaultTimeStart = "2016-01-01T00:00:00Z"
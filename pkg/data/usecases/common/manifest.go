@@ -0,0 +1,112 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/timescale/tsbs/internal/utils"
+)
+
+// DatasetManifest records what tsbs_generate_data generated, so that the
+// queries generated for the dataset can be checked against it: e.g. that they
+// do not query hosts beyond the scale or a time range without data.
+type DatasetManifest struct {
+	Format       string        `json:"format"`
+	Use          string        `json:"use-case"`
+	Scale        uint64        `json:"scale"`
+	InitialScale uint64        `json:"initial-scale"`
+	Seed         int64         `json:"seed"`
+	TimeStart    time.Time     `json:"timestamp-start"`
+	TimeEnd      time.Time     `json:"timestamp-end"`
+	LogInterval  time.Duration `json:"-"`
+}
+
+// manifestJSON is the JSON form of a DatasetManifest, with the log interval
+// written as a duration like 10s rather than in nanoseconds.
+type manifestJSON struct {
+	*datasetManifestFields
+	LogInterval string `json:"log-interval"`
+}
+
+type datasetManifestFields DatasetManifest
+
+// NewDatasetManifest returns the manifest of the dataset generated with the
+// validated DataGeneratorConfig c.
+func NewDatasetManifest(c *DataGeneratorConfig) (*DatasetManifest, error) {
+	start, err := utils.ParseUTCTime(c.TimeStart)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse time from string '%s': %v", c.TimeStart, err)
+	}
+	end, err := utils.ParseUTCTime(c.TimeEnd)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse time from string '%s': %v", c.TimeEnd, err)
+	}
+	return &DatasetManifest{
+		Format:       c.Format,
+		Use:          c.Use,
+		Scale:        c.Scale,
+		InitialScale: c.InitialScale,
+		Seed:         c.Seed,
+		TimeStart:    start,
+		TimeEnd:      end,
+		LogInterval:  c.LogInterval,
+	}, nil
+}
+
+// MarshalJSON writes the manifest with the keys of the flags of
+// tsbs_generate_data.
+func (m *DatasetManifest) MarshalJSON() ([]byte, error) {
+	return json.Marshal(manifestJSON{
+		datasetManifestFields: (*datasetManifestFields)(m),
+		LogInterval:           m.LogInterval.String(),
+	})
+}
+
+// UnmarshalJSON reads a manifest written by MarshalJSON.
+func (m *DatasetManifest) UnmarshalJSON(data []byte) error {
+	j := manifestJSON{datasetManifestFields: (*datasetManifestFields)(m)}
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+	if j.LogInterval == "" {
+		m.LogInterval = 0
+		return nil
+	}
+	d, err := time.ParseDuration(j.LogInterval)
+	if err != nil {
+		return fmt.Errorf("invalid log-interval: %v", err)
+	}
+	m.LogInterval = d
+	return nil
+}
+
+// WriteDatasetManifest writes the manifest m as JSON to fileName.
+func WriteDatasetManifest(fileName string, m *DatasetManifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(fileName, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("cannot write dataset manifest: %v", err)
+	}
+	return nil
+}
+
+// ReadDatasetManifest reads the manifest written to fileName by
+// WriteDatasetManifest.
+func ReadDatasetManifest(fileName string) (*DatasetManifest, error) {
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read dataset manifest: %v", err)
+	}
+	m := &DatasetManifest{}
+	if err = json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid dataset manifest %s: %v", fileName, err)
+	}
+	if m.Use == "" || m.Scale == 0 || m.TimeStart.IsZero() || !m.TimeEnd.After(m.TimeStart) {
+		return nil, fmt.Errorf("invalid dataset manifest %s: the use case, scale and time range are required", fileName)
+	}
+	return m, nil
+}
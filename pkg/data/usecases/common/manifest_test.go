@@ -0,0 +1,99 @@
+package common
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewDatasetManifest(t *testing.T) {
+	c := &DataGeneratorConfig{
+		BaseConfig: BaseConfig{
+			Format:    "influx",
+			Use:       UseCaseDevops,
+			Scale:     100,
+			Seed:      123,
+			TimeStart: "2016-01-01T00:00:00Z",
+			TimeEnd:   "2016-01-04T00:00:00Z",
+		},
+		InitialScale: 50,
+		LogInterval:  10 * time.Second,
+	}
+	m, err := NewDatasetManifest(c)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"format":"influx","use-case":"devops","scale":100,"initial-scale":50,"seed":123,` +
+		`"timestamp-start":"2016-01-01T00:00:00Z","timestamp-end":"2016-01-04T00:00:00Z","log-interval":"10s"}`
+	if got := string(data); got != want {
+		t.Errorf("incorrect manifest:\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	c.TimeEnd = "tomorrow"
+	if _, err = NewDatasetManifest(c); err == nil {
+		t.Errorf("unexpected lack of error for an invalid time")
+	}
+}
+
+func TestReadDatasetManifest(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tsbs-manifest")
+	if err != nil {
+		t.Fatalf("could not create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := &DatasetManifest{
+		Format:       "timescaledb",
+		Use:          UseCaseIoT,
+		Scale:        10,
+		InitialScale: 10,
+		Seed:         -5,
+		TimeStart:    time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		TimeEnd:      time.Date(2016, 1, 2, 0, 0, 0, 0, time.UTC),
+		LogInterval:  time.Minute,
+	}
+	fileName := filepath.Join(dir, "manifest.json")
+	if err = WriteDatasetManifest(fileName, m); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := ReadDatasetManifest(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, m) {
+		t.Errorf("incorrect manifest: got\n%+v\nwant\n%+v", got, m)
+	}
+
+	cases := map[string]string{
+		"not JSON":           "scale: 10",
+		"bad log interval":   `{"use-case": "iot", "scale": 1, "timestamp-start": "2016-01-01T00:00:00Z", "timestamp-end": "2016-01-02T00:00:00Z", "log-interval": "10"}`,
+		"no use case":        `{"scale": 1, "timestamp-start": "2016-01-01T00:00:00Z", "timestamp-end": "2016-01-02T00:00:00Z"}`,
+		"no scale":           `{"use-case": "iot", "timestamp-start": "2016-01-01T00:00:00Z", "timestamp-end": "2016-01-02T00:00:00Z"}`,
+		"empty time range":   `{"use-case": "iot", "scale": 1, "timestamp-start": "2016-01-01T00:00:00Z", "timestamp-end": "2016-01-01T00:00:00Z"}`,
+		"no timestamp-start": `{"use-case": "iot", "scale": 1, "timestamp-end": "2016-01-02T00:00:00Z"}`,
+	}
+	for desc, data := range cases {
+		if err = ioutil.WriteFile(fileName, []byte(data), 0644); err != nil {
+			t.Fatalf("could not write manifest: %v", err)
+		}
+		_, err = ReadDatasetManifest(fileName)
+		if err == nil {
+			t.Errorf("%s: unexpected lack of error", desc)
+		} else if !strings.HasPrefix(err.Error(), "invalid dataset manifest") {
+			t.Errorf("%s: incorrect error: got %v", desc, err)
+		}
+	}
+
+	if _, err = ReadDatasetManifest(filepath.Join(dir, "missing.json")); err == nil {
+		t.Errorf("unexpected lack of error for a missing file")
+	}
+}
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+// RealtimeSimulator wraps a Simulator so that points are only returned once
+// the wall-clock time has reached their timestamp. Used with a simulator that
+// starts at the current time, each epoch is emitted when it becomes current,
+// producing a steady live load instead of a replay of historical data.
+type RealtimeSimulator struct {
+	Simulator
+	nowFn   func() time.Time
+	sleepFn func(time.Duration)
+}
+
+// NewRealtimeSimulator returns a RealtimeSimulator pacing the points of sim.
+func NewRealtimeSimulator(sim Simulator) *RealtimeSimulator {
+	return &RealtimeSimulator{
+		Simulator: sim,
+		nowFn:     time.Now,
+		sleepFn:   time.Sleep,
+	}
+}
+
+// Next advances p to the next state of the wrapped simulator, blocking until
+// the timestamp of the point is not in the future.
+func (s *RealtimeSimulator) Next(p *data.Point) bool {
+	write := s.Simulator.Next(p)
+	if ts := p.Timestamp(); ts != nil {
+		if wait := ts.Sub(s.nowFn()); wait > 0 {
+			s.sleepFn(wait)
+		}
+	}
+	return write
+}
+
+// RealtimeInterval returns the start and end of the simulated interval of a
+// realtime run. The interval starts at now truncated to the log interval, and
+// lasts for duration, or practically forever if duration is 0.
+func RealtimeInterval(now time.Time, logInterval, duration time.Duration) (time.Time, time.Time) {
+	start := now.UTC().Truncate(logInterval)
+	if duration <= 0 {
+		duration = realtimeForever
+	}
+	return start, start.Add(duration)
+}
+
+// realtimeForever is the length of a realtime run that has no duration
+const realtimeForever = 100 * 365 * 24 * time.Hour
@@ -0,0 +1,71 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+type timestampSimulator struct {
+	Simulator
+	timestamps []time.Time
+	made       int
+}
+
+func (s *timestampSimulator) Finished() bool {
+	return s.made >= len(s.timestamps)
+}
+
+func (s *timestampSimulator) Next(p *data.Point) bool {
+	p.SetTimestamp(&s.timestamps[s.made])
+	s.made++
+	return true
+}
+
+func TestRealtimeSimulatorNext(t *testing.T) {
+	now := time.Unix(100, 0)
+	sim := &timestampSimulator{timestamps: []time.Time{
+		now.Add(-time.Second),
+		now,
+		now.Add(10 * time.Second),
+		now.Add(10 * time.Second),
+	}}
+	rs := NewRealtimeSimulator(sim)
+	var slept []time.Duration
+	rs.nowFn = func() time.Time { return now }
+	rs.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+		now = now.Add(d)
+	}
+
+	p := data.NewPoint()
+	for !rs.Finished() {
+		if !rs.Next(p) {
+			t.Errorf("unexpected point not to be written")
+		}
+		if p.Timestamp().After(now) {
+			t.Errorf("point with timestamp %v returned at %v", p.Timestamp(), now)
+		}
+		p.Reset()
+	}
+	if len(slept) != 1 || slept[0] != 10*time.Second {
+		t.Errorf("incorrect sleeps: got %v want [10s]", slept)
+	}
+}
+
+func TestRealtimeInterval(t *testing.T) {
+	now := time.Date(2020, 1, 1, 10, 0, 17, 500, time.UTC)
+	start, end := RealtimeInterval(now, 10*time.Second, time.Minute)
+	if want := time.Date(2020, 1, 1, 10, 0, 10, 0, time.UTC); !start.Equal(want) {
+		t.Errorf("incorrect start: got %v want %v", start, want)
+	}
+	if want := start.Add(time.Minute); !end.Equal(want) {
+		t.Errorf("incorrect end: got %v want %v", end, want)
+	}
+
+	_, end = RealtimeInterval(now, 10*time.Second, 0)
+	if want := start.Add(realtimeForever); !end.Equal(want) {
+		t.Errorf("incorrect end without duration: got %v want %v", end, want)
+	}
+}
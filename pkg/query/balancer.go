@@ -0,0 +1,125 @@
+package query
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+// Strategies to spread the queries over the endpoints of a runner
+const (
+	// BalanceWorker connects each worker to one endpoint, in a round-robin
+	// fashion over the workers, and sends all its queries there
+	BalanceWorker = "worker"
+	// BalanceRoundRobin sends each query to the endpoint after the one the
+	// previous query was sent to, whichever worker runs it
+	BalanceRoundRobin = "round-robin"
+	// BalanceRandom sends each query to an endpoint picked at random
+	BalanceRandom = "random"
+	// BalanceLeastOutstanding sends each query to the endpoint with the
+	// fewest queries in progress
+	BalanceLeastOutstanding = "least-outstanding"
+)
+
+var balanceStrategies = []string{BalanceWorker, BalanceRoundRobin, BalanceRandom, BalanceLeastOutstanding}
+
+// EndpointBalancer picks the endpoint each query is sent to, among the
+// endpoints (hosts or URLs) of a runner. It is safe for concurrent use by the
+// workers.
+type EndpointBalancer struct {
+	strategy    string
+	endpoints   []string
+	mu          sync.Mutex
+	next        int
+	outstanding []int
+	rand        *rand.Rand
+}
+
+// NewEndpointBalancer returns an EndpointBalancer spreading the queries over
+// endpoints with strategy, one of the Balance* constants.
+func NewEndpointBalancer(strategy string, endpoints []string) (*EndpointBalancer, error) {
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("no endpoint to send the queries to")
+	}
+	known := false
+	for _, s := range balanceStrategies {
+		known = known || s == strategy
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown balance strategy %q, expected one of %s", strategy, strings.Join(balanceStrategies, ", "))
+	}
+	return &EndpointBalancer{
+		strategy:    strategy,
+		endpoints:   endpoints,
+		outstanding: make([]int, len(endpoints)),
+		rand:        rand.New(rand.NewSource(1)),
+	}, nil
+}
+
+// Endpoints returns the endpoints the queries are spread over
+func (b *EndpointBalancer) Endpoints() []string {
+	return b.endpoints
+}
+
+// Pick returns the index of the endpoint the next query of worker workerNum
+// is sent to. Done must be called with it once the query has completed.
+func (b *EndpointBalancer) Pick(workerNum int) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var i int
+	switch b.strategy {
+	case BalanceWorker:
+		i = workerNum % len(b.endpoints)
+	case BalanceRoundRobin:
+		i = b.next
+		b.next = (b.next + 1) % len(b.endpoints)
+	case BalanceRandom:
+		i = b.rand.Intn(len(b.endpoints))
+	case BalanceLeastOutstanding:
+		// ties go to the endpoints in turn, rather than to the first one
+		i = b.next
+		for j := 1; j < len(b.endpoints); j++ {
+			k := (b.next + j) % len(b.endpoints)
+			if b.outstanding[k] < b.outstanding[i] {
+				i = k
+			}
+		}
+		b.next = (i + 1) % len(b.endpoints)
+	}
+	b.outstanding[i]++
+	return i
+}
+
+// Done marks the query sent to the endpoint i as completed
+func (b *EndpointBalancer) Done(i int) {
+	b.mu.Lock()
+	b.outstanding[i]--
+	b.mu.Unlock()
+}
+
+// EndpointError is the error of a query sent to an endpoint, so that a query
+// timing out is counted for its endpoint.
+type EndpointError struct {
+	Endpoint string
+	Err      error
+}
+
+func (e *EndpointError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error of the query, e.g. context.DeadlineExceeded.
+func (e *EndpointError) Unwrap() error {
+	return e.Err
+}
+
+// WithEndpoint returns err as the error of a query sent to endpoint, nil if
+// err is nil. The runners with several endpoints return the errors of their
+// queries with it.
+func WithEndpoint(endpoint string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &EndpointError{Endpoint: endpoint, Err: err}
+}
@@ -0,0 +1,136 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestNewEndpointBalancer(t *testing.T) {
+	if _, err := NewEndpointBalancer(BalanceRoundRobin, nil); err == nil {
+		t.Errorf("unexpected lack of error for no endpoint")
+	}
+	if _, err := NewEndpointBalancer("fastest", []string{"a"}); err == nil {
+		t.Errorf("unexpected lack of error for unknown strategy")
+	}
+	for _, s := range balanceStrategies {
+		b, err := NewEndpointBalancer(s, []string{"a", "b"})
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", s, err)
+		} else if !reflect.DeepEqual(b.Endpoints(), []string{"a", "b"}) {
+			t.Errorf("%s: incorrect endpoints: %v", s, b.Endpoints())
+		}
+	}
+}
+
+func TestEndpointBalancerPick(t *testing.T) {
+	endpoints := []string{"a", "b", "c"}
+	cases := []struct {
+		strategy string
+		workers  []int
+		want     []int
+	}{
+		{
+			strategy: BalanceWorker,
+			workers:  []int{0, 1, 2, 3, 4, 0},
+			want:     []int{0, 1, 2, 0, 1, 0},
+		},
+		{
+			strategy: BalanceRoundRobin,
+			workers:  []int{0, 0, 0, 0, 1, 1},
+			want:     []int{0, 1, 2, 0, 1, 2},
+		},
+	}
+	for _, c := range cases {
+		b, err := NewEndpointBalancer(c.strategy, endpoints)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, w := range c.workers {
+			got := b.Pick(w)
+			b.Done(got)
+			if got != c.want[i] {
+				t.Errorf("%s: query %d of worker %d: got endpoint %d want %d", c.strategy, i, w, got, c.want[i])
+			}
+		}
+	}
+}
+
+func TestEndpointBalancerPickRandom(t *testing.T) {
+	b, err := NewEndpointBalancer(BalanceRandom, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int]int{}
+	for i := 0; i < 300; i++ {
+		got := b.Pick(0)
+		b.Done(got)
+		if got < 0 || got > 2 {
+			t.Fatalf("endpoint out of range: %d", got)
+		}
+		seen[got]++
+	}
+	if len(seen) != 3 {
+		t.Errorf("not all endpoints picked: %v", seen)
+	}
+}
+
+func TestEndpointBalancerPickLeastOutstanding(t *testing.T) {
+	b, err := NewEndpointBalancer(BalanceLeastOutstanding, []string{"a", "b", "c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// no query in progress: the endpoints in turn
+	for _, want := range []int{0, 1, 2} {
+		if got := b.Pick(0); got != want {
+			t.Errorf("incorrect endpoint: got %d want %d", got, want)
+		}
+	}
+	// one in progress on each, the ones on a and c complete while the slow
+	// one on b does not: b is skipped even when it is its turn
+	for round := 0; round < 2; round++ {
+		b.Done(0)
+		b.Done(2)
+		for _, want := range []int{0, 2} {
+			if got := b.Pick(0); got != want {
+				t.Errorf("round %d: incorrect endpoint: got %d want %d", round, got, want)
+			}
+		}
+	}
+	if !reflect.DeepEqual(b.outstanding, []int{1, 1, 1}) {
+		t.Errorf("incorrect queries in progress: %v", b.outstanding)
+	}
+}
+
+func TestBenchmarkRunnerEndpointBalancer(t *testing.T) {
+	b := &BenchmarkRunner{}
+	eb, err := b.EndpointBalancer([]string{"a", "b"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if eb.strategy != BalanceWorker {
+		t.Errorf("incorrect default strategy: %s", eb.strategy)
+	}
+	b.Balance = "nope"
+	if _, err := b.EndpointBalancer([]string{"a"}); err == nil {
+		t.Errorf("unexpected lack of error for unknown strategy")
+	}
+}
+
+func TestWithEndpoint(t *testing.T) {
+	if err := WithEndpoint("host1", nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	err := WithEndpoint("host1", context.DeadlineExceeded)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error not wrapped: %v", err)
+	}
+	var endpointErr *EndpointError
+	if !errors.As(err, &endpointErr) || endpointErr.Endpoint != "host1" {
+		t.Errorf("incorrect endpoint error: %v", err)
+	}
+	if got := err.Error(); got != context.DeadlineExceeded.Error() {
+		t.Errorf("incorrect message: got %s want %s", got, context.DeadlineExceeded.Error())
+	}
+}
@@ -2,13 +2,16 @@ package query
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"runtime/pprof"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/spf13/pflag"
@@ -19,25 +22,33 @@ const (
 	labelAllQueries  = "all queries"
 	labelColdQueries = "cold queries"
 	labelWarmQueries = "warm queries"
+	labelColdSuffix  = " (cold)"
 
 	defaultReadSize = 4 << 20 // 4 MB
 )
 
 // BenchmarkRunnerConfig is the configuration of the benchmark runner.
 type BenchmarkRunnerConfig struct {
-	DBName           string `mapstructure:"db-name"`
-	Limit            uint64 `mapstructure:"max-queries"`
-	LimitRPS         uint64 `mapstructure:"max-rps"`
-	MemProfile       string `mapstructure:"memprofile"`
-	HDRLatenciesFile string `mapstructure:"hdr-latencies"`
-	Workers          uint   `mapstructure:"workers"`
-	PrintResponses   bool   `mapstructure:"print-responses"`
-	Debug            int    `mapstructure:"debug"`
-	FileName         string `mapstructure:"file"`
-	BurnIn           uint64 `mapstructure:"burn-in"`
-	PrintInterval    uint64 `mapstructure:"print-interval"`
-	PrewarmQueries   bool   `mapstructure:"prewarm-queries"`
-	ResultsFile      string `mapstructure:"results-file"`
+	DBName           string        `mapstructure:"db-name"`
+	Limit            uint64        `mapstructure:"max-queries"`
+	LimitRPS         uint64        `mapstructure:"max-rps"`
+	MemProfile       string        `mapstructure:"memprofile"`
+	HDRLatenciesFile string        `mapstructure:"hdr-latencies"`
+	Workers          uint          `mapstructure:"workers"`
+	PrintResponses   bool          `mapstructure:"print-responses"`
+	Debug            int           `mapstructure:"debug"`
+	FileName         string        `mapstructure:"file"`
+	QueryFormat      string        `mapstructure:"query-format"`
+	BurnIn           uint64        `mapstructure:"burn-in"`
+	PrintInterval    uint64        `mapstructure:"print-interval"`
+	PrewarmQueries   bool          `mapstructure:"prewarm-queries"`
+	ResultsFile      string        `mapstructure:"results-file"`
+	Balance          string        `mapstructure:"balance"`
+	WarmupDuration   time.Duration `mapstructure:"warmup-duration"`
+	CacheHooks       string        `mapstructure:"cache-hooks"`
+	CacheHookCommand string        `mapstructure:"cache-hook-command"`
+	CacheHookEvery   uint64        `mapstructure:"cache-hook-every"`
+	QueryTimeout     time.Duration `mapstructure:"query-timeout"`
 }
 
 // AddToFlagSet adds command line flags needed by the BenchmarkRunnerConfig to the flag set.
@@ -54,7 +65,14 @@ func (c BenchmarkRunnerConfig) AddToFlagSet(fs *pflag.FlagSet) {
 	fs.Bool("print-responses", false, "Pretty print response bodies for correctness checking (default false).")
 	fs.Int("debug", 0, "Whether to print debug messages.")
 	fs.String("file", "", "File name to read queries from")
+	fs.String("query-format", "", "Format of the queries: gob or jsonl (default jsonl for files ending in .jsonl, gob otherwise)")
 	fs.String("results-file", "", "Write the test results summary json to this file")
+	fs.Duration("warmup-duration", 0, "Run the queries for this long before collecting statistics, 0 = no warm-up. The warm-up queries count towards max-queries")
+	fs.String("cache-hooks", "", "Comma-separated list of the cache hooks of the database to run before the measured queries, see the database docs")
+	fs.String("cache-hook-command", "", "Shell command to run after the cache hooks of the database, e.g. a script dropping the OS page cache")
+	fs.Uint64("cache-hook-every", 0, "Also run the cache hooks before every N measured queries, 0 = only before the first one")
+	fs.Duration("query-timeout", 0, "Cancel the queries taking longer than this and count them as timed out, 0 = no timeout")
+	fs.String("balance", BalanceWorker, "How to spread the queries over several hosts or URLs: worker (each worker sticks to one), round-robin, random or least-outstanding")
 }
 
 // BenchmarkRunner contains the common components for running a query benchmarking
@@ -65,13 +83,27 @@ type BenchmarkRunner struct {
 	sp      statProcessor
 	scanner *scanner
 	ch      chan Query
+
+	// phases of the run, see dispatch
+	cacheHooks  map[string]CacheHook
+	hooks       []namedCacheHook
+	dispatching bool
+	inflight    sync.WaitGroup
+	warmingUp   int32
+	coldNext    int32
+
+	noContextWarning sync.Once
 }
 
 // NewBenchmarkRunner creates a new instance of BenchmarkRunner which is
 // common functionality to be used by query benchmarker programs
 func NewBenchmarkRunner(config BenchmarkRunnerConfig) *BenchmarkRunner {
 	runner := &BenchmarkRunner{BenchmarkRunnerConfig: config}
-	runner.scanner = newScanner(&runner.Limit)
+	format, err := FileFormat(runner.QueryFormat, runner.FileName)
+	if err != nil {
+		panic(err)
+	}
+	runner.scanner = newScanner(&runner.Limit).setFormat(format)
 	spArgs := &statProcessorArgs{
 		limit:            &runner.Limit,
 		printInterval:    runner.PrintInterval,
@@ -104,6 +136,16 @@ func (b *BenchmarkRunner) DatabaseName() string {
 	return b.DBName
 }
 
+// EndpointBalancer returns an EndpointBalancer spreading the queries over
+// endpoints with the --balance strategy
+func (b *BenchmarkRunner) EndpointBalancer(endpoints []string) (*EndpointBalancer, error) {
+	strategy := b.Balance
+	if len(strategy) == 0 {
+		strategy = BalanceWorker
+	}
+	return NewEndpointBalancer(strategy, endpoints)
+}
+
 // ProcessorCreate is a function that creates a new Processor (called in Run)
 type ProcessorCreate func() Processor
 
@@ -116,6 +158,17 @@ type Processor interface {
 	ProcessQuery(q Query, isWarm bool) ([]*Stat, error)
 }
 
+// ContextProcessor is a Processor whose queries can be cancelled, used instead
+// of ProcessQuery when the Processor implements it. The context passed has the
+// deadline of --query-timeout, if any.
+type ContextProcessor interface {
+	Processor
+
+	// ProcessQueryContext handles a given query and reports its stats,
+	// returning an error if the context is done before the query completes
+	ProcessQueryContext(ctx context.Context, q Query, isWarm bool) ([]*Stat, error)
+}
+
 // GetBufferedReader returns the buffered Reader that should be used by the loader
 func (b *BenchmarkRunner) GetBufferedReader() *bufio.Reader {
 	if b.br == nil {
@@ -148,6 +201,16 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, processorCreateFn ProcessorC
 	}
 	b.ch = make(chan Query, b.Workers)
 
+	hooks, err := b.resolveCacheHooks()
+	if err != nil {
+		panic(err)
+	}
+	b.hooks = hooks
+	b.dispatching = b.WarmupDuration > 0 || len(b.hooks) > 0
+	for _, h := range hooks {
+		spArgs.cacheHooks = spArgs.cacheHooks || h.Cold
+	}
+
 	// Launch the stats processor:
 	go b.sp.process(b.Workers)
 
@@ -163,8 +226,16 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, processorCreateFn ProcessorC
 	// Read in jobs, closing the job channel when done:
 	// Wall clock start time
 	wallStart := time.Now()
-	b.scanner.setReader(b.GetBufferedReader()).scan(queryPool, b.ch)
-	close(b.ch)
+	if b.dispatching {
+		// the warm-up and the cache hooks sit between the scanner and the workers
+		in := make(chan Query, b.Workers)
+		go b.dispatch(in)
+		b.scanner.setReader(b.GetBufferedReader()).scan(queryPool, in)
+		close(in)
+	} else {
+		b.scanner.setReader(b.GetBufferedReader()).scan(queryPool, b.ch)
+		close(b.ch)
+	}
 
 	// Block for workers to finish sending requests, closing the stats channel when done:
 	wg.Wait()
@@ -173,7 +244,7 @@ func (b *BenchmarkRunner) Run(queryPool *sync.Pool, processorCreateFn ProcessorC
 	// Wall clock end time
 	wallEnd := time.Now()
 	wallTook := wallEnd.Sub(wallStart)
-	_, err := fmt.Printf("wall clock time: %fsec\n", float64(wallTook.Nanoseconds())/1e9)
+	_, err = fmt.Printf("wall clock time: %fsec\n", float64(wallTook.Nanoseconds())/1e9)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -222,10 +293,13 @@ func (b *BenchmarkRunner) processorHandler(wg *sync.WaitGroup, rateLimiter *rate
 		r := rateLimiter.Reserve()
 		time.Sleep(r.Delay())
 
-		stats, err := processor.ProcessQuery(query, false)
+		stats, err := b.processQuery(processor, query, false)
 		if err != nil {
 			panic(err)
 		}
+		if b.dispatching {
+			b.markStats(stats, atomic.CompareAndSwapInt32(&b.coldNext, 1, 0))
+		}
 		b.sp.send(stats)
 
 		// If PrewarmQueries is set, we run the query as 'cold' first (see above),
@@ -234,17 +308,63 @@ func (b *BenchmarkRunner) processorHandler(wg *sync.WaitGroup, rateLimiter *rate
 		spArgs := b.sp.getArgs()
 		if spArgs.prewarmQueries {
 			// Warm run
-			stats, err = processor.ProcessQuery(query, true)
+			stats, err = b.processQuery(processor, query, true)
 			if err != nil {
 				panic(err)
 			}
+			if b.dispatching {
+				b.markStats(stats, false)
+			}
 			b.sp.sendWarm(stats)
 		}
 		queryPool.Put(query)
+		if b.dispatching {
+			b.inflight.Done()
+		}
 	}
 	wg.Done()
 }
 
+// processQuery runs the query with the processor, with the deadline of
+// --query-timeout when the processor is a ContextProcessor. A query that times
+// out is reported as such under its label, and its endpoint when its error is
+// an EndpointError, instead of failing the run.
+func (b *BenchmarkRunner) processQuery(processor Processor, q Query, isWarm bool) ([]*Stat, error) {
+	cp, ok := processor.(ContextProcessor)
+	if !ok {
+		if b.QueryTimeout > 0 {
+			b.noContextWarning.Do(func() {
+				fmt.Fprintf(os.Stderr, "warning: --query-timeout is not supported by this runner, the queries are not cancelled\n")
+			})
+		}
+		return processor.ProcessQuery(q, isWarm)
+	}
+
+	ctx := context.Background()
+	if b.QueryTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, b.QueryTimeout)
+		defer cancel()
+	}
+	start := time.Now()
+	stats, err := cp.ProcessQueryContext(ctx, q, isWarm)
+	// clients report a deadline enforced by the server, e.g. the maxTimeMS of
+	// MongoDB, by wrapping context.DeadlineExceeded
+	if err != nil && (ctx.Err() == context.DeadlineExceeded || errors.Is(err, context.DeadlineExceeded)) {
+		if b.Debug > 0 {
+			fmt.Fprintf(os.Stderr, "query %d timed out: %v\n", q.GetID(), err)
+		}
+		took := float64(time.Since(start).Nanoseconds()) / 1e6
+		stat := GetStat().Init(q.HumanLabelName(), took).SetTimedOut()
+		var endpointErr *EndpointError
+		if errors.As(err, &endpointErr) {
+			stat.SetEndpoint(endpointErr.Endpoint)
+		}
+		return []*Stat{stat}, nil
+	}
+	return stats, err
+}
+
 func getRateLimiter(limitRPS uint64, workers uint) *rate.Limiter {
 	var requestRate = rate.Inf
 	var requestBurst = 0
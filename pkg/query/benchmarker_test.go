@@ -1,6 +1,9 @@
 package query
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"golang.org/x/time/rate"
 	"io/ioutil"
 	"math"
@@ -9,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 type testProcessor struct {
@@ -339,6 +343,66 @@ func (mp *mockProcessor) ProcessQuery(q Query, isWarm bool) ([]*Stat, error) {
 	return mp.processRes, mp.processErr
 }
 
+// contextProcessor returns err, or waits for its context to be done when err
+// is nil
+type contextProcessor struct {
+	testProcessor
+	err error
+}
+
+func (p *contextProcessor) ProcessQueryContext(ctx context.Context, q Query, _ bool) ([]*Stat, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Second):
+		return []*Stat{GetStat().Init(q.HumanLabelName(), 1000.0)}, nil
+	}
+}
+
+func TestProcessQueryTimeout(t *testing.T) {
+	b := NewBenchmarkRunner(BenchmarkRunnerConfig{QueryTimeout: 10 * time.Millisecond})
+	q := testQueryPool.Get().(*testQuery)
+	q.HumanLabel = []byte("label")
+
+	stats, err := b.processQuery(&contextProcessor{}, q, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stats) != 1 || !stats[0].isTimedOut || string(stats[0].label) != "label" {
+		t.Fatalf("incorrect stats for a timed-out query: %v", stats)
+	}
+	if stats[0].value < 10.0 || stats[0].value > 500.0 {
+		t.Errorf("incorrect latency of the timed-out query: %f", stats[0].value)
+	}
+
+	// a deadline enforced by the server
+	wrapped := fmt.Errorf("maxTimeMS expired: %w", context.DeadlineExceeded)
+	stats, err = b.processQuery(&contextProcessor{err: wrapped}, q, false)
+	if err != nil || len(stats) != 1 || !stats[0].isTimedOut {
+		t.Errorf("server timeout not reported as such: %v %v", stats, err)
+	}
+
+	// the endpoint the query timed out on
+	stats, err = b.processQuery(&contextProcessor{err: WithEndpoint("host1", wrapped)}, q, false)
+	if err != nil || len(stats) != 1 || !stats[0].isTimedOut || string(stats[0].endpoint) != "host1" {
+		t.Errorf("endpoint of the timed-out query not reported: %v %v", stats, err)
+	}
+
+	other := errors.New("syntax error")
+	if _, err = b.processQuery(&contextProcessor{err: other}, q, false); err != other {
+		t.Errorf("incorrect error: got %v want %v", err, other)
+	}
+
+	// the Processor without context still works
+	p := &testProcessor{}
+	if _, err = b.processQuery(p, q, false); err != nil || p.count != 1 {
+		t.Errorf("query not run by the Processor: %d %v", p.count, err)
+	}
+}
+
 func TestGetRateLimiter(t *testing.T) {
 	type args struct {
 		limitRPS uint64
@@ -0,0 +1,154 @@
+package query
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CacheHook sets the caches of the database to a known state before the
+// queries following it. The workers are idle while it runs.
+type CacheHook struct {
+	// Run runs the hook
+	Run func() error
+	// Cold tells whether the hook leaves the caches cold, e.g. by dropping
+	// them, rather than warm, e.g. by loading the tables into them. Only the
+	// query following a cold hook is reported as cold.
+	Cold bool
+}
+
+// namedCacheHook is a CacheHook selected with --cache-hooks, or the
+// --cache-hook-command one
+type namedCacheHook struct {
+	name string
+	CacheHook
+}
+
+// SetCacheHooks sets the cache hooks of the database that can be selected
+// with --cache-hooks, by name. It must be called before Run.
+func (b *BenchmarkRunner) SetCacheHooks(hooks map[string]CacheHook) {
+	b.cacheHooks = hooks
+}
+
+// resolveCacheHooks returns the cache hooks selected with --cache-hooks, in
+// order, followed by the --cache-hook-command one
+func (b *BenchmarkRunner) resolveCacheHooks() ([]namedCacheHook, error) {
+	var hooks []namedCacheHook
+	for _, name := range strings.Split(b.CacheHooks, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		hook, ok := b.cacheHooks[name]
+		if !ok {
+			names := make([]string, 0, len(b.cacheHooks))
+			for n := range b.cacheHooks {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			if len(names) == 0 {
+				return nil, fmt.Errorf("unknown cache hook %q: the database has none, only --cache-hook-command can be used", name)
+			}
+			return nil, fmt.Errorf("unknown cache hook %q, expected one of %s", name, strings.Join(names, ", "))
+		}
+		hooks = append(hooks, namedCacheHook{name: name, CacheHook: hook})
+	}
+	if len(b.CacheHookCommand) > 0 {
+		hooks = append(hooks, namedCacheHook{name: "command", CacheHook: commandCacheHook(b.CacheHookCommand)})
+	}
+	return hooks, nil
+}
+
+// commandCacheHook returns a CacheHook running the shell command cmd, with its
+// output going to stderr. The command is expected to drop the caches, the hook
+// is cold.
+func commandCacheHook(cmd string) CacheHook {
+	return CacheHook{
+		Run: func() error {
+			c := exec.Command("sh", "-c", cmd)
+			c.Stdout = os.Stderr
+			c.Stderr = os.Stderr
+			return c.Run()
+		},
+		Cold: true,
+	}
+}
+
+// runCacheHooks runs the cache hooks in order, returning whether any of them
+// left the caches cold
+func (b *BenchmarkRunner) runCacheHooks() bool {
+	cold := false
+	for _, h := range b.hooks {
+		if err := h.Run(); err != nil {
+			panic(fmt.Sprintf("cache hook %s failed: %v", h.name, err))
+		}
+		cold = cold || h.Cold
+		if b.Debug > 0 {
+			fmt.Fprintf(os.Stderr, "cache hook %s done\n", h.name)
+		}
+	}
+	return cold
+}
+
+// dispatch forwards the queries read in to the workers, in phases:
+//   - for --warmup-duration, the queries of the warm-up, whose stats are not
+//     reported,
+//   - then the cache hooks, before the first measured query and every
+//     --cache-hook-every queries after it. The queries in progress complete
+//     before the hooks run, and the query following them runs alone. It is
+//     reported as cold when one of the hooks left the caches cold.
+//
+// It closes the channel of the workers once all the queries are forwarded.
+func (b *BenchmarkRunner) dispatch(in <-chan Query) {
+	defer close(b.ch)
+	var warmupEnd time.Time
+	if b.WarmupDuration > 0 {
+		atomic.StoreInt32(&b.warmingUp, 1)
+		warmupEnd = time.Now().Add(b.WarmupDuration)
+	}
+	hooksDue := len(b.hooks) > 0
+	measured := uint64(0)
+	for q := range in {
+		warmingUp := atomic.LoadInt32(&b.warmingUp) == 1
+		if warmingUp && !time.Now().Before(warmupEnd) {
+			// the stats of the queries in progress are still the warm-up ones
+			b.inflight.Wait()
+			atomic.StoreInt32(&b.warmingUp, 0)
+			warmingUp = false
+		}
+		if !warmingUp && b.CacheHookEvery > 0 && measured > 0 && measured%b.CacheHookEvery == 0 {
+			hooksDue = len(b.hooks) > 0
+		}
+
+		hooks := !warmingUp && hooksDue
+		if hooks {
+			b.inflight.Wait()
+			if b.runCacheHooks() {
+				atomic.StoreInt32(&b.coldNext, 1)
+			}
+			hooksDue = false
+		}
+		b.inflight.Add(1)
+		b.ch <- q
+		if hooks {
+			b.inflight.Wait()
+		}
+		if !warmingUp {
+			measured++
+		}
+	}
+}
+
+// markStats flags the stats of a query as the ones of the warm-up, or as cold
+// when the query ran right after the cache hooks
+func (b *BenchmarkRunner) markStats(stats []*Stat, cold bool) {
+	warmup := atomic.LoadInt32(&b.warmingUp) == 1
+	for _, s := range stats {
+		s.isWarmup = warmup
+		s.isCold = cold
+	}
+}
@@ -0,0 +1,189 @@
+package query
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestResolveCacheHooks(t *testing.T) {
+	noop := CacheHook{Run: func() error { return nil }}
+	b := NewBenchmarkRunner(BenchmarkRunnerConfig{
+		CacheHooks:       "b, a",
+		CacheHookCommand: "true",
+	})
+	b.SetCacheHooks(map[string]CacheHook{"a": noop, "b": noop})
+	hooks, err := b.resolveCacheHooks()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	names := []string{}
+	for _, h := range hooks {
+		names = append(names, h.name)
+	}
+	if got := strings.Join(names, ","); got != "b,a,command" {
+		t.Errorf("incorrect hooks: got %s want b,a,command", got)
+	}
+	if err := hooks[2].Run(); err != nil {
+		t.Errorf("unexpected error of the command hook: %v", err)
+	}
+	if !hooks[2].Cold {
+		t.Errorf("the command hook is not cold")
+	}
+
+	b.CacheHooks = "a,c"
+	if _, err := b.resolveCacheHooks(); err == nil || !strings.Contains(err.Error(), "expected one of a, b") {
+		t.Errorf("unexpected error for an unknown hook: %v", err)
+	}
+	b.SetCacheHooks(nil)
+	if _, err := b.resolveCacheHooks(); err == nil {
+		t.Errorf("unexpected lack of error for a database without hooks")
+	}
+}
+
+// sleepingProcessor takes a millisecond per query and tracks the queries in
+// progress over all the workers
+type sleepingProcessor struct {
+	running *int32
+}
+
+func (p *sleepingProcessor) Init(_ int) {}
+
+func (p *sleepingProcessor) ProcessQuery(_ Query, _ bool) ([]*Stat, error) {
+	atomic.AddInt32(p.running, 1)
+	time.Sleep(time.Millisecond)
+	atomic.AddInt32(p.running, -1)
+	return []*Stat{GetStat().Init([]byte("q"), 1.0)}, nil
+}
+
+// runDispatched runs n queries through dispatch with the given workers,
+// returning the stats sent in order
+func runDispatched(b *BenchmarkRunner, workers, n int, running *int32) []*Stat {
+	var mu sync.Mutex
+	var sent []*Stat
+	b.sp = &mockStatProcessor{
+		args: &statProcessorArgs{},
+		onSend: func(stats []*Stat) {
+			mu.Lock()
+			sent = append(sent, stats...)
+			mu.Unlock()
+		},
+	}
+	b.ch = make(chan Query, workers)
+	b.dispatching = true
+
+	var wg sync.WaitGroup
+	rateLimiter := rate.NewLimiter(rate.Inf, 0)
+	qPool := &testQueryPool
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go b.processorHandler(&wg, rateLimiter, qPool, &sleepingProcessor{running: running}, i)
+	}
+	in := make(chan Query, workers)
+	go b.dispatch(in)
+	for i := 0; i < n; i++ {
+		in <- qPool.Get().(*testQuery)
+	}
+	close(in)
+	wg.Wait()
+	return sent
+}
+
+func TestDispatchCacheHooks(t *testing.T) {
+	running := int32(0)
+	hooks := 0
+	b := &BenchmarkRunner{BenchmarkRunnerConfig: BenchmarkRunnerConfig{CacheHookEvery: 3}}
+	b.hooks = []namedCacheHook{{name: "count", CacheHook: CacheHook{
+		Run: func() error {
+			if r := atomic.LoadInt32(&running); r != 0 {
+				t.Errorf("cache hook ran with %d queries in progress", r)
+			}
+			hooks++
+			return nil
+		},
+		Cold: true,
+	}}}
+
+	sent := runDispatched(b, 2, 7, &running)
+	if len(sent) != 7 {
+		t.Fatalf("incorrect number of stats: got %d want 7", len(sent))
+	}
+	// before the 1st, 4th and 7th queries
+	if hooks != 3 {
+		t.Errorf("incorrect number of hook runs: got %d want 3", hooks)
+	}
+	cold := 0
+	for _, s := range sent {
+		if s.isWarmup {
+			t.Errorf("unexpected warm-up stat")
+		}
+		if s.isCold {
+			cold++
+		}
+	}
+	if cold != 3 {
+		t.Errorf("incorrect number of cold stats: got %d want 3", cold)
+	}
+}
+
+func TestDispatchWarmCacheHooks(t *testing.T) {
+	running := int32(0)
+	hooks := 0
+	b := &BenchmarkRunner{BenchmarkRunnerConfig: BenchmarkRunnerConfig{CacheHookEvery: 3}}
+	b.hooks = []namedCacheHook{{name: "prewarm", CacheHook: CacheHook{Run: func() error {
+		hooks++
+		return nil
+	}}}}
+
+	sent := runDispatched(b, 2, 7, &running)
+	if len(sent) != 7 {
+		t.Fatalf("incorrect number of stats: got %d want 7", len(sent))
+	}
+	if hooks != 3 {
+		t.Errorf("incorrect number of hook runs: got %d want 3", hooks)
+	}
+	for i, s := range sent {
+		if s.isCold {
+			t.Errorf("stat %d is cold after a hook leaving the caches warm", i)
+		}
+	}
+}
+
+func TestDispatchWarmup(t *testing.T) {
+	running := int32(0)
+	b := &BenchmarkRunner{BenchmarkRunnerConfig: BenchmarkRunnerConfig{WarmupDuration: 20 * time.Millisecond}}
+	b.hooks = []namedCacheHook{{name: "noop", CacheHook: CacheHook{Run: func() error { return nil }, Cold: true}}}
+
+	sent := runDispatched(b, 2, 100, &running)
+	if len(sent) != 100 {
+		t.Fatalf("incorrect number of stats: got %d want 100", len(sent))
+	}
+	warmup, cold := 0, 0
+	for i, s := range sent {
+		if s.isWarmup {
+			if warmup != i {
+				t.Fatalf("warm-up stat %d sent after the measured ones", i)
+			}
+			warmup++
+		}
+		if s.isCold {
+			cold++
+			if i != warmup {
+				t.Errorf("cold stat %d is not the first after the warm-up (%d)", i, warmup)
+			}
+		}
+	}
+	if warmup == 0 || warmup == len(sent) {
+		t.Errorf("incorrect number of warm-up stats: %d", warmup)
+	}
+	if cold != 1 {
+		t.Errorf("incorrect number of cold stats: got %d want 1", cold)
+	}
+	if atomic.LoadInt32(&b.warmingUp) != 0 {
+		t.Errorf("still warming up after the run")
+	}
+}
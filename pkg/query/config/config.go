@@ -5,6 +5,7 @@ import (
 	"github.com/spf13/pflag"
 	"github.com/timescale/tsbs/internal/utils"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/query"
 )
 
 const ErrEmptyQueryType = "query type cannot be empty"
@@ -19,6 +20,12 @@ type QueryGeneratorConfig struct {
 	QueryType            string `mapstructure:"query-type"`
 	InterleavedGroupID   uint   `mapstructure:"interleaved-generation-group-id"`
 	InterleavedNumGroups uint   `mapstructure:"interleaved-generation-groups"`
+	HostDistribution     string `mapstructure:"host-distribution"`
+	TimeDistribution     string `mapstructure:"time-distribution"`
+	QueryFormat          string `mapstructure:"query-format"`
+	QueryTemplateFile    string `mapstructure:"query-templates"`
+	QueryTemplate        string `mapstructure:"query-template"`
+	DatasetManifest      string `mapstructure:"dataset-manifest"`
 
 	// TODO - I think this needs some rethinking, but a simple, elegant solution escapes me right now
 	TimescaleUseJSON       bool `mapstructure:"timescale-use-json"`
@@ -27,6 +34,12 @@ type QueryGeneratorConfig struct {
 
 	ClickhouseUseTags bool `mapstructure:"clickhouse-use-tags"`
 
+	PrometheusUseRemoteRead bool `mapstructure:"prometheus-use-remote-read"`
+
+	GraphiteTemplate string `mapstructure:"graphite-template"`
+
+	PGWireDialect string `mapstructure:"pgwire-dialect"`
+
 	MongoUseNaive bool   `mapstructure:"mongo-use-native"`
 	DbName        string `mapstructure:"db-name"`
 }
@@ -43,6 +56,17 @@ func (c *QueryGeneratorConfig) Validate() error {
 	}
 
 	err = utils.ValidateGroups(c.InterleavedGroupID, c.InterleavedNumGroups)
+	if err != nil {
+		return err
+	}
+
+	if _, err = utils.ParseDistribution(c.HostDistribution); err != nil {
+		return err
+	}
+	if _, err = utils.ParseDistribution(c.TimeDistribution); err != nil {
+		return err
+	}
+	_, err = query.FileFormat(c.QueryFormat, c.File)
 	return err
 }
 
@@ -55,12 +79,26 @@ func (c *QueryGeneratorConfig) AddToFlagSet(fs *pflag.FlagSet) {
 		"Group (0-indexed) to perform round-robin serialization within. Use this to scale up data generation to multiple processes.")
 	fs.Uint("interleaved-generation-groups", 1,
 		"The number of round-robin serialization groups. Use this to scale up data generation to multiple processes.")
+	fs.String("query-format", "",
+		"Format of the queries: gob or jsonl, one query per line of JSON (default jsonl for files ending in .jsonl, gob otherwise)")
+	fs.String("query-templates", "",
+		"YAML file of user-defined query templates, generated with the template query type of the devops and cpu-only use cases")
+	fs.String("query-template", "", "Name of the query template to generate, needed when the file has more than one")
+	fs.String("dataset-manifest", "",
+		"Manifest written by tsbs_generate_data --dataset-manifest, giving the use case, scale and time range of the queries. The ones given explicitly are checked against it")
+	fs.String("host-distribution", utils.DistributionUniform,
+		"Distribution the hosts or trucks of the queries are picked with: uniform, zipf:<s> or hotset:<percent>[:<hit percent>]. The lowest numbered are the hottest.")
+	fs.String("time-distribution", utils.DistributionUniform,
+		"Distribution the time windows of the queries are picked with: uniform, zipf:<s> or hotset:<percent>[:<hit percent>]. The most recent are the hottest.")
 
 	fs.Bool("clickhouse-use-tags", true, "ClickHouse only: Use separate tags table when querying")
+	fs.Bool("prometheus-use-remote-read", false, "Prometheus only: Generate remote-read requests instead of PromQL queries")
+	fs.String("graphite-template", "", "Graphite only: Template the data was loaded with, e.g. {region}.{hostname}.{measurement}.{field} (empty = tagged series)")
+	fs.String("pgwire-dialect", "postgres", "pgwire only: SQL dialect of the queried database: timescaledb (time_bucket), postgres and cratedb (date_trunc), questdb (SAMPLE BY) or greptimedb (date_bin)")
 	fs.Bool("mongo-use-naive", true, "MongoDB only: Generate queries for the 'naive' data storage format for Mongo")
 	fs.Bool("timescale-use-json", false, "TimescaleDB only: Use separate JSON tags table when querying")
 	fs.Bool("timescale-use-tags", true, "TimescaleDB only: Use separate tags table when querying")
 	fs.Bool("timescale-use-time-bucket", true, "TimescaleDB only: Use time bucket. Set to false to test on native PostgreSQL")
 
-	fs.String("db-name", "benchmark", "Specify database name. Timestream requires it in order to generate the queries")
+	fs.String("db-name", "benchmark", "Specify database name. Timestream and Elasticsearch require it in order to generate the queries")
 }
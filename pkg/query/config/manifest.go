@@ -0,0 +1,64 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+// manifestEndMargin is added to the end of the data for the end of the
+// queries, as the end of the data is exclusive.
+const manifestEndMargin = time.Second
+
+// cpuUseCases are the use cases whose data have the cpu measurement all the
+// devops queries read.
+var cpuUseCases = []string{common.UseCaseDevops, common.UseCaseCPUOnly}
+
+// ApplyDatasetManifest sets the use case, scale and time range of the queries
+// from the manifest m of the data they run against. The ones set explicitly,
+// as told by isSet with the name of their flag, are kept but must be
+// consistent with the data: a use case of the same measurements, a scale no
+// larger and a time range within the data.
+func (c *QueryGeneratorConfig) ApplyDatasetManifest(m *common.DatasetManifest, isSet func(flag string) bool) error {
+	if !isSet("use-case") {
+		c.Use = m.Use
+	} else if !compatibleUseCases(c.Use, m.Use) {
+		return fmt.Errorf("use case '%s' is inconsistent with the dataset of use case '%s'", c.Use, m.Use)
+	}
+
+	if !isSet("scale") {
+		c.Scale = m.Scale
+	} else if c.Scale > m.Scale {
+		return fmt.Errorf("scale %d is larger than the scale %d of the dataset", c.Scale, m.Scale)
+	}
+
+	dataEnd := m.TimeEnd.Add(manifestEndMargin)
+	if !isSet("timestamp-start") {
+		c.TimeStart = m.TimeStart.Format(time.RFC3339)
+	} else if start, err := utils.ParseUTCTime(c.TimeStart); err != nil {
+		return fmt.Errorf("cannot parse time from string '%s': %v", c.TimeStart, err)
+	} else if start.Before(m.TimeStart) || !start.Before(dataEnd) {
+		return fmt.Errorf("timestamp-start %s is outside of the dataset from %s to %s",
+			c.TimeStart, m.TimeStart.Format(time.RFC3339), m.TimeEnd.Format(time.RFC3339))
+	}
+	if !isSet("timestamp-end") {
+		c.TimeEnd = dataEnd.Format(time.RFC3339)
+	} else if end, err := utils.ParseUTCTime(c.TimeEnd); err != nil {
+		return fmt.Errorf("cannot parse time from string '%s': %v", c.TimeEnd, err)
+	} else if !end.After(m.TimeStart) || end.After(dataEnd) {
+		return fmt.Errorf("timestamp-end %s is outside of the dataset from %s to %s",
+			c.TimeEnd, m.TimeStart.Format(time.RFC3339), m.TimeEnd.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// compatibleUseCases tells whether the queries of a use case can run against
+// the data of another.
+func compatibleUseCases(queries, data string) bool {
+	if queries == data {
+		return true
+	}
+	return utils.IsIn(queries, cpuUseCases) && utils.IsIn(data, cpuUseCases)
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/internal/utils"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+func testManifest() *common.DatasetManifest {
+	return &common.DatasetManifest{
+		Format:      "timescaledb",
+		Use:         common.UseCaseCPUOnly,
+		Scale:       100,
+		Seed:        123,
+		TimeStart:   time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		TimeEnd:     time.Date(2016, 1, 4, 0, 0, 0, 0, time.UTC),
+		LogInterval: 10 * time.Second,
+	}
+}
+
+func TestApplyDatasetManifest(t *testing.T) {
+	c := &QueryGeneratorConfig{BaseConfig: common.BaseConfig{Seed: 7}}
+	if err := c.ApplyDatasetManifest(testManifest(), func(string) bool { return false }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Use != common.UseCaseCPUOnly || c.Scale != 100 || c.Seed != 7 {
+		t.Errorf("incorrect config: got %+v", c.BaseConfig)
+	}
+	if c.TimeStart != "2016-01-01T00:00:00Z" || c.TimeEnd != "2016-01-04T00:00:01Z" {
+		t.Errorf("incorrect time range: got %s - %s", c.TimeStart, c.TimeEnd)
+	}
+
+	set := []string{"use-case", "scale", "timestamp-start", "timestamp-end"}
+	c = &QueryGeneratorConfig{BaseConfig: common.BaseConfig{
+		Use:       common.UseCaseDevops,
+		Scale:     10,
+		TimeStart: "2016-01-02T00:00:00Z",
+		TimeEnd:   "2016-01-04T00:00:01Z",
+	}}
+	if err := c.ApplyDatasetManifest(testManifest(), func(flag string) bool { return utils.IsIn(flag, set) }); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.Use != common.UseCaseDevops || c.Scale != 10 || c.TimeStart != "2016-01-02T00:00:00Z" || c.TimeEnd != "2016-01-04T00:00:01Z" {
+		t.Errorf("incorrect config with the flags set: got %+v", c.BaseConfig)
+	}
+}
+
+func TestApplyDatasetManifestErrors(t *testing.T) {
+	cases := []struct {
+		desc string
+		base common.BaseConfig
+		set  string
+		want string
+	}{
+		{
+			desc: "use case",
+			base: common.BaseConfig{Use: common.UseCaseIoT},
+			set:  "use-case",
+			want: "use case 'iot' is inconsistent with the dataset of use case 'cpu-only'",
+		},
+		{
+			desc: "scale",
+			base: common.BaseConfig{Scale: 1000},
+			set:  "scale",
+			want: "scale 1000 is larger than the scale 100 of the dataset",
+		},
+		{
+			desc: "start before the data",
+			base: common.BaseConfig{TimeStart: "2015-12-31T00:00:00Z"},
+			set:  "timestamp-start",
+			want: "timestamp-start 2015-12-31T00:00:00Z is outside of the dataset from 2016-01-01T00:00:00Z to 2016-01-04T00:00:00Z",
+		},
+		{
+			desc: "start after the data",
+			base: common.BaseConfig{TimeStart: "2016-01-05T00:00:00Z"},
+			set:  "timestamp-start",
+			want: "timestamp-start 2016-01-05T00:00:00Z is outside of the dataset",
+		},
+		{
+			desc: "end after the data",
+			base: common.BaseConfig{TimeEnd: "2016-01-04T00:00:02Z"},
+			set:  "timestamp-end",
+			want: "timestamp-end 2016-01-04T00:00:02Z is outside of the dataset",
+		},
+		{
+			desc: "bad end",
+			base: common.BaseConfig{TimeEnd: "tomorrow"},
+			set:  "timestamp-end",
+			want: "cannot parse time from string 'tomorrow'",
+		},
+	}
+	for _, c := range cases {
+		conf := &QueryGeneratorConfig{BaseConfig: c.base}
+		err := conf.ApplyDatasetManifest(testManifest(), func(flag string) bool { return flag == c.set })
+		if err == nil {
+			t.Errorf("%s: unexpected lack of error", c.desc)
+		} else if got := err.Error(); !strings.HasPrefix(got, c.want) {
+			t.Errorf("%s: incorrect error: got\n%s\nwant prefix\n%s", c.desc, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// QueryPlan is the plan of a query captured with --explain-sample, as written
+// to the plan directory.
+type QueryPlan struct {
+	Label string `json:"label"`
+	ID    uint64 `json:"id"`
+	Query string `json:"query"`
+	// PlanningTimeMillis and RowsScanned are only set when the database
+	// reports them
+	PlanningTimeMillis *float64 `json:"planningTimeMillis,omitempty"`
+	RowsScanned        *int64   `json:"rowsScanned,omitempty"`
+	// Plan is the output of EXPLAIN, as JSON when the database returns it so
+	// and as its lines of text otherwise
+	Plan interface{} `json:"plan"`
+}
+
+// SetPlanningTime sets the planning time of the plan, in milliseconds
+func (p *QueryPlan) SetPlanningTime(millis float64) {
+	p.PlanningTimeMillis = &millis
+}
+
+// SetRowsScanned sets the number of rows the query scanned
+func (p *QueryPlan) SetRowsScanned(rows int64) {
+	p.RowsScanned = &rows
+}
+
+// PlanSampler picks the first queries of each label whose plan is captured,
+// and writes the plans to a directory with a subdirectory per label and a
+// file per query ID, e.g. plans/timescaledb-cpu-over-threshold-all-hosts/12.json.
+// A nil PlanSampler samples no query.
+type PlanSampler struct {
+	dir      string
+	perLabel int
+	mu       sync.Mutex
+	sampled  map[string]int
+}
+
+// NewPlanSampler returns a PlanSampler capturing the plans of n queries per
+// label into dir, or nil when n is 0.
+func NewPlanSampler(n uint, dir string) *PlanSampler {
+	if n == 0 {
+		return nil
+	}
+	return &PlanSampler{
+		dir:      dir,
+		perLabel: int(n),
+		sampled:  make(map[string]int),
+	}
+}
+
+// Sample returns whether the plan of q should be captured, counting it
+// towards the queries sampled for its label if so. It is safe for concurrent
+// use by the workers.
+func (s *PlanSampler) Sample(q Query) bool {
+	if s == nil {
+		return false
+	}
+	label := string(q.HumanLabelName())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.sampled[label] >= s.perLabel {
+		return false
+	}
+	s.sampled[label]++
+	return true
+}
+
+// NewPlan returns the QueryPlan of q, to fill in with the output of EXPLAIN
+// before writing it.
+func (s *PlanSampler) NewPlan(q Query, sql string) *QueryPlan {
+	return &QueryPlan{
+		Label: string(q.HumanLabelName()),
+		ID:    q.GetID(),
+		Query: sql,
+	}
+}
+
+// Write writes the plan to <dir>/<label>/<id>.json, with the label made safe
+// for a directory name.
+func (s *PlanSampler) Write(p *QueryPlan) error {
+	dir := filepath.Join(s.dir, planDirName(p.Label))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("could not create the plan directory: %v", err)
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal the plan of query %d: %v", p.ID, err)
+	}
+	return ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", p.ID)), b, 0644)
+}
+
+// planDirName returns the lower-cased label with each run of characters other
+// than letters and digits replaced with a dash, e.g. "TimescaleDB max of all
+// CPU metrics, random    8 hosts" becomes "timescaledb-max-of-all-cpu-metrics-random-8-hosts".
+func planDirName(label string) string {
+	fields := strings.FieldsFunc(strings.ToLower(label), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, "-")
+}
+
+// postgresPlanNode is a node of the plan returned by PostgreSQL for
+// EXPLAIN (ANALYZE, FORMAT JSON), with only the fields rows scanned are
+// counted from.
+type postgresPlanNode struct {
+	NodeType            string             `json:"Node Type"`
+	ActualRows          float64            `json:"Actual Rows"`
+	ActualLoops         float64            `json:"Actual Loops"`
+	RowsRemovedByFilter float64            `json:"Rows Removed by Filter"`
+	Plans               []postgresPlanNode `json:"Plans"`
+}
+
+// ParsePostgresPlan fills in the plan p with the output of PostgreSQL for
+// EXPLAIN (ANALYZE, BUFFERS, FORMAT JSON), its planning time, and the rows
+// its scan nodes read, including the ones their filters removed.
+func ParsePostgresPlan(p *QueryPlan, explain []byte) error {
+	var plans []struct {
+		PlanningTime float64          `json:"Planning Time"`
+		Plan         postgresPlanNode `json:"Plan"`
+	}
+	if err := json.Unmarshal(explain, &plans); err != nil {
+		return fmt.Errorf("could not parse the plan of query %d: %v", p.ID, err)
+	}
+	if len(plans) == 0 {
+		return fmt.Errorf("empty plan for query %d", p.ID)
+	}
+	if err := json.Unmarshal(explain, &p.Plan); err != nil {
+		return err
+	}
+	p.SetPlanningTime(plans[0].PlanningTime)
+	p.SetRowsScanned(int64(plans[0].Plan.rowsScanned()))
+	return nil
+}
+
+// rowsScanned returns the rows read by the scans of the node and its children.
+// Custom scans (e.g. the ChunkAppend of TimescaleDB) and bitmap index scans
+// are skipped, their rows are counted by the scans under or above them.
+func (n postgresPlanNode) rowsScanned() float64 {
+	rows := 0.0
+	if strings.HasSuffix(n.NodeType, "Scan") && n.NodeType != "Custom Scan" && n.NodeType != "Bitmap Index Scan" {
+		loops := n.ActualLoops
+		if loops == 0 {
+			loops = 1
+		}
+		rows += (n.ActualRows + n.RowsRemovedByFilter) * loops
+	}
+	for _, c := range n.Plans {
+		rows += c.rowsScanned()
+	}
+	return rows
+}
@@ -0,0 +1,120 @@
+package query
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNewPlanSamplerNone(t *testing.T) {
+	s := NewPlanSampler(0, "plans")
+	if s != nil {
+		t.Fatalf("unexpected sampler for no query: %v", s)
+	}
+	q := NewTimescaleDB()
+	q.HumanLabel = []byte("label")
+	if s.Sample(q) {
+		t.Errorf("nil sampler sampled a query")
+	}
+}
+
+func TestPlanSamplerSample(t *testing.T) {
+	s := NewPlanSampler(2, "plans")
+	newQuery := func(label string) Query {
+		q := NewTimescaleDB()
+		q.HumanLabel = []byte(label)
+		return q
+	}
+	want := []struct {
+		label string
+		want  bool
+	}{
+		{"a", true},
+		{"b", true},
+		{"a", true},
+		{"a", false},
+		{"b", true},
+		{"b", false},
+		{"c", true},
+	}
+	for i, c := range want {
+		if got := s.Sample(newQuery(c.label)); got != c.want {
+			t.Errorf("query %d of %s: got %v want %v", i, c.label, got, c.want)
+		}
+	}
+}
+
+func TestPlanSamplerWrite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "plans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	s := NewPlanSampler(1, dir)
+	q := NewTimescaleDB()
+	q.HumanLabel = []byte("TimescaleDB max of all CPU metrics, random    8 hosts, random 8h0m0s by 1h")
+	q.SetID(12)
+	p := s.NewPlan(q, "SELECT 1")
+	p.SetRowsScanned(42)
+	p.Plan = []string{"Result"}
+	if err := s.Write(p); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "timescaledb-max-of-all-cpu-metrics-random-8-hosts-random-8h0m0s-by-1h", "12.json"))
+	if err != nil {
+		t.Fatalf("plan not written: %v", err)
+	}
+	got := map[string]interface{}{}
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got["label"] != string(q.HumanLabel) || got["id"] != 12.0 || got["query"] != "SELECT 1" || got["rowsScanned"] != 42.0 {
+		t.Errorf("incorrect plan: %s", b)
+	}
+	if _, ok := got["planningTimeMillis"]; ok {
+		t.Errorf("unexpected planning time: %s", b)
+	}
+}
+
+func TestParsePostgresPlan(t *testing.T) {
+	explain := `[{
+		"Plan": {
+			"Node Type": "Sort", "Actual Rows": 10, "Actual Loops": 1,
+			"Plans": [{
+				"Node Type": "Custom Scan", "Actual Rows": 100, "Actual Loops": 1,
+				"Plans": [
+					{"Node Type": "Index Scan", "Actual Rows": 40, "Actual Loops": 2, "Rows Removed by Filter": 5},
+					{"Node Type": "Seq Scan", "Actual Rows": 20, "Actual Loops": 1, "Rows Removed by Filter": 30},
+					{"Node Type": "Bitmap Heap Scan", "Actual Rows": 7, "Actual Loops": 1,
+						"Plans": [{"Node Type": "Bitmap Index Scan", "Actual Rows": 7, "Actual Loops": 1}]}
+				]
+			}]
+		},
+		"Planning Time": 1.25,
+		"Execution Time": 12.5
+	}]`
+	p := &QueryPlan{ID: 1}
+	if err := ParsePostgresPlan(p, []byte(explain)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.PlanningTimeMillis == nil || *p.PlanningTimeMillis != 1.25 {
+		t.Errorf("incorrect planning time: %v", p.PlanningTimeMillis)
+	}
+	// (40+5)*2 + 20+30 + 7
+	if p.RowsScanned == nil || *p.RowsScanned != 147 {
+		t.Errorf("incorrect rows scanned: %v", p.RowsScanned)
+	}
+	if p.Plan == nil {
+		t.Errorf("plan not set")
+	}
+
+	for _, explain := range []string{"", "[]", "{}"} {
+		if err := ParsePostgresPlan(&QueryPlan{}, []byte(explain)); err == nil {
+			t.Errorf("%q: unexpected lack of error", explain)
+		}
+	}
+}
@@ -5,9 +5,16 @@ import (
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/cassandra"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/clickhouse"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/cratedb"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/elasticsearch"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/embedded"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/flightsql"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/graphite"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/influx"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/influx_2"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/mongo"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/opentsdb"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/pgwire"
+	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/prometheus"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/questdb"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/siridb"
 	"github.com/timescale/tsbs/cmd/tsbs_generate_queries/databases/timescaledb"
@@ -37,9 +44,29 @@ func InitQueryFactories(config *config.QueryGeneratorConfig) map[string]interfac
 	}
 	factories[constants.FormatAkumuli] = &akumuli.BaseGenerator{}
 	factories[constants.FormatVictoriaMetrics] = &victoriametrics.BaseGenerator{}
+	factories[constants.FormatPrometheus] = &prometheus.BaseGenerator{
+		UseRemoteRead: config.PrometheusUseRemoteRead,
+	}
 	factories[constants.FormatTimestream] = &timestream.BaseGenerator{
 		DBName: config.DbName,
 	}
 	factories[constants.FormatQuestDB] = &questdb.BaseGenerator{}
+	factories[constants.FormatGraphite] = &graphite.BaseGenerator{
+		Template: config.GraphiteTemplate,
+	}
+	factories[constants.FormatOpenTSDB] = &opentsdb.BaseGenerator{}
+	factories[constants.FormatElasticsearch] = &elasticsearch.BaseGenerator{
+		DBName: config.DbName,
+	}
+	factories[constants.FormatFlightSQL] = &flightsql.BaseGenerator{}
+	factories[constants.FormatPGWire] = &pgwire.BaseGenerator{
+		Dialect: config.PGWireDialect,
+	}
+	factories[constants.FormatDuckDB] = &embedded.BaseGenerator{
+		Engine: constants.FormatDuckDB,
+	}
+	factories[constants.FormatSQLite] = &embedded.BaseGenerator{
+		Engine: constants.FormatSQLite,
+	}
 	return factories
 }
@@ -0,0 +1,406 @@
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+// Formats of the query files.
+const (
+	FileFormatGob   = "gob"
+	FileFormatJSONL = "jsonl"
+
+	// jsonlExtension selects the JSONL format when no format is given.
+	jsonlExtension = ".jsonl"
+)
+
+// Encoder writes Queries to a query file.
+type Encoder interface {
+	Encode(q interface{}) error
+}
+
+// Decoder reads the next Query of a query file into q, returning io.EOF at
+// the end of the file.
+type Decoder interface {
+	Decode(q interface{}) error
+}
+
+// FileFormat returns the format of the query file fileName: format if it is
+// set, otherwise JSONL for the files with the .jsonl extension and gob for any
+// other file, stdin and stdout.
+func FileFormat(format, fileName string) (string, error) {
+	switch format {
+	case FileFormatGob, FileFormatJSONL:
+		return format, nil
+	case "":
+		if strings.EqualFold(filepath.Ext(fileName), jsonlExtension) {
+			return FileFormatJSONL, nil
+		}
+		return FileFormatGob, nil
+	}
+	return "", fmt.Errorf("unknown query file format '%s', choose %s or %s", format, FileFormatGob, FileFormatJSONL)
+}
+
+// NewEncoder returns an Encoder writing the query file format to w.
+func NewEncoder(format string, w io.Writer) Encoder {
+	if format == FileFormatJSONL {
+		return &jsonlEncoder{w: w}
+	}
+	return gob.NewEncoder(w)
+}
+
+// NewDecoder returns a Decoder reading the query file format from r.
+func NewDecoder(format string, r io.Reader) Decoder {
+	if format == FileFormatJSONL {
+		return &jsonlDecoder{r: bufio.NewReader(r)}
+	}
+	return gob.NewDecoder(r)
+}
+
+// jsonlQuery is a line of a JSONL query file. The fields of the Query other
+// than its label, description and time range are the keys of Query, in
+// snake_case, e.g. sql_query or raw_query.
+type jsonlQuery struct {
+	Label       string          `json:"label"`
+	Description string          `json:"description,omitempty"`
+	Start       *time.Time      `json:"start,omitempty"`
+	End         *time.Time      `json:"end,omitempty"`
+	Query       json.RawMessage `json:"query"`
+}
+
+// timeRangeFields are the fields of the Query types which carry the time range
+// their query was generated for, written as the start and end of the line.
+var timeRangeFields = map[reflect.Type][2]string{
+	reflect.TypeOf(HTTP{}):      {"StartTimestamp", "EndTimestamp"},
+	reflect.TypeOf(Cassandra{}): {"TimeStart", "TimeEnd"},
+}
+
+var (
+	bytesType    = reflect.TypeOf([]byte(nil))
+	bsonDocType  = reflect.TypeOf([]bson.M(nil))
+	durationType = reflect.TypeOf(time.Duration(0))
+	timeType     = reflect.TypeOf(time.Time{})
+)
+
+// payloadField is a field of a Query type written in the query object.
+type payloadField struct {
+	index int
+	key   string
+}
+
+// queryLayout describes how a Query type is written in JSONL.
+type queryLayout struct {
+	label, description int
+	start, end         int // -1 without a time range
+	fields             []payloadField
+}
+
+// queryLayouts caches the queryLayout of every Query type encoded or decoded.
+var queryLayouts sync.Map
+
+func layoutOf(t reflect.Type) (*queryLayout, error) {
+	if l, ok := queryLayouts.Load(t); ok {
+		return l.(*queryLayout), nil
+	}
+
+	l := &queryLayout{label: -1, description: -1, start: -1, end: -1}
+	timeRange, hasTimeRange := timeRangeFields[t]
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		switch {
+		case f.PkgPath != "":
+			// unexported, e.g. the id set by the scanner
+		case f.Name == "HumanLabel":
+			l.label = i
+		case f.Name == "HumanDescription":
+			l.description = i
+		case hasTimeRange && f.Name == timeRange[0]:
+			l.start = i
+		case hasTimeRange && f.Name == timeRange[1]:
+			l.end = i
+		default:
+			l.fields = append(l.fields, payloadField{index: i, key: snakeCase(f.Name)})
+		}
+	}
+	if l.label < 0 || l.description < 0 {
+		return nil, fmt.Errorf("%s has no HumanLabel or HumanDescription field", t)
+	}
+	queryLayouts.Store(t, l)
+	return l, nil
+}
+
+// snakeCase converts the name of a Go field to a JSON key, e.g. SqlQuery to
+// sql_query and BsonDoc to bson_doc.
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// queryStruct returns the struct q points to.
+func queryStruct(q interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(q)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("cannot encode or decode %T as a query", q)
+	}
+	return v.Elem(), nil
+}
+
+// jsonlEncoder writes each Query on a line of JSON. HTML characters are not
+// escaped, so that e.g. the comparisons of SQL queries stay readable.
+type jsonlEncoder struct {
+	w       io.Writer
+	payload bytes.Buffer
+	line    bytes.Buffer
+}
+
+// Encode writes the line of q.
+func (e *jsonlEncoder) Encode(q interface{}) error {
+	v, err := queryStruct(q)
+	if err != nil {
+		return err
+	}
+	l, err := layoutOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	// the query object keeps the order of the fields of the Query type
+	e.payload.Reset()
+	e.payload.WriteByte('{')
+	for i, f := range l.fields {
+		if i > 0 {
+			e.payload.WriteByte(',')
+		}
+		if err = encodeJSON(&e.payload, f.key); err != nil {
+			return err
+		}
+		e.payload.WriteByte(':')
+		if err = encodeField(&e.payload, v.Field(f.index)); err != nil {
+			return fmt.Errorf("cannot encode %s: %v", f.key, err)
+		}
+	}
+	e.payload.WriteByte('}')
+
+	line := jsonlQuery{
+		Label:       string(v.Field(l.label).Bytes()),
+		Description: string(v.Field(l.description).Bytes()),
+		Query:       e.payload.Bytes(),
+	}
+	if l.start >= 0 {
+		line.Start = timeOf(v.Field(l.start))
+		line.End = timeOf(v.Field(l.end))
+	}
+
+	e.line.Reset()
+	if err = encodeJSON(&e.line, line); err != nil {
+		return err
+	}
+	e.line.WriteByte('\n')
+	_, err = e.w.Write(e.line.Bytes())
+	return err
+}
+
+// encodeJSON writes the JSON value of x to buf.
+func encodeJSON(buf *bytes.Buffer, x interface{}) error {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(x); err != nil {
+		return err
+	}
+	// json.Encoder ends the value with a newline
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
+
+// timeOf returns the time of a time range field, nil when it is not set.
+func timeOf(v reflect.Value) *time.Time {
+	var t time.Time
+	if v.Type() == timeType {
+		t = v.Interface().(time.Time)
+	} else if ns := v.Int(); ns != 0 {
+		t = time.Unix(0, ns)
+	}
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
+
+// setTime sets a time range field to t.
+func setTime(v reflect.Value, t *time.Time) {
+	if v.Type() == timeType {
+		if t == nil {
+			v.Set(reflect.ValueOf(time.Time{}))
+		} else {
+			v.Set(reflect.ValueOf(t.UTC()))
+		}
+		return
+	}
+	if t == nil {
+		v.SetInt(0)
+	} else {
+		v.SetInt(t.UnixNano())
+	}
+}
+
+// encodeField writes the JSON value of a payload field to buf. Byte slices
+// are written as strings, BSON documents as MongoDB extended JSON and
+// durations like 1h0m0s.
+func encodeField(buf *bytes.Buffer, v reflect.Value) error {
+	switch v.Type() {
+	case bytesType:
+		return encodeJSON(buf, string(v.Bytes()))
+	case bsonDocType:
+		doc, err := bson.MarshalJSON(v.Interface())
+		if err != nil {
+			return err
+		}
+		buf.Write(bytes.TrimSpace(doc))
+		return nil
+	case durationType:
+		return encodeJSON(buf, time.Duration(v.Int()).String())
+	}
+	return encodeJSON(buf, v.Interface())
+}
+
+// decodeField sets a payload field from its JSON value, see encodeField.
+func decodeField(v reflect.Value, value json.RawMessage) error {
+	switch v.Type() {
+	case bytesType:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return err
+		}
+		v.SetBytes(append(v.Bytes()[:0], s...))
+		return nil
+	case bsonDocType:
+		var docs []bson.M
+		if err := bson.UnmarshalJSON(value, &docs); err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(docs))
+		return nil
+	case durationType:
+		var s string
+		if err := json.Unmarshal(value, &s); err != nil {
+			return err
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	return json.Unmarshal(value, v.Addr().Interface())
+}
+
+// jsonlDecoder reads a Query from each line of JSON, skipping blank lines.
+type jsonlDecoder struct {
+	r    *bufio.Reader
+	line int
+}
+
+// Decode reads the next line into q.
+func (d *jsonlDecoder) Decode(q interface{}) error {
+	v, err := queryStruct(q)
+	if err != nil {
+		return err
+	}
+	l, err := layoutOf(v.Type())
+	if err != nil {
+		return err
+	}
+
+	var raw []byte
+	for len(raw) == 0 {
+		var readErr error
+		raw, readErr = d.r.ReadBytes('\n')
+		if readErr != nil && readErr != io.EOF {
+			return readErr
+		}
+		d.line++
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 && readErr == io.EOF {
+			return io.EOF
+		}
+	}
+
+	if err = d.decodeLine(v, l, raw); err != nil {
+		return fmt.Errorf("line %d: %v", d.line, err)
+	}
+	return nil
+}
+
+func (d *jsonlDecoder) decodeLine(v reflect.Value, l *queryLayout, raw []byte) error {
+	var line jsonlQuery
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&line); err != nil {
+		return err
+	}
+	if line.Label == "" {
+		return fmt.Errorf("the label is required")
+	}
+
+	label := v.Field(l.label)
+	label.SetBytes(append(label.Bytes()[:0], line.Label...))
+	description := v.Field(l.description)
+	description.SetBytes(append(description.Bytes()[:0], line.Description...))
+	if l.start >= 0 {
+		setTime(v.Field(l.start), line.Start)
+		setTime(v.Field(l.end), line.End)
+	} else if line.Start != nil || line.End != nil {
+		return fmt.Errorf("%s queries have no time range", v.Type().Name())
+	}
+
+	var payload map[string]json.RawMessage
+	if len(line.Query) > 0 {
+		if err := json.Unmarshal(line.Query, &payload); err != nil {
+			return fmt.Errorf("the query must be an object: %v", err)
+		}
+	}
+	for key, value := range payload {
+		f, ok := l.field(key)
+		if !ok {
+			return fmt.Errorf("unknown field '%s' of %s queries", key, v.Type().Name())
+		}
+		if err := decodeField(v.Field(f.index), value); err != nil {
+			return fmt.Errorf("cannot decode %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// field returns the payload field with the JSON key.
+func (l *queryLayout) field(key string) (payloadField, bool) {
+	for _, f := range l.fields {
+		if f.key == key {
+			return f, true
+		}
+	}
+	return payloadField{}, false
+}
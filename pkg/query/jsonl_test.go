@@ -0,0 +1,290 @@
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+)
+
+func TestFileFormat(t *testing.T) {
+	cases := []struct {
+		format   string
+		fileName string
+		want     string
+		wantErr  bool
+	}{
+		{fileName: "", want: FileFormatGob},
+		{fileName: "/tmp/queries.gz", want: FileFormatGob},
+		{fileName: "/tmp/queries.jsonl", want: FileFormatJSONL},
+		{fileName: "/tmp/queries.JSONL", want: FileFormatJSONL},
+		{format: FileFormatGob, fileName: "/tmp/queries.jsonl", want: FileFormatGob},
+		{format: FileFormatJSONL, want: FileFormatJSONL},
+		{format: "csv", wantErr: true},
+	}
+	for _, c := range cases {
+		got, err := FileFormat(c.format, c.fileName)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%q %q: unexpected lack of error", c.format, c.fileName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q %q: unexpected error: %v", c.format, c.fileName, err)
+		} else if got != c.want {
+			t.Errorf("%q %q: incorrect format: got %s want %s", c.format, c.fileName, got, c.want)
+		}
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	cases := map[string]string{
+		"SqlQuery":        "sql_query",
+		"RawQuery":        "raw_query",
+		"BsonDoc":         "bson_doc",
+		"GroupByDuration": "group_by_duration",
+		"ForEveryN":       "for_every_n",
+		"Table":           "table",
+		"HTTPPath":        "http_path",
+	}
+	for in, want := range cases {
+		if got := snakeCase(in); got != want {
+			t.Errorf("incorrect key for %s: got %s want %s", in, got, want)
+		}
+	}
+}
+
+func TestJSONLEncode(t *testing.T) {
+	q := NewSQL()
+	q.HumanLabel = []byte("QuestDB max cpu")
+	q.HumanDescription = []byte("QuestDB max cpu: 2016-01-01T00:00:00Z")
+	q.Table = []byte("cpu")
+	q.SqlQuery = []byte("SELECT * FROM cpu WHERE usage_user > 90.0 AND timestamp < '2016-01-01T01:00:00Z'")
+
+	var buf bytes.Buffer
+	if err := NewEncoder(FileFormatJSONL, &buf).Encode(q); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"label":"QuestDB max cpu","description":"QuestDB max cpu: 2016-01-01T00:00:00Z",` +
+		`"query":{"table":"cpu","sql_query":"SELECT * FROM cpu WHERE usage_user > 90.0 AND timestamp < '2016-01-01T01:00:00Z'"}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("incorrect line:\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	h := NewHTTP()
+	h.HumanLabel = []byte("Influx max cpu")
+	h.Method = []byte("GET")
+	h.Path = []byte("/query?q=SELECT")
+	h.StartTimestamp = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	h.EndTimestamp = time.Date(2016, 1, 1, 1, 0, 0, 0, time.UTC).UnixNano()
+
+	buf.Reset()
+	if err := NewEncoder(FileFormatJSONL, &buf).Encode(h); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = `{"label":"Influx max cpu","start":"2016-01-01T00:00:00Z","end":"2016-01-01T01:00:00Z",` +
+		`"query":{"method":"GET","path":"/query?q=SELECT","body":"","raw_query":""}}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("incorrect line:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestJSONLRoundTrip(t *testing.T) {
+	cassandra := NewCassandra()
+	cassandra.HumanLabel = []byte("Cassandra max cpu")
+	cassandra.HumanDescription = []byte("Cassandra max cpu: 2016-01-01T00:00:00Z")
+	cassandra.MeasurementName = []byte("cpu")
+	cassandra.FieldName = []byte("usage_user")
+	cassandra.AggregationType = []byte("max")
+	cassandra.TimeStart = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	cassandra.TimeEnd = time.Date(2016, 1, 1, 12, 0, 0, 0, time.UTC)
+	cassandra.GroupByDuration = time.Hour
+	cassandra.Limit = 5
+	cassandra.TagSets = [][]string{{"hostname=host_1", "hostname=host_2"}}
+
+	mongo := NewMongo()
+	mongo.HumanLabel = []byte("Mongo max cpu")
+	mongo.CollectionName = []byte("point_data")
+	mongo.BsonDoc = []bson.M{
+		{"$match": bson.M{"measurement": "cpu", "time": bson.M{"$gte": int64(1451606400000000000)}}},
+		{"$limit": 5},
+	}
+
+	http := NewHTTP()
+	http.HumanLabel = []byte("VictoriaMetrics max cpu")
+	http.Method = []byte("GET")
+	http.Path = []byte("/api/v1/query_range?query=max(cpu_usage_user)&start=1451606400")
+	http.StartTimestamp = time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC).UnixNano()
+	http.EndTimestamp = time.Date(2016, 1, 1, 1, 0, 0, 0, time.UTC).UnixNano()
+
+	sql := NewSQL()
+	sql.HumanLabel = []byte("QuestDB max cpu")
+	sql.Table = []byte("cpu")
+	sql.SqlQuery = []byte("SELECT\n\t\"hostname\" FROM cpu WHERE usage_user < 10 & x <> 'é'")
+
+	cases := []struct {
+		in  Query
+		out Query
+	}{
+		{in: cassandra, out: NewCassandra()},
+		{in: mongo, out: NewMongo()},
+		{in: http, out: NewHTTP()},
+		{in: sql, out: NewSQL()},
+		{in: NewTimescaleDB(), out: NewTimescaleDB()},
+		{in: NewSiriDB(), out: NewSiriDB()},
+	}
+	for _, c := range cases {
+		if len(c.in.HumanLabelName()) == 0 {
+			reflect.ValueOf(c.in).Elem().FieldByName("HumanLabel").SetBytes([]byte("label"))
+		}
+
+		var buf bytes.Buffer
+		if err := NewEncoder(FileFormatJSONL, &buf).Encode(c.in); err != nil {
+			t.Fatalf("%T: unexpected encoding error: %v", c.in, err)
+		}
+		dec := NewDecoder(FileFormatJSONL, &buf)
+		if err := dec.Decode(c.out); err != nil {
+			t.Fatalf("%T: unexpected decoding error: %v", c.in, err)
+		}
+		if got, want := c.out.String(), c.in.String(); got != want {
+			t.Errorf("%T: incorrect query after round trip:\ngot\n%s\nwant\n%s", c.in, got, want)
+		}
+		var in, out bytes.Buffer
+		NewEncoder(FileFormatJSONL, &in).Encode(c.in)
+		NewEncoder(FileFormatJSONL, &out).Encode(c.out)
+		if got, want := out.String(), in.String(); got != want {
+			t.Errorf("%T: incorrect line after round trip:\ngot\n%s\nwant\n%s", c.in, got, want)
+		}
+		if err := dec.Decode(c.out); err != io.EOF {
+			t.Errorf("%T: incorrect error at the end: got %v want %v", c.in, err, io.EOF)
+		}
+	}
+
+	// String leaves out some of the Cassandra fields
+	got := NewCassandra()
+	var buf bytes.Buffer
+	NewEncoder(FileFormatJSONL, &buf).Encode(cassandra)
+	NewDecoder(FileFormatJSONL, &buf).Decode(got)
+	if !got.TimeStart.Equal(cassandra.TimeStart) || !got.TimeEnd.Equal(cassandra.TimeEnd) {
+		t.Errorf("incorrect time range: got %v %v want %v %v", got.TimeStart, got.TimeEnd, cassandra.TimeStart, cassandra.TimeEnd)
+	}
+	if got.GroupByDuration != time.Hour || got.Limit != 5 || !reflect.DeepEqual(got.TagSets, cassandra.TagSets) {
+		t.Errorf("incorrect fields: got %s", got)
+	}
+}
+
+func TestJSONLDecode(t *testing.T) {
+	input := `
+{"label": "hand written", "description": "a regression query", "query": {"table": "cpu", "sql_query": "SELECT 1"}}
+
+{"label": "no payload"}
+`
+	dec := NewDecoder(FileFormatJSONL, strings.NewReader(input))
+	q := NewSQL()
+	if err := dec.Decode(q); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := q.String(); got != `HumanLabel: hand written, HumanDescription: a regression query, Table: cpu, Query: SELECT 1` {
+		t.Errorf("incorrect query: got %s", got)
+	}
+	q.Release()
+
+	q = NewSQL()
+	if err := dec.Decode(q); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if string(q.HumanLabel) != "no payload" || len(q.SqlQuery) != 0 {
+		t.Errorf("incorrect query: got %s", q)
+	}
+	if err := dec.Decode(q); err != io.EOF {
+		t.Errorf("incorrect error at the end: got %v want %v", err, io.EOF)
+	}
+}
+
+func TestJSONLDecodeErrors(t *testing.T) {
+	cases := []struct {
+		desc  string
+		input string
+		want  string
+	}{
+		{
+			desc:  "not JSON",
+			input: "SELECT 1",
+			want:  "line 1: invalid character",
+		},
+		{
+			desc:  "no label",
+			input: `{"query": {"sql_query": "SELECT 1"}}`,
+			want:  "line 1: the label is required",
+		},
+		{
+			desc:  "unknown key",
+			input: `{"label": "l", "sql": "SELECT 1"}`,
+			want:  `line 1: json: unknown field "sql"`,
+		},
+		{
+			desc:  "unknown field",
+			input: "\n" + `{"label": "l", "query": {"sql": "SELECT 1"}}`,
+			want:  "line 2: unknown field 'sql' of SQL queries",
+		},
+		{
+			desc:  "time range",
+			input: `{"label": "l", "start": "2016-01-01T00:00:00Z"}`,
+			want:  "line 1: SQL queries have no time range",
+		},
+		{
+			desc:  "wrong type",
+			input: `{"label": "l", "query": {"sql_query": 1}}`,
+			want:  "line 1: cannot decode sql_query",
+		},
+	}
+	for _, c := range cases {
+		err := NewDecoder(FileFormatJSONL, strings.NewReader(c.input)).Decode(NewSQL())
+		if err == nil {
+			t.Errorf("%s: unexpected lack of error", c.desc)
+		} else if got := err.Error(); !strings.HasPrefix(got, c.want) {
+			t.Errorf("%s: incorrect error: got\n%s\nwant prefix\n%s", c.desc, got, c.want)
+		}
+	}
+}
+
+func TestScanJSONL(t *testing.T) {
+	totalQueries := uint64(5)
+	var b bytes.Buffer
+	enc := NewEncoder(FileFormatJSONL, &b)
+	for i := uint64(0); i < totalQueries; i++ {
+		q := NewSQL()
+		q.HumanLabel = []byte(fmt.Sprintf("label%d", i))
+		q.SqlQuery = []byte(fmt.Sprintf("SELECT %d", i))
+		if err := enc.Encode(q); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		q.Release()
+	}
+
+	limit := uint64(0)
+	queryChan := make(chan Query, totalQueries)
+	newScanner(&limit).setFormat(FileFormatJSONL).setReader(&b).scan(&SQLPool, queryChan)
+	close(queryChan)
+
+	i := uint64(0)
+	for q := range queryChan {
+		sq := q.(*SQL)
+		if got, want := string(sq.SqlQuery), fmt.Sprintf("SELECT %d", i); got != want {
+			t.Errorf("incorrect query %d: got %s want %s", i, got, want)
+		}
+		if got := sq.GetID(); got != i {
+			t.Errorf("incorrect ID: got %d want %d", got, i)
+		}
+		i++
+	}
+	if i != totalQueries {
+		t.Errorf("incorrect number of queries: got %d want %d", i, totalQueries)
+	}
+}
@@ -1,17 +1,17 @@
 package query
 
 import (
-	"encoding/gob"
 	"io"
 	"log"
 	"sync"
 )
 
 // scanner is used to read in Queries from a Reader where they are
-// Go-encoded and then distribute them to workers
+// encoded in a query file format and then distribute them to workers
 type scanner struct {
-	r     io.Reader
-	limit *uint64
+	r      io.Reader
+	format string
+	limit  *uint64
 }
 
 // newScanner returns a new scanner for a given Reader and its limit
@@ -25,9 +25,15 @@ func (s *scanner) setReader(r io.Reader) *scanner {
 	return s
 }
 
+// setFormat sets the query file format the scanner decodes, gob by default
+func (s *scanner) setFormat(format string) *scanner {
+	s.format = format
+	return s
+}
+
 // scan reads encoded Queries and places them into a channel
 func (s *scanner) scan(pool *sync.Pool, c chan Query) {
-	decoder := gob.NewDecoder(s.r)
+	decoder := NewDecoder(s.format, s.r)
 
 	n := uint64(0)
 	for {
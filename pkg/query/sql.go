@@ -0,0 +1,71 @@
+package query
+
+import (
+	"fmt"
+	"sync"
+)
+
+// SQL encodes a plain SQL query that a runner executes as is, e.g. against an
+// embedded DuckDB or SQLite database by tsbs_run_queries_embedded.
+type SQL struct {
+	HumanLabel       []byte
+	HumanDescription []byte
+
+	Table    []byte // e.g. "cpu"
+	SqlQuery []byte
+	id       uint64
+}
+
+// SQLPool is a sync.Pool of SQL Query types
+var SQLPool = sync.Pool{
+	New: func() interface{} {
+		return &SQL{
+			HumanLabel:       make([]byte, 0, 1024),
+			HumanDescription: make([]byte, 0, 1024),
+			Table:            make([]byte, 0, 1024),
+			SqlQuery:         make([]byte, 0, 1024),
+		}
+	},
+}
+
+// NewSQL returns a new SQL Query instance
+func NewSQL() *SQL {
+	return SQLPool.Get().(*SQL)
+}
+
+// GetID returns the ID of this Query
+func (q *SQL) GetID() uint64 {
+	return q.id
+}
+
+// SetID sets the ID for this Query
+func (q *SQL) SetID(n uint64) {
+	q.id = n
+}
+
+// String produces a debug-ready description of a Query.
+func (q *SQL) String() string {
+	return fmt.Sprintf("HumanLabel: %s, HumanDescription: %s, Table: %s, Query: %s", q.HumanLabel, q.HumanDescription, q.Table, q.SqlQuery)
+}
+
+// HumanLabelName returns the human readable name of this Query
+func (q *SQL) HumanLabelName() []byte {
+	return q.HumanLabel
+}
+
+// HumanDescriptionName returns the human readable description of this Query
+func (q *SQL) HumanDescriptionName() []byte {
+	return q.HumanDescription
+}
+
+// Release resets and returns this Query to its pool
+func (q *SQL) Release() {
+	q.HumanLabel = q.HumanLabel[:0]
+	q.HumanDescription = q.HumanDescription[:0]
+	q.id = 0
+
+	q.Table = q.Table[:0]
+	q.SqlQuery = q.SqlQuery[:0]
+
+	SQLPool.Put(q)
+}
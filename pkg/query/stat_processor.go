@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"github.com/HdrHistogram/hdrhistogram-go"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
@@ -30,6 +31,7 @@ type statProcessorArgs struct {
 	burnIn           uint64  // burnIn is the number of statistics to ignore before analyzing
 	printInterval    uint64  // printInterval is how often print intermediate stats (number of queries)
 	hdrLatenciesFile string  // hdrLatenciesFile is the filename to Write the High Dynamic Range (HDR) Histogram of Response Latencies to
+	cacheHooks       bool    // cacheHooks tells the StatProcessor whether cold cache hooks run, the query right after them being cold
 
 }
 
@@ -42,6 +44,9 @@ type defaultStatProcessor struct {
 	startTime   time.Time
 	endTime     time.Time
 	statMapping map[string]*statGroup
+	// endpointMapping has the stat groups per endpoint the queries were
+	// sent to, for the runners with several endpoints
+	endpointMapping map[string]*statGroup
 }
 
 func newStatProcessor(args *statProcessorArgs) statProcessor {
@@ -86,17 +91,36 @@ func (sp *defaultStatProcessor) process(workers uint) {
 		allQueriesLabel: newStatGroup(*sp.args.limit),
 	}
 	// Only needed when differentiating between cold & warm
-	if sp.args.prewarmQueries {
+	if sp.args.prewarmQueries || sp.args.cacheHooks {
 		sp.statMapping[labelColdQueries] = newStatGroup(*sp.args.limit)
 		sp.statMapping[labelWarmQueries] = newStatGroup(*sp.args.limit)
 	}
+	sp.endpointMapping = map[string]*statGroup{}
 
 	i := uint64(0)
 	sp.startTime = time.Now()
 	prevTime := sp.startTime
 	prevRequestCount := uint64(0)
+	warmup := uint64(0)
+	warmedUp := false
 
 	for stat := range sp.c {
+		if stat.isWarmup {
+			if !stat.isPartial && !stat.isWarm {
+				warmup++
+			}
+			statPool.Put(stat)
+			continue
+		} else if warmup > 0 && !warmedUp {
+			warmedUp = true
+			_, err := fmt.Fprintf(os.Stderr, "warm-up complete after %d queries with %d workers\n", warmup, workers)
+			if err != nil {
+				log.Fatal(err)
+			}
+			// the query rates are the ones after the warm-up
+			sp.startTime = time.Now()
+			prevTime = sp.startTime
+		}
 		atomic.AddUint64(&sp.opsCount, 1)
 		if i < sp.args.burnIn {
 			i++
@@ -108,22 +132,38 @@ func (sp *defaultStatProcessor) process(workers uint) {
 				log.Fatal(err)
 			}
 		}
-		if _, ok := sp.statMapping[string(stat.label)]; !ok {
-			sp.statMapping[string(stat.label)] = newStatGroup(*sp.args.limit)
+		label := string(stat.label)
+		if stat.isCold {
+			// reported apart from the warm queries of the same label
+			label += labelColdSuffix
+		}
+		if _, ok := sp.statMapping[label]; !ok {
+			sp.statMapping[label] = newStatGroup(*sp.args.limit)
 		}
 
-		sp.statMapping[string(stat.label)].push(stat.value)
+		sp.statMapping[label].pushStat(stat)
 
 		if !stat.isPartial {
-			sp.statMapping[allQueriesLabel].push(stat.value)
+			sp.statMapping[allQueriesLabel].pushStat(stat)
+
+			if len(stat.endpoint) > 0 {
+				sp.pushEndpoint(stat)
+			}
 
-			// Only needed when differentiating between cold & warm
-			if sp.args.prewarmQueries {
+			// Only needed when differentiating between cold & warm, timed-out
+			// queries having no latency
+			if sp.args.prewarmQueries && !stat.isTimedOut {
 				if stat.isWarm {
 					sp.statMapping[labelWarmQueries].push(stat.value)
 				} else {
 					sp.statMapping[labelColdQueries].push(stat.value)
 				}
+			} else if sp.args.cacheHooks && !stat.isTimedOut {
+				if stat.isCold {
+					sp.statMapping[labelColdQueries].push(stat.value)
+				} else {
+					sp.statMapping[labelWarmQueries].push(stat.value)
+				}
 			}
 
 			// If we're prewarming queries (i.e., running them twice in a row),
@@ -152,7 +192,7 @@ func (sp *defaultStatProcessor) process(workers uint) {
 			if err != nil {
 				log.Fatal(err)
 			}
-			err = writeStatGroupMap(os.Stderr, sp.statMapping)
+			err = sp.writeStats(os.Stderr)
 			if err != nil {
 				log.Fatal(err)
 			}
@@ -171,7 +211,7 @@ func (sp *defaultStatProcessor) process(workers uint) {
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = writeStatGroupMap(os.Stdout, sp.statMapping)
+	err = sp.writeStats(os.Stdout)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -194,6 +234,36 @@ func (sp *defaultStatProcessor) process(workers uint) {
 	sp.wg.Done()
 }
 
+// pushEndpoint updates the stat group of the endpoint of a stat with its
+// latency, or counts it when the query timed out.
+func (sp *defaultStatProcessor) pushEndpoint(stat *Stat) {
+	g, ok := sp.endpointMapping[string(stat.endpoint)]
+	if !ok {
+		g = newStatGroup(*sp.args.limit)
+		sp.endpointMapping[string(stat.endpoint)] = g
+	}
+	if stat.isTimedOut {
+		g.timeouts++
+		return
+	}
+	g.push(stat.value)
+}
+
+// writeStats writes the stat groups per label, followed by the ones per
+// endpoint when the queries were sent to several endpoints
+func (sp *defaultStatProcessor) writeStats(w io.Writer) error {
+	if err := writeStatGroupMap(w, sp.statMapping); err != nil {
+		return err
+	}
+	if len(sp.endpointMapping) < 2 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "Per endpoint:\n"); err != nil {
+		return err
+	}
+	return writeStatGroupMap(w, sp.endpointMapping)
+}
+
 func generateQuantileMap(hist *hdrhistogram.Histogram) (int64, map[string]float64) {
 	ops := hist.TotalCount()
 	q0 := 0.0
@@ -238,6 +308,45 @@ func (sp *defaultStatProcessor) GetTotalsMap() map[string]interface{} {
 		quantiles[stripRegex(label)] = all
 	}
 	totals["overallQuantiles"] = quantiles
+	// the sizes of the results and the server times, when the runner reports them
+	results := make(map[string]interface{})
+	for label, statGroup := range sp.statMapping {
+		if statGroup.result != nil {
+			results[stripRegex(label)] = statGroup.result.totals()
+		}
+	}
+	if len(results) > 0 {
+		totals["overallResults"] = results
+	}
+	// the queries cancelled after --query-timeout
+	timeouts := make(map[string]interface{})
+	for label, statGroup := range sp.statMapping {
+		if statGroup.timeouts > 0 {
+			timeouts[stripRegex(label)] = statGroup.timeouts
+		}
+	}
+	if len(timeouts) > 0 {
+		totals["timeouts"] = timeouts
+	}
+	// the same per endpoint, when the queries were sent to several endpoints
+	if len(sp.endpointMapping) > 1 {
+		endpointRates := make(map[string]interface{})
+		endpointQuantiles := make(map[string]interface{})
+		endpointTimeouts := make(map[string]interface{})
+		for endpoint, statGroup := range sp.endpointMapping {
+			endpointRates[stripRegex(endpoint)] = float64(statGroup.count) / sinceStart.Seconds()
+			_, all := generateQuantileMap(statGroup.latencyHDRHistogram)
+			endpointQuantiles[stripRegex(endpoint)] = all
+			if statGroup.timeouts > 0 {
+				endpointTimeouts[stripRegex(endpoint)] = statGroup.timeouts
+			}
+		}
+		totals["endpointQueryRates"] = endpointRates
+		totals["endpointQuantiles"] = endpointQuantiles
+		if len(endpointTimeouts) > 0 {
+			totals["endpointTimeouts"] = endpointTimeouts
+		}
+	}
 	return totals
 }
 
@@ -1,6 +1,8 @@
 package query
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 	"time"
 )
@@ -77,3 +79,92 @@ func TestStatProcessorSendWarm(t *testing.T) {
 		t.Errorf("empty stat array changed channel length: got %d want %d", got, wantLen)
 	}
 }
+
+func TestStatProcessorWriteStatsEndpoints(t *testing.T) {
+	limit := uint64(0)
+	sp := &defaultStatProcessor{
+		args:            &statProcessorArgs{limit: &limit},
+		statMapping:     map[string]*statGroup{labelAllQueries: newStatGroup(0)},
+		endpointMapping: map[string]*statGroup{"host1": newStatGroup(0)},
+	}
+	sp.statMapping[labelAllQueries].push(1.0)
+	sp.endpointMapping["host1"].push(1.0)
+
+	// a single endpoint is the same as all queries
+	var b bytes.Buffer
+	if err := sp.writeStats(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(b.String(), "host1") {
+		t.Errorf("unexpected stats for a single endpoint:\n%s", b.String())
+	}
+	if _, ok := sp.GetTotalsMap()["endpointQuantiles"]; ok {
+		t.Errorf("unexpected totals for a single endpoint")
+	}
+
+	sp.endpointMapping["host2"] = newStatGroup(0)
+	sp.endpointMapping["host2"].push(3.0)
+	b.Reset()
+	if err := sp.writeStats(&b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	out := b.String()
+	if !strings.Contains(out, "Per endpoint:\nhost1:\n") || !strings.Contains(out, "host2:\n") {
+		t.Errorf("missing stats per endpoint:\n%s", out)
+	}
+	if strings.Index(out, labelAllQueries) > strings.Index(out, "Per endpoint:") {
+		t.Errorf("stats per endpoint before the ones per label:\n%s", out)
+	}
+	totals := sp.GetTotalsMap()
+	quantiles, ok := totals["endpointQuantiles"].(map[string]interface{})
+	if !ok || len(quantiles) != 2 {
+		t.Fatalf("incorrect endpoint quantiles: %v", totals["endpointQuantiles"])
+	}
+	if got := quantiles["host2"].(map[string]float64)["q100"]; got != 3.0 {
+		t.Errorf("incorrect max latency of host2: got %f want 3.0", got)
+	}
+	if rates, ok := totals["endpointQueryRates"].(map[string]interface{}); !ok || len(rates) != 2 {
+		t.Errorf("incorrect endpoint query rates: %v", totals["endpointQueryRates"])
+	}
+}
+
+func TestStatProcessorPushEndpointTimeouts(t *testing.T) {
+	limit := uint64(0)
+	sp := &defaultStatProcessor{
+		args:            &statProcessorArgs{limit: &limit},
+		statMapping:     map[string]*statGroup{labelAllQueries: newStatGroup(0)},
+		endpointMapping: map[string]*statGroup{},
+	}
+	sp.pushEndpoint(GetStat().Init([]byte("q"), 1.0).SetEndpoint("host1"))
+	sp.pushEndpoint(GetStat().Init([]byte("q"), 2.0).SetEndpoint("host2"))
+	sp.pushEndpoint(GetStat().Init([]byte("q"), 100.0).SetEndpoint("host2").SetTimedOut())
+
+	if got := sp.endpointMapping["host2"]; got.count != 1 || got.timeouts != 1 {
+		t.Errorf("incorrect stats of host2: got %d queries, %d timeouts want 1, 1", got.count, got.timeouts)
+	}
+	if got := sp.endpointMapping["host1"].timeouts; got != 0 {
+		t.Errorf("incorrect timeouts of host1: got %d want 0", got)
+	}
+	timeouts, ok := sp.GetTotalsMap()["endpointTimeouts"].(map[string]interface{})
+	if !ok || len(timeouts) != 1 || timeouts["host2"] != int64(1) {
+		t.Errorf("incorrect endpoint timeouts: %v", timeouts)
+	}
+}
+
+func TestStatProcessorGetTotalsMapTimeouts(t *testing.T) {
+	limit := uint64(0)
+	sp := &defaultStatProcessor{
+		args:        &statProcessorArgs{limit: &limit},
+		statMapping: map[string]*statGroup{labelAllQueries: newStatGroup(0), "q 1": newStatGroup(0)},
+	}
+	if _, ok := sp.GetTotalsMap()["timeouts"]; ok {
+		t.Errorf("unexpected timeouts without a timed-out query")
+	}
+	timedOut := GetStat().Init([]byte("q 1"), 100.0).SetTimedOut()
+	sp.statMapping["q 1"].pushStat(timedOut)
+	sp.statMapping[labelAllQueries].pushStat(timedOut)
+	timeouts, ok := sp.GetTotalsMap()["timeouts"].(map[string]interface{})
+	if !ok || timeouts["q_1"] != int64(1) || timeouts["all_queries"] != int64(1) {
+		t.Errorf("incorrect timeouts: %v", timeouts)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/HdrHistogram/hdrhistogram-go"
@@ -16,17 +17,35 @@ var (
 // Stat represents one statistical measurement, typically used to store the
 // latency of a query (or part of query).
 type Stat struct {
-	label     []byte
-	value     float64
-	isWarm    bool
-	isPartial bool
+	label    []byte
+	endpoint []byte
+	value    float64
+	// rows, bytes and serverTime are the rows returned, the bytes received
+	// and the execution time reported by the server, in milliseconds, when
+	// the runner knows them
+	rows          int64
+	bytes         int64
+	serverTime    float64
+	hasRows       bool
+	hasBytes      bool
+	hasServerTime bool
+	isWarm        bool
+	isPartial     bool
+	// isCold marks the query run alone right after the cache hooks, and
+	// isWarmup the queries of the warm-up phase, which are not reported
+	isCold   bool
+	isWarmup bool
+	// isTimedOut marks a query cancelled after --query-timeout, whose
+	// latency is not recorded
+	isTimedOut bool
 }
 
 var statPool = &sync.Pool{
 	New: func() interface{} {
 		return &Stat{
-			label: make([]byte, 0, 1024),
-			value: 0.0,
+			label:    make([]byte, 0, 1024),
+			endpoint: make([]byte, 0, 64),
+			value:    0.0,
 		}
 	},
 }
@@ -47,16 +66,72 @@ func GetPartialStat() *Stat {
 func (s *Stat) Init(label []byte, value float64) *Stat {
 	s.label = s.label[:0] // clear
 	s.label = append(s.label, label...)
+	s.endpoint = s.endpoint[:0]
 	s.value = value
+	s.clearResult()
 	s.isWarm = false
+	s.isCold = false
+	s.isWarmup = false
+	s.isTimedOut = false
+	return s
+}
+
+// SetRows sets the number of rows (or series, points, documents...) the query
+// returned
+func (s *Stat) SetRows(rows int64) *Stat {
+	s.rows = rows
+	s.hasRows = true
+	return s
+}
+
+// SetBytes sets the size of the response to the query, in bytes
+func (s *Stat) SetBytes(bytes int64) *Stat {
+	s.bytes = bytes
+	s.hasBytes = true
+	return s
+}
+
+// SetServerTime sets the time the server reports it took to execute the
+// query, in milliseconds
+func (s *Stat) SetServerTime(millis float64) *Stat {
+	s.serverTime = millis
+	s.hasServerTime = true
+	return s
+}
+
+func (s *Stat) clearResult() {
+	s.rows = 0
+	s.bytes = 0
+	s.serverTime = 0.0
+	s.hasRows = false
+	s.hasBytes = false
+	s.hasServerTime = false
+}
+
+// SetTimedOut marks the query as timed out, so that it is counted apart from
+// the latencies of its label.
+func (s *Stat) SetTimedOut() *Stat {
+	s.isTimedOut = true
+	return s
+}
+
+// SetEndpoint sets the endpoint (host or URL) the query was sent to, for the
+// stats per endpoint of the runners with several endpoints.
+func (s *Stat) SetEndpoint(endpoint string) *Stat {
+	s.endpoint = append(s.endpoint[:0], endpoint...)
 	return s
 }
 
 func (s *Stat) reset() *Stat {
 	s.label = s.label[:0]
+	s.endpoint = s.endpoint[:0]
 	s.value = 0.0
+	s.clearResult()
 	s.isWarm = false
 	s.isPartial = false
+	s.isCold = false
+	s.isWarmup = false
+	s.isTimedOut = false
 	return s
 }
 
@@ -65,6 +140,21 @@ type statGroup struct {
 	latencyHDRHistogram *hdrhistogram.Histogram
 	sum                 float64
 	count               int64
+	timeouts            int64
+	result              *resultGroup
+}
+
+// resultGroup collects the sizes of the results and the server times of the
+// queries of a statGroup, for the runners reporting them.
+type resultGroup struct {
+	rowsSum       int64
+	rowsCount     int64
+	emptyCount    int64 // queries returning no row
+	bytesSum      int64
+	bytesCount    int64
+	serverSum     float64
+	serverLatency float64 // sum of the latencies of the queries with a server time
+	serverCount   int64
 }
 
 // newStatGroup returns a new StatGroup with an initial size
@@ -90,6 +180,40 @@ func (s *statGroup) push(n float64) {
 	s.count++
 }
 
+// pushStat updates a StatGroup with the latency of a Stat, and the size of
+// the result and the server time when the Stat has them. A timed-out query is
+// only counted.
+func (s *statGroup) pushStat(stat *Stat) {
+	if stat.isTimedOut {
+		s.timeouts++
+		return
+	}
+	s.push(stat.value)
+	if !stat.hasRows && !stat.hasBytes && !stat.hasServerTime {
+		return
+	}
+	if s.result == nil {
+		s.result = &resultGroup{}
+	}
+	r := s.result
+	if stat.hasRows {
+		r.rowsSum += stat.rows
+		r.rowsCount++
+		if stat.rows == 0 {
+			r.emptyCount++
+		}
+	}
+	if stat.hasBytes {
+		r.bytesSum += stat.bytes
+		r.bytesCount++
+	}
+	if stat.hasServerTime {
+		r.serverSum += stat.serverTime
+		r.serverLatency += stat.value
+		r.serverCount++
+	}
+}
+
 // string makes a simple description of a statGroup.
 func (s *statGroup) string() string {
 	return fmt.Sprintf("min: %8.2fms, med: %8.2fms, mean: %8.2fms, max: %7.2fms, stddev: %8.2fms, sum: %5.1fsec, count: %d",
@@ -104,9 +228,89 @@ func (s *statGroup) string() string {
 
 func (s *statGroup) write(w io.Writer) error {
 	_, err := fmt.Fprintln(w, s.string())
+	if err != nil {
+		return err
+	}
+	if s.timeouts > 0 {
+		if _, err = fmt.Fprintf(w, "timed out: %d\n", s.timeouts); err != nil {
+			return err
+		}
+	}
+	if s.result == nil {
+		return nil
+	}
+	_, err = fmt.Fprintln(w, s.result.string())
 	return err
 }
 
+// string makes a simple description of a resultGroup, with only what the
+// runner reported.
+func (r *resultGroup) string() string {
+	var parts []string
+	if r.rowsCount > 0 {
+		parts = append(parts, fmt.Sprintf("rows: %8.1f, empty: %d", r.MeanRows(), r.emptyCount))
+	}
+	if r.bytesCount > 0 {
+		parts = append(parts, fmt.Sprintf("bytes: %10.1f", r.MeanBytes()))
+	}
+	if r.serverCount > 0 {
+		parts = append(parts, fmt.Sprintf("server: %8.2fms, overhead: %8.2fms", r.MeanServerTime(), r.MeanOverhead()))
+	}
+	return "mean " + strings.Join(parts, ", ")
+}
+
+// MeanRows returns the mean number of rows returned by the queries
+func (r *resultGroup) MeanRows() float64 {
+	if r.rowsCount == 0 {
+		return 0
+	}
+	return float64(r.rowsSum) / float64(r.rowsCount)
+}
+
+// MeanBytes returns the mean size of the responses in bytes
+func (r *resultGroup) MeanBytes() float64 {
+	if r.bytesCount == 0 {
+		return 0
+	}
+	return float64(r.bytesSum) / float64(r.bytesCount)
+}
+
+// MeanServerTime returns the mean execution time reported by the server in
+// milliseconds
+func (r *resultGroup) MeanServerTime() float64 {
+	if r.serverCount == 0 {
+		return 0
+	}
+	return r.serverSum / float64(r.serverCount)
+}
+
+// MeanOverhead returns the mean of the latencies minus the server times in
+// milliseconds, i.e. the time spent in the network and decoding the results
+func (r *resultGroup) MeanOverhead() float64 {
+	if r.serverCount == 0 {
+		return 0
+	}
+	return (r.serverLatency - r.serverSum) / float64(r.serverCount)
+}
+
+// totals returns the means of the resultGroup for the results file, with
+// only what the runner reported
+func (r *resultGroup) totals() map[string]interface{} {
+	totals := make(map[string]interface{})
+	if r.rowsCount > 0 {
+		totals["meanRows"] = r.MeanRows()
+		totals["emptyResults"] = r.emptyCount
+	}
+	if r.bytesCount > 0 {
+		totals["meanBytes"] = r.MeanBytes()
+	}
+	if r.serverCount > 0 {
+		totals["meanServerMillis"] = r.MeanServerTime()
+		totals["meanOverheadMillis"] = r.MeanOverhead()
+	}
+	return totals
+}
+
 // Median returns the Median value of the StatGroup in milliseconds
 func (s *statGroup) Median() float64 {
 	return float64(s.latencyHDRHistogram.ValueAtQuantile(50.0)) / hdrScaleFactor
@@ -43,11 +43,24 @@ func TestStatInit(t *testing.T) {
 	}
 }
 
+func TestStatSetEndpoint(t *testing.T) {
+	s := GetStat()
+	s.Init([]byte("foo"), 11.0).SetEndpoint("http://host1:8086")
+	if string(s.endpoint) != "http://host1:8086" {
+		t.Errorf("SetEndpoint() failed - endpoint is incorrect: %s", s.endpoint)
+	}
+	s.Init([]byte("foo"), 12.0)
+	if len(s.endpoint) > 0 {
+		t.Errorf("Init() failed - endpoint of the previous query kept: %s", s.endpoint)
+	}
+}
+
 func TestStatReset(t *testing.T) {
 	s := GetStat()
 	s.isPartial = true
 	s.isWarm = true
 	s.label = []byte("foo")
+	s.endpoint = []byte("bar")
 	s.value = 100.0
 	s.reset()
 	if s.isPartial {
@@ -59,6 +72,9 @@ func TestStatReset(t *testing.T) {
 	if len(s.label) > 0 {
 		t.Errorf("reset() failed - label has non-0 length")
 	}
+	if len(s.endpoint) > 0 {
+		t.Errorf("reset() failed - endpoint has non-0 length")
+	}
 	if s.value != 0.0 {
 		t.Errorf("reset() failed - value is not 0.0")
 	}
@@ -405,3 +421,118 @@ func TestWriteStatGroupMap(t *testing.T) {
 		}
 	}
 }
+
+func TestStatSetResult(t *testing.T) {
+	s := GetStat()
+	s.Init([]byte("foo"), 11.0).SetRows(3).SetBytes(120).SetServerTime(4.5)
+	if !s.hasRows || s.rows != 3 {
+		t.Errorf("SetRows() failed - rows = %d", s.rows)
+	}
+	if !s.hasBytes || s.bytes != 120 {
+		t.Errorf("SetBytes() failed - bytes = %d", s.bytes)
+	}
+	if !s.hasServerTime || s.serverTime != 4.5 {
+		t.Errorf("SetServerTime() failed - serverTime = %f", s.serverTime)
+	}
+	s.Init([]byte("foo"), 12.0)
+	if s.hasRows || s.hasBytes || s.hasServerTime || s.rows != 0 || s.bytes != 0 || s.serverTime != 0 {
+		t.Errorf("Init() failed - result of the previous query kept: %v", s)
+	}
+	s.SetRows(1).reset()
+	if s.hasRows || s.rows != 0 {
+		t.Errorf("reset() failed - rows kept")
+	}
+}
+
+func TestStatGroupPushStat(t *testing.T) {
+	sg := newStatGroup(0)
+	sg.pushStat(GetStat().Init([]byte("foo"), 10.0))
+	if sg.result != nil {
+		t.Errorf("unexpected result for stats without one")
+	}
+	var buf bytes.Buffer
+	if err := sg.write(&buf); err != nil {
+		t.Fatalf("unexpected error for write: %v", err)
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 1 {
+		t.Errorf("incorrect number of lines without result: got %d want 1", got)
+	}
+
+	sg.pushStat(GetStat().Init([]byte("foo"), 10.0).SetRows(4).SetBytes(100))
+	sg.pushStat(GetStat().Init([]byte("foo"), 20.0).SetRows(0).SetBytes(50).SetServerTime(15.0))
+	sg.pushStat(GetStat().Init([]byte("foo"), 8.0).SetServerTime(2.0))
+	if sg.count != 4 {
+		t.Errorf("incorrect count: got %d want 4", sg.count)
+	}
+	r := sg.result
+	if got := r.MeanRows(); got != 2.0 {
+		t.Errorf("incorrect mean rows: got %f want 2.0", got)
+	}
+	if r.emptyCount != 1 {
+		t.Errorf("incorrect empty results: got %d want 1", r.emptyCount)
+	}
+	if got := r.MeanBytes(); got != 75.0 {
+		t.Errorf("incorrect mean bytes: got %f want 75.0", got)
+	}
+	if got := r.MeanServerTime(); got != 8.5 {
+		t.Errorf("incorrect mean server time: got %f want 8.5", got)
+	}
+	// (20-15 + 8-2) / 2
+	if got := r.MeanOverhead(); got != 5.5 {
+		t.Errorf("incorrect mean overhead: got %f want 5.5", got)
+	}
+
+	buf.Reset()
+	if err := sg.write(&buf); err != nil {
+		t.Fatalf("unexpected error for write: %v", err)
+	}
+	want := "mean rows:      2.0, empty: 1, bytes:       75.0, server:     8.50ms, overhead:     5.50ms\n"
+	if got := strings.SplitAfter(buf.String(), "\n")[1]; got != want {
+		t.Errorf("incorrect result line: got\n%q\nwant\n%q", got, want)
+	}
+
+	totals := r.totals()
+	if len(totals) != 5 || totals["meanRows"] != 2.0 || totals["emptyResults"] != int64(1) || totals["meanServerMillis"] != 8.5 {
+		t.Errorf("incorrect totals: %v", totals)
+	}
+}
+
+func TestResultGroupPartial(t *testing.T) {
+	r := &resultGroup{bytesSum: 30, bytesCount: 2}
+	if got, want := r.string(), "mean bytes:       15.0"; got != want {
+		t.Errorf("incorrect string: got %q want %q", got, want)
+	}
+	totals := r.totals()
+	if len(totals) != 1 || totals["meanBytes"] != 15.0 {
+		t.Errorf("incorrect totals: %v", totals)
+	}
+	if r.MeanRows() != 0 || r.MeanServerTime() != 0 || r.MeanOverhead() != 0 {
+		t.Errorf("unexpected means without values")
+	}
+}
+
+func TestStatGroupPushStatTimedOut(t *testing.T) {
+	sg := newStatGroup(0)
+	sg.pushStat(GetStat().Init([]byte("q"), 10.0))
+	sg.pushStat(GetStat().Init([]byte("q"), 1000.0).SetTimedOut())
+	if sg.count != 1 || sg.timeouts != 1 {
+		t.Errorf("incorrect counts: got %d queries and %d timeouts, want 1 and 1", sg.count, sg.timeouts)
+	}
+	if got := sg.Max(); got != 10.0 {
+		t.Errorf("latency of the timed-out query recorded: max %f", got)
+	}
+
+	var buf bytes.Buffer
+	if err := sg.write(&buf); err != nil {
+		t.Fatalf("unexpected error for write: %v", err)
+	}
+	if got := strings.SplitAfter(buf.String(), "\n")[1]; got != "timed out: 1\n" {
+		t.Errorf("incorrect timeout line: %q", got)
+	}
+
+	s := GetStat().SetTimedOut()
+	statPool.Put(s)
+	if GetStat().isTimedOut {
+		t.Errorf("stat from the pool is timed out")
+	}
+}
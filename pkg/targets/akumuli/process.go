@@ -2,6 +2,7 @@ package akumuli
 
 import (
 	"encoding/binary"
+	"fmt"
 	"github.com/timescale/tsbs/pkg/targets"
 	"log"
 	"net"
@@ -17,9 +18,7 @@ type processor struct {
 
 func (p *processor) Init(numWorker int, _, _ bool) {
 	p.worker = numWorker
-	c, err := net.Dial("tcp", p.endpoint)
-	if err == nil {
-		p.conn = c
+	if err := p.connect(); err == nil {
 		log.Println("Connection with", p.endpoint, "successful")
 	} else {
 		log.Println("Can't establish connection with", p.endpoint)
@@ -27,27 +26,53 @@ func (p *processor) Init(numWorker int, _, _ bool) {
 	}
 }
 
+func (p *processor) connect() error {
+	c, err := net.Dial("tcp", p.endpoint)
+	if err != nil {
+		return err
+	}
+	p.conn = c
+	return nil
+}
+
 func (p *processor) Close(doLoad bool) {
-	if doLoad {
+	if doLoad && p.conn != nil {
 		p.conn.Close()
 	}
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+// ProcessBatch writes the payloads of the batch, each one a row, removing
+// them from the batch as they are written so that a retry after an error only
+// writes the rest. The connection is closed on an error and opened again on
+// the retry.
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	batch := b.(*batch)
-	var nmetrics uint64
+	var nmetrics, nrows uint64
 	if doLoad {
-		head := batch.buf.Bytes()
-		for len(head) != 0 {
+		if p.conn == nil {
+			if err := p.connect(); err != nil {
+				return 0, 0, fmt.Errorf("error connecting to %s: %v", p.endpoint, err)
+			}
+		}
+		for batch.buf.Len() != 0 {
+			head := batch.buf.Bytes()
 			nbytes := binary.LittleEndian.Uint16(head[4:6])
 			nfields := binary.LittleEndian.Uint16(head[6:8])
 			payload := head[8:nbytes]
-			p.conn.Write(payload)
+			if _, err := p.conn.Write(payload); err != nil {
+				p.conn.Close()
+				p.conn = nil
+				return nmetrics, nrows, fmt.Errorf("error writing to %s: %v", p.endpoint, err)
+			}
+			batch.buf.Next(int(nbytes))
+			batch.rows--
 			nmetrics += uint64(nfields)
-			head = head[nbytes:]
+			nrows++
 		}
+	} else {
+		nrows = uint64(batch.rows)
 	}
 	batch.buf.Reset()
 	p.bufPool.Put(batch.buf)
-	return nmetrics, uint64(batch.rows)
+	return nmetrics, nrows, nil
 }
@@ -0,0 +1,80 @@
+package akumuli
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+// failingConn is a connection whose writes fail once failAfter payloads were
+// written
+type failingConn struct {
+	net.Conn
+	written   [][]byte
+	failAfter int
+}
+
+func (c *failingConn) Write(b []byte) (int, error) {
+	if len(c.written) == c.failAfter {
+		return 0, errors.New("connection reset by peer")
+	}
+	c.written = append(c.written, append([]byte(nil), b...))
+	return len(b), nil
+}
+
+func (c *failingConn) Close() error {
+	return nil
+}
+
+// headerLength is the length of the header the serializer writes before each
+// point
+const headerLength = 8
+
+// testPayload returns a serialized point with nfields fields and body
+func testPayload(nfields uint16, body string) []byte {
+	buf := make([]byte, headerLength, headerLength+len(body))
+	buf = append(buf, body...)
+	binary.LittleEndian.PutUint16(buf[4:6], uint16(len(buf)))
+	binary.LittleEndian.PutUint16(buf[6:headerLength], nfields)
+	return buf
+}
+
+func TestProcessorProcessBatchPartialWrite(t *testing.T) {
+	bufPool := &sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+	b := (&factory{bufPool: bufPool}).New().(*batch)
+	for i, body := range []string{"+row0\r\n", "+row1\r\n", "+row2\r\n"} {
+		b.Append(data.LoadedPoint{Data: testPayload(uint16(i+1), body)})
+	}
+
+	conn := &failingConn{failAfter: 1}
+	p := &processor{bufPool: bufPool, conn: conn}
+	mCnt, rCnt, err := p.ProcessBatch(b, true)
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if mCnt != 1 || rCnt != 1 {
+		t.Errorf("incorrect counts of the written data: got %d metrics, %d rows want 1, 1", mCnt, rCnt)
+	}
+	if b.Len() != 2 {
+		t.Errorf("incorrect rows left in the batch: got %d want 2", b.Len())
+	}
+
+	// the retry only writes the payloads that were not written
+	conn = &failingConn{failAfter: -1}
+	p.conn = conn
+	mCnt, rCnt, err = p.ProcessBatch(b, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mCnt != 5 || rCnt != 2 {
+		t.Errorf("incorrect counts of the retry: got %d metrics, %d rows want 5, 2", mCnt, rCnt)
+	}
+	if len(conn.written) != 2 || string(conn.written[0]) != "+row1\r\n" || string(conn.written[1]) != "+row2\r\n" {
+		t.Errorf("incorrect payloads written by the retry: %q", conn.written)
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"github.com/timescale/tsbs/load"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
-	"log"
 )
 
 type benchmark struct {
@@ -67,7 +66,7 @@ func (p *processor) Init(_ int, _, _ bool) {}
 
 // ProcessBatch reads eventsBatches which contain rows of CQL strings and
 // creates a gocql.LoggedBatch to insert
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	events := b.(*eventsBatch)
 
 	if doLoad {
@@ -78,11 +77,11 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 
 		err := p.dbc.clientSession.ExecuteBatch(batch)
 		if err != nil {
-			log.Fatalf("Error writing: %s\n", err.Error())
+			return 0, 0, fmt.Errorf("error writing: %v", err)
 		}
 	}
 	metricCnt := uint64(len(events.rows))
 	events.rows = events.rows[:0]
 	ePool.Put(events)
-	return metricCnt, 0
+	return metricCnt, 0, nil
 }
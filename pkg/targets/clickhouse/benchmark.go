@@ -21,6 +21,8 @@ type ClickhouseConfig struct {
 	InTableTag bool
 	Debug      int
 	DbName     string
+	// Rollups creates the 1m and 1h rollups of the cpu table
+	Rollups bool
 }
 
 // String values of tags and fields to insert - string representation
@@ -131,5 +133,9 @@ func (b *benchmark) GetProcessor() targets.Processor {
 
 // loader.Benchmark interface implementation
 func (b *benchmark) GetDBCreator() targets.DBCreator {
-	return &dbCreator{ds: b.GetDataSource(), config: b.conf}
+	dbc := &dbCreator{ds: b.GetDataSource(), config: b.conf}
+	if b.conf.Rollups {
+		return &rollupCreator{dbc}
+	}
+	return dbc
 }
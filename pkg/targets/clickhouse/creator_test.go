@@ -66,3 +66,18 @@ func TestGenerateTagsTableQueryPanicOnWrongType(t *testing.T) {
 
 	t.Fatalf("test should have stopped at this point")
 }
+
+func TestGenerateRollupQuery(t *testing.T) {
+	want := `
+			CREATE MATERIALIZED VIEW cpu_1h (
+				bucket  DateTime,
+				tags_id UInt32,
+				max_usage_user SimpleAggregateFunction(max, Nullable(Float64)),max_usage_system SimpleAggregateFunction(max, Nullable(Float64))
+			) ENGINE = AggregatingMergeTree() ORDER BY (tags_id, bucket)
+			AS SELECT toStartOfHour(created_at) AS bucket, tags_id, max(usage_user) AS max_usage_user, max(usage_system) AS max_usage_system FROM cpu GROUP BY bucket, tags_id
+			`
+	got := generateRollupQuery("cpu_1h", "toStartOfHour", []string{"usage_user", "", "usage_system"})
+	if got != want {
+		t.Errorf("unexpected result.\nexpected: %s\ngot: %s", want, got)
+	}
+}
@@ -0,0 +1,106 @@
+package clickhouse
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// rollupTable is the table rolled up with --rollups
+const rollupTable = "cpu"
+
+// rollups are the rollups of the cpu table, with the max of each field per
+// host per minute and hour. Their names are the ones the queries generated
+// for them read.
+var rollups = []struct {
+	name     string
+	function string
+}{
+	{name: rollupTable + "_1m", function: "toStartOfMinute"},
+	{name: rollupTable + "_1h", function: "toStartOfHour"},
+}
+
+// rollupCreator is the dbCreator when the rollups of the cpu table are
+// created, as materialized views filled in as the data is inserted. Their
+// parts are merged once the data is loaded, so that the time it takes is
+// reported.
+type rollupCreator struct {
+	*dbCreator
+}
+
+// loader.DBCreator interface implementation
+func (d *rollupCreator) CreateDB(dbName string) error {
+	if err := d.dbCreator.CreateDB(dbName); err != nil {
+		return err
+	}
+	columns, ok := d.headers.FieldKeys[rollupTable]
+	if !ok {
+		return fmt.Errorf("rollups are only created for the %s table of the devops and cpu-only use cases", rollupTable)
+	}
+
+	db := sqlx.MustConnect(dbType, getConnectString(d.config, true))
+	defer db.Close()
+	for _, r := range rollups {
+		sql := generateRollupQuery(r.name, r.function, columns)
+		if d.config.Debug > 0 {
+			fmt.Printf(sql)
+		}
+		if _, err := db.Exec(sql); err != nil {
+			panic(err)
+		}
+	}
+	return nil
+}
+
+// PostLoad merges the parts of the rollups, aggregated per inserted batch.
+// The rollups are only created with the database, they may not exist when the
+// data is loaded into an existing one: the missing ones are skipped.
+func (d *rollupCreator) PostLoad(dbName string) error {
+	db := sqlx.MustConnect(dbType, getConnectString(d.config, true))
+	defer db.Close()
+	for _, r := range rollups {
+		var n uint64
+		err := db.Get(&n, fmt.Sprintf("SELECT count() FROM system.tables WHERE database = currentDatabase() AND name = '%s'", r.name))
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			fmt.Printf("rollup %s does not exist, not merging it\n", r.name)
+			continue
+		}
+		if _, err := db.Exec(fmt.Sprintf("OPTIMIZE TABLE %s FINAL", r.name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateRollupQuery builds the CREATE MATERIALIZED VIEW SQL statement of the
+// rollup name, with the max of the fields columns of the cpu table per host
+// per bucket of time, the start of which function returns
+func generateRollupQuery(name, function string, columns []string) string {
+	var columnsWithType, selectClauses []string
+	for _, column := range columns {
+		if len(column) == 0 {
+			// Skip nameless columns
+			continue
+		}
+		columnsWithType = append(columnsWithType, fmt.Sprintf("max_%s SimpleAggregateFunction(max, Nullable(Float64))", column))
+		selectClauses = append(selectClauses, fmt.Sprintf("max(%[1]s) AS max_%[1]s", column))
+	}
+
+	return fmt.Sprintf(`
+			CREATE MATERIALIZED VIEW %s (
+				bucket  DateTime,
+				tags_id UInt32,
+				%s
+			) ENGINE = AggregatingMergeTree() ORDER BY (tags_id, bucket)
+			AS SELECT %s(created_at) AS bucket, tags_id, %s FROM %s GROUP BY bucket, tags_id
+			`,
+		name,
+		strings.Join(columnsWithType, ","),
+		function,
+		strings.Join(selectClauses, ", "),
+		rollupTable)
+}
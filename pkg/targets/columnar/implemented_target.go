@@ -0,0 +1,36 @@
+package columnar
+
+import (
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// NewTarget returns the target for the given columnar file format, either
+// constants.FormatParquet or constants.FormatArrow.
+func NewTarget(format string) targets.ImplementedTarget {
+	return &columnarTarget{format: format}
+}
+
+type columnarTarget struct {
+	format string
+}
+
+func (t *columnarTarget) TargetSpecificFlags(string, *pflag.FlagSet) {}
+
+func (t *columnarTarget) TargetName() string {
+	return t.format
+}
+
+// Serializer returns a Serializer without any output, the files of a
+// columnar format depend on the generated measurements, so the Serializer
+// that writes them is created with NewSerializer.
+func (t *columnarTarget) Serializer() serialize.PointSerializer {
+	return &Serializer{format: t.format}
+}
+
+func (t *columnarTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
+	panic("not implemented")
+}
@@ -0,0 +1,377 @@
+package columnar
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/compress"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+const (
+	// DefaultRowGroupSize is the number of rows of a Parquet row group or of
+	// an Arrow record batch when none is configured.
+	DefaultRowGroupSize = 64 * 1024
+
+	// TimeColumn is the name of the timestamp column of every file.
+	TimeColumn = "time"
+
+	writeBufferSize = 4 << 20
+)
+
+var errNotOpened = fmt.Errorf("columnar serializer has no output, it must be created with NewSerializer")
+
+// Options configures where and how a Serializer writes its files.
+type Options struct {
+	// Dir is the directory the files are written to, it is created if needed.
+	Dir string
+	// RowGroupSize is the number of rows per Parquet row group or Arrow
+	// record batch.
+	RowGroupSize int
+	// Part is appended to the file names, e.g. to keep apart the files of
+	// interleaved generation groups. Files are named <measurement>[-<part>].<ext>
+	Part string
+}
+
+// Serializer writes Points in a columnar format, into one file per
+// measurement. Each file has a timestamp column, a typed column per tag and a
+// float64 column per field, tags and fields without a value are null.
+// Close must be called to flush the files.
+type Serializer struct {
+	format  string
+	writers map[string]*tableWriter
+}
+
+// NewSerializer creates the files of every measurement in headers.
+func NewSerializer(format string, headers *common.GeneratedDataHeaders, opts Options) (*Serializer, error) {
+	if format != constants.FormatParquet && format != constants.FormatArrow {
+		return nil, fmt.Errorf("unknown columnar format %s", format)
+	}
+	if opts.RowGroupSize <= 0 {
+		opts.RowGroupSize = DefaultRowGroupSize
+	}
+	if err := os.MkdirAll(opts.Dir, 0755); err != nil {
+		return nil, err
+	}
+
+	s := &Serializer{format: format, writers: make(map[string]*tableWriter)}
+	// sort the measurements so the files are created in the same order
+	measurements := make([]string, 0, len(headers.FieldKeys))
+	for m := range headers.FieldKeys {
+		measurements = append(measurements, m)
+	}
+	sort.Strings(measurements)
+	for _, m := range measurements {
+		schema, err := Schema(headers.TagKeys, headers.TagTypes, headers.FieldKeys[m])
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		w, err := newTableWriter(format, filepath.Join(opts.Dir, FileName(format, m, opts.Part)), schema, opts.RowGroupSize)
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.writers[m] = w
+	}
+	return s, nil
+}
+
+// FileName returns the name of the file of a measurement.
+func FileName(format, measurement, part string) string {
+	ext := ".parquet"
+	if format == constants.FormatArrow {
+		ext = ".arrows"
+	}
+	if part != "" {
+		return measurement + "-" + part + ext
+	}
+	return measurement + ext
+}
+
+// Schema returns the Arrow schema of a measurement with the given tags and
+// fields.
+func Schema(tagKeys, tagTypes, fieldKeys []string) (*arrow.Schema, error) {
+	fields := make([]arrow.Field, 0, 1+len(tagKeys)+len(fieldKeys))
+	fields = append(fields, arrow.Field{Name: TimeColumn, Type: &arrow.TimestampType{Unit: arrow.Microsecond, TimeZone: "UTC"}})
+	for i, key := range tagKeys {
+		dt, err := tagTypeToArrowType(tagTypes[i])
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, arrow.Field{Name: key, Type: dt, Nullable: true})
+	}
+	for _, key := range fieldKeys {
+		fields = append(fields, arrow.Field{Name: key, Type: arrow.PrimitiveTypes.Float64, Nullable: true})
+	}
+	return arrow.NewSchema(fields, nil), nil
+}
+
+func tagTypeToArrowType(tagType string) (arrow.DataType, error) {
+	switch tagType {
+	case "string":
+		return arrow.BinaryTypes.String, nil
+	case "float32":
+		return arrow.PrimitiveTypes.Float32, nil
+	case "float64":
+		return arrow.PrimitiveTypes.Float64, nil
+	case "int64", "int":
+		return arrow.PrimitiveTypes.Int64, nil
+	case "int32":
+		return arrow.PrimitiveTypes.Int32, nil
+	default:
+		return nil, fmt.Errorf("unsupported tag type %s", tagType)
+	}
+}
+
+// Serialize appends Point p to the file of its measurement, the Writer w is
+// not used.
+func (s *Serializer) Serialize(p *data.Point, _ io.Writer) error {
+	if s.writers == nil {
+		return errNotOpened
+	}
+	tw, ok := s.writers[string(p.MeasurementName())]
+	if !ok {
+		return fmt.Errorf("measurement %s is not in the headers", p.MeasurementName())
+	}
+	return tw.append(p)
+}
+
+// Close writes the buffered rows and closes all the files.
+func (s *Serializer) Close() error {
+	var firstErr error
+	for _, tw := range s.writers {
+		if err := tw.close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// tableWriter buffers the rows of one measurement and writes them out every
+// rowGroupSize rows.
+type tableWriter struct {
+	builder      *array.RecordBuilder
+	columns      map[string]int
+	values       []interface{}
+	rows         int
+	rowGroupSize int
+
+	write    func(arrow.Record) error
+	finalize func() error
+}
+
+func newTableWriter(format, path string, schema *arrow.Schema, rowGroupSize int) (*tableWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	out := &bufferedFile{Writer: bufio.NewWriterSize(f, writeBufferSize), f: f}
+
+	mem := memory.NewGoAllocator()
+	tw := &tableWriter{
+		builder:      array.NewRecordBuilder(mem, schema),
+		columns:      make(map[string]int, len(schema.Fields())),
+		values:       make([]interface{}, len(schema.Fields())),
+		rowGroupSize: rowGroupSize,
+	}
+	for i, field := range schema.Fields() {
+		tw.columns[field.Name] = i
+	}
+
+	switch format {
+	case constants.FormatParquet:
+		props := parquet.NewWriterProperties(
+			parquet.WithAllocator(mem),
+			parquet.WithMaxRowGroupLength(int64(rowGroupSize)),
+			parquet.WithCompression(compress.Codecs.Snappy),
+		)
+		fw, err := pqarrow.NewFileWriter(schema, out, props, pqarrow.NewArrowWriterProperties(pqarrow.WithAllocator(mem)))
+		if err != nil {
+			out.Close()
+			return nil, err
+		}
+		tw.write = fw.Write
+		// closing the Parquet writer closes the file
+		tw.finalize = fw.Close
+	case constants.FormatArrow:
+		iw := ipc.NewWriter(out, ipc.WithSchema(schema), ipc.WithAllocator(mem))
+		tw.write = iw.Write
+		tw.finalize = func() error {
+			if err := iw.Close(); err != nil {
+				out.Close()
+				return err
+			}
+			return out.Close()
+		}
+	}
+	return tw, nil
+}
+
+func (tw *tableWriter) append(p *data.Point) error {
+	for i := range tw.values {
+		tw.values[i] = nil
+	}
+	for i, key := range p.TagKeys() {
+		if col, ok := tw.columns[string(key)]; ok {
+			tw.values[col] = p.TagValues()[i]
+		}
+	}
+	for i, key := range p.FieldKeys() {
+		col, ok := tw.columns[string(key)]
+		if !ok {
+			return fmt.Errorf("field %s of %s is not in the headers", key, p.MeasurementName())
+		}
+		tw.values[col] = p.FieldValues()[i]
+	}
+
+	ts := p.Timestamp().UnixNano() / int64(time.Microsecond)
+	tw.builder.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(ts))
+	for col := 1; col < len(tw.values); col++ {
+		if err := appendValue(tw.builder.Field(col), tw.values[col]); err != nil {
+			return fmt.Errorf("column %s of %s: %v", tw.builder.Schema().Field(col).Name, p.MeasurementName(), err)
+		}
+	}
+
+	tw.rows++
+	if tw.rows >= tw.rowGroupSize {
+		return tw.flush()
+	}
+	return nil
+}
+
+func (tw *tableWriter) flush() error {
+	if tw.rows == 0 {
+		return nil
+	}
+	rec := tw.builder.NewRecord()
+	defer rec.Release()
+	tw.rows = 0
+	return tw.write(rec)
+}
+
+func (tw *tableWriter) close() error {
+	err := tw.flush()
+	tw.builder.Release()
+	if ferr := tw.finalize(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// appendValue appends v to the column built by b, converting it to the type of
+// the column.
+func appendValue(b array.Builder, v interface{}) error {
+	if v == nil {
+		b.AppendNull()
+		return nil
+	}
+	switch b := b.(type) {
+	case *array.StringBuilder:
+		switch v := v.(type) {
+		case string:
+			b.Append(v)
+		case []byte:
+			b.Append(string(v))
+		default:
+			b.Append(fmt.Sprintf("%v", v))
+		}
+	case *array.Float64Builder:
+		f, err := toFloat64(v)
+		if err != nil {
+			return err
+		}
+		b.Append(f)
+	case *array.Float32Builder:
+		f, err := toFloat64(v)
+		if err != nil {
+			return err
+		}
+		b.Append(float32(f))
+	case *array.Int64Builder:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		b.Append(i)
+	case *array.Int32Builder:
+		i, err := toInt64(v)
+		if err != nil {
+			return err
+		}
+		b.Append(int32(i))
+	default:
+		return fmt.Errorf("unsupported column type %s", b.Type())
+	}
+	return nil
+}
+
+func toFloat64(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return 0, fmt.Errorf("can not convert %T to float64", v)
+	}
+}
+
+func toInt64(v interface{}) (int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case uint64:
+		return int64(v), nil
+	case float64:
+		return int64(v), nil
+	case float32:
+		return int64(v), nil
+	default:
+		return 0, fmt.Errorf("can not convert %T to int64", v)
+	}
+}
+
+// bufferedFile buffers the writes to a file and flushes them when closed.
+type bufferedFile struct {
+	*bufio.Writer
+	f *os.File
+}
+
+func (b *bufferedFile) Close() error {
+	err := b.Flush()
+	if cerr := b.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,186 @@
+package columnar
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v12/arrow"
+	"github.com/apache/arrow/go/v12/arrow/array"
+	"github.com/apache/arrow/go/v12/arrow/ipc"
+	"github.com/apache/arrow/go/v12/arrow/memory"
+	"github.com/apache/arrow/go/v12/parquet"
+	"github.com/apache/arrow/go/v12/parquet/file"
+	"github.com/apache/arrow/go/v12/parquet/pqarrow"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+var testHeaders = &common.GeneratedDataHeaders{
+	TagKeys:  []string{"name", "load_capacity"},
+	TagTypes: []string{"string", "float64"},
+	FieldKeys: map[string][]string{
+		"readings":    {"latitude", "velocity"},
+		"diagnostics": {"fuel_state"},
+	},
+}
+
+func testPoint(name interface{}, seconds int64, velocity interface{}) *data.Point {
+	p := data.NewPoint()
+	ts := time.Unix(seconds, 0)
+	p.SetTimestamp(&ts)
+	p.SetMeasurementName([]byte("readings"))
+	p.AppendTag([]byte("name"), name)
+	p.AppendTag([]byte("load_capacity"), 1500.0)
+	p.AppendField([]byte("latitude"), 52.5)
+	p.AppendField([]byte("velocity"), velocity)
+	return p
+}
+
+func TestSerializerWritesFilePerMeasurement(t *testing.T) {
+	for _, format := range []string{constants.FormatParquet, constants.FormatArrow} {
+		t.Run(format, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tsbs-columnar")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			s, err := NewSerializer(format, testHeaders, Options{Dir: dir, RowGroupSize: 2, Part: "1"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			points := []*data.Point{
+				testPoint("truck_0", 1451606400, 10),
+				testPoint(nil, 1451606400, nil),
+				testPoint("truck_0", 1451606410, int64(30)),
+			}
+			for _, p := range points {
+				if err := s.Serialize(p, nil); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := s.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			tbl, batches := readTable(t, format, filepath.Join(dir, FileName(format, "readings", "1")))
+			defer tbl.Release()
+			if batches != 2 {
+				t.Errorf("incorrect number of row groups: got %d want 2", batches)
+			}
+			if tbl.NumRows() != 3 || tbl.NumCols() != 5 {
+				t.Fatalf("incorrect shape: got %d rows and %d columns", tbl.NumRows(), tbl.NumCols())
+			}
+
+			times := column(tbl, 0)
+			if got := times[2].(arrow.Timestamp); got != arrow.Timestamp(1451606410*1000000) {
+				t.Errorf("incorrect time: got %d", got)
+			}
+			names := column(tbl, 1)
+			if names[0] != "truck_0" || names[1] != nil {
+				t.Errorf("incorrect names: got %v", names)
+			}
+			if got := column(tbl, 2)[0]; got != 1500.0 {
+				t.Errorf("incorrect load_capacity: got %v", got)
+			}
+			velocities := column(tbl, 4)
+			if velocities[0] != 10.0 || velocities[1] != nil || velocities[2] != 30.0 {
+				t.Errorf("incorrect velocities: got %v", velocities)
+			}
+
+			// measurements without points still get a file with their schema
+			empty, _ := readTable(t, format, filepath.Join(dir, FileName(format, "diagnostics", "1")))
+			defer empty.Release()
+			if empty.NumRows() != 0 || empty.Schema().Field(3).Name != "fuel_state" {
+				t.Errorf("incorrect empty file: %d rows, schema %s", empty.NumRows(), empty.Schema())
+			}
+		})
+	}
+}
+
+func TestSerializerErrors(t *testing.T) {
+	if _, err := NewSerializer(constants.FormatTimescaleDB, testHeaders, Options{}); err == nil {
+		t.Errorf("expected an error for a non columnar format")
+	}
+	headers := &common.GeneratedDataHeaders{
+		TagKeys:   []string{"ok"},
+		TagTypes:  []string{"[]uint8"},
+		FieldKeys: map[string][]string{"cpu": {"usage"}},
+	}
+	if _, err := Schema(headers.TagKeys, headers.TagTypes, headers.FieldKeys["cpu"]); err == nil {
+		t.Errorf("expected an error for an unsupported tag type")
+	}
+	if err := (&Serializer{}).Serialize(testPoint("truck_0", 0, 1), nil); err != errNotOpened {
+		t.Errorf("unexpected error for a serializer without output: %v", err)
+	}
+}
+
+// readTable reads back a file, returning its content and the number of row
+// groups or record batches it has.
+func readTable(t *testing.T, format, path string) (arrow.Table, int) {
+	mem := memory.NewGoAllocator()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if format == constants.FormatParquet {
+		rdr, err := file.NewParquetReader(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rowGroups := rdr.NumRowGroups()
+		if _, err := f.Seek(0, 0); err != nil {
+			t.Fatal(err)
+		}
+		tbl, err := pqarrow.ReadTable(context.Background(), f, parquet.NewReaderProperties(mem), pqarrow.ArrowReadProperties{}, mem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return tbl, rowGroups
+	}
+
+	rdr, err := ipc.NewReader(f, ipc.WithAllocator(mem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rdr.Release()
+	var recs []arrow.Record
+	for rdr.Next() {
+		rec := rdr.Record()
+		rec.Retain()
+		recs = append(recs, rec)
+	}
+	if rdr.Err() != nil {
+		t.Fatal(rdr.Err())
+	}
+	return array.NewTableFromRecords(rdr.Schema(), recs), len(recs)
+}
+
+// column returns the values of column i, with nil for nulls.
+func column(tbl arrow.Table, i int) []interface{} {
+	var values []interface{}
+	for _, chunk := range tbl.Column(i).Data().Chunks() {
+		for j := 0; j < chunk.Len(); j++ {
+			if chunk.IsNull(j) {
+				values = append(values, nil)
+				continue
+			}
+			switch arr := chunk.(type) {
+			case *array.Timestamp:
+				values = append(values, arr.Value(j))
+			case *array.String:
+				values = append(values, arr.Value(j))
+			case *array.Float64:
+				values = append(values, arr.Value(j))
+			}
+		}
+	}
+	return values
+}
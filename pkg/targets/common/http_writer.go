@@ -0,0 +1,123 @@
+package common
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	headerContentEncoding = "Content-Encoding"
+	headerGzip            = "gzip"
+)
+
+// ErrBackoff is returned by HTTPWriter.Write when the server asks for the
+// writes to back off.
+var ErrBackoff = fmt.Errorf("backpressure is needed")
+
+// HTTPWriterConfig is the configuration used to create an HTTPWriter.
+type HTTPWriterConfig struct {
+	// URL of the write endpoint, e.g. "http://example.com:8086/write?db=benchmark"
+	URL string
+
+	// ContentType of the bodies written, e.g. "text/plain".
+	ContentType string
+
+	// ClientName is the name the HTTP client sends as its User-Agent.
+	ClientName string
+
+	// StatusCodes are the status codes of successful writes, 204 No
+	// Content if empty.
+	StatusCodes []int
+
+	// Backpressure tells whether the response with the status code and
+	// body of a failed write asks to back off, never if nil.
+	Backpressure func(statusCode int, body []byte) bool
+
+	// Debug label for more informative errors.
+	DebugInfo string
+}
+
+// HTTPWriter writes bodies to the write endpoint of an HTTP server, e.g.
+// the line protocol of InfluxDB or the JSON data points of OpenTSDB.
+type HTTPWriter struct {
+	client fasthttp.Client
+
+	c           HTTPWriterConfig
+	url         []byte
+	contentType []byte
+}
+
+// NewHTTPWriter returns a new HTTPWriter from the supplied HTTPWriterConfig.
+func NewHTTPWriter(c HTTPWriterConfig) *HTTPWriter {
+	if len(c.StatusCodes) == 0 {
+		c.StatusCodes = []int{fasthttp.StatusNoContent}
+	}
+	return &HTTPWriter{
+		client: fasthttp.Client{
+			Name: c.ClientName,
+		},
+
+		c:           c,
+		url:         []byte(c.URL),
+		contentType: []byte(c.ContentType),
+	}
+}
+
+// URL returns the URL of the write endpoint.
+func (w *HTTPWriter) URL() string {
+	return string(w.url)
+}
+
+var methodPost = []byte("POST")
+
+func (w *HTTPWriter) initializeReq(req *fasthttp.Request, body []byte, isGzip bool) {
+	req.Header.SetContentTypeBytes(w.contentType)
+	req.Header.SetMethodBytes(methodPost)
+	req.Header.SetRequestURIBytes(w.url)
+	if isGzip {
+		req.Header.Add(headerContentEncoding, headerGzip)
+	}
+	req.SetBody(body)
+}
+
+func (w *HTTPWriter) executeReq(req *fasthttp.Request, resp *fasthttp.Response) (int64, error) {
+	start := time.Now()
+	err := w.client.Do(req, resp)
+	lat := time.Since(start).Nanoseconds()
+	if err == nil {
+		sc := resp.StatusCode()
+		if !w.isSuccess(sc) {
+			if w.c.Backpressure != nil && w.c.Backpressure(sc, resp.Body()) {
+				err = ErrBackoff
+			} else {
+				err = fmt.Errorf("[DebugInfo: %s] Invalid write response (status %d): %s", w.c.DebugInfo, sc, resp.Body())
+			}
+		}
+	}
+	return lat, err
+}
+
+func (w *HTTPWriter) isSuccess(statusCode int) bool {
+	for _, sc := range w.c.StatusCodes {
+		if statusCode == sc {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the given byte slice to the HTTP server described in the Writer's HTTPWriterConfig.
+// It returns the latency in nanoseconds and any error received while sending the data over HTTP,
+// or it returns a new error if the HTTP response isn't as expected.
+func (w *HTTPWriter) Write(body []byte, isGzip bool) (int64, error) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	w.initializeReq(req, body, isGzip)
+
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+
+	return w.executeReq(req, resp)
+}
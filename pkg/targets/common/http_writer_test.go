@@ -0,0 +1,85 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestHTTPWriterInitializeReq(t *testing.T) {
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	w := NewHTTPWriter(HTTPWriterConfig{URL: "http://localhost:8086/write?db=test", ContentType: "text/plain"})
+	body := "this is a test body"
+	w.initializeReq(req, []byte(body), false)
+
+	if got := string(req.Body()); got != body {
+		t.Errorf("non-gzip: body not correct: got '%s' want '%s'", got, body)
+	}
+	if got := string(req.Header.Method()); got != string(methodPost) {
+		t.Errorf("non-gzip: method not correct: got %s want %s", got, string(methodPost))
+	}
+	if got := string(req.Header.RequestURI()); got != w.URL() {
+		t.Errorf("non-gzip: URI is not correct: got %s want %s", got, w.URL())
+	}
+	if got := string(req.Header.ContentType()); got != "text/plain" {
+		t.Errorf("non-gzip: Content-Type is not correct: got %s want text/plain", got)
+	}
+	if got := string(req.Header.Peek(headerContentEncoding)); got != "" {
+		t.Errorf("non-gzip: Content-Encoding is not empty: got %s", got)
+	}
+
+	w.initializeReq(req, []byte(body), true)
+	if got := string(req.Header.Peek(headerContentEncoding)); got != headerGzip {
+		t.Errorf("gzip: Content-Encoding is not correct: got %s want %s", got, headerGzip)
+	}
+}
+
+func TestHTTPWriterWrite(t *testing.T) {
+	// the server responds with the status code of the status parameter
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status, _ := strconv.Atoi(r.URL.Query().Get("status"))
+		w.WriteHeader(status)
+		w.Write([]byte("slow down"))
+	}))
+	defer s.Close()
+
+	backpressure := func(statusCode int, body []byte) bool {
+		return statusCode == http.StatusServiceUnavailable && string(body) == "slow down"
+	}
+	cases := []struct {
+		desc        string
+		status      int
+		statusCodes []int
+		wantErr     bool
+		wantBackoff bool
+	}{
+		{desc: "no content", status: http.StatusNoContent},
+		{desc: "ok not accepted", status: http.StatusOK, wantErr: true},
+		{desc: "ok accepted", status: http.StatusOK, statusCodes: []int{http.StatusNoContent, http.StatusOK}},
+		{desc: "backoff", status: http.StatusServiceUnavailable, wantErr: true, wantBackoff: true},
+		{desc: "error", status: http.StatusInternalServerError, wantErr: true},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			w := NewHTTPWriter(HTTPWriterConfig{
+				URL:          s.URL + "/write?status=" + strconv.Itoa(c.status),
+				StatusCodes:  c.statusCodes,
+				Backpressure: backpressure,
+			})
+			lat, err := w.Write([]byte("body"), false)
+			if lat <= 0 {
+				t.Errorf("latency is unrealistic (<= 0): %d", lat)
+			}
+			if got := err != nil; got != c.wantErr {
+				t.Errorf("incorrect error: got %v", err)
+			}
+			if got := err == ErrBackoff; got != c.wantBackoff {
+				t.Errorf("incorrect backoff: got %v want %v", got, c.wantBackoff)
+			}
+		})
+	}
+}
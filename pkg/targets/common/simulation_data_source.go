@@ -0,0 +1,108 @@
+package common
+
+import (
+	"log"
+	"sync"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+// PointConverter converts a simulated data.Point into the target specific
+// representation that the target's Batch knows how to append.
+type PointConverter interface {
+	// Convert appends the data.LoadedPoints created from p to dst and returns
+	// the extended slice. A single simulated point may result in several
+	// loaded points (e.g. one time series per field). The point is reused
+	// after Convert returns, so implementations must copy any data they keep.
+	Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error)
+}
+
+// PointConverterFunc is an adapter to allow the use of ordinary functions
+// that convert a point to a single data.LoadedPoint as a PointConverter.
+type PointConverterFunc func(p *data.Point) data.LoadedPoint
+
+// Convert calls f(p) and appends the result to dst.
+func (f PointConverterFunc) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	return append(dst, f(p)), nil
+}
+
+// SimulationDataSource implements targets.DataSource by driving a
+// common.Simulator and passing each simulated point through a
+// target specific PointConverter.
+type SimulationDataSource struct {
+	simulator common.Simulator
+	converter PointConverter
+	headers   *common.GeneratedDataHeaders
+	pointPool *sync.Pool
+
+	pending    []data.LoadedPoint
+	pendingInd int
+}
+
+// NewSimulationDataSource creates a targets.DataSource that generates points
+// with sim and converts them with converter.
+func NewSimulationDataSource(sim common.Simulator, converter PointConverter) *SimulationDataSource {
+	return &SimulationDataSource{
+		simulator: sim,
+		converter: converter,
+		headers:   sim.Headers(),
+		pointPool: &sync.Pool{New: func() interface{} {
+			return data.NewPoint()
+		}},
+	}
+}
+
+// Headers returns the headers of the simulated data set.
+func (d *SimulationDataSource) Headers() *common.GeneratedDataHeaders {
+	if d.headers != nil {
+		return d.headers
+	}
+
+	d.headers = d.simulator.Headers()
+	return d.headers
+}
+
+// NextItem returns the next converted point, or an empty data.LoadedPoint
+// when the simulator has finished.
+func (d *SimulationDataSource) NextItem() data.LoadedPoint {
+	for d.pendingInd >= len(d.pending) {
+		if !d.convertNext() {
+			return data.LoadedPoint{}
+		}
+	}
+	next := d.pending[d.pendingInd]
+	d.pending[d.pendingInd] = data.LoadedPoint{}
+	d.pendingInd++
+	return next
+}
+
+// convertNext simulates the next point that should be written and converts it
+// into the pending buffer. Returns false when no more points can be simulated.
+func (d *SimulationDataSource) convertNext() bool {
+	p := d.pointPool.Get().(*data.Point)
+	defer func() {
+		p.Reset()
+		d.pointPool.Put(p)
+	}()
+
+	var write bool
+	for !d.simulator.Finished() {
+		write = d.simulator.Next(p)
+		if write {
+			break
+		}
+		p.Reset()
+	}
+	if !write {
+		return false
+	}
+
+	var err error
+	d.pending, err = d.converter.Convert(p, d.pending[:0])
+	d.pendingInd = 0
+	if err != nil {
+		log.Printf("could not convert simulated point: %v", err)
+	}
+	return true
+}
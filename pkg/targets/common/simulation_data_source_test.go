@@ -0,0 +1,117 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+type testSimulator struct {
+	made     int
+	max      int
+	skipEven bool
+}
+
+func (s *testSimulator) Finished() bool {
+	return s.made >= s.max
+}
+
+func (s *testSimulator) Next(p *data.Point) bool {
+	s.made++
+	now := time.Unix(int64(s.made), 0)
+	p.SetMeasurementName([]byte("cpu"))
+	p.SetTimestamp(&now)
+	p.AppendTag([]byte("hostname"), fmt.Sprintf("host_%d", s.made))
+	p.AppendField([]byte("usage_user"), float64(s.made))
+	p.AppendField([]byte("usage_system"), float64(s.made))
+	return !s.skipEven || s.made%2 == 1
+}
+
+func (s *testSimulator) Fields() map[string][]string {
+	return map[string][]string{"cpu": {"usage_user", "usage_system"}}
+}
+
+func (s *testSimulator) TagKeys() []string {
+	return []string{"hostname"}
+}
+
+func (s *testSimulator) TagTypes() []string {
+	return []string{"string"}
+}
+
+func (s *testSimulator) Headers() *common.GeneratedDataHeaders {
+	return &common.GeneratedDataHeaders{
+		TagTypes:  s.TagTypes(),
+		TagKeys:   s.TagKeys(),
+		FieldKeys: s.Fields(),
+	}
+}
+
+type perFieldConverter struct{}
+
+func (c *perFieldConverter) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	host := p.GetTagValue([]byte("hostname")).(string)
+	for _, k := range p.FieldKeys() {
+		dst = append(dst, data.NewLoadedPoint(host+"."+string(k)))
+	}
+	return dst, nil
+}
+
+func TestSimulationDataSourceNextItem(t *testing.T) {
+	cases := []struct {
+		desc      string
+		sim       *testSimulator
+		converter PointConverter
+		want      []string
+	}{
+		{
+			desc: "one loaded point per simulated point",
+			sim:  &testSimulator{max: 3},
+			converter: PointConverterFunc(func(p *data.Point) data.LoadedPoint {
+				return data.NewLoadedPoint(p.GetTagValue([]byte("hostname")).(string))
+			}),
+			want: []string{"host_1", "host_2", "host_3"},
+		},
+		{
+			desc:      "multiple loaded points per simulated point",
+			sim:       &testSimulator{max: 2},
+			converter: &perFieldConverter{},
+			want:      []string{"host_1.usage_user", "host_1.usage_system", "host_2.usage_user", "host_2.usage_system"},
+		},
+		{
+			desc: "points not to be written are skipped",
+			sim:  &testSimulator{max: 4, skipEven: true},
+			converter: PointConverterFunc(func(p *data.Point) data.LoadedPoint {
+				return data.NewLoadedPoint(p.GetTagValue([]byte("hostname")).(string))
+			}),
+			want: []string{"host_1", "host_3"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			ds := NewSimulationDataSource(c.sim, c.converter)
+			if got := ds.Headers(); len(got.TagKeys) != 1 || got.TagKeys[0] != "hostname" {
+				t.Errorf("unexpected headers: %v", got)
+			}
+			var got []string
+			for {
+				item := ds.NextItem()
+				if item.Data == nil {
+					break
+				}
+				got = append(got, item.Data.(string))
+			}
+			if len(got) != len(c.want) {
+				t.Fatalf("incorrect number of items: got %d want %d (%v)", len(got), len(c.want), got)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Errorf("incorrect item %d: got %s want %s", i, got[i], c.want[i])
+				}
+			}
+		})
+	}
+}
@@ -15,6 +15,16 @@ const (
 	FormatVictoriaMetrics = "victoriametrics"
 	FormatTimestream      = "timestream"
 	FormatQuestDB         = "questdb"
+	FormatOTLP            = "otlp"
+	FormatGraphite        = "graphite"
+	FormatOpenTSDB        = "opentsdb"
+	FormatDuckDB          = "duckdb"
+	FormatSQLite          = "sqlite"
+	FormatParquet         = "parquet"
+	FormatArrow           = "arrow"
+	FormatElasticsearch   = "elasticsearch"
+	FormatFlightSQL       = "flightsql"
+	FormatPGWire          = "pgwire"
 )
 
 func SupportedFormats() []string {
@@ -32,5 +42,15 @@ func SupportedFormats() []string {
 		FormatVictoriaMetrics,
 		FormatTimestream,
 		FormatQuestDB,
+		FormatOTLP,
+		FormatGraphite,
+		FormatOpenTSDB,
+		FormatDuckDB,
+		FormatSQLite,
+		FormatParquet,
+		FormatArrow,
+		FormatElasticsearch,
+		FormatFlightSQL,
+		FormatPGWire,
 	}
 }
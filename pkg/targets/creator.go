@@ -34,3 +34,13 @@ type DBCreatorPost interface {
 	// PostCreateDB does further initialization after the database is created
 	PostCreateDB(dbName string) error
 }
+
+// DBCreatorPostLoad is a DBCreator that also needs to do some work once all the
+// data is loaded (e.g., refreshing rollups of the data). Its duration is
+// reported apart from the loading.
+type DBCreatorPostLoad interface {
+	DBCreator
+
+	// PostLoad does the work after the data is loaded
+	PostLoad(dbName string) error
+}
@@ -0,0 +1,110 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"log"
+
+	"github.com/timescale/tsbs/pkg/data"
+)
+
+var indexKey = []byte(`"_index":"`)
+
+// document is the position of an action and its document in the buffer of a batch
+type document struct {
+	start, end int
+	metrics    uint64
+}
+
+// batch is the body of a _bulk request, it keeps the position of every
+// document so the ones that failed can be sent again
+type batch struct {
+	buf         *bytes.Buffer
+	indexPrefix string
+	docs        []document
+	metrics     uint64
+}
+
+func (b *batch) Len() uint {
+	return uint(len(b.docs))
+}
+
+// Append adds the action and document of item, the index of the action is
+// prefixed with the database name
+func (b *batch) Append(item data.LoadedPoint) {
+	that := item.Data.([]byte)
+	nl := bytes.IndexByte(that, '\n')
+	if nl < 0 {
+		log.Fatalf("parse error: item has no document: %s", that)
+	}
+	doc := document{start: b.buf.Len(), metrics: countFields(that[nl+1:])}
+	if i := bytes.Index(that[:nl], indexKey); i >= 0 {
+		i += len(indexKey)
+		b.buf.Write(that[:i])
+		b.buf.WriteString(b.indexPrefix)
+		b.buf.Write(that[i:])
+	} else {
+		b.buf.Write(that)
+	}
+	doc.end = b.buf.Len()
+	b.docs = append(b.docs, doc)
+	b.metrics += doc.metrics
+}
+
+// retain keeps only the documents at the given positions
+func (b *batch) retain(keep []int) {
+	buf := b.buf.Bytes()
+	n := 0
+	b.metrics = 0
+	for i, k := range keep {
+		d := b.docs[k]
+		size := copy(buf[n:], buf[d.start:d.end])
+		b.docs[i] = document{start: n, end: n + size, metrics: d.metrics}
+		b.metrics += d.metrics
+		n += size
+	}
+	b.buf.Truncate(n)
+	b.docs = b.docs[:len(keep)]
+}
+
+func (b *batch) reset() {
+	b.buf.Reset()
+	b.docs = b.docs[:0]
+	b.metrics = 0
+}
+
+// countFields returns the number of fields of a document serialized by the
+// Serializer, i.e. the number of top-level keys besides the timestamp and the
+// tags
+func countFields(doc []byte) uint64 {
+	depth := 0
+	inString := false
+	commas := uint64(0)
+	for i := 0; i < len(doc); i++ {
+		c := doc[i]
+		if inString {
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if depth == 1 {
+				commas++
+			}
+		}
+	}
+	// the keys are separated by commas, two of them are the timestamp and the tags
+	if commas < 1 {
+		return 0
+	}
+	return commas - 1
+}
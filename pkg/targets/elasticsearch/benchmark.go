@@ -0,0 +1,98 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"sync"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/common"
+)
+
+type SpecificConfig struct {
+	ServerURLs []string `yaml:"urls" mapstructure:"urls"`
+	Gzip       bool     `yaml:"gzip" mapstructure:"gzip"`
+	Shards     int      `yaml:"shards" mapstructure:"shards"`
+	Replicas   int      `yaml:"replicas" mapstructure:"replicas"`
+}
+
+func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
+	var conf SpecificConfig
+	if err := v.Unmarshal(&conf); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// loader.Benchmark interface implementation
+type benchmark struct {
+	dbName     string
+	conf       *SpecificConfig
+	dataSource targets.DataSource
+}
+
+// NewBenchmark returns the benchmark loading the data into the indices of
+// dbName, which are named <dbName>-<measurement>
+func NewBenchmark(dbName string, esSpecificConfig *SpecificConfig, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	var ds targets.DataSource
+	if dataSourceConfig.Type == source.FileDataSourceType {
+		br := load.GetBufferedReader(dataSourceConfig.File.Location)
+		ds = &fileDataSource{scanner: bufio.NewScanner(br)}
+	} else {
+		dataGenerator := &inputs.DataGenerator{}
+		simulator, err := dataGenerator.CreateSimulator(dataSourceConfig.Simulator)
+		if err != nil {
+			return nil, err
+		}
+		ds = common.NewSimulationDataSource(simulator, &bulkConverter{})
+	}
+
+	return &benchmark{
+		dbName:     dbName,
+		conf:       esSpecificConfig,
+		dataSource: ds,
+	}, nil
+}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return b.dataSource
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	bufPool := sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 4*1024*1024))
+		},
+	}
+	return &factory{bufPool: &bufPool, indexPrefix: IndexName(b.dbName, "")}
+}
+
+func (b *benchmark) GetPointIndexer(maxPartitions uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{urls: b.conf.ServerURLs, gzip: b.conf.Gzip}
+}
+
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return &dbCreator{conf: b.conf}
+}
+
+type factory struct {
+	bufPool     *sync.Pool
+	indexPrefix string
+}
+
+func (f *factory) New() targets.Batch {
+	return &batch{buf: f.bufPool.Get().(*bytes.Buffer), indexPrefix: f.indexPrefix}
+}
+
+// IndexName returns the name of the index of a measurement
+func IndexName(dbName, measurement string) string {
+	return dbName + "-" + measurement
+}
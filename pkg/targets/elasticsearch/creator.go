@@ -0,0 +1,157 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// dbCreator manages the index template of a database. The indices of the
+// measurements are created by the _bulk requests from the template, which
+// makes them time series indices with the tags as dimensions and the fields
+// as gauges. Plain indices are used instead of data streams since a time
+// series data stream only accepts documents close to the current time.
+type dbCreator struct {
+	conf   *SpecificConfig
+	url    string
+	client *http.Client
+}
+
+func (d *dbCreator) Init() {
+	d.url = strings.TrimSuffix(d.conf.ServerURLs[0], "/")
+	d.client = &http.Client{}
+}
+
+func (d *dbCreator) DBExists(dbName string) bool {
+	status, _, err := d.request("GET", "/_index_template/"+dbName, nil)
+	if err != nil {
+		panic(err)
+	}
+	return status == http.StatusOK
+}
+
+func (d *dbCreator) CreateDB(dbName string) error {
+	body, err := json.Marshal(IndexTemplate(dbName, d.conf.Shards, d.conf.Replicas))
+	if err != nil {
+		return err
+	}
+	status, resp, err := d.request("PUT", "/_index_template/"+dbName, body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("could not create index template %s: HTTP status %d: %s", dbName, status, resp)
+	}
+	return nil
+}
+
+// RemoveOldDB deletes the indices of the database and its index template
+func (d *dbCreator) RemoveOldDB(dbName string) error {
+	status, resp, err := d.request("GET", "/_cat/indices/"+IndexName(dbName, "*")+"?format=json&h=index", nil)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusOK {
+		var indices []struct {
+			Index string `json:"index"`
+		}
+		if err := json.Unmarshal(resp, &indices); err != nil {
+			return fmt.Errorf("could not decode the indices of %s: %s", dbName, err)
+		}
+		for _, index := range indices {
+			status, resp, err := d.request("DELETE", "/"+index.Index, nil)
+			if err != nil {
+				return err
+			}
+			if status != http.StatusOK && status != http.StatusNotFound {
+				return fmt.Errorf("could not delete index %s: HTTP status %d: %s", index.Index, status, resp)
+			}
+		}
+	}
+	status, resp, err = d.request("DELETE", "/_index_template/"+dbName, nil)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNotFound {
+		return fmt.Errorf("could not delete index template %s: HTTP status %d: %s", dbName, status, resp)
+	}
+	return nil
+}
+
+func (d *dbCreator) request(method, path string, body []byte) (int, []byte, error) {
+	req, err := http.NewRequest(method, d.url+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, err
+}
+
+// IndexTemplate returns the index template applied to the indices of a
+// database. The indices are in time_series mode, routed by their tags
+func IndexTemplate(dbName string, shards, replicas int) map[string]interface{} {
+	return map[string]interface{}{
+		"index_patterns": []string{IndexName(dbName, "*")},
+		"priority":       500,
+		"template": map[string]interface{}{
+			"settings": map[string]interface{}{
+				"index.mode":               "time_series",
+				"index.routing_path":       []string{TagsField + ".*"},
+				"index.number_of_shards":   shards,
+				"index.number_of_replicas": replicas,
+			},
+			"mappings": map[string]interface{}{
+				"dynamic_templates": []interface{}{
+					map[string]interface{}{
+						"tags": map[string]interface{}{
+							"path_match":         TagsField + ".*",
+							"match_mapping_type": "string",
+							"mapping": map[string]interface{}{
+								"type":                  "keyword",
+								"time_series_dimension": true,
+							},
+						},
+					},
+					map[string]interface{}{
+						"long_fields": map[string]interface{}{
+							"match_mapping_type": "long",
+							"mapping": map[string]interface{}{
+								"type":               "double",
+								"time_series_metric": "gauge",
+							},
+						},
+					},
+					map[string]interface{}{
+						"double_fields": map[string]interface{}{
+							"match_mapping_type": "double",
+							"mapping": map[string]interface{}{
+								"type":               "double",
+								"time_series_metric": "gauge",
+							},
+						},
+					},
+				},
+				"properties": map[string]interface{}{
+					TimestampField: map[string]interface{}{
+						"type":   "date",
+						"format": "epoch_millis",
+					},
+					TagsField: map[string]interface{}{
+						"type": "object",
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/targets/elasticsearch/fake"
+)
+
+func TestDBCreator(t *testing.T) {
+	es := fake.NewServer()
+	s := httptest.NewServer(es)
+	defer s.Close()
+
+	d := &dbCreator{conf: &SpecificConfig{ServerURLs: []string{s.URL}, Shards: 2, Replicas: 1}}
+	d.Init()
+	if d.DBExists("benchmark") {
+		t.Fatalf("database should not exist")
+	}
+	if err := d.CreateDB("benchmark"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.DBExists("benchmark") {
+		t.Fatalf("database should exist")
+	}
+	var template struct {
+		IndexPatterns []string `json:"index_patterns"`
+		Template      struct {
+			Settings map[string]interface{} `json:"settings"`
+		} `json:"template"`
+	}
+	if err := json.Unmarshal(es.Template("benchmark"), &template); err != nil {
+		t.Fatal(err)
+	}
+	settings := template.Template.Settings
+	if template.IndexPatterns[0] != "benchmark-*" || settings["index.mode"] != "time_series" || settings["index.number_of_shards"] != 2.0 {
+		t.Errorf("incorrect template: %+v", template)
+	}
+
+	p := &processor{urls: []string{s.URL}}
+	p.Init(0, true, false)
+	if _, _, err := p.ProcessBatch(newTestBatch(t, "benchmark"), true); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := p.ProcessBatch(newTestBatch(t, "other"), true); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.RemoveOldDB("benchmark"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d.DBExists("benchmark") || es.Docs("benchmark-cpu") != 0 {
+		t.Errorf("database not removed")
+	}
+	if es.Docs("other-cpu") != 2 {
+		t.Errorf("the indices of another database were removed")
+	}
+	// removing a database that doesn't exist is not an error
+	if err := d.RemoveOldDB("benchmark"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
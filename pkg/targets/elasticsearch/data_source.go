@@ -0,0 +1,59 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"log"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+)
+
+// fileDataSource reads the action and document lines written by the Serializer
+type fileDataSource struct {
+	scanner *bufio.Scanner
+}
+
+func (f *fileDataSource) NextItem() data.LoadedPoint {
+	var item []byte
+	for i := 0; i < 2; i++ {
+		ok := f.scanner.Scan()
+		if !ok && f.scanner.Err() == nil { // nothing scanned & no error = EOF
+			if i == 1 {
+				log.Fatalf("parse error: action without document")
+			}
+			return data.LoadedPoint{}
+		} else if !ok {
+			log.Fatalf("scan error: %v", f.scanner.Err())
+		}
+		item = append(item, f.scanner.Bytes()...)
+		item = append(item, '\n')
+	}
+	return data.NewLoadedPoint(item)
+}
+
+func (f *fileDataSource) Headers() *common.GeneratedDataHeaders {
+	return nil
+}
+
+// bulkConverter implements common.PointConverter by serializing each
+// simulated point into its action and document, which is what the batch
+// expects
+type bulkConverter struct {
+	serializer Serializer
+	buf        bytes.Buffer
+}
+
+func (c *bulkConverter) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	c.buf.Reset()
+	if err := c.serializer.Serialize(p, &c.buf); err != nil {
+		return dst, err
+	}
+	// points without any field are not serialized
+	if c.buf.Len() == 0 {
+		return dst, nil
+	}
+	item := make([]byte, c.buf.Len())
+	copy(item, c.buf.Bytes())
+	return append(dst, data.NewLoadedPoint(item)), nil
+}
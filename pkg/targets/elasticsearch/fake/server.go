@@ -0,0 +1,249 @@
+// Package fake implements the parts of the Elasticsearch REST API used by the
+// elasticsearch target: _bulk, index templates, listing and deleting indices
+// and a _search that only counts the documents. The documents are counted and
+// checked but not stored. Useful for testing the target without a cluster.
+package fake
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Server is an http.Handler faking an Elasticsearch node
+type Server struct {
+	mu        sync.Mutex
+	templates map[string]json.RawMessage
+	indices   map[string]uint64
+	requests  uint64
+}
+
+// NewServer returns a new fake Elasticsearch node without any index
+func NewServer() *Server {
+	return &Server{
+		templates: make(map[string]json.RawMessage),
+		indices:   make(map[string]uint64),
+	}
+}
+
+// ListenAndServe serves the fake API on addr. This call will block go-routine
+func (s *Server) ListenAndServe(addr string) error {
+	log.Printf("Starting fake Elasticsearch listening on: %s\n", addr)
+	return http.ListenAndServe(addr, s)
+}
+
+// Docs returns the number of documents indexed into index
+func (s *Server) Docs(index string) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.indices[index]
+}
+
+// BulkRequests returns the number of _bulk requests received
+func (s *Server) BulkRequests() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// Template returns the body of an index template, nil if it doesn't exist
+func (s *Server) Template(name string) json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.templates[name]
+}
+
+func (s *Server) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case parts[0] == "_bulk" && req.Method == "POST":
+		s.bulk(rw, req)
+	case parts[0] == "_index_template" && len(parts) == 2:
+		s.template(rw, req, parts[1])
+	case parts[0] == "_cat" && len(parts) == 3 && parts[1] == "indices":
+		s.catIndices(rw, parts[2])
+	case len(parts) == 2 && parts[1] == "_search":
+		s.search(rw, parts[0])
+	case len(parts) == 1 && parts[0] != "" && req.Method == "DELETE":
+		s.deleteIndex(rw, parts[0])
+	default:
+		writeError(rw, http.StatusBadRequest, "illegal_argument_exception", fmt.Sprintf("unsupported request %s %s", req.Method, req.URL.Path))
+	}
+}
+
+type bulkItem struct {
+	Index  string      `json:"_index"`
+	Status int         `json:"status"`
+	Error  interface{} `json:"error,omitempty"`
+}
+
+func (s *Server) bulk(rw http.ResponseWriter, req *http.Request) {
+	var body io.Reader = req.Body
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(req.Body)
+		if err != nil {
+			writeError(rw, http.StatusBadRequest, "parse_exception", err.Error())
+			return
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var items []map[string]bulkItem
+	hasErrors := false
+	counts := make(map[string]uint64)
+	for scanner.Scan() {
+		var action map[string]struct {
+			Index string `json:"_index"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || len(action) != 1 {
+			writeError(rw, http.StatusBadRequest, "illegal_argument_exception", fmt.Sprintf("malformed action: %s", scanner.Text()))
+			return
+		}
+		if !scanner.Scan() {
+			writeError(rw, http.StatusBadRequest, "illegal_argument_exception", "action without document")
+			return
+		}
+		for name, meta := range action {
+			item := bulkItem{Index: meta.Index, Status: http.StatusCreated}
+			if reason := checkDocument(scanner.Bytes()); reason != "" {
+				item.Status = http.StatusBadRequest
+				item.Error = map[string]string{"type": "document_parsing_exception", "reason": reason}
+				hasErrors = true
+			} else {
+				counts[meta.Index]++
+			}
+			items = append(items, map[string]bulkItem{name: item})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(rw, http.StatusBadRequest, "parse_exception", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	s.requests++
+	for index, cnt := range counts {
+		s.indices[index] += cnt
+	}
+	s.mu.Unlock()
+	writeJSON(rw, http.StatusOK, map[string]interface{}{"took": 0, "errors": hasErrors, "items": items})
+}
+
+// checkDocument returns why a document would be rejected by a time series
+// index, empty if it wouldn't
+func checkDocument(doc []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(doc, &fields); err != nil {
+		return fmt.Sprintf("failed to parse document: %v", err)
+	}
+	if _, ok := fields["@timestamp"]; !ok {
+		return "data stream timestamp field [@timestamp] is missing"
+	}
+	var tags map[string]string
+	if err := json.Unmarshal(fields["tags"], &tags); err != nil || len(tags) == 0 {
+		return "error extracting routing: source didn't contain any routing fields"
+	}
+	return ""
+}
+
+func (s *Server) template(rw http.ResponseWriter, req *http.Request, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch req.Method {
+	case "PUT":
+		var template json.RawMessage
+		if err := json.NewDecoder(req.Body).Decode(&template); err != nil {
+			writeError(rw, http.StatusBadRequest, "parse_exception", err.Error())
+			return
+		}
+		s.templates[name] = template
+		writeJSON(rw, http.StatusOK, map[string]bool{"acknowledged": true})
+	case "GET":
+		template, ok := s.templates[name]
+		if !ok {
+			writeError(rw, http.StatusNotFound, "resource_not_found_exception", fmt.Sprintf("index template matching [%s] not found", name))
+			return
+		}
+		writeJSON(rw, http.StatusOK, map[string]interface{}{
+			"index_templates": []interface{}{map[string]interface{}{"name": name, "index_template": template}},
+		})
+	case "DELETE":
+		if _, ok := s.templates[name]; !ok {
+			writeError(rw, http.StatusNotFound, "resource_not_found_exception", fmt.Sprintf("index_template [%s] missing", name))
+			return
+		}
+		delete(s.templates, name)
+		writeJSON(rw, http.StatusOK, map[string]bool{"acknowledged": true})
+	default:
+		writeError(rw, http.StatusMethodNotAllowed, "illegal_argument_exception", "unsupported method "+req.Method)
+	}
+}
+
+func (s *Server) catIndices(rw http.ResponseWriter, pattern string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	indices := []map[string]string{}
+	for index := range s.indices {
+		if ok, _ := path.Match(pattern, index); ok {
+			indices = append(indices, map[string]string{"index": index})
+		}
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i]["index"] < indices[j]["index"] })
+	writeJSON(rw, http.StatusOK, indices)
+}
+
+func (s *Server) deleteIndex(rw http.ResponseWriter, index string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indices[index]; !ok {
+		writeError(rw, http.StatusNotFound, "index_not_found_exception", fmt.Sprintf("no such index [%s]", index))
+		return
+	}
+	delete(s.indices, index)
+	writeJSON(rw, http.StatusOK, map[string]bool{"acknowledged": true})
+}
+
+// search answers every search with the number of documents of the matching
+// indices and without any hit or aggregation
+func (s *Server) search(rw http.ResponseWriter, pattern string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var total uint64
+	for index, cnt := range s.indices {
+		if ok, _ := path.Match(pattern, index); ok {
+			total += cnt
+		}
+	}
+	writeJSON(rw, http.StatusOK, map[string]interface{}{
+		"took":         0,
+		"timed_out":    false,
+		"hits":         map[string]interface{}{"total": map[string]interface{}{"value": total, "relation": "eq"}, "hits": []interface{}{}},
+		"aggregations": map[string]interface{}{},
+	})
+}
+
+func writeError(rw http.ResponseWriter, status int, errType, reason string) {
+	writeJSON(rw, status, map[string]interface{}{
+		"error":  map[string]string{"type": errType, "reason": reason},
+		"status": status,
+	})
+}
+
+func writeJSON(rw http.ResponseWriter, status int, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(status)
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		log.Printf("could not write response: %v", err)
+	}
+}
@@ -0,0 +1,40 @@
+package elasticsearch
+
+import (
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func NewTarget() targets.ImplementedTarget {
+	return &elasticsearchTarget{}
+}
+
+type elasticsearchTarget struct {
+}
+
+func (t *elasticsearchTarget) TargetName() string {
+	return constants.FormatElasticsearch
+}
+
+func (t *elasticsearchTarget) Serializer() serialize.PointSerializer {
+	return &Serializer{}
+}
+
+func (t *elasticsearchTarget) Benchmark(targetDB string, dataSourceConfig *source.DataSourceConfig, v *viper.Viper) (targets.Benchmark, error) {
+	esSpecificConfig, err := parseSpecificConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewBenchmark(targetDB, esSpecificConfig, dataSourceConfig)
+}
+
+func (t *elasticsearchTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"urls", "http://localhost:9200", "Elasticsearch or OpenSearch URLs, comma-separated. Will be used in a round-robin fashion.")
+	flagSet.Bool(flagPrefix+"gzip", false, "Whether to gzip encode the _bulk requests")
+	flagSet.Int(flagPrefix+"shards", 1, "Number of primary shards of each index")
+	flagSet.Int(flagPrefix+"replicas", 0, "Number of replicas of each index")
+}
@@ -0,0 +1,148 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// bulkResponse is the part of the response of the _bulk API the processor
+// looks at, items has the result of every action in the order they were sent
+type bulkResponse struct {
+	Errors bool                  `json:"errors"`
+	Items  []map[string]bulkItem `json:"items"`
+}
+
+type bulkItem struct {
+	Status int `json:"status"`
+	Error  *struct {
+		Type   string `json:"type"`
+		Reason string `json:"reason"`
+	} `json:"error"`
+}
+
+type processor struct {
+	urls      []string
+	gzip      bool
+	url       string
+	workerNum int
+	client    *http.Client
+	gzipBuf   bytes.Buffer
+	rejected  uint64
+}
+
+func (p *processor) Init(workerNum int, doLoad, hashWorkers bool) {
+	p.workerNum = workerNum
+	p.url = strings.TrimSuffix(p.urls[workerNum%len(p.urls)], "/") + "/_bulk"
+	p.client = &http.Client{}
+}
+
+// ProcessBatch sends the batch with a _bulk request. The documents rejected
+// with a retryable status (429 or 5xx) are kept in the batch and an error is
+// returned so the loader retries them, the other rejected documents are
+// counted and dropped. The counts are of the documents that were indexed.
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64, err error) {
+	batch := b.(*batch)
+	if !doLoad {
+		metricCount, rowCount = batch.metrics, uint64(len(batch.docs))
+		batch.reset()
+		return metricCount, rowCount, nil
+	}
+
+	resp, err := p.do(batch.buf.Bytes())
+	if err != nil {
+		return 0, 0, err
+	}
+	if !resp.Errors {
+		metricCount, rowCount = batch.metrics, uint64(len(batch.docs))
+		batch.reset()
+		return metricCount, rowCount, nil
+	}
+	if len(resp.Items) != len(batch.docs) {
+		return 0, 0, fmt.Errorf("_bulk returned %d items for %d documents", len(resp.Items), len(batch.docs))
+	}
+
+	var retry []int
+	var rejected uint64
+	var reason string
+	for i, item := range resp.Items {
+		res := item["create"]
+		switch {
+		case res.Status >= 200 && res.Status < 300:
+			metricCount += batch.docs[i].metrics
+			rowCount++
+			continue
+		case res.Status == http.StatusTooManyRequests || res.Status >= 500:
+			retry = append(retry, i)
+		default:
+			rejected++
+		}
+		if reason == "" && res.Error != nil {
+			reason = fmt.Sprintf("%d %s: %s", res.Status, res.Error.Type, res.Error.Reason)
+		}
+	}
+	if rejected > 0 {
+		p.rejected += rejected
+		log.Printf("worker %d: %d documents rejected, first error: %s", p.workerNum, rejected, reason)
+	}
+	if len(retry) == 0 {
+		batch.reset()
+		return metricCount, rowCount, nil
+	}
+	batch.retain(retry)
+	return metricCount, rowCount, fmt.Errorf("%d documents failed, first error: %s", len(retry), reason)
+}
+
+func (p *processor) Close(doLoad bool) {
+	if p.rejected > 0 {
+		log.Printf("worker %d: %d documents rejected in total", p.workerNum, p.rejected)
+	}
+}
+
+func (p *processor) do(body []byte) (*bulkResponse, error) {
+	var r io.Reader = bytes.NewReader(body)
+	if p.gzip {
+		p.gzipBuf.Reset()
+		zw := gzip.NewWriter(&p.gzipBuf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		r = &p.gzipBuf
+	}
+	req, err := http.NewRequest("POST", p.url, r)
+	if err != nil {
+		return nil, fmt.Errorf("error while creating new request: %s", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if p.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error while executing request: %s", err)
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading response: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("server returned HTTP status %d: %s", resp.StatusCode, respBody)
+	}
+	var bulkResp bulkResponse
+	if err := json.Unmarshal(respBody, &bulkResp); err != nil {
+		return nil, fmt.Errorf("could not decode _bulk response: %s", err)
+	}
+	return &bulkResp, nil
+}
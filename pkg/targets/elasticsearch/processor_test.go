@@ -0,0 +1,177 @@
+package elasticsearch
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/targets/elasticsearch/fake"
+)
+
+const testItems = `{"create":{"_index":"cpu"}}
+{"@timestamp":1451606400000,"tags":{"hostname":"host_0"},"usage_user":58,"usage_system":2}
+{"create":{"_index":"cpu"}}
+{"@timestamp":1451606410000,"tags":{"hostname":"host_1"},"usage_user":59,"usage_system":3}
+{"create":{"_index":"mem"}}
+{"@timestamp":1451606400000,"tags":{"hostname":"host_0"},"used":12}
+`
+
+func newTestBatch(t *testing.T, dbName string) *batch {
+	f := &factory{
+		bufPool:     &sync.Pool{New: func() interface{} { return new(bytes.Buffer) }},
+		indexPrefix: IndexName(dbName, ""),
+	}
+	b := f.New().(*batch)
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader(testItems))}
+	for item := ds.NextItem(); item.Data != nil; item = ds.NextItem() {
+		b.Append(item)
+	}
+	if b.Len() != 3 || b.metrics != 5 {
+		t.Fatalf("incorrect batch: got %d documents and %d metrics", b.Len(), b.metrics)
+	}
+	return b
+}
+
+func TestBatchAppendPrefixesIndex(t *testing.T) {
+	b := newTestBatch(t, "benchmark")
+	want := strings.Replace(testItems, `"_index":"`, `"_index":"benchmark-`, -1)
+	if got := b.buf.String(); got != want {
+		t.Errorf("incorrect body:\ngot\n%s\nwant\n%s", got, want)
+	}
+	b.retain([]int{2})
+	if got := b.buf.String(); got != strings.Join(strings.Split(want, "\n")[4:], "\n") {
+		t.Errorf("incorrect body after retain: %s", got)
+	}
+	if b.Len() != 1 || b.metrics != 1 {
+		t.Errorf("incorrect batch after retain: got %d documents and %d metrics", b.Len(), b.metrics)
+	}
+}
+
+func TestFileDataSourceEOF(t *testing.T) {
+	ds := &fileDataSource{scanner: bufio.NewScanner(strings.NewReader(""))}
+	if item := ds.NextItem(); item.Data != nil {
+		t.Errorf("expected no item, got %s", item.Data)
+	}
+}
+
+func TestProcessorProcessBatch(t *testing.T) {
+	for _, gzip := range []bool{false, true} {
+		t.Run(fmt.Sprintf("gzip=%v", gzip), func(t *testing.T) {
+			es := fake.NewServer()
+			s := httptest.NewServer(es)
+			defer s.Close()
+
+			p := &processor{urls: []string{s.URL}, gzip: gzip}
+			p.Init(0, true, false)
+			metrics, rows, err := p.ProcessBatch(newTestBatch(t, "benchmark"), true)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if metrics != 5 || rows != 3 {
+				t.Errorf("incorrect counts: got %d metrics and %d rows", metrics, rows)
+			}
+			if es.Docs("benchmark-cpu") != 2 || es.Docs("benchmark-mem") != 1 {
+				t.Errorf("incorrect documents: got %d cpu and %d mem", es.Docs("benchmark-cpu"), es.Docs("benchmark-mem"))
+			}
+		})
+	}
+}
+
+func TestProcessorProcessBatchNoLoad(t *testing.T) {
+	p := &processor{urls: []string{"http://localhost:1"}}
+	p.Init(0, false, false)
+	b := newTestBatch(t, "benchmark")
+	metrics, rows, err := p.ProcessBatch(b, false)
+	if err != nil || metrics != 5 || rows != 3 {
+		t.Errorf("incorrect result: got %d metrics, %d rows, error %v", metrics, rows, err)
+	}
+	if b.Len() != 0 || b.buf.Len() != 0 {
+		t.Errorf("batch not reset")
+	}
+}
+
+func TestProcessorProcessBatchItemFailures(t *testing.T) {
+	// the first document is rejected, the second is throttled once
+	attempts := 0
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		attempts++
+		var items []string
+		scanner := bufio.NewScanner(req.Body)
+		for scanner.Scan() {
+			scanner.Scan()
+			status := 201
+			switch {
+			case strings.Contains(scanner.Text(), "host_0") && strings.Contains(scanner.Text(), "usage_user"):
+				status = 400
+			case strings.Contains(scanner.Text(), "host_1") && attempts == 1:
+				status = 429
+			}
+			items = append(items, fmt.Sprintf(`{"create":{"status":%d,"error":{"type":"t%d","reason":"r"}}}`, status, status))
+		}
+		fmt.Fprintf(rw, `{"errors":true,"items":[%s]}`, strings.Join(items, ","))
+	}))
+	defer s.Close()
+
+	p := &processor{urls: []string{s.URL}}
+	p.Init(0, true, false)
+	b := newTestBatch(t, "benchmark")
+	metrics, rows, err := p.ProcessBatch(b, true)
+	if err == nil {
+		t.Fatalf("expected an error for the throttled document")
+	}
+	if metrics != 1 || rows != 1 || p.rejected != 1 {
+		t.Errorf("incorrect first attempt: got %d metrics, %d rows, %d rejected", metrics, rows, p.rejected)
+	}
+	if b.Len() != 1 || !strings.Contains(b.buf.String(), "host_1") {
+		t.Fatalf("incorrect retained documents: %s", b.buf.String())
+	}
+
+	metrics, rows, err = p.ProcessBatch(b, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if metrics != 2 || rows != 1 || b.Len() != 0 {
+		t.Errorf("incorrect second attempt: got %d metrics, %d rows, %d left", metrics, rows, b.Len())
+	}
+}
+
+func TestProcessorProcessBatchRequestFailure(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer s.Close()
+
+	p := &processor{urls: []string{s.URL}}
+	p.Init(0, true, false)
+	b := newTestBatch(t, "benchmark")
+	if _, _, err := p.ProcessBatch(b, true); err == nil {
+		t.Errorf("expected an error")
+	}
+	if b.Len() != 3 {
+		t.Errorf("batch should be kept for a retry, has %d documents", b.Len())
+	}
+}
+
+func TestBulkConverter(t *testing.T) {
+	c := &bulkConverter{}
+	p := data.NewPoint()
+	p.SetTimestamp(&serialize.TestNow)
+	p.SetMeasurementName([]byte("cpu"))
+	p.AppendTag([]byte("hostname"), "host_0")
+	p.AppendField([]byte("usage_user"), 58)
+	items, err := c.Convert(p, nil)
+	if err != nil || len(items) != 1 {
+		t.Fatalf("unexpected result: %v, %v", items, err)
+	}
+	p.FieldValues()[0] = nil
+	if items, _ = c.Convert(p, items); len(items) != 1 {
+		t.Errorf("a point without fields should not be converted")
+	}
+}
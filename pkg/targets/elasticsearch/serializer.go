@@ -0,0 +1,112 @@
+package elasticsearch
+
+import (
+	"io"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+)
+
+const (
+	// TimestampField is the field holding the time of a document
+	TimestampField = "@timestamp"
+	// TagsField is the object holding the tags of a document, they are the
+	// dimensions of the time series
+	TagsField = "tags"
+)
+
+// Serializer writes a Point as an action and a document of the Elasticsearch
+// _bulk API
+type Serializer struct{}
+
+// Serialize writes Point data to the given writer as two NDJSON lines, a
+// create action for the index of the measurement and the document itself.
+// Tags are written as strings in the tags object, fields as numbers, and the
+// nil tags and fields are left out.
+//
+// This function writes output that looks like:
+// {"create":{"_index":"<measurement>"}}\n
+// {"@timestamp":<ms>,"tags":{"<tag key>":"<tag value>",...},"<field>":<value>,...}\n
+//
+// For example:
+// {"create":{"_index":"cpu"}}
+// {"@timestamp":1451606400000,"tags":{"hostname":"host_0"},"usage_user":58}
+func (s *Serializer) Serialize(p *data.Point, w io.Writer) error {
+	buf := make([]byte, 0, 1024)
+	buf = append(buf, `{"create":{"_index":`...)
+	buf = appendJSONString(buf, p.MeasurementName())
+	buf = append(buf, "}}\n"...)
+	actionLen := len(buf)
+
+	buf = append(buf, `{"`+TimestampField+`":`...)
+	buf = serialize.FastFormatAppend(p.Timestamp().UTC().UnixNano()/1e6, buf)
+	buf = append(buf, `,"`+TagsField+`":{`...)
+	tagKeys := p.TagKeys()
+	first := true
+	for i, v := range p.TagValues() {
+		if v == nil {
+			continue
+		}
+		if !first {
+			buf = append(buf, ',')
+		}
+		first = false
+		buf = appendJSONString(buf, tagKeys[i])
+		buf = append(buf, ':')
+		buf = appendJSONString(buf, serialize.FastFormatAppend(v, nil))
+	}
+	buf = append(buf, '}')
+
+	fieldKeys := p.FieldKeys()
+	fields := 0
+	for i, v := range p.FieldValues() {
+		if v == nil {
+			continue
+		}
+		fields++
+		buf = append(buf, ',')
+		buf = appendJSONString(buf, fieldKeys[i])
+		buf = append(buf, ':')
+		buf = appendValue(buf, v)
+	}
+	// all the fields were nil, there is nothing to write
+	if fields == 0 {
+		return nil
+	}
+	buf = append(buf, '}', '\n')
+	_, err := w.Write(buf[:actionLen])
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf[actionLen:])
+	return err
+}
+
+// appendValue appends a field value; the fields are mapped as gauges, which
+// are numbers, so booleans are written as 1 and 0
+func appendValue(buf []byte, v interface{}) []byte {
+	if b, ok := v.(bool); ok {
+		if b {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	}
+	return serialize.FastFormatAppend(v, buf)
+}
+
+// appendJSONString appends s as a quoted JSON string
+func appendJSONString(buf, s []byte) []byte {
+	const hex = "0123456789abcdef"
+	buf = append(buf, '"')
+	for _, c := range s {
+		switch {
+		case c == '"', c == '\\':
+			buf = append(buf, '\\', c)
+		case c < 0x20:
+			buf = append(buf, '\\', 'u', '0', '0', hex[c>>4], hex[c&0xf])
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return append(buf, '"')
+}
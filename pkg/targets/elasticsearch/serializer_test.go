@@ -0,0 +1,107 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+)
+
+const (
+	testAction = `{"create":{"_index":"cpu"}}` + "\n"
+	testTags   = `"tags":{"hostname":"host_0","region":"eu-west-1","datacenter":"eu-west-1b"}`
+)
+
+func TestElasticsearchSerializerSerialize(t *testing.T) {
+	cases := []serialize.SerializeCase{
+		{
+			Desc:       "a regular Point",
+			InputPoint: serialize.TestPointDefault(),
+			Output:     testAction + `{"@timestamp":1451606400000,` + testTags + `,"usage_guest_nice":38.24311829}` + "\n",
+		},
+		{
+			Desc:       "a regular Point using int as value",
+			InputPoint: serialize.TestPointInt(),
+			Output:     testAction + `{"@timestamp":1451606400000,` + testTags + `,"usage_guest":38}` + "\n",
+		},
+		{
+			Desc:       "a regular Point with multiple fields",
+			InputPoint: serialize.TestPointMultiField(),
+			Output:     testAction + `{"@timestamp":1451606400000,` + testTags + `,"big_usage_guest":5000000000,"usage_guest":38,"usage_guest_nice":38.24311829}` + "\n",
+		},
+		{
+			Desc:       "a Point with no tags",
+			InputPoint: serialize.TestPointNoTags(),
+			Output:     testAction + `{"@timestamp":1451606400000,"tags":{},"usage_guest_nice":38.24311829}` + "\n",
+		},
+		{
+			Desc:       "a Point with a nil tag",
+			InputPoint: serialize.TestPointWithNilTag(),
+			Output:     testAction + `{"@timestamp":1451606400000,"tags":{},"usage_guest_nice":38.24311829}` + "\n",
+		},
+		{
+			Desc:       "a Point with a nil field",
+			InputPoint: serialize.TestPointWithNilField(),
+			Output:     testAction + `{"@timestamp":1451606400000,"tags":{},"usage_guest_nice":38.24311829}` + "\n",
+		},
+	}
+
+	serialize.SerializerTest(t, cases, &Serializer{})
+}
+
+func TestElasticsearchSerializerValidJSON(t *testing.T) {
+	p := data.NewPoint()
+	now := time.Unix(1451606400, 0)
+	p.SetTimestamp(&now)
+	p.SetMeasurementName([]byte("readings"))
+	p.AppendTag([]byte("name"), "truck \"1\"\\")
+	p.AppendTag([]byte("load_capacity"), 1500.5)
+	p.AppendField([]byte("velocity"), 12.5)
+	p.AppendField([]byte("moving"), true)
+
+	var out strings.Builder
+	if err := (&Serializer{}).Serialize(p, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("incorrect number of lines: got %d want 2", len(lines))
+	}
+	var doc struct {
+		Timestamp int64             `json:"@timestamp"`
+		Tags      map[string]string `json:"tags"`
+		Velocity  float64           `json:"velocity"`
+		Moving    float64           `json:"moving"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &doc); err != nil {
+		t.Fatalf("document is not valid JSON: %v\n%s", err, lines[1])
+	}
+	if doc.Tags["name"] != "truck \"1\"\\" || doc.Tags["load_capacity"] != "1500.5" {
+		t.Errorf("incorrect tags: %v", doc.Tags)
+	}
+	if doc.Velocity != 12.5 || doc.Moving != 1 {
+		t.Errorf("incorrect fields: %+v", doc)
+	}
+	if got := countFields([]byte(lines[1])); got != 2 {
+		t.Errorf("incorrect field count: got %d want 2", got)
+	}
+}
+
+func TestElasticsearchSerializerNoFields(t *testing.T) {
+	p := data.NewPoint()
+	p.SetTimestamp(&serialize.TestNow)
+	p.SetMeasurementName([]byte("cpu"))
+	p.AppendTag([]byte("hostname"), "host_0")
+	p.AppendField([]byte("usage_user"), nil)
+
+	var out strings.Builder
+	if err := (&Serializer{}).Serialize(p, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no output for a point without fields, got %s", out.String())
+	}
+}
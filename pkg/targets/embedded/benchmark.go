@@ -0,0 +1,64 @@
+package embedded
+
+import (
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// NewBenchmark creates a benchmark loading into the database file of engine
+// named after dbName.
+func NewBenchmark(engine Engine, dbName string, opts *LoadingOptions, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	var ds targets.DataSource
+	if dataSourceConfig.Type == source.FileDataSourceType {
+		ds = newFileDataSource(dataSourceConfig.File.Location)
+	} else {
+		dataGenerator := &inputs.DataGenerator{}
+		simulator, err := dataGenerator.CreateSimulator(dataSourceConfig.Simulator)
+		if err != nil {
+			return nil, err
+		}
+		ds = newSimulationDataSource(simulator)
+	}
+
+	path := Path(opts.Dir, dbName, engine.Name())
+	return &benchmark{
+		opts:   opts,
+		engine: engine,
+		path:   path,
+		ds:     ds,
+		store:  &store{engine: engine, path: path},
+	}, nil
+}
+
+type benchmark struct {
+	opts   *LoadingOptions
+	engine Engine
+	path   string
+	ds     targets.DataSource
+	// store is shared by all the processors, an embedded database can only
+	// be opened once per process
+	store *store
+}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return b.ds
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	return &factory{}
+}
+
+// GetPointIndexer returns a constant indexer, all workers write into the same
+// database and share the tags cache, so there is nothing to gain by hashing.
+func (b *benchmark) GetPointIndexer(_ uint) targets.PointIndexer {
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{opts: b.opts, ds: b.ds, store: b.store}
+}
+
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return &dbCreator{opts: b.opts, engine: b.engine, path: b.path, ds: b.ds}
+}
@@ -0,0 +1,128 @@
+package embedded
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+type dbCreator struct {
+	opts    *LoadingOptions
+	engine  Engine
+	path    string
+	ds      targets.DataSource
+	headers *common.GeneratedDataHeaders
+}
+
+func (d *dbCreator) Init() {
+	d.headers = d.ds.Headers()
+}
+
+// DBExists checks whether the database file exists.
+func (d *dbCreator) DBExists(_ string) bool {
+	_, err := os.Stat(d.path)
+	return err == nil
+}
+
+// RemoveOldDB deletes the database file and its side files.
+func (d *dbCreator) RemoveOldDB(_ string) error {
+	for _, f := range d.engine.Files(d.path) {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateDB creates the database file with the tags table and one wide table
+// per measurement.
+func (d *dbCreator) CreateDB(_ string) error {
+	db, err := d.engine.Open(d.path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stmts := []string{createTagsTableQuery(d.headers.TagKeys, d.headers.TagTypes)}
+	tables := make([]string, 0, len(d.headers.FieldKeys))
+	for table := range d.headers.FieldKeys {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+	for _, table := range tables {
+		stmts = append(stmts, createMetricsTableQuery(table, d.headers.TagKeys, d.headers.TagTypes, d.headers.FieldKeys[table]))
+		if d.opts.CreateIndexes {
+			stmts = append(stmts, createIndexQueries(table, d.headers.TagKeys)...)
+		}
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			return fmt.Errorf("could not execute '%s': %v", stmt, err)
+		}
+	}
+	return nil
+}
+
+// createTagsTableQuery returns the statement creating the table that holds
+// one row per series, with a column per tag.
+func createTagsTableQuery(tagNames, tagTypes []string) string {
+	cols := make([]string, 0, len(tagNames)+1)
+	cols = append(cols, "id BIGINT PRIMARY KEY")
+	for i, tag := range tagNames {
+		cols = append(cols, quoteIdent(tag)+" "+serializedTypeToSQLType(tagTypes[i]))
+	}
+	return fmt.Sprintf("CREATE TABLE tags (%s)", strings.Join(cols, ", "))
+}
+
+// createMetricsTableQuery returns the statement creating the table of a
+// measurement: the time, the id of the series in the tags table, the first
+// tag (e.g. the hostname) so that it can be filtered on without a join, and
+// a column per field.
+func createMetricsTableQuery(table string, tagNames, tagTypes, fields []string) string {
+	cols := metricsColumns(tagNames, fields)
+	defs := make([]string, len(cols))
+	defs[0] = quoteIdent(cols[0]) + " TIMESTAMP NOT NULL"
+	defs[1] = quoteIdent(cols[1]) + " BIGINT"
+	defs[2] = quoteIdent(cols[2]) + " " + serializedTypeToSQLType(tagTypes[0])
+	for i, field := range fields {
+		defs[i+3] = quoteIdent(field) + " DOUBLE"
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdent(table), strings.Join(defs, ", "))
+}
+
+func createIndexQueries(table string, tagNames []string) []string {
+	return []string{
+		fmt.Sprintf("CREATE INDEX %s ON %s (tags_id, time)", quoteIdent(table+"_tags_id_time"), quoteIdent(table)),
+		fmt.Sprintf("CREATE INDEX %s ON %s (%s, time)", quoteIdent(table+"_"+tagNames[0]+"_time"), quoteIdent(table), quoteIdent(tagNames[0])),
+	}
+}
+
+// metricsColumns returns the columns of a measurement table in order.
+func metricsColumns(tagNames, fields []string) []string {
+	cols := make([]string, 0, len(fields)+3)
+	cols = append(cols, "time", "tags_id", tagNames[0])
+	return append(cols, fields...)
+}
+
+// serializedTypeToSQLType maps the type of a tag in the data header to a
+// column type both DuckDB and SQLite understand.
+func serializedTypeToSQLType(serializedType string) string {
+	switch serializedType {
+	case "string":
+		return "VARCHAR"
+	case "float32":
+		return "FLOAT"
+	case "float64":
+		return "DOUBLE"
+	case "int64":
+		return "BIGINT"
+	case "int32":
+		return "INTEGER"
+	default:
+		panic(fmt.Sprintf("unrecognized type %s", serializedType))
+	}
+}
@@ -0,0 +1,54 @@
+package embedded
+
+import (
+	"testing"
+)
+
+func TestCreateTagsTableQuery(t *testing.T) {
+	got := createTagsTableQuery([]string{"hostname", "rack", "load_capacity"}, []string{"string", "int32", "float64"})
+	want := `CREATE TABLE tags (id BIGINT PRIMARY KEY, "hostname" VARCHAR, "rack" INTEGER, "load_capacity" DOUBLE)`
+	if got != want {
+		t.Errorf("unexpected query:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestCreateMetricsTableQuery(t *testing.T) {
+	got := createMetricsTableQuery("diagnostics", []string{"name", "fleet"}, []string{"string", "string"}, []string{"fuel_state", "current_load"})
+	want := `CREATE TABLE "diagnostics" ("time" TIMESTAMP NOT NULL, "tags_id" BIGINT, "name" VARCHAR, "fuel_state" DOUBLE, "current_load" DOUBLE)`
+	if got != want {
+		t.Errorf("unexpected query:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestCreateIndexQueries(t *testing.T) {
+	got := createIndexQueries("cpu", []string{"hostname", "region"})
+	want := []string{
+		`CREATE INDEX "cpu_tags_id_time" ON "cpu" (tags_id, time)`,
+		`CREATE INDEX "cpu_hostname_time" ON "cpu" ("hostname", time)`,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("unexpected number of queries: got %d want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("unexpected query %d:\ngot  %s\nwant %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestInsertQuery(t *testing.T) {
+	got := insertQuery("cpu", []string{"time", "tags_id", "usage_user"})
+	want := `INSERT INTO "cpu" ("time", "tags_id", "usage_user") VALUES (?, ?, ?)`
+	if got != want {
+		t.Errorf("unexpected query:\ngot  %s\nwant %s", got, want)
+	}
+}
+
+func TestSerializedTypeToSQLTypePanicsOnUnknownType(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("did not panic when should")
+		}
+	}()
+	serializedTypeToSQLType("uint8")
+}
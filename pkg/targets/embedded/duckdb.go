@@ -0,0 +1,95 @@
+//go:build cgo
+// +build cgo
+
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+
+	"github.com/marcboeker/go-duckdb"
+)
+
+func (duckdbEngine) Open(path string) (*sql.DB, error) {
+	connector, err := duckdb.NewConnector(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return sql.OpenDB(connector), nil
+}
+
+// Append writes the rows through the DuckDB appender. The appender of
+// go-duckdb cannot write NULLs, so rows with missing values are inserted with
+// a prepared statement on the same connection afterwards. Both are done in a
+// single transaction, so that none of the rows are written if either fails.
+func (duckdbEngine) Append(db *sql.DB, table string, cols []string, rows [][]interface{}) error {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var appendable, withNulls [][]interface{}
+	for _, row := range rows {
+		if hasNull(row) {
+			withNulls = append(withNulls, row)
+		} else {
+			appendable = append(appendable, row)
+		}
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if len(appendable) > 0 {
+		err = conn.Raw(func(dc interface{}) error {
+			return appendRows(dc.(driver.Conn), table, appendable)
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if len(withNulls) > 0 {
+		if err := insertRowsTx(tx, table, cols, withNulls); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func appendRows(conn driver.Conn, table string, rows [][]interface{}) error {
+	a, err := duckdb.NewAppenderFromConn(conn, "", table)
+	if err != nil {
+		return err
+	}
+	values := make([]driver.Value, len(rows[0]))
+	for _, row := range rows {
+		for i, v := range row {
+			values[i] = v
+		}
+		if err := a.AppendRow(values...); err != nil {
+			a.Close()
+			return err
+		}
+	}
+	// closing the appender does not flush the rows that are still buffered
+	if err := a.Flush(); err != nil {
+		a.Close()
+		return err
+	}
+	return a.Close()
+}
+
+func hasNull(row []interface{}) bool {
+	for _, v := range row {
+		if v == nil {
+			return true
+		}
+	}
+	return false
+}
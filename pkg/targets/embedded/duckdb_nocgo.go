@@ -0,0 +1,19 @@
+//go:build !cgo
+// +build !cgo
+
+package embedded
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var errNoCgo = errors.New("the duckdb engine requires cgo, rebuild with CGO_ENABLED=1")
+
+func (duckdbEngine) Open(string) (*sql.DB, error) {
+	return nil, errNoCgo
+}
+
+func (duckdbEngine) Append(*sql.DB, string, []string, [][]interface{}) error {
+	return errNoCgo
+}
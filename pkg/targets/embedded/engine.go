@@ -0,0 +1,149 @@
+package embedded
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets/constants"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// TimeFormat is the layout of the time column in SQLite, which has no
+// timestamp type. It sorts the same way as the times it represents, so time
+// ranges can be compared as strings.
+const TimeFormat = "2006-01-02 15:04:05.999999"
+
+// Engine is an embedded SQL database library the benchmark runs in-process.
+type Engine interface {
+	// Name returns the format name of the engine.
+	Name() string
+	// Open opens, and creates if needed, the database file at path.
+	Open(path string) (*sql.DB, error)
+	// Files returns the files making up the database at path, including
+	// write-ahead logs and other side files.
+	Files(path string) []string
+	// Append bulk inserts rows into table. The values of each row are in the
+	// order of cols, which are all the columns of the table.
+	Append(db *sql.DB, table string, cols []string, rows [][]interface{}) error
+}
+
+// GetEngine returns the engine with the given format name.
+func GetEngine(name string) (Engine, error) {
+	switch name {
+	case constants.FormatDuckDB:
+		return duckdbEngine{}, nil
+	case constants.FormatSQLite:
+		return sqliteEngine{}, nil
+	}
+	return nil, fmt.Errorf("unknown embedded engine %s, supported: %s, %s", name, constants.FormatDuckDB, constants.FormatSQLite)
+}
+
+// Path returns the path of the database file of engine for the given database
+// name in dir.
+func Path(dir, dbName, engine string) string {
+	return filepath.Join(dir, dbName+"."+engine)
+}
+
+type duckdbEngine struct{}
+
+func (duckdbEngine) Name() string {
+	return constants.FormatDuckDB
+}
+
+func (duckdbEngine) Files(path string) []string {
+	return []string{path, path + ".wal"}
+}
+
+type sqliteEngine struct{}
+
+func (sqliteEngine) Name() string {
+	return constants.FormatSQLite
+}
+
+// Open opens the database in WAL mode. Transactions take the write lock when
+// they begin, so that concurrent writers wait for each other instead of
+// failing when upgrading a read lock.
+func (sqliteEngine) Open(path string) (*sql.DB, error) {
+	dsn := "file:" + path + "?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=60000&_txlock=immediate"
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (sqliteEngine) Files(path string) []string {
+	return []string{path, path + "-wal", path + "-shm", path + "-journal"}
+}
+
+// Append inserts the rows with a prepared statement in a single transaction,
+// which is how SQLite is bulk loaded.
+func (sqliteEngine) Append(db *sql.DB, table string, cols []string, rows [][]interface{}) error {
+	for _, row := range rows {
+		for i, v := range row {
+			if t, ok := v.(time.Time); ok {
+				row[i] = t.UTC().Format(TimeFormat)
+			}
+		}
+	}
+	return insertRows(db, table, cols, rows)
+}
+
+// txBeginner is implemented by both *sql.DB and *sql.Conn
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// insertRows inserts rows into table one by one with a prepared INSERT
+// statement, in a single transaction.
+func insertRows(db txBeginner, table string, cols []string, rows [][]interface{}) error {
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	if err := insertRowsTx(tx, table, cols, rows); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// insertRowsTx inserts rows into table one by one with a prepared INSERT
+// statement in tx, which is left for the caller to commit or roll back.
+func insertRowsTx(tx *sql.Tx, table string, cols []string, rows [][]interface{}) error {
+	stmt, err := tx.Prepare(insertQuery(table, cols))
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if _, err := stmt.Exec(row...); err != nil {
+			stmt.Close()
+			return err
+		}
+	}
+	return stmt.Close()
+}
+
+func insertQuery(table string, cols []string) string {
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		quoted[i] = quoteIdent(c)
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quoteIdent(table), strings.Join(quoted, ", "), strings.Repeat(", ?", len(cols))[2:])
+}
+
+// quoteIdent quotes a table or column name, so that field names like "load"
+// are never taken for keywords.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,166 @@
+package embedded
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets"
+	targetscommon "github.com/timescale/tsbs/pkg/targets/common"
+)
+
+const tagsKey = "tags"
+
+// allows for testing
+var fatal = log.Fatalf
+
+func newFileDataSource(fileName string) targets.DataSource {
+	br := load.GetBufferedReader(fileName)
+	return &fileDataSource{scanner: bufio.NewScanner(br)}
+}
+
+// fileDataSource reads the TimescaleDB format: a header describing the tags
+// and the columns of each measurement, followed by two lines per point.
+type fileDataSource struct {
+	scanner *bufio.Scanner
+	headers *common.GeneratedDataHeaders
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders {
+	// headers are read from the input file, and should be read first
+	if d.headers != nil {
+		return d.headers
+	}
+	// First N lines are header, with the first line containing the tags
+	// and their types, the second through N-1 line containing the column
+	// names, and last line being blank to separate from the data
+	var tags string
+	var cols []string
+	for i := 0; ; i++ {
+		ok := d.scanner.Scan()
+		if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+			fatal("ended too soon, no tags or cols read")
+			return nil
+		} else if !ok {
+			fatal("scan error: %v", d.scanner.Err())
+			return nil
+		}
+		line := strings.TrimSpace(d.scanner.Text())
+		if i == 0 {
+			tags = line
+			continue
+		}
+		if len(line) == 0 {
+			break
+		}
+		cols = append(cols, line)
+	}
+
+	tagsarr := strings.Split(tags, ",")
+	if tagsarr[0] != tagsKey {
+		fatal("input header in wrong format. got '%s', expected '%s'", tagsarr[0], tagsKey)
+		return nil
+	}
+	tagNames := make([]string, 0, len(tagsarr)-1)
+	tagTypes := make([]string, 0, len(tagsarr)-1)
+	for _, tagWithType := range tagsarr[1:] {
+		tagAndType := strings.Split(tagWithType, " ")
+		if len(tagAndType) != 2 {
+			fatal("tag header has invalid format: '%s'", tagWithType)
+			return nil
+		}
+		tagNames = append(tagNames, tagAndType[0])
+		tagTypes = append(tagTypes, tagAndType[1])
+	}
+	fieldKeys := make(map[string][]string)
+	for _, tableDef := range cols {
+		columns := strings.Split(tableDef, ",")
+		fieldKeys[columns[0]] = columns[1:]
+	}
+	d.headers = &common.GeneratedDataHeaders{
+		TagTypes:  tagTypes,
+		TagKeys:   tagNames,
+		FieldKeys: fieldKeys,
+	}
+	return d.headers
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	if d.headers == nil {
+		fatal("headers not read before starting to decode points")
+		return data.LoadedPoint{}
+	}
+	ok := d.scanner.Scan()
+	if !ok && d.scanner.Err() == nil { // nothing scanned & no error = EOF
+		return data.LoadedPoint{}
+	} else if !ok {
+		fatal("scan error: %v", d.scanner.Err())
+		return data.LoadedPoint{}
+	}
+
+	// The first line is a CSV line of tags with the first element being "tags"
+	parts := strings.SplitN(d.scanner.Text(), ",", 2) // prefix & then rest of line
+	if parts[0] != tagsKey {
+		fatal("data file in invalid format; got %s expected %s", parts[0], tagsKey)
+		return data.LoadedPoint{}
+	}
+	row := &insertData{}
+	if len(parts) > 1 {
+		row.tags = parts[1]
+	}
+
+	// Scan again to get the data line
+	if !d.scanner.Scan() {
+		fatal("scan error: %v", d.scanner.Err())
+		return data.LoadedPoint{}
+	}
+	parts = strings.SplitN(d.scanner.Text(), ",", 2)
+	if len(parts) != 2 {
+		fatal("data file in invalid format; no fields in line '%s'", d.scanner.Text())
+		return data.LoadedPoint{}
+	}
+	row.fields = parts[1]
+
+	return data.NewLoadedPoint(&point{
+		table: parts[0],
+		row:   row,
+	})
+}
+
+func newSimulationDataSource(sim common.Simulator) targets.DataSource {
+	return targetscommon.NewSimulationDataSource(sim, targetscommon.PointConverterFunc(convertSimulatedPoint))
+}
+
+// convertSimulatedPoint converts a simulated point into the same
+// representation the file data source produces.
+func convertSimulatedPoint(p *data.Point) data.LoadedPoint {
+	row := &insertData{}
+	tagKeys := p.TagKeys()
+	buf := make([]byte, 0, 256)
+	for i, v := range p.TagValues() {
+		if i > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, tagKeys[i]...)
+		buf = append(buf, '=')
+		buf = serialize.FastFormatAppend(v, buf)
+	}
+	row.tags = string(buf)
+	buf = buf[:0]
+	buf = append(buf, fmt.Sprintf("%d", p.Timestamp().UTC().UnixNano())...)
+	for _, v := range p.FieldValues() {
+		buf = append(buf, ',')
+		buf = serialize.FastFormatAppend(v, buf)
+	}
+	row.fields = string(buf)
+
+	return data.NewLoadedPoint(&point{
+		table: string(p.MeasurementName()),
+		row:   row,
+	})
+}
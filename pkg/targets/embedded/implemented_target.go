@@ -0,0 +1,52 @@
+package embedded
+
+import (
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/timescaledb"
+)
+
+// NewTarget returns the target for the embedded engine with the given name,
+// either constants.FormatDuckDB or constants.FormatSQLite.
+func NewTarget(engine string) targets.ImplementedTarget {
+	return &embeddedTarget{engine: engine}
+}
+
+type embeddedTarget struct {
+	engine string
+}
+
+func (t *embeddedTarget) TargetName() string {
+	return t.engine
+}
+
+// Serializer returns the TimescaleDB serializer, the embedded targets read
+// the same header-prefixed CSV format.
+func (t *embeddedTarget) Serializer() serialize.PointSerializer {
+	return &timescaledb.Serializer{}
+}
+
+func (t *embeddedTarget) Benchmark(
+	targetDB string, dataSourceConfig *source.DataSourceConfig, v *viper.Viper,
+) (targets.Benchmark, error) {
+	var loadingOptions LoadingOptions
+	if err := v.Unmarshal(&loadingOptions); err != nil {
+		return nil, err
+	}
+	engine, err := GetEngine(t.engine)
+	if err != nil {
+		return nil, err
+	}
+	return NewBenchmark(engine, targetDB, &loadingOptions, dataSourceConfig)
+}
+
+func (t *embeddedTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"dir", ".", "Directory of the database file, the file itself is named <db-name>."+t.engine)
+	flagSet.Bool(flagPrefix+"create-indexes", t.engine == constants.FormatSQLite,
+		"Whether to index each measurement table on (tags_id, time) and (<first tag>, time)")
+	flagSet.Bool(flagPrefix+"log-batches", false, "Whether to time individual batches.")
+}
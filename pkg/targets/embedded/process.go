@@ -0,0 +1,297 @@
+package embedded
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// store is the database shared by the processors of a benchmark, together
+// with the cache mapping the first tag of each series (e.g. the hostname) to
+// its id in the tags table.
+type store struct {
+	engine Engine
+	path   string
+
+	mu     sync.Mutex
+	db     *sql.DB
+	refs   int
+	ids    map[string]int64
+	nextID int64
+}
+
+// acquire opens the database on first use and returns it.
+func (s *store) acquire() (*sql.DB, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.db == nil {
+		db, err := s.engine.Open(s.path)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.loadTags(db); err != nil {
+			db.Close()
+			return nil, err
+		}
+		s.db = db
+	}
+	s.refs++
+	return s.db, nil
+}
+
+// release closes the database once the last processor is done with it.
+func (s *store) release() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.refs--
+	if s.refs > 0 {
+		return nil
+	}
+	err := s.db.Close()
+	s.db = nil
+	return err
+}
+
+// loadTags fills the cache with the series already in the database, so that
+// loading into an existing database does not duplicate them.
+func (s *store) loadTags(db *sql.DB) error {
+	rows, err := db.Query("SELECT * FROM tags")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+
+	s.ids = make(map[string]int64)
+	s.nextID = 0
+	vals := make([]interface{}, len(cols))
+	var id int64
+	var key sql.NullString
+	vals[0], vals[1] = &id, &key
+	for i := 2; i < len(cols); i++ {
+		vals[i] = new(interface{})
+	}
+	for rows.Next() {
+		if err := rows.Scan(vals...); err != nil {
+			return err
+		}
+		s.ids[key.String] = id
+		if id > s.nextID {
+			s.nextID = id
+		}
+	}
+	return rows.Err()
+}
+
+// tagIDs returns the ids of the series of tagRows, inserting the ones that
+// are not in the tags table yet. The first value of each row identifies the
+// series.
+func (s *store) tagIDs(db *sql.DB, tagKeys, tagTypes []string, tagRows [][]string) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ids := make([]int64, len(tagRows))
+	newIDs := make(map[string]int64)
+	var newRows [][]interface{}
+	for i, tags := range tagRows {
+		if id, ok := s.ids[tags[0]]; ok {
+			ids[i] = id
+			continue
+		}
+		if id, ok := newIDs[tags[0]]; ok {
+			ids[i] = id
+			continue
+		}
+		id := s.nextID + int64(len(newRows)) + 1
+		newIDs[tags[0]] = id
+		ids[i] = id
+
+		row := make([]interface{}, 0, len(tags)+1)
+		row = append(row, id)
+		for j, v := range tags {
+			row = append(row, convertBasedOnType(tagTypes[j], v))
+		}
+		newRows = append(newRows, row)
+	}
+	if len(newRows) == 0 {
+		return ids, nil
+	}
+
+	cols := append([]string{"id"}, tagKeys...)
+	if err := insertRows(db, "tags", cols, newRows); err != nil {
+		return nil, fmt.Errorf("could not insert tags: %v", err)
+	}
+	for k, id := range newIDs {
+		s.ids[k] = id
+	}
+	s.nextID += int64(len(newRows))
+	return ids, nil
+}
+
+type processor struct {
+	opts  *LoadingOptions
+	ds    targets.DataSource
+	store *store
+	db    *sql.DB
+}
+
+func (p *processor) Init(_ int, doLoad, _ bool) {
+	if !doLoad {
+		return
+	}
+	db, err := p.store.acquire()
+	if err != nil {
+		fatal("could not open %s: %v", p.store.path, err)
+		return
+	}
+	p.db = db
+}
+
+func (p *processor) Close(doLoad bool) {
+	if doLoad && p.db != nil {
+		if err := p.store.release(); err != nil {
+			fatal("could not close %s: %v", p.store.path, err)
+		}
+	}
+}
+
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
+	batches := b.(*tableArr)
+	rowCnt := uint64(0)
+	metricCnt := uint64(0)
+	for table, rows := range batches.m {
+		if doLoad {
+			start := time.Now()
+			numMetrics, err := p.insert(table, rows)
+			if err != nil {
+				return metricCnt, rowCnt, fmt.Errorf("could not insert into %s: %v", table, err)
+			}
+			metricCnt += numMetrics
+			// remove the inserted rows so a retry only inserts what is left
+			delete(batches.m, table)
+			batches.cnt -= uint(len(rows))
+
+			if p.opts.LogBatches {
+				took := time.Since(start)
+				fmt.Printf("BATCH: batchsize %d row rate %f/sec (took %v)\n", len(rows), float64(len(rows))/took.Seconds(), took)
+			}
+		}
+		rowCnt += uint64(len(rows))
+	}
+	batches.m = map[string][]*insertData{}
+	batches.cnt = 0
+	return metricCnt, rowCnt, nil
+}
+
+// insert parses the rows of a measurement and appends them to its table,
+// returning the number of metrics written.
+func (p *processor) insert(table string, rows []*insertData) (uint64, error) {
+	headers := p.ds.Headers()
+	fields, ok := headers.FieldKeys[table]
+	if !ok {
+		return 0, fmt.Errorf("unknown measurement %s", table)
+	}
+	tagKeys, tagTypes := headers.TagKeys, headers.TagTypes
+
+	tagRows := make([][]string, len(rows))
+	dataRows := make([][]interface{}, len(rows))
+	numMetrics := uint64(0)
+	for i, data := range rows {
+		// the values of the tags without the "<key>=" prefix, any tags
+		// beyond the ones in the header are dropped
+		tags := strings.SplitN(data.tags, ",", len(tagKeys)+1)
+		if len(tags) < len(tagKeys) {
+			return 0, fmt.Errorf("expected %d tags, got '%s'", len(tagKeys), data.tags)
+		}
+		tags = tags[:len(tagKeys)]
+		for j, t := range tags {
+			tags[j] = t[strings.IndexByte(t, '=')+1:]
+		}
+		tagRows[i] = tags
+
+		values := strings.Split(data.fields, ",")
+		if len(values) != len(fields)+1 {
+			return 0, fmt.Errorf("expected %d fields, got '%s'", len(fields), data.fields)
+		}
+		numMetrics += uint64(len(values) - 1) // 1 field is timestamp
+		ts, err := strconv.ParseInt(values[0], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not parse timestamp '%s': %v", values[0], err)
+		}
+
+		r := make([]interface{}, 3, len(fields)+3)
+		r[0], r[2] = time.Unix(0, ts).UTC(), convertBasedOnType(tagTypes[0], tags[0])
+		for _, v := range values[1:] {
+			if v == "" {
+				r = append(r, nil)
+				continue
+			}
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return 0, fmt.Errorf("could not parse field '%s': %v", v, err)
+			}
+			r = append(r, f)
+		}
+		dataRows[i] = r
+	}
+
+	ids, err := p.store.tagIDs(p.db, tagKeys, tagTypes, tagRows)
+	if err != nil {
+		return 0, err
+	}
+	for i, r := range dataRows {
+		r[1] = ids[i]
+	}
+
+	if err := p.store.engine.Append(p.db, table, metricsColumns(tagKeys, fields), dataRows); err != nil {
+		return 0, err
+	}
+	return numMetrics, nil
+}
+
+// convertBasedOnType converts a serialized tag value into the Go type of its
+// column, empty values are NULL.
+func convertBasedOnType(serializedType, value string) interface{} {
+	if value == "" {
+		return nil
+	}
+
+	switch serializedType {
+	case "string":
+		return value
+	case "float32":
+		f, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			panic(fmt.Sprintf("could not parse '%s' to float32", value))
+		}
+		return float32(f)
+	case "float64":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			panic(fmt.Sprintf("could not parse '%s' to float64", value))
+		}
+		return f
+	case "int64":
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			panic(fmt.Sprintf("could not parse '%s' to int64", value))
+		}
+		return i
+	case "int32":
+		i, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			panic(fmt.Sprintf("could not parse '%s' to int32", value))
+		}
+		return int32(i)
+	default:
+		panic(fmt.Sprintf("unrecognized type %s", serializedType))
+	}
+}
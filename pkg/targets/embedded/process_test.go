@@ -0,0 +1,172 @@
+package embedded
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+const testData = `tags,name string,fleet string,load_capacity float64
+diagnostics,fuel_state,current_load
+
+tags,name=truck_0,fleet=South,load_capacity=1500
+diagnostics,1451606400000000000,0.5,100
+tags,name=truck_1,fleet=North,load_capacity=
+diagnostics,1451606400000000000,0.25,
+tags,name=truck_0,fleet=South,load_capacity=1500
+diagnostics,1451606410000000000,0.45,200
+`
+
+func TestProcessBatch(t *testing.T) {
+	for _, name := range []string{constants.FormatDuckDB, constants.FormatSQLite} {
+		t.Run(name, func(t *testing.T) {
+			engine, err := GetEngine(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dir, err := ioutil.TempDir("", "tsbs-embedded")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			ds := &fileDataSource{scanner: bufio.NewScanner(bytes.NewBufferString(testData))}
+			ds.Headers()
+			opts := &LoadingOptions{Dir: dir, CreateIndexes: true}
+			path := Path(dir, "benchmark", name)
+			s := &store{engine: engine, path: path}
+			dbc := &dbCreator{opts: opts, engine: engine, path: path, ds: ds}
+			dbc.Init()
+			if dbc.DBExists("benchmark") {
+				t.Fatalf("database exists before it was created")
+			}
+			if err := dbc.CreateDB("benchmark"); err != nil {
+				t.Fatal(err)
+			}
+			if !dbc.DBExists("benchmark") {
+				t.Fatalf("database does not exist after it was created")
+			}
+
+			batch := (&factory{}).New()
+			for item := ds.NextItem(); item.Data != nil; item = ds.NextItem() {
+				batch.Append(item)
+			}
+
+			p := &processor{opts: opts, ds: ds, store: s}
+			p.Init(0, true, false)
+			metrics, rows, err := p.ProcessBatch(batch, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if metrics != 6 || rows != 3 {
+				t.Errorf("unexpected counts: got %d metrics and %d rows, want 6 and 3", metrics, rows)
+			}
+			if batch.Len() != 0 {
+				t.Errorf("batch not emptied after processing")
+			}
+
+			var tagCount, rowCount, nullCount int
+			var maxLoad float64
+			if err := p.db.QueryRow("SELECT count(*) FROM tags").Scan(&tagCount); err != nil {
+				t.Fatal(err)
+			}
+			err = p.db.QueryRow(`SELECT count(*), count(*) - count(current_load), max(current_load) FROM diagnostics d
+				JOIN tags t ON t.id = d.tags_id WHERE d.name = t.name`).Scan(&rowCount, &nullCount, &maxLoad)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tagCount != 2 || rowCount != 3 || nullCount != 1 || maxLoad != 200 {
+				t.Errorf("unexpected content: %d tags, %d rows, %d nulls, max load %v", tagCount, rowCount, nullCount, maxLoad)
+			}
+			p.Close(true)
+
+			// a second load into the existing database reuses the series
+			p = &processor{opts: opts, ds: ds, store: s}
+			p.Init(0, true, false)
+			defer p.Close(true)
+			batch.Append(data.NewLoadedPoint(&point{
+				table: "diagnostics",
+				row:   &insertData{tags: "name=truck_1,fleet=North,load_capacity=", fields: "1451606420000000000,0.2,50"},
+			}))
+			if _, _, err := p.ProcessBatch(batch, true); err != nil {
+				t.Fatal(err)
+			}
+			if err := p.db.QueryRow("SELECT count(*) FROM tags").Scan(&tagCount); err != nil {
+				t.Fatal(err)
+			}
+			if tagCount != 2 {
+				t.Errorf("series were duplicated: got %d tags want 2", tagCount)
+			}
+		})
+	}
+}
+
+func TestProcessBatchKeepsRowsOnError(t *testing.T) {
+	engine, _ := GetEngine(constants.FormatSQLite)
+	dir, err := ioutil.TempDir("", "tsbs-embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ds := &fileDataSource{scanner: bufio.NewScanner(bytes.NewBufferString(testData))}
+	ds.Headers()
+	// the table is never created, so the insert fails
+	s := &store{engine: engine, path: Path(dir, "benchmark", engine.Name())}
+	db, err := engine.Open(s.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(createTagsTableQuery(ds.headers.TagKeys, ds.headers.TagTypes)); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	batch := (&factory{}).New()
+	batch.Append(ds.NextItem())
+	p := &processor{opts: &LoadingOptions{}, ds: ds, store: s}
+	p.Init(0, true, false)
+	defer p.Close(true)
+	if _, _, err := p.ProcessBatch(batch, true); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if batch.Len() != 1 {
+		t.Errorf("batch should be kept for a retry: got len %d", batch.Len())
+	}
+}
+
+func TestDuckDBAppendAtomic(t *testing.T) {
+	engine, _ := GetEngine(constants.FormatDuckDB)
+	dir, err := ioutil.TempDir("", "tsbs-embedded")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := engine.Open(Path(dir, "benchmark", engine.Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE t (a DOUBLE, b DOUBLE)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first row is appended, the insert of the second one with a NULL fails
+	rows := [][]interface{}{{1.0, 2.0}, {nil, "not a number"}}
+	if err := engine.Append(db, "t", []string{"a", "b"}, rows); err == nil {
+		t.Fatalf("expected an error")
+	}
+	var n int
+	if err := db.QueryRow("SELECT count(*) FROM t").Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("rows written by a failed append: got %d want 0", n)
+	}
+}
@@ -0,0 +1,8 @@
+package embedded
+
+// LoadingOptions holds the target specific flags of the embedded targets.
+type LoadingOptions struct {
+	Dir           string `yaml:"dir" mapstructure:"dir"`
+	CreateIndexes bool   `yaml:"create-indexes" mapstructure:"create-indexes"`
+	LogBatches    bool   `yaml:"log-batches" mapstructure:"log-batches"`
+}
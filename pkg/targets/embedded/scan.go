@@ -0,0 +1,43 @@
+package embedded
+
+import (
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+// insertData holds the tags and the fields of a row as they were read,
+// e.g. "hostname=host_0,region=eu-west-1,..." and "1451606400000000000,58,2,..."
+type insertData struct {
+	tags   string
+	fields string
+}
+
+// point is a single row of data keyed by the table it belongs to
+type point struct {
+	table string
+	row   *insertData
+}
+
+type tableArr struct {
+	m   map[string][]*insertData
+	cnt uint
+}
+
+func (ta *tableArr) Len() uint {
+	return ta.cnt
+}
+
+func (ta *tableArr) Append(item data.LoadedPoint) {
+	that := item.Data.(*point)
+	ta.m[that.table] = append(ta.m[that.table], that.row)
+	ta.cnt++
+}
+
+type factory struct{}
+
+func (f *factory) New() targets.Batch {
+	return &tableArr{
+		m:   map[string][]*insertData{},
+		cnt: 0,
+	}
+}
@@ -0,0 +1,35 @@
+package flightsql
+
+import (
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+	"github.com/timescale/tsbs/pkg/targets/influx"
+)
+
+// NewTarget returns the target of the FlightSQL engines, e.g. InfluxDB 3.
+// They ingest the InfluxDB line protocol, so the data is generated in that
+// format and loaded with tsbs_load_influx, only the queries are specific.
+func NewTarget() targets.ImplementedTarget {
+	return &flightSQLTarget{}
+}
+
+type flightSQLTarget struct {
+}
+
+func (t *flightSQLTarget) TargetSpecificFlags(string, *pflag.FlagSet) {}
+
+func (t *flightSQLTarget) TargetName() string {
+	return constants.FormatFlightSQL
+}
+
+func (t *flightSQLTarget) Serializer() serialize.PointSerializer {
+	return &influx.Serializer{}
+}
+
+func (t *flightSQLTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
+	panic("not implemented")
+}
@@ -0,0 +1,42 @@
+package graphite
+
+import (
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// Protocols to send the data to carbon with
+const (
+	ProtocolPlaintext = "plaintext"
+	ProtocolPickle    = "pickle"
+)
+
+func NewTarget() targets.ImplementedTarget {
+	return &graphiteTarget{}
+}
+
+type graphiteTarget struct {
+}
+
+func (t *graphiteTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"protocol", ProtocolPlaintext, "Carbon protocol to send the data with, plaintext or pickle")
+	flagSet.String(flagPrefix+"plaintext-addr", "127.0.0.1:2003", "Carbon plaintext protocol TCP ip:port")
+	flagSet.String(flagPrefix+"pickle-addr", "127.0.0.1:2004", "Carbon pickle protocol TCP ip:port")
+	flagSet.String(flagPrefix+"template", "", "Template flattening the tags into dotted paths, e.g. {region}.{hostname}.{measurement}.{field} (empty = tagged-series syntax)")
+}
+
+func (t *graphiteTarget) TargetName() string {
+	return constants.FormatGraphite
+}
+
+func (t *graphiteTarget) Serializer() serialize.PointSerializer {
+	return &Serializer{}
+}
+
+func (t *graphiteTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
+	panic("not implemented")
+}
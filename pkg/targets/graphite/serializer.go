@@ -0,0 +1,96 @@
+package graphite
+
+import (
+	"io"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+)
+
+// Serializer writes a Point in the Graphite plaintext protocol, naming the
+// series with the tagged-series syntax
+type Serializer struct{}
+
+// Serialize writes Point data to the given writer, one line per field.
+//
+// This function writes output that looks like:
+// <measurement>.<field>;<tag key>=<tag value> <value> <timestamp in seconds>\n
+//
+// For example:
+// cpu.usage_user;hostname=host_0;region=eu-west-1 58.13 1451606400\n
+//
+// The lines of a Point are written together, which is how the loader
+// recognizes the rows of the data set. A template configured in the loader
+// can turn the tagged names into dotted paths.
+func (s *Serializer) Serialize(p *data.Point, w io.Writer) error {
+	tags := make([]byte, 0, 256)
+	tagKeys := p.TagKeys()
+	tagValues := p.TagValues()
+	for i, v := range tagValues {
+		if v == nil {
+			continue
+		}
+		tags = append(tags, ';')
+		tags = appendSanitized(tags, tagKeys[i])
+		tags = append(tags, '=')
+		tags = appendSanitized(tags, serialize.FastFormatAppend(v, nil))
+	}
+	ts := p.Timestamp().UTC().Unix()
+
+	buf := make([]byte, 0, 1024)
+	fieldKeys := p.FieldKeys()
+	for i, v := range p.FieldValues() {
+		if v == nil {
+			continue
+		}
+		buf = appendNameNode(buf, p.MeasurementName())
+		buf = append(buf, '.')
+		buf = appendNameNode(buf, fieldKeys[i])
+		buf = append(buf, tags...)
+		buf = append(buf, ' ')
+		buf = appendValue(buf, v)
+		buf = append(buf, ' ')
+		buf = serialize.FastFormatAppend(ts, buf)
+		buf = append(buf, '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// appendValue appends a field value; Graphite only stores numbers, so booleans
+// are written as 1 and 0
+func appendValue(buf []byte, v interface{}) []byte {
+	if b, ok := v.(bool); ok {
+		if b {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	}
+	return serialize.FastFormatAppend(v, buf)
+}
+
+// appendSanitized appends a tag key or value, replacing the characters that
+// can't be part of a tag in a plaintext line
+func appendSanitized(buf, s []byte) []byte {
+	for _, c := range s {
+		switch c {
+		case ' ', ';', '~', '=', '\n':
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// appendNameNode appends a measurement or field name as a single node of the
+// series name
+func appendNameNode(buf, s []byte) []byte {
+	for _, c := range s {
+		switch c {
+		case '.', ' ', ';', '~', '=', '\n':
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
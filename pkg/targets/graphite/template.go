@@ -0,0 +1,152 @@
+package graphite
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Names of the template placeholders that aren't tags
+const (
+	MeasurementKey = "measurement"
+	FieldKey       = "field"
+)
+
+// missingNode is used in a path for a tag that a series doesn't have, so that
+// all the paths built with a template have the same depth
+const missingNode = "unknown"
+
+// Template describes how the tagged series names are flattened into dotted
+// Graphite paths, e.g. {region}.{hostname}.{measurement}.{field}. Every node
+// of the template is either a literal or a placeholder, in braces, for the
+// measurement name, the field name or the value of a tag.
+type Template struct {
+	nodes []templateNode
+}
+
+type templateNode struct {
+	literal string
+	key     string
+}
+
+// ParseTemplate parses a template of dotted nodes. An empty template returns nil,
+// meaning the tagged-series syntax is used.
+func ParseTemplate(s string) (*Template, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t := &Template{}
+	for _, node := range strings.Split(s, ".") {
+		switch {
+		case node == "":
+			return nil, fmt.Errorf("template %q has an empty node", s)
+		case strings.HasPrefix(node, "{") && strings.HasSuffix(node, "}"):
+			key := node[1 : len(node)-1]
+			if key == "" || strings.ContainsAny(key, "{}") {
+				return nil, fmt.Errorf("template %q has an invalid placeholder %q", s, node)
+			}
+			t.nodes = append(t.nodes, templateNode{key: key})
+		case strings.ContainsAny(node, "{}"):
+			return nil, fmt.Errorf("template %q has an invalid node %q", s, node)
+		default:
+			t.nodes = append(t.nodes, templateNode{literal: node})
+		}
+	}
+	if t.NodeIndex(FieldKey) < 0 {
+		return nil, fmt.Errorf("template %q must contain the {%s} placeholder", s, FieldKey)
+	}
+	return t, nil
+}
+
+// NodeIndex returns the index of the node with the placeholder for key, or -1
+// if the template doesn't contain it
+func (t *Template) NodeIndex(key string) int {
+	for i, n := range t.nodes {
+		if n.key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Tag is a tag of a series parsed from a tagged-series name
+type Tag struct {
+	Key   []byte
+	Value []byte
+}
+
+// AppendPath appends the path of the series with the given measurement, field
+// and tags. Dots in the values are replaced so every value is a single node.
+func (t *Template) AppendPath(buf, measurement, field []byte, tags []Tag) []byte {
+	for i, n := range t.nodes {
+		if i > 0 {
+			buf = append(buf, '.')
+		}
+		switch n.key {
+		case "":
+			buf = append(buf, n.literal...)
+		case MeasurementKey:
+			buf = appendNode(buf, measurement)
+		case FieldKey:
+			buf = appendNode(buf, field)
+		default:
+			buf = appendNode(buf, tagValue(tags, n.key))
+		}
+	}
+	return buf
+}
+
+// Glob returns a path pattern matching the series whose placeholders have the
+// given values. The placeholders without a value match any node.
+func (t *Template) Glob(values map[string]string) string {
+	nodes := make([]string, len(t.nodes))
+	for i, n := range t.nodes {
+		if n.key == "" {
+			nodes[i] = n.literal
+		} else if v, ok := values[n.key]; ok {
+			nodes[i] = v
+		} else {
+			nodes[i] = "*"
+		}
+	}
+	return strings.Join(nodes, ".")
+}
+
+func tagValue(tags []Tag, key string) []byte {
+	for _, tag := range tags {
+		if string(tag.Key) == key {
+			return tag.Value
+		}
+	}
+	return []byte(missingNode)
+}
+
+func appendNode(buf, v []byte) []byte {
+	for _, c := range v {
+		if c == '.' {
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
+
+// ParseTaggedName splits a series name written by the Serializer,
+// <measurement>.<field>;<tag key>=<tag value>..., into its parts. The
+// returned slices point into name.
+func ParseTaggedName(name []byte, tags []Tag) (measurement, field []byte, _ []Tag, err error) {
+	tags = tags[:0]
+	parts := bytes.Split(name, []byte(";"))
+	dot := bytes.IndexByte(parts[0], '.')
+	if dot < 0 {
+		return nil, nil, tags, fmt.Errorf("series name %q is not <measurement>.<field>", parts[0])
+	}
+	for _, part := range parts[1:] {
+		eq := bytes.IndexByte(part, '=')
+		if eq < 0 {
+			return nil, nil, tags, fmt.Errorf("tag %q is not <key>=<value>", part)
+		}
+		tags = append(tags, Tag{Key: part[:eq], Value: part[eq+1:]})
+	}
+	return parts[0][:dot], parts[0][dot+1:], tags, nil
+}
@@ -0,0 +1,91 @@
+package graphite
+
+import (
+	"testing"
+)
+
+func TestParseTemplate(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		template  string
+		expectNil bool
+		expectErr bool
+	}{
+		{desc: "empty template", template: "", expectNil: true},
+		{desc: "tags and literals", template: "tsbs.{region}.{hostname}.{measurement}.{field}"},
+		{desc: "missing field", template: "{hostname}.{measurement}", expectErr: true},
+		{desc: "empty node", template: "{hostname}..{field}", expectErr: true},
+		{desc: "empty placeholder", template: "{}.{field}", expectErr: true},
+		{desc: "unbalanced braces", template: "{hostname.{field}", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tc.template)
+			if err != nil && !tc.expectErr {
+				t.Errorf("unexpected error: %v", err)
+			} else if err == nil && tc.expectErr {
+				t.Errorf("unexpected lack of error")
+			}
+			if !tc.expectErr && (tmpl == nil) != tc.expectNil {
+				t.Errorf("incorrect template: %v", tmpl)
+			}
+		})
+	}
+}
+
+func TestTemplateAppendPath(t *testing.T) {
+	tmpl, err := ParseTemplate("tsbs.{region}.{hostname}.{os}.{measurement}.{field}")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	measurement, field, tags, err := ParseTaggedName([]byte("cpu.usage_user;hostname=host_0;region=eu-west-1;rack=4.1"), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got := string(tmpl.AppendPath(nil, measurement, field, tags))
+	if want := "tsbs.eu-west-1.host_0.unknown.cpu.usage_user"; got != want {
+		t.Errorf("incorrect path: got %s want %s", got, want)
+	}
+
+	got = tmpl.Glob(map[string]string{FieldKey: "usage_user", MeasurementKey: "cpu", "hostname": "{host_0,host_1}"})
+	if want := "tsbs.*.{host_0,host_1}.*.cpu.usage_user"; got != want {
+		t.Errorf("incorrect glob: got %s want %s", got, want)
+	}
+	if idx := tmpl.NodeIndex("hostname"); idx != 2 {
+		t.Errorf("incorrect hostname node index: got %d want 2", idx)
+	}
+}
+
+func TestParseTaggedName(t *testing.T) {
+	testCases := []struct {
+		desc      string
+		name      string
+		expTags   int
+		expectErr bool
+	}{
+		{desc: "no tags", name: "cpu.usage_user"},
+		{desc: "tags", name: "cpu.usage_user;hostname=host_0;os=Ubuntu16.10", expTags: 2},
+		{desc: "no field", name: "cpu;hostname=host_0", expectErr: true},
+		{desc: "invalid tag", name: "cpu.usage_user;hostname", expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.desc, func(t *testing.T) {
+			measurement, field, tags, err := ParseTaggedName([]byte(tc.name), nil)
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("unexpected lack of error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(measurement) != "cpu" || string(field) != "usage_user" {
+				t.Errorf("incorrect name: got %s.%s", measurement, field)
+			}
+			if len(tags) != tc.expTags {
+				t.Errorf("incorrect number of tags: got %d want %d", len(tags), tc.expTags)
+			}
+		})
+	}
+}
@@ -26,6 +26,7 @@ func (t *influxTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.Fla
 	flagSet.Bool(flagPrefix+"gzip", true, "Whether to gzip encode requests (default true).")
 	flagSet.String(flagPrefix+"token", "", "Token for authentication with InfluxDB 2.0+ (default empty).")
   flagSet.String(flagPrefix+"org", "", "InfluxDB organization ID")
+	flagSet.Bool(flagPrefix+"rollups", false, "Create tasks of 1m and 1h rollups of the cpu measurement, run over the data once loaded")
 }
 
 func (t *influxTarget) TargetName() string {
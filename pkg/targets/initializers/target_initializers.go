@@ -8,11 +8,19 @@ import (
 	"github.com/timescale/tsbs/pkg/targets/akumuli"
 	"github.com/timescale/tsbs/pkg/targets/cassandra"
 	"github.com/timescale/tsbs/pkg/targets/clickhouse"
+	"github.com/timescale/tsbs/pkg/targets/columnar"
 	"github.com/timescale/tsbs/pkg/targets/constants"
 	"github.com/timescale/tsbs/pkg/targets/crate"
+	"github.com/timescale/tsbs/pkg/targets/elasticsearch"
+	"github.com/timescale/tsbs/pkg/targets/embedded"
+	"github.com/timescale/tsbs/pkg/targets/flightsql"
+	"github.com/timescale/tsbs/pkg/targets/graphite"
 	"github.com/timescale/tsbs/pkg/targets/influx"
 	"github.com/timescale/tsbs/pkg/targets/influx_2"
 	"github.com/timescale/tsbs/pkg/targets/mongo"
+	"github.com/timescale/tsbs/pkg/targets/opentsdb"
+	"github.com/timescale/tsbs/pkg/targets/otlp"
+	"github.com/timescale/tsbs/pkg/targets/pgwire"
 	"github.com/timescale/tsbs/pkg/targets/prometheus"
 	"github.com/timescale/tsbs/pkg/targets/questdb"
 	"github.com/timescale/tsbs/pkg/targets/siridb"
@@ -49,6 +57,26 @@ func GetTarget(format string) targets.ImplementedTarget {
 		return timestream.NewTarget()
 	case constants.FormatQuestDB:
 		return questdb.NewTarget()
+	case constants.FormatOTLP:
+		return otlp.NewTarget()
+	case constants.FormatGraphite:
+		return graphite.NewTarget()
+	case constants.FormatOpenTSDB:
+		return opentsdb.NewTarget()
+	case constants.FormatDuckDB:
+		return embedded.NewTarget(constants.FormatDuckDB)
+	case constants.FormatSQLite:
+		return embedded.NewTarget(constants.FormatSQLite)
+	case constants.FormatParquet:
+		return columnar.NewTarget(constants.FormatParquet)
+	case constants.FormatArrow:
+		return columnar.NewTarget(constants.FormatArrow)
+	case constants.FormatElasticsearch:
+		return elasticsearch.NewTarget()
+	case constants.FormatFlightSQL:
+		return flightsql.NewTarget()
+	case constants.FormatPGWire:
+		return pgwire.NewTarget()
 	}
 
 	supportedFormatsStr := strings.Join(constants.SupportedFormats(), ",")
@@ -0,0 +1,37 @@
+package opentsdb
+
+import (
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func NewTarget() targets.ImplementedTarget {
+	return &opentsdbTarget{}
+}
+
+type opentsdbTarget struct {
+}
+
+func (t *opentsdbTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"urls", "http://localhost:4242", "OpenTSDB URLs, comma-separated. Will be used in a round-robin fashion.")
+	flagSet.Duration(flagPrefix+"backoff", time.Second, "Time to sleep between requests when server indicates backpressure is needed.")
+	flagSet.Bool(flagPrefix+"gzip", true, "Whether to gzip encode requests (default true).")
+}
+
+func (t *opentsdbTarget) TargetName() string {
+	return constants.FormatOpenTSDB
+}
+
+func (t *opentsdbTarget) Serializer() serialize.PointSerializer {
+	return &Serializer{}
+}
+
+func (t *opentsdbTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
+	panic("not implemented")
+}
@@ -0,0 +1,114 @@
+package opentsdb
+
+import (
+	"io"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+)
+
+// OpenTSDB rejects data points without tags, so readings that have none are
+// written with this tag
+const (
+	noTagsKey   = "tsbs"
+	noTagsValue = "untagged"
+)
+
+// Serializer writes a Point in the JSON format of the OpenTSDB /api/put endpoint
+type Serializer struct{}
+
+// Serialize writes Point data to the given writer as a JSON array with one
+// data point per field, on a single line.
+//
+// This function writes output that looks like:
+// [{"metric":"<measurement>.<field>","timestamp":<ms>,"value":<value>,"tags":{"<tag key>":"<tag value>"}},...]\n
+//
+// For example:
+// [{"metric":"cpu.usage_user","timestamp":1451606400000,"value":58,"tags":{"hostname":"host_0"}}]\n
+func (s *Serializer) Serialize(p *data.Point, w io.Writer) error {
+	tags := make([]byte, 0, 256)
+	tags = append(tags, '{')
+	tagKeys := p.TagKeys()
+	for i, v := range p.TagValues() {
+		if v == nil {
+			continue
+		}
+		if len(tags) > 1 {
+			tags = append(tags, ',')
+		}
+		tags = appendString(tags, tagKeys[i])
+		tags = append(tags, ':')
+		tags = appendString(tags, serialize.FastFormatAppend(v, nil))
+	}
+	if len(tags) == 1 {
+		tags = appendString(tags, []byte(noTagsKey))
+		tags = append(tags, ':')
+		tags = appendString(tags, []byte(noTagsValue))
+	}
+	tags = append(tags, '}')
+	ts := p.Timestamp().UTC().UnixNano() / 1e6
+
+	buf := make([]byte, 0, 1024)
+	buf = append(buf, '[')
+	fieldKeys := p.FieldKeys()
+	for i, v := range p.FieldValues() {
+		if v == nil {
+			continue
+		}
+		if len(buf) > 1 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, `{"metric":"`...)
+		buf = appendSanitized(buf, p.MeasurementName())
+		buf = append(buf, '.')
+		buf = appendSanitized(buf, fieldKeys[i])
+		buf = append(buf, `","timestamp":`...)
+		buf = serialize.FastFormatAppend(ts, buf)
+		buf = append(buf, `,"value":`...)
+		buf = appendValue(buf, v)
+		buf = append(buf, `,"tags":`...)
+		buf = append(buf, tags...)
+		buf = append(buf, '}')
+	}
+	// all the fields were nil, there is nothing to write
+	if len(buf) == 1 {
+		return nil
+	}
+	buf = append(buf, ']', '\n')
+	_, err := w.Write(buf)
+	return err
+}
+
+// appendValue appends a field value; OpenTSDB only stores numbers, so booleans
+// are written as 1 and 0
+func appendValue(buf []byte, v interface{}) []byte {
+	if b, ok := v.(bool); ok {
+		if b {
+			return append(buf, '1')
+		}
+		return append(buf, '0')
+	}
+	return serialize.FastFormatAppend(v, buf)
+}
+
+func appendString(buf, s []byte) []byte {
+	buf = append(buf, '"')
+	buf = appendSanitized(buf, s)
+	return append(buf, '"')
+}
+
+// appendSanitized appends a metric name, tag key or tag value, replacing the
+// characters OpenTSDB doesn't allow in them. Only ASCII letters, digits and
+// -_./ are kept, so the result never needs escaping in JSON.
+func appendSanitized(buf, s []byte) []byte {
+	for _, c := range s {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == '/':
+		default:
+			c = '_'
+		}
+		buf = append(buf, c)
+	}
+	return buf
+}
@@ -0,0 +1,83 @@
+package opentsdb
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+)
+
+const testTags = `"tags":{"hostname":"host_0","region":"eu-west-1","datacenter":"eu-west-1b"}`
+
+func TestOpenTSDBSerializerSerialize(t *testing.T) {
+	cases := []serialize.SerializeCase{
+		{
+			Desc:       "a regular Point",
+			InputPoint: serialize.TestPointDefault(),
+			Output:     `[{"metric":"cpu.usage_guest_nice","timestamp":1451606400000,"value":38.24311829,` + testTags + "}]\n",
+		},
+		{
+			Desc:       "a regular Point using int as value",
+			InputPoint: serialize.TestPointInt(),
+			Output:     `[{"metric":"cpu.usage_guest","timestamp":1451606400000,"value":38,` + testTags + "}]\n",
+		},
+		{
+			Desc:       "a regular Point with multiple fields",
+			InputPoint: serialize.TestPointMultiField(),
+			Output: `[{"metric":"cpu.big_usage_guest","timestamp":1451606400000,"value":5000000000,` + testTags + "}," +
+				`{"metric":"cpu.usage_guest","timestamp":1451606400000,"value":38,` + testTags + "}," +
+				`{"metric":"cpu.usage_guest_nice","timestamp":1451606400000,"value":38.24311829,` + testTags + "}]\n",
+		},
+		{
+			Desc:       "a Point with no tags",
+			InputPoint: serialize.TestPointNoTags(),
+			Output:     `[{"metric":"cpu.usage_guest_nice","timestamp":1451606400000,"value":38.24311829,"tags":{"tsbs":"untagged"}}]` + "\n",
+		}, {
+			Desc:       "a Point with a nil tag",
+			InputPoint: serialize.TestPointWithNilTag(),
+			Output:     `[{"metric":"cpu.usage_guest_nice","timestamp":1451606400000,"value":38.24311829,"tags":{"tsbs":"untagged"}}]` + "\n",
+		}, {
+			Desc:       "a Point with a nil field",
+			InputPoint: serialize.TestPointWithNilField(),
+			Output:     `[{"metric":"cpu.usage_guest_nice","timestamp":1451606400000,"value":38.24311829,"tags":{"tsbs":"untagged"}}]` + "\n",
+		},
+	}
+
+	serialize.SerializerTest(t, cases, &Serializer{})
+}
+
+func TestOpenTSDBSerializerValidJSON(t *testing.T) {
+	p := data.NewPoint()
+	now := time.Unix(1451606400, 0)
+	p.SetTimestamp(&now)
+	p.SetMeasurementName([]byte("readings"))
+	p.AppendTag([]byte("name"), "truck 1;\"quoted\"")
+	p.AppendTag([]byte("load_capacity"), 1500.5)
+	p.AppendField([]byte("velocity"), 12.5)
+	p.AppendField([]byte("moving"), true)
+
+	var out strings.Builder
+	if err := (&Serializer{}).Serialize(p, &out); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var dps []struct {
+		Metric string            `json:"metric"`
+		Value  float64           `json:"value"`
+		Tags   map[string]string `json:"tags"`
+	}
+	if err := json.Unmarshal([]byte(out.String()), &dps); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if len(dps) != 2 || dps[1].Metric != "readings.moving" || dps[1].Value != 1 {
+		t.Errorf("incorrect data points: %+v", dps)
+	}
+	if got := dps[0].Tags["name"]; got != "truck_1__quoted_" {
+		t.Errorf("incorrect sanitized tag: got %s", got)
+	}
+	if got := dps[0].Tags["load_capacity"]; got != "1500.5" {
+		t.Errorf("incorrect float tag: got %s", got)
+	}
+}
@@ -0,0 +1,44 @@
+package otlp
+
+import (
+	"github.com/timescale/tsbs/pkg/data"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// batch merges the resources of the requests of every point into a single request
+type batch struct {
+	req     colmetricspb.ExportMetricsServiceRequest
+	rows    uint64
+	metrics uint64
+}
+
+func (b *batch) Len() uint {
+	return uint(b.rows)
+}
+
+func (b *batch) Append(item data.LoadedPoint) {
+	req := item.Data.(*colmetricspb.ExportMetricsServiceRequest)
+	b.rows++
+	b.metrics += countDataPoints(req.ResourceMetrics)
+	b.req.ResourceMetrics = append(b.req.ResourceMetrics, req.ResourceMetrics...)
+}
+
+func (b *batch) reset() {
+	b.req.ResourceMetrics = b.req.ResourceMetrics[:0]
+	b.rows = 0
+	b.metrics = 0
+}
+
+// countDataPoints returns the number of gauge data points in the resources
+func countDataPoints(resources []*metricspb.ResourceMetrics) uint64 {
+	var cnt uint64
+	for _, rm := range resources {
+		for _, ilm := range rm.InstrumentationLibraryMetrics {
+			for _, m := range ilm.Metrics {
+				cnt += uint64(len(m.GetGauge().GetDataPoints()))
+			}
+		}
+	}
+	return cnt
+}
@@ -0,0 +1,109 @@
+package otlp
+
+import (
+	"bufio"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/internal/inputs"
+	"github.com/timescale/tsbs/load"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/common"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+type SpecificConfig struct {
+	Protocol     string        `yaml:"protocol" mapstructure:"protocol"`
+	GRPCEndpoint string        `yaml:"grpc-endpoint" mapstructure:"grpc-endpoint"`
+	HTTPURL      string        `yaml:"http-url" mapstructure:"http-url"`
+	Timeout      time.Duration `yaml:"timeout" mapstructure:"timeout"`
+}
+
+func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
+	var conf SpecificConfig
+	if err := v.Unmarshal(&conf); err != nil {
+		return nil, err
+	}
+	return &conf, nil
+}
+
+// loader.Benchmark interface implementation
+type benchmark struct {
+	conf       *SpecificConfig
+	dataSource targets.DataSource
+	batchPool  *sync.Pool
+}
+
+func NewBenchmark(otlpSpecificConfig *SpecificConfig, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
+	var ds targets.DataSource
+	if dataSourceConfig.Type == source.FileDataSourceType {
+		ds = &fileDataSource{reader: bufio.NewReader(load.GetBufferedReader(dataSourceConfig.File.Location))}
+	} else {
+		dataGenerator := &inputs.DataGenerator{}
+		simulator, err := dataGenerator.CreateSimulator(dataSourceConfig.Simulator)
+		if err != nil {
+			return nil, err
+		}
+		ds = common.NewSimulationDataSource(simulator, &requestConverter{})
+	}
+
+	return &benchmark{
+		conf:       otlpSpecificConfig,
+		dataSource: ds,
+		batchPool: &sync.Pool{New: func() interface{} {
+			return &batch{}
+		}},
+	}, nil
+}
+
+func (b *benchmark) GetDataSource() targets.DataSource {
+	return b.dataSource
+}
+
+func (b *benchmark) GetBatchFactory() targets.BatchFactory {
+	return &factory{batchPool: b.batchPool}
+}
+
+func (b *benchmark) GetPointIndexer(maxPartitions uint) targets.PointIndexer {
+	if maxPartitions > 1 {
+		return &resourceIndexer{partitions: maxPartitions}
+	}
+	return &targets.ConstantIndexer{}
+}
+
+func (b *benchmark) GetProcessor() targets.Processor {
+	return &processor{conf: b.conf}
+}
+
+func (b *benchmark) GetDBCreator() targets.DBCreator {
+	return nil
+}
+
+type factory struct {
+	batchPool *sync.Pool
+}
+
+func (f *factory) New() targets.Batch {
+	return f.batchPool.Get().(*batch)
+}
+
+// resourceIndexer sends all the points of a resource (i.e. host) to the same partition
+type resourceIndexer struct {
+	partitions uint
+}
+
+func (i *resourceIndexer) GetIndex(item data.LoadedPoint) uint {
+	req := item.Data.(*colmetricspb.ExportMetricsServiceRequest)
+	h := fnv.New32a()
+	for _, rm := range req.ResourceMetrics {
+		for _, attr := range rm.GetResource().GetAttributes() {
+			h.Write([]byte(attr.Key))
+			h.Write([]byte(attr.GetValue().GetStringValue()))
+		}
+	}
+	return uint(h.Sum32()) % i.partitions
+}
@@ -0,0 +1,56 @@
+package otlp
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"log"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+// fileDataSource reads the length-delimited requests written by the Serializer
+type fileDataSource struct {
+	reader *bufio.Reader
+	buf    []byte
+}
+
+func (d *fileDataSource) NextItem() data.LoadedPoint {
+	size, err := binary.ReadUvarint(d.reader)
+	if err == io.EOF {
+		return data.LoadedPoint{}
+	} else if err != nil {
+		log.Fatalf("could not read message size: %v", err)
+	}
+	if uint64(cap(d.buf)) < size {
+		d.buf = make([]byte, size)
+	}
+	d.buf = d.buf[:size]
+	if _, err := io.ReadFull(d.reader, d.buf); err != nil {
+		log.Fatalf("could not read message: %v", err)
+	}
+	req := &colmetricspb.ExportMetricsServiceRequest{}
+	if err := proto.Unmarshal(d.buf, req); err != nil {
+		log.Fatalf("could not unmarshal message: %v", err)
+	}
+	return data.NewLoadedPoint(req)
+}
+
+func (d *fileDataSource) Headers() *common.GeneratedDataHeaders {
+	return nil
+}
+
+// requestConverter implements common.PointConverter by converting each
+// simulated point into the request the Serializer would write for it
+type requestConverter struct{}
+
+func (c *requestConverter) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	req, err := pointToRequest(p)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, data.NewLoadedPoint(req)), nil
+}
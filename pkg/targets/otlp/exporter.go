@@ -0,0 +1,103 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+)
+
+const (
+	protocolGRPC = "grpc"
+	protocolHTTP = "http"
+)
+
+// exporter sends an export request to an OTLP endpoint
+type exporter interface {
+	export(req *colmetricspb.ExportMetricsServiceRequest) error
+	close() error
+}
+
+func newExporter(conf *SpecificConfig) (exporter, error) {
+	switch conf.Protocol {
+	case protocolGRPC:
+		return newGRPCExporter(conf.GRPCEndpoint, conf.Timeout)
+	case protocolHTTP:
+		return &httpExporter{
+			url:    conf.HTTPURL,
+			client: &http.Client{Timeout: conf.Timeout},
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown OTLP protocol %q, valid: %s, %s", conf.Protocol, protocolGRPC, protocolHTTP)
+}
+
+// grpcExporter exports with the OTLP/gRPC MetricsService
+type grpcExporter struct {
+	conn    *grpc.ClientConn
+	client  colmetricspb.MetricsServiceClient
+	timeout time.Duration
+}
+
+func newGRPCExporter(endpoint string, timeout time.Duration) (*grpcExporter, error) {
+	conn, err := grpc.Dial(endpoint, grpc.WithInsecure())
+	if err != nil {
+		return nil, fmt.Errorf("could not connect to %s: %v", endpoint, err)
+	}
+	return &grpcExporter{
+		conn:    conn,
+		client:  colmetricspb.NewMetricsServiceClient(conn),
+		timeout: timeout,
+	}, nil
+}
+
+func (e *grpcExporter) export(req *colmetricspb.ExportMetricsServiceRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+	_, err := e.client.Export(ctx, req)
+	return err
+}
+
+func (e *grpcExporter) close() error {
+	return e.conn.Close()
+}
+
+// httpExporter exports with OTLP/HTTP using binary protobuf encoding
+type httpExporter struct {
+	url    string
+	client *http.Client
+}
+
+func (e *httpExporter) export(req *colmetricspb.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequest("POST", e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error while creating new request: %s", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("error while executing request: %s", err)
+	}
+	defer func() {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("server returned HTTP status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *httpExporter) close() error {
+	return nil
+}
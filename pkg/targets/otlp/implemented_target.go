@@ -0,0 +1,42 @@
+package otlp
+
+import (
+	"time"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+func NewTarget() targets.ImplementedTarget {
+	return &otlpTarget{}
+}
+
+type otlpTarget struct {
+}
+
+func (t *otlpTarget) TargetName() string {
+	return constants.FormatOTLP
+}
+
+func (t *otlpTarget) Serializer() serialize.PointSerializer {
+	return &Serializer{}
+}
+
+func (t *otlpTarget) Benchmark(_ string, dataSourceConfig *source.DataSourceConfig, v *viper.Viper) (targets.Benchmark, error) {
+	otlpSpecificConfig, err := parseSpecificConfig(v)
+	if err != nil {
+		return nil, err
+	}
+	return NewBenchmark(otlpSpecificConfig, dataSourceConfig)
+}
+
+func (t *otlpTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet) {
+	flagSet.String(flagPrefix+"protocol", protocolGRPC, "OTLP transport to use, grpc or http")
+	flagSet.String(flagPrefix+"grpc-endpoint", "localhost:4317", "host:port of the OTLP/gRPC receiver, used when protocol=grpc")
+	flagSet.String(flagPrefix+"http-url", "http://localhost:4318/v1/metrics", "URL of the OTLP/HTTP metrics receiver, used when protocol=http")
+	flagSet.Duration(flagPrefix+"timeout", 30*time.Second, "Timeout of a single export request")
+}
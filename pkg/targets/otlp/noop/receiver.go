@@ -0,0 +1,87 @@
+// Package noop implements an OTLP metrics receiver that accepts and counts
+// exported metrics without storing them. Useful for testing the otlp target.
+package noop
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"google.golang.org/grpc"
+)
+
+// Receiver counts the requests and data points received over OTLP/gRPC and OTLP/HTTP
+type Receiver struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+
+	ReqCounter       uint64
+	DataPointCounter uint64
+}
+
+// NewReceiver returns a new no-op OTLP receiver
+func NewReceiver() *Receiver {
+	return &Receiver{}
+}
+
+// ServeGRPC serves the OTLP/gRPC MetricsService on the listener. This call will block go-routine
+func (r *Receiver) ServeGRPC(lis net.Listener) error {
+	server := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(server, r)
+	log.Printf("Starting noop OTLP/gRPC receiver listening on: %s\n", lis.Addr())
+	return server.Serve(lis)
+}
+
+// ListenAndServeHTTP serves OTLP/HTTP on addr. This call will block go-routine
+func (r *Receiver) ListenAndServeHTTP(addr string) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/metrics", r.Handler)
+	log.Printf("Starting noop OTLP/HTTP receiver listening on: %s\n", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// Export implements the OTLP/gRPC MetricsService
+func (r *Receiver) Export(_ context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	r.count(req)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+// Handler handles binary protobuf encoded OTLP/HTTP export requests
+func (r *Receiver) Handler(rw http.ResponseWriter, req *http.Request) {
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		http.Error(rw, fmt.Sprintf("error while reading request: %v", err), http.StatusInternalServerError)
+		return
+	}
+	var exportReq colmetricspb.ExportMetricsServiceRequest
+	if err := proto.Unmarshal(body, &exportReq); err != nil {
+		http.Error(rw, fmt.Sprintf("error while unmarshalling protobuf request: %v", err), http.StatusBadRequest)
+		return
+	}
+	r.count(&exportReq)
+	resp, err := proto.Marshal(&colmetricspb.ExportMetricsServiceResponse{})
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/x-protobuf")
+	rw.Write(resp)
+}
+
+func (r *Receiver) count(req *colmetricspb.ExportMetricsServiceRequest) {
+	var dataPoints uint64
+	for _, rm := range req.ResourceMetrics {
+		for _, ilm := range rm.InstrumentationLibraryMetrics {
+			for _, m := range ilm.Metrics {
+				dataPoints += uint64(len(m.GetGauge().GetDataPoints()))
+			}
+		}
+	}
+	atomic.AddUint64(&r.ReqCounter, 1)
+	atomic.AddUint64(&r.DataPointCounter, dataPoints)
+}
@@ -0,0 +1,44 @@
+package otlp
+
+import (
+	"log"
+
+	"github.com/timescale/tsbs/pkg/targets"
+)
+
+type processor struct {
+	conf     *SpecificConfig
+	exporter exporter
+}
+
+func (p *processor) Init(workerNum int, doLoad, hashWorkers bool) {
+	if !doLoad {
+		return
+	}
+	var err error
+	p.exporter, err = newExporter(p.conf)
+	if err != nil {
+		log.Fatalf("could not create OTLP exporter: %v", err)
+	}
+}
+
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64, err error) {
+	batch := b.(*batch)
+	if doLoad {
+		if err := p.exporter.export(&batch.req); err != nil {
+			return 0, 0, err
+		}
+	}
+	metricCount, rowCount = batch.metrics, batch.rows
+	batch.reset()
+	return metricCount, rowCount, nil
+}
+
+func (p *processor) Close(doLoad bool) {
+	if p.exporter == nil {
+		return
+	}
+	if err := p.exporter.close(); err != nil {
+		log.Printf("could not close OTLP exporter: %v", err)
+	}
+}
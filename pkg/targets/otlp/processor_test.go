@@ -0,0 +1,87 @@
+package otlp
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/targets/otlp/noop"
+)
+
+func TestProcessorProcessBatch(t *testing.T) {
+	receiver := noop.NewReceiver()
+	httpServer := httptest.NewServer(http.HandlerFunc(receiver.Handler))
+	defer httpServer.Close()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	go receiver.ServeGRPC(lis)
+
+	confs := []*SpecificConfig{
+		{Protocol: protocolHTTP, HTTPURL: httpServer.URL, Timeout: 5 * time.Second},
+		{Protocol: protocolGRPC, GRPCEndpoint: lis.Addr().String(), Timeout: 5 * time.Second},
+	}
+	var wantReqs, wantDataPoints uint64
+	for _, conf := range confs {
+		for _, doLoad := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s load %v", conf.Protocol, doLoad), func(t *testing.T) {
+				b := &batch{}
+				for i := 0; i < 3; i++ {
+					req, err := pointToRequest(testPoint(fmt.Sprintf("host_%d", i), int64(i)))
+					if err != nil {
+						t.Fatalf("unexpected error: %v", err)
+					}
+					b.Append(data.NewLoadedPoint(req))
+				}
+				p := &processor{conf: conf}
+				p.Init(0, doLoad, false)
+				defer p.Close(doLoad)
+				metrics, rows, err := p.ProcessBatch(b, doLoad)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if metrics != 6 || rows != 3 {
+					t.Errorf("incorrect counts: got %d metrics, %d rows want 6 metrics, 3 rows", metrics, rows)
+				}
+				if b.Len() != 0 {
+					t.Errorf("batch not reset")
+				}
+				if doLoad {
+					wantReqs++
+					wantDataPoints += 6
+				}
+				if receiver.ReqCounter != wantReqs || receiver.DataPointCounter != wantDataPoints {
+					t.Errorf("incorrect received counts: got %d requests, %d data points want %d, %d",
+						receiver.ReqCounter, receiver.DataPointCounter, wantReqs, wantDataPoints)
+				}
+			})
+		}
+	}
+}
+
+func TestProcessorProcessBatchError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	req, err := pointToRequest(testPoint("host_0", 0))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	b := &batch{}
+	b.Append(data.NewLoadedPoint(req))
+	p := &processor{conf: &SpecificConfig{Protocol: protocolHTTP, HTTPURL: server.URL, Timeout: time.Second}}
+	p.Init(0, true, false)
+	if _, _, err := p.ProcessBatch(b, true); err == nil {
+		t.Errorf("expected error")
+	}
+	if b.Len() != 1 {
+		t.Errorf("failed batch should be kept for a retry")
+	}
+}
@@ -0,0 +1,106 @@
+package otlp
+
+// The OTLP serializer writes each point as a length-delimited protobuf
+// ExportMetricsServiceRequest: <<message_size><message>><<message_size><message>>...
+// where message_size is a uvarint. This is the usual way of streaming protobuf
+// messages, see https://developers.google.com/protocol-buffers/docs/techniques#streaming
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/timescale/tsbs/pkg/data"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+// instrumentationLibrary is the name of the instrumentation library of the generated metrics
+const instrumentationLibrary = "tsbs"
+
+// Serializer writes a Point as a length-delimited OTLP ExportMetricsServiceRequest
+type Serializer struct{}
+
+// Serialize writes Point p to the given Writer w
+func (s *Serializer) Serialize(p *data.Point, w io.Writer) error {
+	req, err := pointToRequest(p)
+	if err != nil {
+		return fmt.Errorf("could not serialize point: %v", err)
+	}
+	protoBytes, err := proto.Marshal(req)
+	if err != nil {
+		return err
+	}
+	var msgSizeBuf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(msgSizeBuf[:], uint64(len(protoBytes)))
+	if _, err := w.Write(msgSizeBuf[:n]); err != nil {
+		return err
+	}
+	_, err = w.Write(protoBytes)
+	return err
+}
+
+// pointToRequest converts a point into an ExportMetricsServiceRequest with a
+// single resource. Every field becomes a gauge named <measurement>_<field>.
+// The tags are set as the attributes of the resource and of every data point,
+// since many backends only keep a few resource attributes as series labels.
+func pointToRequest(p *data.Point) (*colmetricspb.ExportMetricsServiceRequest, error) {
+	tagKeys := p.TagKeys()
+	tagValues := p.TagValues()
+	attributes := make([]*commonpb.KeyValue, 0, len(tagKeys))
+	for i, k := range tagKeys {
+		if tagValues[i] == nil {
+			continue
+		}
+		attributes = append(attributes, &commonpb.KeyValue{
+			Key:   string(k),
+			Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprintf("%v", tagValues[i])}},
+		})
+	}
+
+	ts := uint64(p.Timestamp().UnixNano())
+	prefix := string(p.MeasurementName()) + "_"
+	fieldKeys := p.FieldKeys()
+	fieldValues := p.FieldValues()
+	metrics := make([]*metricspb.Metric, 0, len(fieldKeys))
+	for i, k := range fieldKeys {
+		if fieldValues[i] == nil {
+			continue
+		}
+		dp := &metricspb.NumberDataPoint{
+			Attributes:   attributes,
+			TimeUnixNano: ts,
+		}
+		switch v := fieldValues[i].(type) {
+		case int:
+			dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: int64(v)}
+		case int64:
+			dp.Value = &metricspb.NumberDataPoint_AsInt{AsInt: v}
+		case float32:
+			dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: float64(v)}
+		case float64:
+			dp.Value = &metricspb.NumberDataPoint_AsDouble{AsDouble: v}
+		default:
+			return nil, fmt.Errorf("unsupported value type %T of field %s", v, k)
+		}
+		metrics = append(metrics, &metricspb.Metric{
+			Name: prefix + string(k),
+			Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+				DataPoints: []*metricspb.NumberDataPoint{dp},
+			}},
+		})
+	}
+
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: &resourcepb.Resource{Attributes: attributes},
+			InstrumentationLibraryMetrics: []*metricspb.InstrumentationLibraryMetrics{{
+				InstrumentationLibrary: &commonpb.InstrumentationLibrary{Name: instrumentationLibrary},
+				Metrics:                metrics,
+			}},
+		}},
+	}, nil
+}
@@ -0,0 +1,70 @@
+package otlp
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/timescale/tsbs/pkg/data"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+func testPoint(host string, ts int64) *data.Point {
+	p := data.NewPoint()
+	t := time.Unix(0, ts)
+	p.SetTimestamp(&t)
+	p.SetMeasurementName([]byte("cpu"))
+	p.AppendTag([]byte("hostname"), host)
+	p.AppendTag([]byte("region"), "eu-west-1")
+	p.AppendField([]byte("usage_user"), 12.5)
+	p.AppendField([]byte("usage_system"), int64(3))
+	return p
+}
+
+func TestSerializeAndRead(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Serializer{}
+	for i, host := range []string{"host_0", "host_1"} {
+		if err := s.Serialize(testPoint(host, int64(i+1)), &buf); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	ds := &fileDataSource{reader: bufio.NewReader(&buf)}
+	for i, host := range []string{"host_0", "host_1"} {
+		item := ds.NextItem()
+		if item.Data == nil {
+			t.Fatalf("missing request %d", i)
+		}
+		req := item.Data.(*colmetricspb.ExportMetricsServiceRequest)
+		if len(req.ResourceMetrics) != 1 {
+			t.Fatalf("incorrect number of resources: got %d want 1", len(req.ResourceMetrics))
+		}
+		rm := req.ResourceMetrics[0]
+		attrs := rm.Resource.Attributes
+		if len(attrs) != 2 || attrs[0].Key != "hostname" || attrs[0].Value.GetStringValue() != host {
+			t.Errorf("incorrect resource attributes: %v", attrs)
+		}
+		metrics := rm.InstrumentationLibraryMetrics[0].Metrics
+		if len(metrics) != 2 {
+			t.Fatalf("incorrect number of metrics: got %d want 2", len(metrics))
+		}
+		if got := metrics[0].Name; got != "cpu_usage_user" {
+			t.Errorf("incorrect metric name: got %s want cpu_usage_user", got)
+		}
+		dp := metrics[0].GetGauge().DataPoints[0]
+		if dp.GetAsDouble() != 12.5 || dp.TimeUnixNano != uint64(i+1) {
+			t.Errorf("incorrect data point: %v", dp)
+		}
+		if len(dp.Attributes) != 2 {
+			t.Errorf("incorrect data point attributes: %v", dp.Attributes)
+		}
+		if got := metrics[1].GetGauge().DataPoints[0].GetAsInt(); got != 3 {
+			t.Errorf("incorrect int value: got %d want 3", got)
+		}
+	}
+	if item := ds.NextItem(); item.Data != nil {
+		t.Errorf("expected end of data, got %v", item.Data)
+	}
+}
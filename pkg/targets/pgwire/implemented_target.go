@@ -0,0 +1,49 @@
+package pgwire
+
+import (
+	"errors"
+	"io"
+
+	"github.com/blagojts/viper"
+	"github.com/spf13/pflag"
+	"github.com/timescale/tsbs/pkg/data"
+	"github.com/timescale/tsbs/pkg/data/serialize"
+	"github.com/timescale/tsbs/pkg/data/source"
+	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/constants"
+)
+
+// errQueryOnly is returned when generating data for the pgwire format.
+var errQueryOnly = errors.New("pgwire is a query only format, generate and load the data in the format of the queried database")
+
+// NewTarget returns the target of the databases queried over the PostgreSQL
+// wire protocol. Each of them is loaded with its own loader, e.g.
+// tsbs_load_timescaledb or tsbs_load_questdb, so only the queries are
+// specific to this target.
+func NewTarget() targets.ImplementedTarget {
+	return &pgwireTarget{}
+}
+
+type pgwireTarget struct {
+}
+
+func (t *pgwireTarget) TargetSpecificFlags(string, *pflag.FlagSet) {}
+
+func (t *pgwireTarget) TargetName() string {
+	return constants.FormatPGWire
+}
+
+func (t *pgwireTarget) Serializer() serialize.PointSerializer {
+	return &serializer{}
+}
+
+func (t *pgwireTarget) Benchmark(string, *source.DataSourceConfig, *viper.Viper) (targets.Benchmark, error) {
+	panic("not implemented")
+}
+
+// serializer refuses to write data, see errQueryOnly.
+type serializer struct{}
+
+func (s *serializer) Serialize(*data.Point, io.Writer) error {
+	return errQueryOnly
+}
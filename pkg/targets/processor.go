@@ -4,8 +4,11 @@ package targets
 type Processor interface {
 	// Init does per-worker setup needed before receiving data
 	Init(workerNum int, doLoad, hashWorkers bool)
-	// ProcessBatch handles a single batch of data
-	ProcessBatch(b Batch, doLoad bool) (metricCount, rowCount uint64)
+	// ProcessBatch handles a single batch of data. If (part of) the batch could
+	// not be inserted an error is returned, along with the counts of the data
+	// that was inserted before the failure. The data that was not inserted must
+	// be left in the batch so the runner can retry it.
+	ProcessBatch(b Batch, doLoad bool) (metricCount, rowCount uint64, err error)
 }
 
 // ProcessorCloser is a Processor that also needs to close or cleanup afterwards
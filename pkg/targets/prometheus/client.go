@@ -2,6 +2,7 @@ package prometheus
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -69,6 +70,7 @@ func (c *Client) Post(series []prompb.TimeSeries) error {
 	buffer.Reset()
 	err := buffer.Marshal(wr)
 	if err != nil {
+		bufferPool.Put(buffer)
 		return err
 	}
 	compressed := snappyPool.Get().([]byte)
@@ -97,3 +99,48 @@ func (c *Client) Post(series []prompb.TimeSeries) error {
 	}
 	return nil
 }
+
+// Read sends a remote-read request to the Prometheus adapter and returns the
+// decoded response together with the size of the compressed response body.
+// The request is cancelled when ctx is done.
+func (c *Client) Read(ctx context.Context, req *prompb.ReadRequest) (*prompb.ReadResponse, int, error) {
+	buffer := bufferPool.Get().(*proto.Buffer)
+	buffer.Reset()
+	err := buffer.Marshal(req)
+	if err != nil {
+		bufferPool.Put(buffer)
+		return nil, 0, err
+	}
+	compressed := snappy.Encode(nil, buffer.Bytes())
+	bufferPool.Put(buffer)
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.url.String(), bytes.NewReader(compressed))
+	if err != nil {
+		return nil, 0, err
+	}
+	httpReq.Header.Add("Content-Encoding", "snappy")
+	httpReq.Header.Add("Accept-Encoding", "snappy")
+	httpReq.Header.Set("Content-Type", "application/x-protobuf")
+	httpReq.Header.Set("X-Prometheus-Remote-Read-Version", "0.1.0")
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer httpResp.Body.Close()
+
+	body, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if httpResp.StatusCode/100 != 2 {
+		return nil, len(body), fmt.Errorf("Prometheus adapter returned status: %s: %s", httpResp.Status, body)
+	}
+	decompressed, err := snappy.Decode(nil, body)
+	if err != nil {
+		return nil, len(body), fmt.Errorf("could not decompress read response: %v", err)
+	}
+	var resp prompb.ReadResponse
+	if err := proto.Unmarshal(decompressed, &resp); err != nil {
+		return nil, len(body), fmt.Errorf("could not unmarshal read response: %v", err)
+	}
+	return &resp, len(body), nil
+}
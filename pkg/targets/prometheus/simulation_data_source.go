@@ -1,63 +1,37 @@
 package prometheus
 
 import (
-	"log"
+	"fmt"
 	"time"
 
 	"github.com/timescale/promscale/pkg/prompb"
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
 	"github.com/timescale/tsbs/pkg/targets"
+	targetscommon "github.com/timescale/tsbs/pkg/targets/common"
 )
 
 func newSimulationDataSource(sim common.Simulator, useCurrentTime bool) targets.DataSource {
-	return &simulationDataSource{
-		simulator:       sim,
-		headers:         sim.Headers(),
+	converter := &timeSeriesConverter{
 		generatedSeries: &timeSeriesIterator{useCurrentTime: useCurrentTime},
 	}
+	return targetscommon.NewSimulationDataSource(sim, converter)
 }
 
-type simulationDataSource struct {
-	simulator       common.Simulator
-	headers         *common.GeneratedDataHeaders
+// timeSeriesConverter implements targetscommon.PointConverter
+// by converting each simulated point into one time series per field.
+type timeSeriesConverter struct {
 	generatedSeries *timeSeriesIterator
 }
 
-func (d *simulationDataSource) Headers() *common.GeneratedDataHeaders {
-	if d.headers != nil {
-		return d.headers
+func (c *timeSeriesConverter) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	if err := c.generatedSeries.Set(p); err != nil {
+		return dst, fmt.Errorf("couldn't convert simulated point to Prometheus TimeSeries: %v", err)
 	}
-
-	d.headers = d.simulator.Headers()
-	return d.headers
-}
-
-func (d *simulationDataSource) NextItem() data.LoadedPoint {
-	if d.generatedSeries.HasNext() {
-		next := d.generatedSeries.Next()
-		return data.LoadedPoint{Data: next}
-	}
-
-	newSimulatorPoint := data.NewPoint()
-	var write bool
-	for !d.simulator.Finished() {
-		write = d.simulator.Next(newSimulatorPoint)
-		if write {
-			break
-		}
-		newSimulatorPoint.Reset()
-	}
-	if d.simulator.Finished() || !write {
-		return data.LoadedPoint{}
-	}
-
-	err := d.generatedSeries.Set(newSimulatorPoint)
-	if err != nil {
-		log.Printf("Couldn't convert simulated point to Prometheus TimeSeries: %v", err)
+	for c.generatedSeries.HasNext() {
+		dst = append(dst, data.NewLoadedPoint(c.generatedSeries.Next()))
 	}
-	next := d.generatedSeries.Next()
-	return data.LoadedPoint{Data: next}
+	return dst, nil
 }
 
 type timeSeriesIterator struct {
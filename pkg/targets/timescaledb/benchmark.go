@@ -55,13 +55,17 @@ func (b *benchmark) GetProcessor() targets.Processor {
 }
 
 func (b *benchmark) GetDBCreator() targets.DBCreator {
-	return &dbCreator{
+	dbc := &dbCreator{
 		opts:    b.opts,
 		connDB:  b.opts.ConnDB,
 		ds:      b.ds,
 		driver:  getDriver(b.opts.ForceTextFormat),
 		connStr: b.opts.GetConnectString(b.dbName),
 	}
+	if b.opts.Rollups {
+		return &rollupCreator{dbc}
+	}
+	return dbc
 }
 
 func getDriver(forceTextFormat bool) string {
@@ -68,4 +68,5 @@ func (t *timescaleTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.
 
 	flagSet.Bool(flagPrefix+"use-insert", false, "Provides the option to test data inserts with batched INSERT commands rather than the preferred COPY function")
 	flagSet.Bool(flagPrefix+"force-text-format", false, "Send/receive data in text format")
+	flagSet.Bool(flagPrefix+"rollups", false, "Create 1m and 1h rollups of the cpu table (continuous aggregates, or materialized views without hypertables), refreshed after loading")
 }
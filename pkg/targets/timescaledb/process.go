@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
-	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -56,7 +55,7 @@ func subsystemTagsToJSON(tags []string) map[string]interface{} {
 	return jsonToReturn
 }
 
-func (p *processor) insertTags(db *sql.DB, tagRows [][]string) map[string]int64 {
+func (p *processor) insertTags(db *sql.DB, tagRows [][]string) (map[string]int64, error) {
 	tagCols := tableCols[tagsKey]
 	cols := tagCols
 	values := make([]string, 0)
@@ -86,11 +85,11 @@ func (p *processor) insertTags(db *sql.DB, tagRows [][]string) map[string]int64
 	defer tx.Commit()
 	res, err := tx.Query(fmt.Sprintf(insertTagsSQL, strings.Join(cols, ","), strings.Join(values, ",")))
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	ret := p.sqlTagsToCacheLine(res, err, tagCols)
-	return ret
+	return ret, nil
 }
 
 func (p *processor) sqlTagsToCacheLine(res *sql.Rows, err error, tagCols []string) map[string]int64 {
@@ -183,7 +182,7 @@ func (p *processor) splitTagsAndMetrics(rows []*insertData, dataCols int) ([][]s
 	return tagRows, dataRows, numMetrics
 }
 
-func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
+func (p *processor) processCSI(hypertable string, rows []*insertData) (uint64, error) {
 	colLen := len(tableCols[hypertable]) + numExtraCols
 	if p.opts.InTableTag {
 		colLen++
@@ -201,7 +200,11 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 	p._csi.mutex.RUnlock()
 	if len(newTags) > 0 {
 		p._csi.mutex.Lock()
-		res := p.insertTags(p._db, newTags)
+		res, err := p.insertTags(p._db, newTags)
+		if err != nil {
+			p._csi.mutex.Unlock()
+			return 0, fmt.Errorf("could not insert tags: %v", err)
+		}
 		for k, v := range res {
 			p._csi.m[k] = v
 		}
@@ -226,7 +229,8 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 		tx := MustBegin(p._db)
 		stmt, err := tx.Prepare(pq.CopyIn(hypertable, cols...))
 		if err != nil {
-			panic(err)
+			tx.Rollback()
+			return 0, err
 		}
 
 		for _, r := range dataRows {
@@ -234,17 +238,19 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 		}
 		_, err = stmt.Exec()
 		if err != nil {
-			panic(err)
+			tx.Rollback()
+			return 0, err
 		}
 
 		err = stmt.Close()
 		if err != nil {
-			panic(err)
+			tx.Rollback()
+			return 0, err
 		}
 
 		err = tx.Commit()
 		if err != nil {
-			panic(err)
+			return 0, err
 		}
 	} else {
 		if !p.opts.UseInsert {
@@ -252,12 +258,11 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 			inserted, err := p._pgxConn.CopyFrom(context.Background(), pgx.Identifier{hypertable}, cols, rows)
 
 			if err != nil {
-				panic(err)
+				return 0, err
 			}
 
 			if inserted != int64(len(dataRows)) {
-				fmt.Fprintf(os.Stderr, "Failed to insert all the data! Expected: %d, Got: %d", len(dataRows), inserted)
-				os.Exit(1)
+				return 0, fmt.Errorf("failed to insert all the data! Expected: %d, Got: %d", len(dataRows), inserted)
 			}
 		} else {
 			tx := MustBegin(p._db)
@@ -266,25 +271,31 @@ func (p *processor) processCSI(hypertable string, rows []*insertData) uint64 {
 
 			stmtString := genBatchInsertStmt(hypertable, cols, len(dataRows))
 			stmt, err = tx.Prepare(stmtString)
+			if err != nil {
+				tx.Rollback()
+				return 0, err
+			}
 
 			_, err = stmt.Exec(flatten(dataRows)...)
 			if err != nil {
-				panic(err)
+				tx.Rollback()
+				return 0, err
 			}
 
 			err = stmt.Close()
 			if err != nil {
-				panic(err)
+				tx.Rollback()
+				return 0, err
 			}
 
 			err = tx.Commit()
 			if err != nil {
-				panic(err)
+				return 0, err
 			}
 		}
 	}
 
-	return numMetrics
+	return numMetrics, nil
 }
 
 func newProcessor(opts *LoadingOptions, driver, dbName string) *processor {
@@ -381,15 +392,21 @@ func (p *processor) Close(doLoad bool) {
 	}
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64, error) {
 	batches := b.(*hypertableArr)
 	rowCnt := 0
 	metricCnt := uint64(0)
 	for hypertable, rows := range batches.m {
-		rowCnt += len(rows)
 		if doLoad {
 			start := time.Now()
-			metricCnt += p.processCSI(hypertable, rows)
+			numMetrics, err := p.processCSI(hypertable, rows)
+			if err != nil {
+				return metricCnt, uint64(rowCnt), fmt.Errorf("could not insert into %s: %v", hypertable, err)
+			}
+			metricCnt += numMetrics
+			// remove the inserted rows so a retry only inserts what is left
+			delete(batches.m, hypertable)
+			batches.cnt -= uint(len(rows))
 
 			if p.opts.LogBatches {
 				now := time.Now()
@@ -398,10 +415,11 @@ func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (uint64, uint64)
 				fmt.Printf("BATCH: batchsize %d row rate %f/sec (took %v)\n", batchSize, float64(batchSize)/float64(took.Seconds()), took)
 			}
 		}
+		rowCnt += len(rows)
 	}
 	batches.m = map[string][]*insertData{}
 	batches.cnt = 0
-	return metricCnt, uint64(rowCnt)
+	return metricCnt, uint64(rowCnt), nil
 }
 func convertValsToSQLBasedOnType(values []string, types []string) []string {
 	return convertValsToBasedOnType(values, types, "'", "NULL")
@@ -39,6 +39,9 @@ type LoadingOptions struct {
 	ForceTextFormat    bool     `yaml:"force-text-format" mapstructure:"force-text-format"`
 	TagColumnTypes     []string `yaml:",omitempty" mapstructure:",omitempty"`
 	UseInsert          bool     `yaml:"use-insert" mapstructure:"use-insert"`
+	// Rollups creates the 1m and 1h rollups of the cpu table, refreshed
+	// after the data is loaded
+	Rollups bool `yaml:"rollups" mapstructure:"rollups"`
 }
 
 func (o *LoadingOptions) GetConnectString(dbName string) string {
@@ -0,0 +1,106 @@
+package timescaledb
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// rollupTable is the table rolled up with --rollups
+const rollupTable = "cpu"
+
+// rollups are the rollups of the cpu table, with the max of each field per
+// host per minute and hour. Their names are the ones the queries generated
+// for them read.
+var rollups = []struct {
+	name string
+	unit string
+}{
+	{name: rollupTable + "_1m", unit: "minute"},
+	{name: rollupTable + "_1h", unit: "hour"},
+}
+
+// rollupCreator is the dbCreator when the rollups of the cpu table are
+// created: continuous aggregates on hypertables, materialized views otherwise.
+// They are created empty and refreshed once the data is loaded, so that the
+// time refreshing them is reported.
+type rollupCreator struct {
+	*dbCreator
+}
+
+func (d *rollupCreator) PostCreateDB(dbName string) error {
+	if !d.opts.CreateMetricsTable {
+		return d.dbCreator.PostCreateDB(dbName)
+	}
+	columns, ok := d.ds.Headers().FieldKeys[rollupTable]
+	if !ok {
+		return fmt.Errorf("rollups are only created for the %s table of the devops and cpu-only use cases", rollupTable)
+	}
+
+	dbBench := MustConnect(d.driver, d.opts.GetConnectString(dbName))
+	defer dbBench.Close()
+	// the rollups depend on the table, which is dropped and created again
+	for i := len(rollups) - 1; i >= 0; i-- {
+		MustExec(dbBench, fmt.Sprintf("DROP MATERIALIZED VIEW IF EXISTS %s", rollups[i].name))
+	}
+	if err := d.dbCreator.PostCreateDB(dbName); err != nil {
+		return err
+	}
+	for _, r := range rollups {
+		for _, q := range d.getCreateRollupCmds(r.name, r.unit, columns) {
+			MustExec(dbBench, q)
+		}
+	}
+	return nil
+}
+
+// PostLoad refreshes the rollups with the data loaded
+func (d *rollupCreator) PostLoad(dbName string) error {
+	if !d.opts.CreateMetricsTable {
+		return nil
+	}
+	dbBench := MustConnect(d.driver, d.opts.GetConnectString(dbName))
+	defer dbBench.Close()
+	for _, r := range rollups {
+		refreshRollup(dbBench, r.name, d.opts.UseHypertable)
+	}
+	return nil
+}
+
+// getCreateRollupCmds returns the commands creating the rollup name of the
+// fields columns of the cpu table per host per unit of time.
+func (d *dbCreator) getCreateRollupCmds(name, unit string, columns []string) []string {
+	groupBy := []string{"tags_id"}
+	if d.opts.InTableTag {
+		groupBy = append(groupBy, tableCols[tagsKey][0])
+	}
+	selectClauses := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if len(column) == 0 {
+			continue
+		}
+		selectClauses = append(selectClauses, fmt.Sprintf("max(%[1]s) AS max_%[1]s", column))
+	}
+
+	if d.opts.UseHypertable {
+		// continuous aggregates index their group by columns themselves
+		return []string{fmt.Sprintf(
+			"CREATE MATERIALIZED VIEW %s WITH (timescaledb.continuous) AS SELECT time_bucket(INTERVAL '1 %s', time) AS time, %s, %s FROM %s GROUP BY 1, %s WITH NO DATA",
+			name, unit, strings.Join(groupBy, ", "), strings.Join(selectClauses, ", "), rollupTable, strings.Join(groupBy, ", "))}
+	}
+	return []string{
+		fmt.Sprintf(
+			"CREATE MATERIALIZED VIEW %s AS SELECT date_trunc('%s', time) AS time, %s, %s FROM %s GROUP BY 1, %s WITH NO DATA",
+			name, unit, strings.Join(groupBy, ", "), strings.Join(selectClauses, ", "), rollupTable, strings.Join(groupBy, ", ")),
+		fmt.Sprintf("CREATE INDEX ON %s(%s, \"time\" DESC)", name, groupBy[len(groupBy)-1]),
+	}
+}
+
+// refreshRollup refreshes the whole of the rollup name
+func refreshRollup(db *sql.DB, name string, continuous bool) {
+	if continuous {
+		MustExec(db, fmt.Sprintf("CALL refresh_continuous_aggregate('%s', NULL, NULL)", name))
+		return
+	}
+	MustExec(db, fmt.Sprintf("REFRESH MATERIALIZED VIEW %s", name))
+}
@@ -0,0 +1,58 @@
+package timescaledb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetCreateRollupCmds(t *testing.T) {
+	cases := []struct {
+		desc          string
+		useHypertable bool
+		inTableTag    bool
+		want          []string
+	}{
+		{
+			desc:          "continuous aggregate",
+			useHypertable: true,
+			want: []string{"CREATE MATERIALIZED VIEW cpu_1m WITH (timescaledb.continuous) AS SELECT time_bucket(INTERVAL '1 minute', time) AS time, " +
+				"tags_id, max(usage_user) AS max_usage_user, max(usage_system) AS max_usage_system FROM cpu GROUP BY 1, tags_id WITH NO DATA"},
+		},
+		{
+			desc:          "continuous aggregate with in-table tag",
+			useHypertable: true,
+			inTableTag:    true,
+			want: []string{"CREATE MATERIALIZED VIEW cpu_1m WITH (timescaledb.continuous) AS SELECT time_bucket(INTERVAL '1 minute', time) AS time, " +
+				"tags_id, hostname, max(usage_user) AS max_usage_user, max(usage_system) AS max_usage_system FROM cpu GROUP BY 1, tags_id, hostname WITH NO DATA"},
+		},
+		{
+			desc: "materialized view",
+			want: []string{
+				"CREATE MATERIALIZED VIEW cpu_1m AS SELECT date_trunc('minute', time) AS time, " +
+					"tags_id, max(usage_user) AS max_usage_user, max(usage_system) AS max_usage_system FROM cpu GROUP BY 1, tags_id WITH NO DATA",
+				"CREATE INDEX ON cpu_1m(tags_id, \"time\" DESC)",
+			},
+		},
+		{
+			desc:       "materialized view with in-table tag",
+			inTableTag: true,
+			want: []string{
+				"CREATE MATERIALIZED VIEW cpu_1m AS SELECT date_trunc('minute', time) AS time, " +
+					"tags_id, hostname, max(usage_user) AS max_usage_user, max(usage_system) AS max_usage_system FROM cpu GROUP BY 1, tags_id, hostname WITH NO DATA",
+				"CREATE INDEX ON cpu_1m(hostname, \"time\" DESC)",
+			},
+		},
+	}
+
+	tableCols[tagsKey] = []string{"hostname"}
+	for _, c := range cases {
+		dbc := &dbCreator{opts: &LoadingOptions{
+			UseHypertable: c.useHypertable,
+			InTableTag:    c.inTableTag,
+		}}
+		got := dbc.getCreateRollupCmds("cpu_1m", "minute", []string{"usage_user", "", "usage_system"})
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: incorrect commands:\ngot\n%v\nwant\n%v", c.desc, got, c.want)
+		}
+	}
+}
@@ -7,46 +7,16 @@ import (
 	"github.com/timescale/tsbs/pkg/data/serialize"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
 	"github.com/timescale/tsbs/pkg/targets"
+	targetscommon "github.com/timescale/tsbs/pkg/targets/common"
 )
 
 func newSimulationDataSource(sim common.Simulator) targets.DataSource {
-	return &simulationDataSource{
-		simulator: sim,
-		headers:   sim.Headers(),
-	}
-}
-
-type simulationDataSource struct {
-	simulator common.Simulator
-	headers   *common.GeneratedDataHeaders
+	return targetscommon.NewSimulationDataSource(sim, targetscommon.PointConverterFunc(convertSimulatedPoint))
 }
 
-func (d *simulationDataSource) Headers() *common.GeneratedDataHeaders {
-	if d.headers != nil {
-		return d.headers
-	}
-
-	d.headers = d.simulator.Headers()
-	return d.headers
-}
-
-func (d *simulationDataSource) NextItem() data.LoadedPoint {
-	if d.headers == nil {
-		fatal("headers not read before starting to read points")
-		return data.LoadedPoint{}
-	}
-	newSimulatorPoint := data.NewPoint()
-	var write bool
-	for !d.simulator.Finished() {
-		write = d.simulator.Next(newSimulatorPoint)
-		if write {
-			break
-		}
-		newSimulatorPoint.Reset()
-	}
-	if d.simulator.Finished() || !write {
-		return data.LoadedPoint{}
-	}
+// convertSimulatedPoint converts a simulated point into the same
+// representation the file data source produces.
+func convertSimulatedPoint(newSimulatorPoint *data.Point) data.LoadedPoint {
 	newLoadPoint := &insertData{}
 	tagValues := newSimulatorPoint.TagValues()
 	tagKeys := newSimulatorPoint.TagKeys()
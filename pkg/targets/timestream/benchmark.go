@@ -100,10 +100,9 @@ func initDataSource(config *source.DataSourceConfig, useCurrentTs bool) (targets
 		if err != nil {
 			return nil, err
 		}
-		return &simulatorDataSource{
-			simulator:    simulator,
+		return common.NewSimulationDataSource(simulator, &simulatedPointConverter{
 			useCurrentTs: useCurrentTs,
-		}, nil
+		}), nil
 	}
 	panic("unhandled data source type!!!")
 }
@@ -6,7 +6,6 @@ import (
 	"github.com/pkg/errors"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
 	"github.com/timescale/tsbs/pkg/targets"
-	"log"
 	"sync"
 )
 
@@ -21,22 +20,24 @@ type eachValueARecordProcessor struct {
 
 func (p *eachValueARecordProcessor) Init(_ int, _, _ bool) {}
 
-func (p *eachValueARecordProcessor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64) {
-	var timestreamBatch batch
-	timestreamBatch = *b.(*batch)
+func (p *eachValueARecordProcessor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64, err error) {
+	timestreamBatch := b.(*batch)
 	for table, rows := range timestreamBatch.rows {
-		rowCount += uint64(len(rows))
 		if doLoad {
 			newMetricCount, err := p.writeBatch(table, rows)
 			if err != nil {
-				log.Fatal("could not write to table: " + err.Error())
+				return metricCount, rowCount, errors.Wrap(err, "could not write to table "+table)
 			}
 			metricCount += newMetricCount
+			// remove the written rows so a retry only writes what is left
+			delete(timestreamBatch.rows, table)
+			timestreamBatch.cnt -= uint(len(rows))
 		}
+		rowCount += uint64(len(rows))
 	}
 	timestreamBatch.reset()
 	p.batchPool.Put(b)
-	return metricCount, rowCount
+	return metricCount, rowCount, nil
 }
 
 func (p *eachValueARecordProcessor) writeBatch(table string, rows []deserializedPoint) (numMetrics uint64, err error) {
@@ -3,46 +3,28 @@ package timestream
 import (
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/serialize"
-	"github.com/timescale/tsbs/pkg/data/usecases/common"
-	"log"
 	"strconv"
 	"time"
 )
 
-type simulatorDataSource struct {
-	_headers     *common.GeneratedDataHeaders
-	simulator    common.Simulator
+// simulatedPointConverter implements common.PointConverter by
+// converting each simulated point into a deserializedPoint.
+type simulatedPointConverter struct {
 	useCurrentTs bool
 }
 
-func (s *simulatorDataSource) NextItem() data.LoadedPoint {
-	if s._headers == nil {
-		log.Fatal("headers not read before starting to read points")
-		return data.LoadedPoint{}
-	}
-	newSimulatorPoint := data.NewPoint()
-	var write bool
-	for !s.simulator.Finished() {
-		write = s.simulator.Next(newSimulatorPoint)
-		if write {
-			break
-		}
-		newSimulatorPoint.Reset()
-	}
-	if s.simulator.Finished() || !write {
-		return data.LoadedPoint{}
-	}
-	timeUnixNano := s.prepareTimestamp(newSimulatorPoint.Timestamp())
-	return data.NewLoadedPoint(&deserializedPoint{
+func (s *simulatedPointConverter) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	timeUnixNano := s.prepareTimestamp(p.Timestamp())
+	return append(dst, data.NewLoadedPoint(&deserializedPoint{
 		timeUnixNano: timeUnixNano,
-		table:        string(newSimulatorPoint.MeasurementName()),
-		tags:         tagsToStringArr(newSimulatorPoint.TagValues()),
-		tagKeys:      tagKeysToStringArr(newSimulatorPoint.TagKeys()),
-		fields:       fieldsToStringArr(newSimulatorPoint.FieldValues()),
-	})
+		table:        string(p.MeasurementName()),
+		tags:         tagsToStringArr(p.TagValues()),
+		tagKeys:      tagKeysToStringArr(p.TagKeys()),
+		fields:       fieldsToStringArr(p.FieldValues()),
+	})), nil
 }
 
-func (s *simulatorDataSource) prepareTimestamp(pointTs *time.Time) string {
+func (s *simulatedPointConverter) prepareTimestamp(pointTs *time.Time) string {
 	var ts time.Time
 	if !s.useCurrentTs {
 		ts = *pointTs
@@ -52,15 +34,6 @@ func (s *simulatorDataSource) prepareTimestamp(pointTs *time.Time) string {
 	return strconv.FormatInt(ts.UnixNano(), 10)
 }
 
-func (s *simulatorDataSource) Headers() *common.GeneratedDataHeaders {
-	if s._headers != nil {
-		return s._headers
-	}
-
-	s._headers = s.simulator.Headers()
-	return s._headers
-}
-
 func tagsToStringArr(tagValues []interface{}) []string {
 	tagsAsStr := make([]string, len(tagValues))
 	for i, tag := range tagValues {
@@ -3,16 +3,24 @@ package victoriametrics
 import (
 	"bufio"
 	"bytes"
-	"errors"
 	"github.com/blagojts/viper"
+	"github.com/timescale/tsbs/internal/inputs"
 	"github.com/timescale/tsbs/load"
 	"github.com/timescale/tsbs/pkg/data/source"
 	"github.com/timescale/tsbs/pkg/targets"
+	"github.com/timescale/tsbs/pkg/targets/common"
+	"strings"
 	"sync"
 )
 
 type SpecificConfig struct {
 	ServerURLs []string `yaml:"urls" mapstructure:"urls"`
+	// Rollups backfills the recording rules of the 1m and 1h rollups of the
+	// cpu metrics once the data is loaded, through the querying and import
+	// APIs at RollupsQueryURL and RollupsImportURL
+	Rollups          bool   `yaml:"rollups" mapstructure:"rollups"`
+	RollupsQueryURL  string `yaml:"rollups-query-url" mapstructure:"rollups-query-url"`
+	RollupsImportURL string `yaml:"rollups-import-url" mapstructure:"rollups-import-url"`
 }
 
 func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
@@ -27,19 +35,27 @@ func parseSpecificConfig(v *viper.Viper) (*SpecificConfig, error) {
 type benchmark struct {
 	serverURLs []string
 	dataSource targets.DataSource
+	conf       *SpecificConfig
 }
 
 func NewBenchmark(vmSpecificConfig *SpecificConfig, dataSourceConfig *source.DataSourceConfig) (targets.Benchmark, error) {
-	if dataSourceConfig.Type != source.FileDataSourceType {
-		return nil, errors.New("only FILE data source type is supported for VictoriaMetrics")
+	var ds targets.DataSource
+	if dataSourceConfig.Type == source.FileDataSourceType {
+		br := load.GetBufferedReader(dataSourceConfig.File.Location)
+		ds = &fileDataSource{scanner: bufio.NewScanner(br)}
+	} else {
+		dataGenerator := &inputs.DataGenerator{}
+		simulator, err := dataGenerator.CreateSimulator(dataSourceConfig.Simulator)
+		if err != nil {
+			return nil, err
+		}
+		ds = common.NewSimulationDataSource(simulator, &lineProtocolConverter{})
 	}
 
-	br := load.GetBufferedReader(dataSourceConfig.File.Location)
 	return &benchmark{
-		dataSource: &fileDataSource{
-			scanner: bufio.NewScanner(br),
-		},
+		dataSource: ds,
 		serverURLs: vmSpecificConfig.ServerURLs,
+		conf:       vmSpecificConfig,
 	}, nil
 }
 
@@ -65,6 +81,18 @@ func (b *benchmark) GetProcessor() targets.Processor {
 }
 
 func (b *benchmark) GetDBCreator() targets.DBCreator {
+	if b.conf.Rollups {
+		// the base URL of a single node is the one of its /write endpoint
+		base := strings.TrimSuffix(b.serverURLs[0], "/write")
+		rc := &rollupCreator{queryURL: b.conf.RollupsQueryURL, importURL: b.conf.RollupsImportURL}
+		if rc.queryURL == "" {
+			rc.queryURL = base
+		}
+		if rc.importURL == "" {
+			rc.importURL = base
+		}
+		return rc
+	}
 	return &dbCreator{}
 }
 
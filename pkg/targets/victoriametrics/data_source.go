@@ -2,8 +2,10 @@ package victoriametrics
 
 import (
 	"bufio"
+	"bytes"
 	"github.com/timescale/tsbs/pkg/data"
 	"github.com/timescale/tsbs/pkg/data/usecases/common"
+	"github.com/timescale/tsbs/pkg/targets/influx"
 	"log"
 )
 
@@ -28,3 +30,21 @@ func (f fileDataSource) Headers() *common.GeneratedDataHeaders {
 type decoder struct {
 	scanner *bufio.Scanner
 }
+
+// lineProtocolConverter implements common.PointConverter by serializing
+// each simulated point into a single InfluxDB line, which is the format
+// the batch expects.
+type lineProtocolConverter struct {
+	serializer influx.Serializer
+	buf        bytes.Buffer
+}
+
+func (c *lineProtocolConverter) Convert(p *data.Point, dst []data.LoadedPoint) ([]data.LoadedPoint, error) {
+	c.buf.Reset()
+	if err := c.serializer.Serialize(p, &c.buf); err != nil {
+		return dst, err
+	}
+	line := make([]byte, c.buf.Len())
+	copy(line, c.buf.Bytes())
+	return append(dst, data.NewLoadedPoint(bytes.TrimSuffix(line, newLine))), nil
+}
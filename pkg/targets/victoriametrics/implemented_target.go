@@ -36,6 +36,9 @@ func (vm vmTarget) TargetSpecificFlags(flagPrefix string, flagSet *pflag.FlagSet
 		"http://localhost:8428/write",
 		"Comma-separated list of VictoriaMetrics ingestion URLs(single-node or VMInsert)",
 	)
+	flagSet.Bool(flagPrefix+"rollups", false, "Backfill the recording rules of 1m and 1h rollups of the cpu metrics once the data is loaded")
+	flagSet.String(flagPrefix+"rollups-query-url", "", "Base URL of the Prometheus querying API the rollup rules are evaluated with (default: the first --urls without /write, for a single node)")
+	flagSet.String(flagPrefix+"rollups-import-url", "", "Base URL of the import API the rollups are written with (default: the first --urls without /write, for a single node)")
 }
 
 func (vm vmTarget) TargetName() string {
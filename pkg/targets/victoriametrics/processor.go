@@ -2,10 +2,9 @@ package victoriametrics
 
 import (
 	"bytes"
+	"fmt"
 	"github.com/timescale/tsbs/pkg/targets"
-	"log"
 	"net/http"
-	"time"
 )
 
 type processor struct {
@@ -17,32 +16,28 @@ func (p *processor) Init(workerNum int, doLoad, hashWorkers bool) {
 	p.url = p.vmURLs[workerNum%len(p.vmURLs)]
 }
 
-func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64) {
+func (p *processor) ProcessBatch(b targets.Batch, doLoad bool) (metricCount, rowCount uint64, err error) {
 	batch := b.(*batch)
 	if !doLoad {
-		return batch.metrics, batch.rows
+		return batch.metrics, batch.rows, nil
 	}
-	mc, rc := p.do(batch)
-	return mc, rc
+	return p.do(batch)
 }
 
-func (p *processor) do(b *batch) (uint64, uint64) {
-	for {
-		r := bytes.NewReader(b.buf.Bytes())
-		req, err := http.NewRequest("POST", p.url, r)
-		if err != nil {
-			log.Fatalf("error while creating new request: %s", err)
-		}
-		resp, err := http.DefaultClient.Do(req)
-		if err != nil {
-			log.Fatalf("error while executing request: %s", err)
-		}
-		resp.Body.Close()
-		if resp.StatusCode == http.StatusNoContent {
-			b.buf.Reset()
-			return b.metrics, b.rows
-		}
-		log.Printf("server returned HTTP status %d. Retrying", resp.StatusCode)
-		time.Sleep(time.Millisecond * 10)
+func (p *processor) do(b *batch) (uint64, uint64, error) {
+	r := bytes.NewReader(b.buf.Bytes())
+	req, err := http.NewRequest("POST", p.url, r)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error while creating new request: %s", err)
 	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, 0, fmt.Errorf("error while executing request: %s", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return 0, 0, fmt.Errorf("server returned HTTP status %d", resp.StatusCode)
+	}
+	b.buf.Reset()
+	return b.metrics, b.rows, nil
 }
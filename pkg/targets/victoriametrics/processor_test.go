@@ -69,7 +69,10 @@ func TestProcessorProcessBatch(t *testing.T) {
 			const ignored = false
 			p.Init(1, ignored, ignored)
 			callsBefore := vm.getCalls()
-			metrics, rows := p.ProcessBatch(b, tc.doLoad)
+			metrics, rows, err := p.ProcessBatch(b, tc.doLoad)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
 			if metrics != tc.metrics {
 				t.Fatalf("expected %d metrics; got %d", tc.metrics, metrics)
 			}
@@ -87,9 +90,41 @@ func TestProcessorProcessBatch(t *testing.T) {
 	}
 }
 
+func TestProcessorProcessBatchError(t *testing.T) {
+	f := &factory{bufPool: &sync.Pool{
+		New: func() interface{} {
+			return bytes.NewBuffer(make([]byte, 0, 1024))
+		},
+	}}
+	vm := startFakeVMServer(t)
+	vm.status = http.StatusServiceUnavailable
+	b := f.New().(*batch)
+	b.Append(data.LoadedPoint{
+		Data: []byte("tag1=tag1val,tag2=tag2val col1=0.0,col2=0.0 140"),
+	})
+	sizeBefore := b.buf.Len()
+
+	p := &processor{vmURLs: []string{vm.server.URL}}
+	p.Init(0, true, false)
+	metrics, rows, err := p.ProcessBatch(b, true)
+	if err == nil {
+		t.Fatalf("expected error for HTTP status %d", vm.status)
+	}
+	if metrics != 0 || rows != 0 {
+		t.Errorf("expected no metrics or rows for failed batch; got %d and %d", metrics, rows)
+	}
+	if b.buf.Len() != sizeBefore {
+		t.Errorf("failed batch was modified; expected %d bytes, got %d", sizeBefore, b.buf.Len())
+	}
+	if vm.getCalls() != 1 {
+		t.Errorf("expected a single request; got %d", vm.getCalls())
+	}
+}
+
 type fakeVMServer struct {
 	t      *testing.T
 	calls  uint64
+	status int
 	server *httptest.Server
 }
 
@@ -101,11 +136,11 @@ func (vm *fakeVMServer) handler(w http.ResponseWriter, r *http.Request) {
 		vm.t.Fatalf("unexpected HTTP method %q", r.Method)
 	}
 	vm.incCalls()
-	w.WriteHeader(http.StatusNoContent)
+	w.WriteHeader(vm.status)
 }
 
 func startFakeVMServer(t *testing.T) *fakeVMServer {
-	vm := &fakeVMServer{t: t}
+	vm := &fakeVMServer{t: t, status: http.StatusNoContent}
 	s := httptest.NewServer(http.HandlerFunc(vm.handler))
 	vm.server = s
 	return vm
@@ -0,0 +1,259 @@
+package victoriametrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// rollupMetricPrefix is the prefix of the metrics of the fields of the cpu
+// measurement, the ones rolled up with --rollups
+const rollupMetricPrefix = "cpu_"
+
+// rollupWindowSteps is the number of steps of a rollup evaluated per request
+const rollupWindowSteps = 1440
+
+// rollups are the recording rules backfilled with --rollups, with the max of
+// each cpu metric per host per minute and hour. The metrics they record are
+// named after the rollup and the column of the other databases, e.g.
+// cpu_1m_max_usage_user, as the queries generated for them read.
+var rollups = []struct {
+	name string
+	step time.Duration
+}{
+	{name: rollupMetricPrefix + "1m", step: time.Minute},
+	{name: rollupMetricPrefix + "1h", step: time.Hour},
+}
+
+// rollupMetricName returns the name of the metric recorded by the rule of the
+// rollup name for the cpu metric, e.g. cpu_1m_max_usage_user for cpu_usage_user
+func rollupMetricName(name, metric string) string {
+	return name + "_max_" + strings.TrimPrefix(metric, rollupMetricPrefix)
+}
+
+// isRollupMetric tells whether metric is one recorded by the rules
+func isRollupMetric(metric string) bool {
+	for _, r := range rollups {
+		if strings.HasPrefix(metric, r.name+"_max_") {
+			return true
+		}
+	}
+	return false
+}
+
+// rollupCreator is the dbCreator when the recording rules of the rollups of
+// the cpu metrics are backfilled once the data is loaded. VictoriaMetrics
+// leaves recording rules to vmalert, which evaluates them as the data comes
+// in; the loaded data being in the past, the rules are evaluated over its
+// time range instead, as vmalert -replay does, and their results imported.
+type rollupCreator struct {
+	dbCreator
+	// queryURL and importURL are the base URLs of the Prometheus querying
+	// and import APIs, e.g. http://localhost:8428 for a single node
+	queryURL  string
+	importURL string
+}
+
+// PostLoad backfills the rollups with the data loaded
+func (d *rollupCreator) PostLoad(_ string) error {
+	// the data is searchable once flushed, and the results of the queries
+	// run before the import must not be served from the cache. Both are
+	// only available on single nodes, errors are ignored.
+	d.get("/internal/force_flush", nil)
+	defer d.get("/internal/resetRollupResultCache", nil)
+
+	metrics, err := d.cpuMetrics()
+	if err != nil {
+		return err
+	}
+	if len(metrics) == 0 {
+		return fmt.Errorf("no %s metrics to roll up, rollups are only created for the devops and cpu-only use cases", rollupMetricPrefix)
+	}
+	start, end, err := d.timeRange(metrics[0])
+	if err != nil {
+		return err
+	}
+	for _, r := range rollups {
+		for _, metric := range metrics {
+			if err := d.backfill(rollupMetricName(r.name, metric), metric, r.step, start, end); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// get sends a GET request to the query API path with params, returning the
+// body of the response
+func (d *rollupCreator) get(path string, params url.Values) ([]byte, error) {
+	u := d.queryURL + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+	resp, err := http.Get(u)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP status %d: %s", path, resp.StatusCode, body)
+	}
+	return body, nil
+}
+
+// promResponse is a response of the Prometheus querying API
+type promResponse struct {
+	Status string          `json:"status"`
+	Error  string          `json:"error"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// getData sends the query API request and decodes the data of its response
+// into data
+func (d *rollupCreator) getData(path string, params url.Values, data interface{}) error {
+	body, err := d.get(path, params)
+	if err != nil {
+		return err
+	}
+	var resp promResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("could not decode the response of %s: %v", path, err)
+	}
+	if resp.Status != "success" {
+		return fmt.Errorf("%s failed: %s", path, resp.Error)
+	}
+	return json.Unmarshal(resp.Data, data)
+}
+
+// getResult sends the query or query_range request and decodes the result
+// of its response into result
+func (d *rollupCreator) getResult(path string, params url.Values, result interface{}) error {
+	var data struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := d.getData(path, params, &data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data.Result, result)
+}
+
+// cpuMetrics returns the names of the metrics of the cpu fields, in order
+func (d *rollupCreator) cpuMetrics() ([]string, error) {
+	var names []string
+	params := url.Values{
+		"match[]": {fmt.Sprintf(`{__name__=~"%s.+"}`, rollupMetricPrefix)},
+		// label values are only looked up over the last day by default
+		"start": {"1"},
+	}
+	if err := d.getData("/api/v1/label/__name__/values", params, &names); err != nil {
+		return nil, err
+	}
+	metrics := names[:0]
+	for _, name := range names {
+		if !isRollupMetric(name) {
+			metrics = append(metrics, name)
+		}
+	}
+	sort.Strings(metrics)
+	return metrics, nil
+}
+
+// timeRange returns the time of the first and last samples of metric
+func (d *rollupCreator) timeRange(metric string) (time.Time, time.Time, error) {
+	var times [2]time.Time
+	for i, f := range []string{"min(tfirst_over_time(%s[100y]))", "max(tlast_over_time(%s[100y]))"} {
+		var vector []struct {
+			Value [2]interface{} `json:"value"`
+		}
+		if err := d.getResult("/api/v1/query", url.Values{"query": {fmt.Sprintf(f, metric)}}, &vector); err != nil {
+			return time.Time{}, time.Time{}, err
+		}
+		if len(vector) == 0 {
+			return time.Time{}, time.Time{}, fmt.Errorf("no samples of %s", metric)
+		}
+		s, _ := vector[0].Value[1].(string)
+		secs, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid time of the samples of %s: %v", metric, err)
+		}
+		times[i] = time.Unix(0, int64(secs*1e9))
+	}
+	return times[0], times[1], nil
+}
+
+// importSeries is a series in the JSON line format of /api/v1/import
+type importSeries struct {
+	Metric     map[string]string `json:"metric"`
+	Values     []float64         `json:"values"`
+	Timestamps []int64           `json:"timestamps"`
+}
+
+// backfill evaluates the rule recording the max of metric per step as name
+// from start to end, rollupWindowSteps steps at a time, and imports its
+// results
+func (d *rollupCreator) backfill(name, metric string, step time.Duration, start, end time.Time) error {
+	expr := fmt.Sprintf("max_over_time(%s[%ds])", metric, int64(step/time.Second))
+	for from := start.Truncate(step); from.Before(end.Add(step)); from = from.Add(rollupWindowSteps * step) {
+		to := from.Add((rollupWindowSteps - 1) * step)
+		var matrix []struct {
+			Metric map[string]string `json:"metric"`
+			Values [][2]interface{}  `json:"values"`
+		}
+		params := url.Values{
+			"query": {expr},
+			"start": {strconv.FormatInt(from.Unix(), 10)},
+			"end":   {strconv.FormatInt(to.Unix(), 10)},
+			"step":  {strconv.FormatInt(int64(step/time.Second), 10)},
+		}
+		if err := d.getResult("/api/v1/query_range", params, &matrix); err != nil {
+			return fmt.Errorf("could not evaluate the rule of %s: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, series := range matrix {
+			s := importSeries{Metric: series.Metric}
+			if s.Metric == nil {
+				s.Metric = map[string]string{}
+			}
+			s.Metric["__name__"] = name
+			for _, v := range series.Values {
+				ts, _ := v[0].(float64)
+				str, _ := v[1].(string)
+				value, err := strconv.ParseFloat(str, 64)
+				if err != nil {
+					return fmt.Errorf("invalid value of %s: %v", name, err)
+				}
+				s.Timestamps = append(s.Timestamps, int64(math.Round(ts*1000)))
+				s.Values = append(s.Values, value)
+			}
+			if err := enc.Encode(&s); err != nil {
+				return err
+			}
+		}
+		if buf.Len() == 0 {
+			continue
+		}
+		resp, err := http.Post(d.importURL+"/api/v1/import", "application/json", &buf)
+		if err != nil {
+			return fmt.Errorf("could not import %s: %v", name, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNoContent {
+			return fmt.Errorf("import of %s returned HTTP status %d", name, resp.StatusCode)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package victoriametrics
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestRollupMetricName(t *testing.T) {
+	if got := rollupMetricName("cpu_1m", "cpu_usage_user"); got != "cpu_1m_max_usage_user" {
+		t.Errorf("incorrect rollup metric name: got %s", got)
+	}
+	if !isRollupMetric("cpu_1h_max_usage_user") {
+		t.Errorf("cpu_1h_max_usage_user not recognized as a rollup metric")
+	}
+	if isRollupMetric("cpu_usage_user") {
+		t.Errorf("cpu_usage_user recognized as a rollup metric")
+	}
+}
+
+func TestRollupCreatorPostLoad(t *testing.T) {
+	const first = 1451606400
+	var mu sync.Mutex
+	var ranges []string
+	imported := map[string][]importSeries{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/label/__name__/values", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"status":"success","data":["cpu_usage_user","cpu_1m_max_usage_user","cpu_usage_system"]}`)
+	})
+	mux.HandleFunc("/api/v1/query", func(w http.ResponseWriter, r *http.Request) {
+		ts := first
+		if strings.HasPrefix(r.FormValue("query"), "max(tlast_over_time") {
+			ts = first + 150
+		}
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"vector","result":[{"metric":{},"value":[1700000000,"%d"]}]}}`, ts)
+	})
+	mux.HandleFunc("/api/v1/query_range", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		ranges = append(ranges, fmt.Sprintf("%s %s-%s/%s", r.FormValue("query"), r.FormValue("start"), r.FormValue("end"), r.FormValue("step")))
+		mu.Unlock()
+		start, _ := strconv.Atoi(r.FormValue("start"))
+		fmt.Fprintf(w, `{"status":"success","data":{"resultType":"matrix","result":[{"metric":{"hostname":"host_0"},"values":[[%d,"1.5"]]}]}}`, start)
+	})
+	mux.HandleFunc("/api/v1/import", func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var s importSeries
+			if err := json.Unmarshal(scanner.Bytes(), &s); err != nil {
+				t.Errorf("invalid imported series: %v", err)
+			}
+			mu.Lock()
+			imported[s.Metric["__name__"]] = append(imported[s.Metric["__name__"]], s)
+			mu.Unlock()
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	d := &rollupCreator{queryURL: server.URL, importURL: server.URL}
+	if err := d.PostLoad("benchmark"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	sort.Strings(ranges)
+	wantRanges := []string{
+		fmt.Sprintf("max_over_time(cpu_usage_system[3600s]) %d-%d/3600", first, first+1439*3600),
+		fmt.Sprintf("max_over_time(cpu_usage_system[60s]) %d-%d/60", first, first+1439*60),
+		fmt.Sprintf("max_over_time(cpu_usage_user[3600s]) %d-%d/3600", first, first+1439*3600),
+		fmt.Sprintf("max_over_time(cpu_usage_user[60s]) %d-%d/60", first, first+1439*60),
+	}
+	if got, want := strings.Join(ranges, "\n"), strings.Join(wantRanges, "\n"); got != want {
+		t.Errorf("incorrect rule evaluations:\ngot\n%s\nwant\n%s", got, want)
+	}
+
+	for _, name := range []string{"cpu_1m_max_usage_user", "cpu_1m_max_usage_system", "cpu_1h_max_usage_user", "cpu_1h_max_usage_system"} {
+		series := imported[name]
+		if len(series) != 1 {
+			t.Errorf("incorrect number of imported series of %s: got %d want 1", name, len(series))
+			continue
+		}
+		s := series[0]
+		if s.Metric["hostname"] != "host_0" {
+			t.Errorf("labels of %s not kept: %v", name, s.Metric)
+		}
+		if len(s.Timestamps) != 1 || s.Timestamps[0] != first*1000 || s.Values[0] != 1.5 {
+			t.Errorf("incorrect samples of %s: %v %v", name, s.Timestamps, s.Values)
+		}
+	}
+	if len(imported) != 4 {
+		t.Errorf("incorrect number of imported metrics: got %d want 4", len(imported))
+	}
+}